@@ -0,0 +1,41 @@
+package agents
+
+import (
+	"errors"
+
+	"github.com/cloudwego/eino/adk"
+)
+
+// acquireAgent 取出一个空闲的 agent，没有空闲时用当前模型新建，返回 agent 及其模型版本。
+// eino 每次 Run 都会重新编译包含 agent 内部图的执行链，同一 agent 被并发 Run 时编译会产生数据竞争，
+// 因此每个并发运行独占一个 agent，运行结束后归还复用
+func (a *BaseAgentImpl) acquireAgent() (adk.ResumableAgent, uint64, error) {
+	a.mu.Lock()
+	gen := a.agentGen
+	if n := len(a.idleAgents); n > 0 {
+		agent := a.idleAgents[n-1]
+		a.idleAgents = a.idleAgents[:n-1]
+		a.mu.Unlock()
+		return agent, gen, nil
+	}
+	build, llm := a.buildAgent, a.llmModel
+	a.mu.Unlock()
+
+	if build == nil {
+		return nil, 0, errors.New("agent is not initialized")
+	}
+	agent, err := build(llm)
+	if err != nil {
+		return nil, 0, err
+	}
+	return agent, gen, nil
+}
+
+// releaseAgent 归还运行结束的 agent；取出后替换过模型时丢弃旧模型构建的 agent
+func (a *BaseAgentImpl) releaseAgent(agent adk.ResumableAgent, gen uint64) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if gen == a.agentGen {
+		a.idleAgents = append(a.idleAgents, agent)
+	}
+}
@@ -0,0 +1,56 @@
+package agents
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"superman/ds"
+	"superman/tools"
+)
+
+// isAlertPriority 判断通知是否为需要处理的告警（high、critical）
+func isAlertPriority(priority string) bool {
+	switch strings.ToLower(priority) {
+	case "high", "critical":
+		return true
+	}
+	return false
+}
+
+// submitAlertTask 将告警通知转为分配给自己的高优先级处置任务并提交到调度器
+func (a *BaseAgentImpl) submitAlertTask(ctx context.Context, sender string, body *ds.NotificationBody) error {
+	a.mu.RLock()
+	submitter := a.taskSubmitter
+	a.mu.RUnlock()
+	if submitter == nil {
+		return fmt.Errorf("task submitter is not set")
+	}
+
+	task := ds.NewTask(
+		ds.GenerateTaskID(),
+		fmt.Sprintf("处理告警: %s", body.Title),
+		body.Content,
+		a.name,
+		sender,
+		ds.TaskStatusPending,
+		ds.TaskPriorityHigh,
+	)
+	task.Metadata["source"] = "alert"
+	task.Metadata["alert_priority"] = body.Priority
+	if msgID := tools.SourceMessageID(ctx); msgID != "" {
+		task.Metadata[ds.MetadataSourceMessageID] = msgID
+	}
+	submitter(task, "High")
+	a.linkSourceMessage(task)
+
+	a.incrMetric("alert_tasks_created")
+	slog.Info("alert notification converted to task",
+		slog.String("agent", a.name),
+		slog.String("from", sender),
+		slog.String("task_id", task.ID),
+		slog.String("title", body.Title),
+	)
+	return nil
+}
@@ -0,0 +1,39 @@
+package agents
+
+import (
+	"context"
+	"testing"
+
+	"superman/config"
+	"superman/ds"
+)
+
+// 开启 alert_to_task 的运营 Agent 收到 critical 通知时提交一条高优先级处置任务，普通通知只记录日志
+func TestCriticalNotificationCreatesTask(t *testing.T) {
+	agent, _ := newTestAgent(t, newFakeModel("ok"), config.AgentConfig{Name: "operations", AlertToTask: true})
+	var submitted []*ds.Task
+	var priorities []string
+	agent.SetTaskSubmitter(func(task *ds.Task, priority string) {
+		submitted = append(submitted, task)
+		priorities = append(priorities, priority)
+	})
+	startTestAgent(t, agent)
+
+	for _, priority := range []string{"low", "critical"} {
+		msg, err := ds.NewNotificationMessage("monitor", "operations", "数据库延迟升高", "主库 p99 延迟超过 2s", priority)
+		if err != nil {
+			t.Fatalf("NewNotificationMessage: %v", err)
+		}
+		if err := agent.ProcessMessage(context.Background(), msg); err != nil {
+			t.Fatalf("ProcessMessage(%s): %v", priority, err)
+		}
+	}
+
+	if len(submitted) != 1 {
+		t.Fatalf("submitted %d tasks, want 1 for the critical notification only", len(submitted))
+	}
+	task := submitted[0]
+	if priorities[0] != "High" || task.Priority != ds.TaskPriorityHigh || task.AssignedTo != "operations" || task.Description != "主库 p99 延迟超过 2s" {
+		t.Fatalf("task = %+v (queue priority %s), want a high priority task for operations carrying the alert", task, priorities[0])
+	}
+}
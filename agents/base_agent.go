@@ -3,10 +3,13 @@ package agents
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"slices"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"superman/config"
@@ -32,6 +35,18 @@ type TaskSubmitFunc func(task *ds.Task, priority string)
 // OnTaskCompleteFunc 任务完成回调（通知调度器减少负载）
 type OnTaskCompleteFunc func(taskID, agentName string, success bool)
 
+// TaskGenGuardFunc 任务生成前的检查回调，返回 false 时跳过本轮生成
+type TaskGenGuardFunc func() bool
+
+// ErrGlobalStateNotSet 启动前未设置全局状态
+var ErrGlobalStateNotSet = errors.New("global state is not set")
+
+// 任务生成被跳过的原因
+var (
+	ErrTaskGenPaused     = errors.New("task generation is paused")
+	ErrTaskGenInProgress = errors.New("task generation already in progress")
+)
+
 // Agent 定义 Agent 接口
 type Agent interface {
 	GetName() string
@@ -47,17 +62,32 @@ type Agent interface {
 	GetExecutionHistoryByTaskID(taskID string) []*state.AgentExecutionHistory
 	GetExecutionHistoryByTimeRange(start, end time.Time) []*state.AgentExecutionHistory
 	GetRecentExecutions(count int) []*state.AgentExecutionHistory
+	GetMaxHistoryQuery() int
 	SetGlobalState(gs *state.GlobalState)
 	GetGlobalState() *state.GlobalState
 	ReceiveMessage(msg *ds.Message) error
 	Start() error
-	Stop() error
+	Stop(ctx context.Context) error
 	IsRunning() bool
+	IsBusy() bool
 	GetExecutionStats() map[string]interface{}
+	GetInFlightTasks() int
 	GetLLMModel() model.ToolCallingChatModel
+	SetLLMModel(m model.ToolCallingChatModel) error
 	SetTaskSubmitter(fn TaskSubmitFunc)
 	SetOnTaskComplete(fn OnTaskCompleteFunc)
+	SetTaskGenGuard(fn TaskGenGuardFunc)
+	SetTaskGenLimiter(sem *utils.Semaphore)
+	SetAgentDirectory(fn func() []tools.AgentInfo)
+	SetTaskGenInterval(d time.Duration) error
+	SetMemoryStore(store state.AgentMemoryStore, historySize int) error
+	SetManualTaskGen(manual bool)
+	SetCapabilities(capabilities []string)
+	GetCapabilities() []string
+	GetTaskGenInterval() time.Duration
 	GenerateTasks(ctx context.Context) ([]*ds.Task, error)
+	ExplainDecision(ctx context.Context, id string) (string, error)
+	TriggerTaskGeneration(ctx context.Context) ([]string, error)
 }
 
 // BaseAgentImpl 是所有 Agent 的基础实现
@@ -66,7 +96,9 @@ type BaseAgentImpl struct {
 	name string
 	desc string
 
-	agent adk.ResumableAgent
+	idleAgents []adk.ResumableAgent                                             // 空闲的 agent，每个并发运行独占一个
+	agentGen   uint64                                                           // 模型版本，替换模型后旧模型构建的 agent 不再复用
+	buildAgent func(llm model.ToolCallingChatModel) (adk.ResumableAgent, error) // 用指定模型构建 agent，替换模型时重建
 
 	currentTasks       []*ds.Task
 	completedTasks     []*ds.Task
@@ -82,33 +114,110 @@ type BaseAgentImpl struct {
 	executionHistory []*state.AgentExecutionHistory
 	historyMaxSize   int
 
+	// 自身能力，启动与变化时通告给调度器
+	capabilities []string
+
+	// 工作记忆持久化（可选）：停止时保存绩效指标与最近执行历史
+	memoryStore       state.AgentMemoryStore
+	memoryHistorySize int
+
 	globalState *state.GlobalState
 
 	llmModel model.ToolCallingChatModel // LLM 模型
 
 	// 生命周期
+	ctx          context.Context // 运行期上下文，Stop 时取消以中止进行中的 LLM 调用
+	cancel       context.CancelFunc
 	stopCh       chan struct{}
 	wg           sync.WaitGroup
 	running      bool
 	processingMu sync.RWMutex
 
+	// 崩溃监督
+	supervisor    supervisorConfig
+	lastHeartbeat atomic.Int64
+
 	// 回调
 	taskSubmitter  TaskSubmitFunc
 	onTaskComplete OnTaskCompleteFunc
+	taskGenGuard   TaskGenGuardFunc
+	taskGenLimiter *utils.Semaphore // 全系统任务生成并发限制，nil 不限制
+
+	// 任务完成后通知委派者
+	notifyCompletion bool
+
+	// 停止时的收件箱处理
+	drainMode    string
+	drainTimeout time.Duration
+
+	// 任务并发控制，容量为最大并发任务数
+	taskSem chan struct{}
+	// 非任务消息并发控制，与任务并发相互独立，避免一方占满时另一方无法处理
+	msgSem chan struct{}
+	// 收件箱消息按类型分流后等待获取并发槽位的队列
+	taskQueue chan *ds.Message
+	msgQueue  chan *ds.Message
+	// 无需运行模型的消息走快速通道，由固定数量的工作协程处理，不与需要模型的消息争用槽位
+	quickQueue   chan *ds.Message
+	quickWorkers int
+	quickBusy    atomic.Int32
+	// 按发送者保序：同一发送者的消息依次处理，为 nil 表示不保序
+	senderOrder *senderOrder
 
 	// 任务生成配置
-	taskGenInterval time.Duration
+	taskGenInterval      time.Duration
+	taskGenReset         chan time.Duration
+	manualTaskGen        bool          // 不启动定时生成循环，仅通过 TriggerTaskGeneration 生成（模拟模式）
+	taskGenInitialDelay  time.Duration // 首次任务生成前的等待时间
+	taskGenJitter        float64
+	taskGenReformatRetry bool
+	maxTasksPerGen       int              // 每轮生成的最大任务数
+	taskGenSchema        taskGenSchema    // 任务生成的期望输出格式
+	maxResponseSize      int              // 模型输出最大字节数，超出部分截断
+	responseFormat       string           // 任务回复格式：text、json
+	checkDeliverables    bool             // 任务完成前校验交付物
+	selfReflection       bool             // 任务完成后让模型自评产出质量
+	alertToTask          bool             // 高优先级通知转为处置任务
+	taskGenDedup         float64          // 生成任务与已有任务的相似度阈值，达到时跳过，0 不去重
+	maxHistoryQuery      int              // 单次查询执行历史的最大条数
+	toolTimeout          time.Duration    // 单次工具调用超时，0 不限制
+	llmTimeout           time.Duration    // 单次模型运行（消息处理、任务执行）超时，0 不限制
+	requestTimeout       time.Duration    // 由模型处理的请求消息的处理超时，超时后回复失败响应，0 不限制
+	taskGenWatchKeys     []string         // 变化时触发任务生成的全局状态键
+	generating           atomic.Bool      // 是否正在生成任务
+	executing            atomic.Int32     // 正在 executeTask 中执行的任务数
+	genCache             *generationCache // 任务生成结果缓存，未开启时为 nil
+
+	// 同事信息来源（list agents 工具）
+	agentDirectory *tools.ListAgents
 }
 
 var _ Agent = (*BaseAgentImpl)(nil)
 
+// OversizedMessageTruncate 入站消息体超限时截断（默认拒收）
+const OversizedMessageTruncate = "truncate"
+
 // NewBaseAgent 创建基础 Agent 实例
 func NewBaseAgent(ctx context.Context, llm model.ToolCallingChatModel, bus *mailbox.MailboxBus, agentConfig config.AgentConfig, allAgentConfig ...config.AgentConfig) (*BaseAgentImpl, error) {
 	mailboxConfig := mailbox.DefaultMailboxConfig(agentConfig.Name)
+	if agentConfig.MessageDedupWindow != "" {
+		if d, err := time.ParseDuration(agentConfig.MessageDedupWindow); err == nil {
+			mailboxConfig.DedupWindow = d
+		}
+	}
+	mailboxConfig.MaxBodySize = agentConfig.GetMaxMessageBodySize()
+	mailboxConfig.TruncateBody = agentConfig.OversizePolicy == OversizedMessageTruncate
+	mailboxConfig.Role = agentConfig.Role
+	mailboxConfig.Hierarchy = agentConfig.GetHierarchy()
+	mailboxConfig.OrderedSenders = agentConfig.OrderedDelivery
+	mailboxConfig.MaxArchive = agentConfig.MaxArchive
+	mailboxConfig.AllowedSenders = agentConfig.AllowedSenders
+	mailboxConfig.DeniedSenders = agentConfig.DeniedSenders
+	mailboxConfig.DeadLetterRejected = agentConfig.DeadLetterRejected
 	mb := mailbox.NewMailbox(mailboxConfig)
 
 	localSkillBackend, err := skill.NewLocalBackend(&skill.LocalBackendConfig{
-		BaseDir: agentConfig.SkillDir,
+		BaseDir: agentConfig.ResolvedSkillDir(),
 	})
 	if err != nil {
 		return nil, err
@@ -127,23 +236,95 @@ func NewBaseAgent(ctx context.Context, llm model.ToolCallingChatModel, bus *mail
 		Sender:     agentConfig.Name,
 		Receivers:  allAgentNames,
 		MailboxBus: bus, // 使用传入的 MailboxBus，而非未初始化的 mailbox.bus
+		Limiter:    utils.NewRateLimiter(agentConfig.MessageRateLimit, agentConfig.MessageBurst),
 	}
 	sendMessageTool, err := sendMessage.ToEinoTool()
 	if err != nil {
 		return nil, err
 	}
+	taskComment := tools.TaskComment{
+		Author:     agentConfig.Name,
+		MailboxBus: bus,
+	}
+	taskCommentTool, err := taskComment.ToEinoTool()
+	if err != nil {
+		return nil, err
+	}
+	taskWarning := tools.TaskWarning{
+		MailboxBus: bus,
+	}
+	taskWarningTool, err := taskWarning.ToEinoTool()
+	if err != nil {
+		return nil, err
+	}
+	announce := tools.Announce{
+		Author:     agentConfig.Name,
+		Hierarchy:  agentConfig.GetHierarchy(),
+		MailboxBus: bus,
+	}
+	announceTool, err := announce.ToEinoTool()
+	if err != nil {
+		return nil, err
+	}
+	readAnnouncements := tools.ReadAnnouncements{
+		MailboxBus: bus,
+	}
+	readAnnouncementsTool, err := readAnnouncements.ToEinoTool()
+	if err != nil {
+		return nil, err
+	}
+	listAgents := &tools.ListAgents{}
+	listAgentsTool, err := listAgents.ToEinoTool()
+	if err != nil {
+		return nil, err
+	}
+
+	// 工具调用中间件与委派工具引用 Agent 实例，实例字段在本函数末尾填充，工具调用发生在此之后
+	impl := &BaseAgentImpl{}
+
+	delegateTask := tools.DelegateTask{
+		Delegator:  agentConfig.Name,
+		Receivers:  allAgentNames,
+		MaxDepth:   agentConfig.GetMaxDelegationDepth(),
+		MailboxBus: bus,
+		Submit:     impl.submitDelegatedTask,
+		OnRejected: impl.rejectDelegation,
+	}
+	delegateTaskTool, err := delegateTask.ToEinoTool()
+	if err != nil {
+		return nil, err
+	}
+	agentTools := []tool.BaseTool{sendMessageTool, taskCommentTool, taskWarningTool, announceTool, readAnnouncementsTool, listAgentsTool, delegateTaskTool}
+	// 只有高管可以记录 KPI
+	if agentConfig.GetHierarchy() <= tools.MaxKPIHierarchy {
+		setKPI := tools.SetKPI{
+			Author:     agentConfig.Name,
+			MailboxBus: bus,
+		}
+		setKPITool, err := setKPI.ToEinoTool()
+		if err != nil {
+			return nil, err
+		}
+		agentTools = append(agentTools, setKPITool)
+	}
 
-	agent, err := deep.New(ctx, &deep.Config{
-		Name:        agentConfig.Name,
-		Description: agentConfig.Desc,
-		ChatModel:   llm,
-		Middlewares: []adk.AgentMiddleware{skillBackend},
-		ToolsConfig: adk.ToolsConfig{
-			ToolsNodeConfig: compose.ToolsNodeConfig{
-				Tools: []tool.BaseTool{sendMessageTool},
+	// agent 在构建时绑定模型，运行时替换模型需用相同配置重建
+	buildAgent := func(llm model.ToolCallingChatModel) (adk.ResumableAgent, error) {
+		return deep.New(ctx, &deep.Config{
+			Name:        agentConfig.Name,
+			Description: agentConfig.Desc,
+			Instruction: systemPrompt(agentConfig),
+			ChatModel:   llm,
+			Middlewares: []adk.AgentMiddleware{skillBackend},
+			ToolsConfig: adk.ToolsConfig{
+				ToolsNodeConfig: compose.ToolsNodeConfig{
+					Tools:               slices.Clone(agentTools), // eino 构建时会追加内置工具，各 agent 不能共用底层数组
+					ToolCallMiddlewares: []compose.ToolMiddleware{impl.toolTimeoutMiddleware()},
+				},
 			},
-		},
-	})
+		})
+	}
+	agent, err := buildAgent(llm)
 	if err != nil {
 		return nil, err
 	}
@@ -156,26 +337,133 @@ func NewBaseAgent(ctx context.Context, llm model.ToolCallingChatModel, bus *mail
 		}
 	}
 
-	return &BaseAgentImpl{
-		name:               agentConfig.Name,
-		desc:               agentConfig.Desc,
-		agent:              agent,
-		currentTasks:       make([]*ds.Task, 0),
-		completedTasks:     make([]*ds.Task, 0),
-		messages:           make([]*ds.Message, 0),
-		performanceMetrics: make(map[string]float64),
-		lastActive:         time.Now(),
-		roleHierarchy:      agentConfig.Hierarchy,
-		mailbox:            mb,
-		mailboxBus:         bus,
-		executionHistory:   make([]*state.AgentExecutionHistory, 0),
-		historyMaxSize:     10000,
-		stopCh:             make(chan struct{}),
-		running:            false,
-		globalState:        nil,
-		llmModel:           llm,
-		taskGenInterval:    taskGenInterval,
-	}, nil
+	// 解析单次工具调用超时
+	var llmTimeout time.Duration
+	if d, err := time.ParseDuration(agentConfig.LLMTimeout); err == nil && d > 0 {
+		llmTimeout = d
+	}
+	var requestTimeout time.Duration
+	if d, err := time.ParseDuration(agentConfig.RequestTimeout); err == nil && d > 0 {
+		requestTimeout = d
+	}
+	var toolTimeout time.Duration
+	if d, err := time.ParseDuration(agentConfig.ToolTimeout); err == nil && d > 0 {
+		toolTimeout = d
+	}
+
+	// 解析首次任务生成前的等待时间
+	taskGenInitialDelay := 10 * time.Second
+	if agentConfig.TaskGenInitialDelay != "" {
+		if d, err := time.ParseDuration(agentConfig.TaskGenInitialDelay); err == nil && d >= 0 {
+			taskGenInitialDelay = d
+		}
+	}
+
+	genSchema, err := newTaskGenSchema(agentConfig.TaskGenFields)
+	if err != nil {
+		return nil, fmt.Errorf("agent %s: %w", agentConfig.Name, err)
+	}
+
+	// 任务生成结果缓存（可选）
+	var genCache *generationCache
+	if agentConfig.TaskGenCacheTTL != "" {
+		if d, err := time.ParseDuration(agentConfig.TaskGenCacheTTL); err == nil && d > 0 {
+			genCache = newGenerationCache(d)
+		}
+	}
+
+	// 解析停止时收件箱处理超时
+	drainTimeout := 30 * time.Second
+	if agentConfig.DrainTimeout != "" {
+		if d, err := time.ParseDuration(agentConfig.DrainTimeout); err == nil {
+			drainTimeout = d
+		}
+	}
+
+	// 解析崩溃重启退避时间
+	restartBackoff := time.Second
+	if agentConfig.RestartBackoff != "" {
+		if d, err := time.ParseDuration(agentConfig.RestartBackoff); err == nil {
+			restartBackoff = d
+		}
+	}
+	heartbeatTimeout := defaultHeartbeatTimeout
+	if agentConfig.HeartbeatTimeout != "" {
+		if d, err := time.ParseDuration(agentConfig.HeartbeatTimeout); err == nil && d > 0 {
+			heartbeatTimeout = d
+		}
+	}
+
+	*impl = BaseAgentImpl{
+		name:                 agentConfig.Name,
+		desc:                 agentConfig.Desc,
+		idleAgents:           []adk.ResumableAgent{agent},
+		buildAgent:           buildAgent,
+		currentTasks:         make([]*ds.Task, 0),
+		completedTasks:       make([]*ds.Task, 0),
+		messages:             make([]*ds.Message, 0),
+		performanceMetrics:   make(map[string]float64),
+		lastActive:           time.Now(),
+		roleHierarchy:        agentConfig.GetHierarchy(),
+		mailbox:              mb,
+		mailboxBus:           bus,
+		capabilities:         agentConfig.GetCapabilities(),
+		executionHistory:     make([]*state.AgentExecutionHistory, 0),
+		historyMaxSize:       10000,
+		maxHistoryQuery:      agentConfig.GetMaxHistoryQuery(),
+		toolTimeout:          toolTimeout,
+		llmTimeout:           llmTimeout,
+		requestTimeout:       requestTimeout,
+		stopCh:               make(chan struct{}),
+		running:              false,
+		globalState:          nil,
+		llmModel:             llm,
+		taskSem:              make(chan struct{}, agentConfig.GetMaxTasks()),
+		msgSem:               make(chan struct{}, agentConfig.GetMessageConcurrency()),
+		taskQueue:            make(chan *ds.Message, cap(mb.Inbox)),
+		msgQueue:             make(chan *ds.Message, cap(mb.Inbox)),
+		quickQueue:           make(chan *ds.Message, cap(mb.Inbox)),
+		quickWorkers:         agentConfig.GetQuickWorkers(),
+		taskGenInterval:      taskGenInterval,
+		taskGenReset:         make(chan time.Duration, 1),
+		taskGenInitialDelay:  taskGenInitialDelay,
+		taskGenJitter:        agentConfig.TaskGenJitter,
+		taskGenReformatRetry: agentConfig.TaskGenReformatRetry,
+		maxTasksPerGen:       agentConfig.GetMaxTasksPerGen(),
+		taskGenSchema:        genSchema,
+		maxResponseSize:      agentConfig.GetMaxResponseSize(),
+		responseFormat:       agentConfig.ResponseFormat,
+		checkDeliverables:    agentConfig.CheckDeliverables,
+		selfReflection:       agentConfig.SelfReflection,
+		alertToTask:          agentConfig.AlertToTask,
+		taskGenDedup:         agentConfig.TaskGenDedup,
+		taskGenWatchKeys:     agentConfig.TaskGenWatchKeys,
+		genCache:             genCache,
+		agentDirectory:       listAgents,
+		notifyCompletion:     agentConfig.NotifyCompletion,
+		drainMode:            agentConfig.DrainOnStop,
+		drainTimeout:         drainTimeout,
+		supervisor: supervisorConfig{
+			enabled:          agentConfig.RestartOnCrash,
+			maxRestarts:      agentConfig.GetMaxRestarts(),
+			backoff:          restartBackoff,
+			heartbeatTimeout: heartbeatTimeout,
+		},
+	}
+	if agentConfig.OrderedDelivery {
+		impl.senderOrder = newSenderOrder()
+	}
+	return impl, nil
+}
+
+// systemPrompt 返回 Agent 的系统提示词，未配置时根据名称与描述生成默认提示词
+func systemPrompt(agentConfig config.AgentConfig) string {
+	if agentConfig.SystemPrompt != "" {
+		return agentConfig.SystemPrompt
+	}
+	return fmt.Sprintf(`你是 %s，职责描述：%s
+
+请始终站在该角色的立场思考与行动，完成分配给你的任务；需要协作时使用工具与其他 Agent 沟通。`, agentConfig.Name, agentConfig.Desc)
 }
 
 // SetTaskSubmitter 设置任务提交回调
@@ -192,6 +480,25 @@ func (a *BaseAgentImpl) SetOnTaskComplete(fn OnTaskCompleteFunc) {
 	a.onTaskComplete = fn
 }
 
+// SetTaskGenGuard 设置任务生成前的检查回调
+func (a *BaseAgentImpl) SetTaskGenGuard(fn TaskGenGuardFunc) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.taskGenGuard = fn
+}
+
+// SetTaskGenLimiter 设置全系统共享的任务生成并发限制
+func (a *BaseAgentImpl) SetTaskGenLimiter(sem *utils.Semaphore) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.taskGenLimiter = sem
+}
+
+// SetAgentDirectory 设置同事信息来源，供 list agents 工具使用
+func (a *BaseAgentImpl) SetAgentDirectory(fn func() []tools.AgentInfo) {
+	a.agentDirectory.SetSource(fn)
+}
+
 // GetName 获取名称
 func (a *BaseAgentImpl) GetName() string {
 	a.mu.RLock()
@@ -210,18 +517,41 @@ func (a *BaseAgentImpl) GetDesc() string {
 func (a *BaseAgentImpl) GetState() *state.AgentState {
 	a.mu.RLock()
 	defer a.mu.RUnlock()
+
+	// 返回副本，调用方可在 Agent 继续执行时安全读取
+	messages := make([]*ds.Message, len(a.messages))
+	for i, msg := range a.messages {
+		messages[i] = msg.Copy()
+	}
+	metrics := make(map[string]float64, len(a.performanceMetrics))
+	for k, v := range a.performanceMetrics {
+		metrics[k] = v
+	}
+
 	return &state.AgentState{
 		Name:               a.name,
-		CurrentTasks:       a.currentTasks,
-		CompletedTasks:     a.completedTasks,
-		Messages:           a.messages,
-		PerformanceMetrics: a.performanceMetrics,
+		CurrentTasks:       copyTasks(a.currentTasks),
+		CompletedTasks:     copyTasks(a.completedTasks),
+		Messages:           messages,
+		PerformanceMetrics: metrics,
 		Workload:           a.workload,
 		LastActive:         a.lastActive,
 	}
 }
 
+// copyTasks 深拷贝任务列表
+func copyTasks(tasks []*ds.Task) []*ds.Task {
+	result := make([]*ds.Task, len(tasks))
+	for i, task := range tasks {
+		result[i] = task.Copy()
+	}
+	return result
+}
+
 // ProcessMessage 处理一般消息（非任务消息）
+// 各类型消息的 handle* 处理函数调用时不持有 a.mu，处理函数内部按需加锁；
+// 嵌入 BaseAgentImpl 的角色 Agent 应调用本方法而不是在自身锁内直接调用处理函数，
+// 入站消息的记录（messages、lastActive）统一在 ReceiveMessage 中完成
 func (a *BaseAgentImpl) ProcessMessage(ctx context.Context, msg *ds.Message) error {
 	a.processingMu.Lock()
 	running := a.running
@@ -231,6 +561,9 @@ func (a *BaseAgentImpl) ProcessMessage(ctx context.Context, msg *ds.Message) err
 		return fmt.Errorf("agent is not running")
 	}
 
+	// 记录来源消息，处理过程中创建的任务据此关联回该消息
+	ctx = tools.WithSourceMessageID(ctx, msg.ID)
+
 	// 根据消息类型进行不同处理
 	switch msg.Type {
 	case ds.MessageTypeRequest:
@@ -241,13 +574,18 @@ func (a *BaseAgentImpl) ProcessMessage(ctx context.Context, msg *ds.Message) err
 	case ds.MessageTypeNotification:
 		body, ok := msg.GetNotificationBody()
 		if ok {
-			return a.handleNotificationMessage(ctx, body)
+			return a.handleNotificationMessage(ctx, msg.Sender, body)
 		}
 	case ds.MessageTypeResponse:
 		body, ok := msg.GetResponseBody()
 		if ok {
 			return a.handleResponseMessage(ctx, body)
 		}
+	case ds.MessageTypeTaskComplete:
+		body, ok := msg.GetTaskCompleteBody()
+		if ok {
+			return a.handleTaskCompleteMessage(ctx, msg.Sender, body)
+		}
 	default:
 		break
 	}
@@ -258,25 +596,26 @@ func (a *BaseAgentImpl) ProcessMessage(ctx context.Context, msg *ds.Message) err
 	}
 
 	// 运行 agent
-	iter := a.agent.Run(ctx, &adk.AgentInput{
-		Messages: messages,
-	})
-
-	for {
-		event, ok := iter.Next()
-		if !ok {
-			break
-		}
-		if event == nil {
-			continue
-		}
-		slog.Info("agent response",
-			slog.String("agent", a.name),
-			slog.String("output", fmt.Sprintf("%v", event.Output.MessageOutput)),
-		)
+	run := func(ctx context.Context) error {
+		return a.runAgent(ctx, "process_message", a.llmTimeout, messages, func(event *adk.AgentEvent) error {
+			if event.Output == nil {
+				return nil
+			}
+			output, err := a.readOutput("process_message", event.Output.MessageOutput)
+			if err != nil {
+				return err
+			}
+			slog.Info("agent response",
+				slog.String("agent", a.name),
+				slog.String("output", output),
+			)
+			return nil
+		})
 	}
-
-	return nil
+	if msg.Type == ds.MessageTypeRequest {
+		return a.handleRequestWithTimeout(ctx, msg, run)
+	}
+	return run(ctx)
 }
 
 // handleRequestMessage 处理请求消息
@@ -317,12 +656,15 @@ func (a *BaseAgentImpl) handleRequestMessage(ctx context.Context, body *ds.Reque
 }
 
 // handleNotificationMessage 处理通知消息
-func (a *BaseAgentImpl) handleNotificationMessage(ctx context.Context, body *ds.NotificationBody) error {
+func (a *BaseAgentImpl) handleNotificationMessage(ctx context.Context, sender string, body *ds.NotificationBody) error {
 	slog.Info("received notification",
 		slog.String("agent", a.name),
 		slog.String("title", body.Title),
 		slog.String("content", body.Content),
 	)
+	if a.alertToTask && isAlertPriority(body.Priority) {
+		return a.submitAlertTask(ctx, sender, body)
+	}
 	return nil
 }
 
@@ -336,6 +678,18 @@ func (a *BaseAgentImpl) handleResponseMessage(ctx context.Context, body *ds.Resp
 	return nil
 }
 
+// handleTaskCompleteMessage 处理委派任务的完成通知
+func (a *BaseAgentImpl) handleTaskCompleteMessage(ctx context.Context, sender string, body *ds.TaskCompleteBody) error {
+	slog.Info("delegated task completed",
+		slog.String("agent", a.name),
+		slog.String("from", sender),
+		slog.String("task_id", body.TaskID),
+		slog.Bool("success", body.Success),
+		slog.String("error", body.ErrorMessage),
+	)
+	return nil
+}
+
 // ProcessTask 处理任务（专门的任务处理逻辑）
 func (a *BaseAgentImpl) ProcessTask(ctx context.Context, task *ds.Task) error {
 	a.processingMu.Lock()
@@ -363,7 +717,7 @@ func (a *BaseAgentImpl) ProcessTask(ctx context.Context, task *ds.Task) error {
 	// 更新全局状态
 	if a.globalState != nil {
 		a.globalState.UpdateTask(task.ID, func(t *ds.Task) {
-			t.Status = ds.TaskStatusAssigned
+			t.Status = ds.TaskStatusProcessing
 			t.AssignedTo = a.name
 		})
 	}
@@ -384,10 +738,27 @@ func (a *BaseAgentImpl) ProcessTask(ctx context.Context, task *ds.Task) error {
 		return err
 	}
 	history.Status = "processing"
-	a.AddExecutionHistory(history)
+	// 执行期间记录的是快照，history 在本函数内继续修改（含 Output），结束时通过 updateExecutionHistory 在锁内替换，
+	// 避免与读取执行历史的查询并发读写
+	snapshot := *history
+	snapshot.Output = map[string]any{}
+	a.AddExecutionHistory(&snapshot)
+
+	// 重新执行（重试、重新分发）时重新打开执行记录；panic 时由 defer 关闭，避免订阅者一直等待
+	closeTranscript := sync.OnceFunc(func() {
+		if a.globalState != nil {
+			a.globalState.CloseTranscript(task.ID)
+		}
+	})
+	if a.globalState != nil {
+		a.globalState.OpenTranscript(task.ID)
+	}
+	defer closeTranscript()
 
-	// 调用agent处理任务
-	err = a.executeTask(ctx, task)
+	// 调用agent处理任务；executeTask panic 由监督者恢复时也要归还计数，否则 Agent 一直显示忙碌
+	a.executing.Add(1)
+	defer a.executing.Add(-1)
+	output, err := a.executeTask(ctx, task)
 
 	duration := time.Since(startTime)
 	history.Duration = duration
@@ -405,6 +776,18 @@ func (a *BaseAgentImpl) ProcessTask(ctx context.Context, task *ds.Task) error {
 		if a.globalState != nil {
 			a.globalState.UpdateTask(task.ID, func(t *ds.Task) {
 				t.Status = ds.TaskStatusFailed
+				for _, reason := range []error{ErrDeliverablesNotMet, ErrLLMTimeout} {
+					if errors.Is(err, reason) {
+						if t.Metadata == nil {
+							t.Metadata = make(map[string]any)
+						}
+						t.Metadata["failure_reason"] = reason.Error()
+					}
+				}
+				// 记录实际生效的超时，供调度器升级时在此基础上延长
+				if errors.Is(err, ErrLLMTimeout) {
+					t.Metadata[ds.MetadataLLMTimeout] = a.taskLLMTimeout(task).String()
+				}
 			})
 		}
 	} else {
@@ -413,6 +796,9 @@ func (a *BaseAgentImpl) ProcessTask(ctx context.Context, task *ds.Task) error {
 			"processed_at": time.Now(),
 			"duration_ms":  duration.Milliseconds(),
 		}
+		if a.selfReflection {
+			a.recordSelfRating(ctx, task, output, history.Output)
+		}
 
 		a.mu.Lock()
 		a.completedTasks = append(a.completedTasks, task.Copy())
@@ -425,14 +811,47 @@ func (a *BaseAgentImpl) ProcessTask(ctx context.Context, task *ds.Task) error {
 		a.workload = float64(len(a.currentTasks))
 		a.mu.Unlock()
 
+		var warnings []string
 		if a.globalState != nil {
+			result := &state.TaskResult{TaskID: task.ID, Agent: a.name, Title: task.Title, Status: string(ds.TaskStatusCompleted), Output: output}
 			a.globalState.UpdateTask(task.ID, func(t *ds.Task) {
 				t.Status = ds.TaskStatusCompleted
+				t.AddContributor(a.name)
+				if len(t.Warnings) > 0 {
+					t.Status = ds.TaskStatusCompletedWithWarnings
+					warnings = append(warnings, t.Warnings...)
+				}
+				result.Status = string(t.Status)
+				result.Result = t.Metadata[MetadataTaskResult]
 			})
+			a.globalState.RecordTaskResult(result)
+		}
+		// 子任务完成后，执行者计入父任务的贡献者
+		if parentID := task.ParentTaskID(); parentID != "" && a.globalState != nil {
+			if err := a.globalState.AddTaskContributor(parentID, a.name); err != nil {
+				slog.Warn("failed to record contributor on parent task",
+					slog.String("task_id", task.ID),
+					slog.String("parent_task_id", parentID),
+					slog.Any("error", err),
+				)
+			}
+		}
+		if len(warnings) > 0 {
+			history.Status = "success_with_warnings"
+			history.Output["warnings"] = warnings
+			task.Warnings = warnings
+			a.incrMetric("tasks_completed_with_warnings")
+			slog.Warn("task completed with warnings",
+				slog.String("agent", a.name),
+				slog.String("task_id", task.ID),
+				slog.Any("warnings", warnings),
+			)
 		}
 	}
 
 	a.updateExecutionHistory(history)
+	a.invalidateGenerationCache()
+	closeTranscript()
 
 	// 通知调度器任务完成
 	a.mu.RLock()
@@ -442,35 +861,152 @@ func (a *BaseAgentImpl) ProcessTask(ctx context.Context, task *ds.Task) error {
 		completeFn(task.ID, a.name, success)
 	}
 
+	if a.notifyCompletion {
+		a.sendCompletionNotice(task, err)
+	}
+
 	return err
 }
 
-// executeTask 执行任务
-func (a *BaseAgentImpl) executeTask(ctx context.Context, task *ds.Task) error {
+// sendCompletionNotice 向任务委派者发送任务完成消息，委派者不是本公司的 Agent 时跳过
+func (a *BaseAgentImpl) sendCompletionNotice(task *ds.Task, taskErr error) {
+	if a.mailboxBus == nil || task.AssignedBy == "" || task.AssignedBy == a.name {
+		return
+	}
+	if _, err := a.mailboxBus.GetMailbox(task.AssignedBy); err != nil {
+		return
+	}
+
+	errMsg := ""
+	if taskErr != nil {
+		errMsg = taskErr.Error()
+	}
+	metadata := map[string]any{
+		"title": task.Title,
+	}
+	if len(task.Warnings) > 0 {
+		metadata["warnings"] = task.Warnings
+	}
+	msg, err := ds.NewTaskCompleteMessage(task.ID, taskErr == nil, errMsg, metadata)
+	if err != nil {
+		slog.Error("failed to build task complete message", slog.String("task_id", task.ID), slog.Any("error", err))
+		return
+	}
+	msg.Sender = a.name
+	msg.Receiver = task.AssignedBy
+	if err := a.mailboxBus.Send(msg); err != nil {
+		slog.Error("failed to send task complete message",
+			slog.String("task_id", task.ID),
+			slog.String("receiver", task.AssignedBy),
+			slog.Any("error", err),
+		)
+	}
+}
+
+// executeTask 执行任务，返回最后一条助手回复
+func (a *BaseAgentImpl) executeTask(ctx context.Context, task *ds.Task) (string, error) {
+	// 记录正在执行的任务，执行过程中委派的子任务以其为父任务
+	ctx = tools.WithSourceTaskID(ctx, task.ID)
+	input := fmt.Sprintf("任务: %s\n描述: %s\n请完成此任务。", task.Title, task.Description)
+	if a.responseFormat == ResponseFormatJSON {
+		input += jsonFormatInstruction
+	}
 	messages := []*schema.Message{
-		schema.UserMessage(fmt.Sprintf("任务: %s\n描述: %s\n请完成此任务。", task.Title, task.Description)),
+		schema.UserMessage(input),
 	}
 
-	iter := a.agent.Run(ctx, &adk.AgentInput{
-		Messages: messages,
-	})
+	final, err := a.runTaskAgent(ctx, task, messages)
+	if err != nil {
+		return "", err
+	}
+	if a.responseFormat != ResponseFormatJSON {
+		return final, a.completeTaskResult(task, final, nil)
+	}
 
-	for {
-		event, ok := iter.Next()
-		if !ok {
-			break
+	// JSON 模式：校验最终回复可解析，失败时携带解析错误重试一次
+	result, parseErr := parseJSONResponse(final)
+	if parseErr != nil {
+		a.incrMetric("json_response_parse_failures")
+		messages = append(messages,
+			schema.AssistantMessage(final, nil),
+			schema.UserMessage(jsonRetryPrompt(parseErr)),
+		)
+		if final, err = a.runTaskAgent(ctx, task, messages); err != nil {
+			return "", err
 		}
-		if event == nil {
-			continue
+		if result, parseErr = parseJSONResponse(final); parseErr != nil {
+			a.incrMetric("json_response_parse_failures")
+			return "", fmt.Errorf("task %s: %w", task.ID, parseErr)
+		}
+	}
+	if err := a.completeTaskResult(task, final, result); err != nil {
+		return "", err
+	}
+	if a.globalState != nil {
+		a.globalState.UpdateTask(task.ID, func(t *ds.Task) {
+			if t.Metadata == nil {
+				t.Metadata = make(map[string]any)
+			}
+			t.Metadata[MetadataTaskResult] = result
+		})
+	}
+	return final, nil
+}
+
+// completeTaskResult 按配置校验任务交付物，未满足时返回 ErrDeliverablesNotMet
+func (a *BaseAgentImpl) completeTaskResult(task *ds.Task, final string, result any) error {
+	if !a.shouldCheckDeliverables(task) {
+		return nil
+	}
+	if err := verifyDeliverables(task.Deliverables, final, result); err != nil {
+		a.incrMetric("tasks_deliverables_not_met")
+		return fmt.Errorf("task %s: %w", task.ID, err)
+	}
+	return nil
+}
+
+// runTaskAgent 运行 agent 执行任务，返回最后一条助手回复
+func (a *BaseAgentImpl) runTaskAgent(ctx context.Context, task *ds.Task, messages []*schema.Message) (string, error) {
+	final := ""
+	timeout := a.taskLLMTimeout(task)
+	if a.globalState != nil {
+		// 记录开始时间与生效的超时，供查询任务剩余执行时间
+		startedAt := time.Now()
+		a.globalState.UpdateTask(task.ID, func(t *ds.Task) {
+			t.StartedAt = &startedAt
+			t.ExecTimeout = timeout
+		})
+	}
+	err := a.runAgent(ctx, "execute_task", timeout, messages, func(event *adk.AgentEvent) error {
+		// 仅含动作（如转交、退出）的事件没有消息输出
+		if event.Output == nil || event.Output.MessageOutput == nil {
+			return nil
+		}
+		output, err := a.readOutput("execute_task", event.Output.MessageOutput)
+		if err != nil {
+			return err
 		}
 		slog.Info("task execution output",
 			slog.String("agent", a.name),
 			slog.String("task_id", task.ID),
-			slog.String("output", fmt.Sprintf("%v", event.Output.MessageOutput)),
+			slog.String("output", output),
 		)
+		if a.globalState != nil {
+			a.globalState.AppendTranscript(task.ID, state.TranscriptEntry{
+				Agent:   a.name,
+				Role:    string(event.Output.MessageOutput.Role),
+				Content: output,
+			})
+		}
+		if event.Output.MessageOutput.Role == schema.Assistant {
+			final = output
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
 	}
-
-	return nil
+	return final, nil
 }
 
 // GetRoleHierarchy 获取角色层级
@@ -541,6 +1077,10 @@ func (a *BaseAgentImpl) GetExecutionHistoryByTimeRange(start, end time.Time) []*
 			result = append(result, history)
 		}
 	}
+	// 超出上限时只保留最近的记录
+	if len(result) > a.maxHistoryQuery {
+		result = result[len(result)-a.maxHistoryQuery:]
+	}
 	return result
 }
 
@@ -551,6 +1091,7 @@ func (a *BaseAgentImpl) GetRecentExecutions(count int) []*state.AgentExecutionHi
 	if count <= 0 {
 		return []*state.AgentExecutionHistory{}
 	}
+	count = min(count, a.maxHistoryQuery)
 	total := len(a.executionHistory)
 	if total == 0 {
 		return []*state.AgentExecutionHistory{}
@@ -564,6 +1105,11 @@ func (a *BaseAgentImpl) GetRecentExecutions(count int) []*state.AgentExecutionHi
 	return result
 }
 
+// GetMaxHistoryQuery 获取单次查询执行历史的最大条数
+func (a *BaseAgentImpl) GetMaxHistoryQuery() int {
+	return a.maxHistoryQuery
+}
+
 // SetGlobalState 设置全局状态
 func (a *BaseAgentImpl) SetGlobalState(gs *state.GlobalState) {
 	a.mu.Lock()
@@ -585,7 +1131,7 @@ func (a *BaseAgentImpl) GetLLMModel() model.ToolCallingChatModel {
 	return a.llmModel
 }
 
-// ReceiveMessage 接收消息
+// ReceiveMessage 接收消息，校验与拒收在信箱入箱时完成（与经 MailboxBus 投递的消息一致）
 func (a *BaseAgentImpl) ReceiveMessage(msg *ds.Message) error {
 	if msg == nil {
 		return fmt.Errorf("message is nil")
@@ -608,35 +1154,106 @@ func (a *BaseAgentImpl) Start() error {
 	if a.running {
 		return fmt.Errorf("agent is already running")
 	}
+	// 未设置全局状态时任务状态更新会被静默跳过，拒绝启动
+	if a.GetGlobalState() == nil {
+		return fmt.Errorf("agent %s: %w", a.name, ErrGlobalStateNotSet)
+	}
 	a.running = true
 	a.stopCh = make(chan struct{})
-
-	// 启动消息处理循环
-	a.wg.Add(1)
-	go a.messageProcessingLoop()
+	if a.senderOrder != nil {
+		a.senderOrder.reset()
+	}
+	a.ctx, a.cancel = context.WithCancel(context.Background())
+
+	// 启动消息处理循环：收件箱按类型分流，任务与非任务消息分别在各自的并发上限内处理
+	a.wg.Add(3)
+	a.heartbeat()
+	go a.superviseLoop("message_processing", a.messageProcessingLoop)
+	go a.superviseLoop("task_dispatch", func() { a.dispatchLoop(a.taskQueue, a.taskSem) })
+	go a.superviseLoop("message_dispatch", func() { a.dispatchLoop(a.msgQueue, a.msgSem) })
+	for i := range a.quickWorkers {
+		a.wg.Add(1)
+		go a.superviseLoop(fmt.Sprintf("quick_worker_%d", i), a.quickWorker)
+	}
+	if a.supervisor.enabled {
+		a.wg.Add(1)
+		go a.heartbeatMonitor()
+	}
 
 	// 启动任务生成循环
-	a.wg.Add(1)
-	go a.taskGenerationLoop()
+	if !a.manualTaskGen {
+		a.wg.Add(1)
+		go a.superviseLoop("task_generation", a.taskGenerationLoop)
+	}
+
+	// 启动状态变更触发的任务生成
+	if len(a.taskGenWatchKeys) > 0 && a.globalState != nil {
+		// 订阅跨越循环重启保持有效，监督结束（停止或放弃重启）后才取消
+		changes, unsubscribe := a.globalState.Subscribe(0)
+		a.wg.Add(1)
+		go func() {
+			defer unsubscribe()
+			a.superviseLoop("state_watch", func() { a.stateWatchLoop(changes) })
+		}()
+	}
+
+	// 向调度器通告能力，支持运行时加入的 Agent 与能力变化
+	a.advertiseCapabilities()
 
 	slog.Info("agent started", slog.String("name", a.name))
 	return nil
 }
 
-// Stop 停止 Agent
-func (a *BaseAgentImpl) Stop() error {
+// Stop 停止 Agent，ctx 到期时不再等待后台循环退出并返回错误
+func (a *BaseAgentImpl) Stop(ctx context.Context) error {
+	if a.drainMode == DrainModeProcess && a.IsRunning() {
+		a.waitInboxDrained(ctx)
+	}
+
 	a.processingMu.Lock()
-	defer a.processingMu.Unlock()
 	if !a.running {
+		a.processingMu.Unlock()
 		return nil
 	}
 	a.running = false
 	close(a.stopCh)
-	a.wg.Wait()
+	a.cancel()
+	// 等待前释放锁，避免执行中的任务读取运行状态时死锁
+	a.processingMu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		a.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return fmt.Errorf("agent %s did not stop: %w", a.name, ctx.Err())
+	}
+	if a.drainMode != "" {
+		a.archiveInbox()
+	}
+	a.saveMemory()
 	slog.Info("agent stopped", slog.String("name", a.name))
 	return nil
 }
 
+// lifecycleCtx 返回运行期上下文，Agent 停止后该上下文被取消
+func (a *BaseAgentImpl) lifecycleCtx() context.Context {
+	a.processingMu.RLock()
+	defer a.processingMu.RUnlock()
+	if a.ctx == nil {
+		return context.Background()
+	}
+	return a.ctx
+}
+
+// IsBusy 检查是否有任务正在执行（不含收件箱中排队的消息）
+func (a *BaseAgentImpl) IsBusy() bool {
+	return a.executing.Load() > 0
+}
+
 // IsRunning 检查是否正在运行
 func (a *BaseAgentImpl) IsRunning() bool {
 	a.processingMu.RLock()
@@ -655,7 +1272,12 @@ func (a *BaseAgentImpl) GetExecutionStats() map[string]interface{} {
 	}
 	var totalDuration time.Duration
 	var lastExecutionTime time.Time
+	var ratingSum, ratingCount int
 	for _, history := range a.executionHistory {
+		if score, ok := history.Output[MetadataSelfRating].(int); ok {
+			ratingSum += score
+			ratingCount++
+		}
 		switch history.Status {
 		case "success":
 			stats["success_count"] = stats["success_count"].(int) + 1
@@ -671,79 +1293,281 @@ func (a *BaseAgentImpl) GetExecutionStats() map[string]interface{} {
 		stats["avg_duration"] = totalDuration / time.Duration(len(a.executionHistory))
 		stats["last_execution_time"] = lastExecutionTime
 	}
+	if ratingCount > 0 {
+		stats["avg_self_rating"] = float64(ratingSum) / float64(ratingCount)
+		stats["self_rated_count"] = ratingCount
+	}
 	return stats
 }
 
-// messageProcessingLoop 消息处理循环
+// messageProcessingLoop 消息处理循环：将收件箱消息按任务/非任务分流到各自的等待队列
 func (a *BaseAgentImpl) messageProcessingLoop() {
-	defer a.wg.Done()
+	a.heartbeat()
+	ticker := time.NewTicker(a.supervisor.heartbeatInterval())
+	defer ticker.Stop()
 	for {
 		select {
 		case <-a.stopCh:
 			return
+		case <-ticker.C:
+			a.heartbeat()
 		case msg := <-a.mailbox.Inbox:
-			a.processMessageAsync(msg)
+			a.heartbeat()
+			if a.senderOrder != nil {
+				a.senderOrder.enter(msg)
+			}
+			queue := a.msgQueue
+			if _, ok := msg.GetTaskCreateBody(); ok {
+				queue = a.taskQueue
+			} else if isQuickMessage(msg) {
+				queue = a.quickQueue
+			}
+			// 等待队列满时仍保持心跳，排队等待不视为失活；进入等待队列后才从收件箱任务数中扣除，
+			// 使等待入队的任务仍计入在途任务数
+			for sent := false; !sent; {
+				select {
+				case <-a.stopCh:
+					a.mailbox.Received(msg)
+					a.requeuePending(msg)
+					return
+				case <-ticker.C:
+					a.heartbeat()
+				case queue <- msg:
+					a.mailbox.Received(msg)
+					sent = true
+				}
+			}
+		}
+	}
+}
+
+// dispatchLoop 从等待队列取出消息，获取并发槽位后异步处理；槽位占满时阻塞等待，多余的消息留在队列中排队
+func (a *BaseAgentImpl) dispatchLoop(queue <-chan *ds.Message, sem chan struct{}) {
+	for {
+		select {
+		case <-a.stopCh:
+			return
+		case msg := <-queue:
+			select {
+			case <-a.stopCh:
+				a.requeuePending(msg)
+				return
+			case sem <- struct{}{}:
+			}
+			a.wg.Add(1)
+			go func() {
+				defer a.wg.Done()
+				defer func() { <-sem }()
+				a.processRecovered(msg)
+			}()
 		}
 	}
 }
 
+// requeuePending 停止时将未开始处理的消息放回收件箱，由停止时的排空逻辑处理；
+// 绕过去重，否则开启去重时放回的消息会被当作重复消息丢弃
+func (a *BaseAgentImpl) requeuePending(msg *ds.Message) {
+	if a.senderOrder != nil {
+		a.senderOrder.leave(msg)
+	}
+	if err := a.mailbox.Requeue(msg); err != nil {
+		slog.Warn("failed to requeue pending message on stop",
+			slog.String("agent", a.name),
+			slog.String("message_id", msg.ID),
+			slog.Any("error", err),
+		)
+	}
+}
+
+// pendingCount 获取已分流但尚未开始处理的消息数
+func (a *BaseAgentImpl) pendingCount() int {
+	return len(a.taskQueue) + len(a.msgQueue) + len(a.quickQueue)
+}
+
+// GetMaxConcurrency 获取 Agent 允许同时执行的最大任务数
+func (a *BaseAgentImpl) GetMaxConcurrency() int {
+	return cap(a.taskSem)
+}
+
+// GetInFlightMessages 获取当前正在处理的非任务消息数
+func (a *BaseAgentImpl) GetInFlightMessages() int {
+	return len(a.msgSem) + int(a.quickBusy.Load())
+}
+
+// GetInFlightTasks 获取已投递且未完成的任务数（收件箱中未取出的、等待并发槽位的与正在执行的）
+func (a *BaseAgentImpl) GetInFlightTasks() int {
+	return a.mailbox.GetInboxTaskCount() + len(a.taskQueue) + len(a.taskSem)
+}
+
 // taskGenerationLoop 任务生成循环（Phase 2: 自驱任务生成）
 func (a *BaseAgentImpl) taskGenerationLoop() {
-	defer a.wg.Done()
 
-	// 首次生成前先等待系统完成初始化
+	// 首次生成前先等待系统完成初始化（叠加抖动，错开各 Agent 的生成时间）
 	select {
 	case <-a.stopCh:
 		return
-	case <-time.After(10 * time.Second):
+	case <-time.After(utils.Jitter(a.taskGenInitialDelay, a.taskGenJitter)):
 	}
 
-	ticker := time.NewTicker(a.taskGenInterval)
-	defer ticker.Stop()
+	timer := time.NewTimer(utils.Jitter(a.GetTaskGenInterval(), a.taskGenJitter))
+	defer timer.Stop()
 
 	for {
 		select {
 		case <-a.stopCh:
 			return
-		case <-ticker.C:
-			a.mu.RLock()
-			submitter := a.taskSubmitter
-			a.mu.RUnlock()
+		case d := <-a.taskGenReset:
+			if !timer.Stop() {
+				select {
+				case <-timer.C:
+				default:
+				}
+			}
+			timer.Reset(utils.Jitter(d, a.taskGenJitter))
+		case <-timer.C:
+			a.heartbeat()
+			a.runTaskGeneration("")
+			timer.Reset(utils.Jitter(a.GetTaskGenInterval(), a.taskGenJitter))
+		}
+	}
+}
 
-			if submitter == nil {
-				continue
+// SetManualTaskGen 设置是否关闭定时任务生成循环，需在 Start 之前调用；关闭后只能通过 TriggerTaskGeneration 触发生成
+func (a *BaseAgentImpl) SetManualTaskGen(manual bool) {
+	a.processingMu.Lock()
+	defer a.processingMu.Unlock()
+	a.manualTaskGen = manual
+}
+
+// SetTaskGenInterval 运行时调整任务生成间隔，生成循环在下一次 select 时按新间隔重置定时器
+func (a *BaseAgentImpl) SetTaskGenInterval(d time.Duration) error {
+	if d <= 0 {
+		return fmt.Errorf("task generation interval must be positive, got %s", d)
+	}
+	a.mu.Lock()
+	a.taskGenInterval = d
+	a.mu.Unlock()
+
+	// 只保留最新的间隔：通道已满时替换旧值
+	for {
+		select {
+		case a.taskGenReset <- d:
+			slog.Info("task generation interval updated",
+				slog.String("agent", a.name),
+				slog.Duration("interval", d),
+			)
+			return nil
+		default:
+			select {
+			case <-a.taskGenReset:
+			default:
 			}
+		}
+	}
+}
 
-			ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
-			tasks, err := a.GenerateTasks(ctx)
-			cancel()
+// GetTaskGenInterval 获取任务生成间隔
+func (a *BaseAgentImpl) GetTaskGenInterval() time.Duration {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.taskGenInterval
+}
 
-			if err != nil {
-				slog.Error("task generation failed",
-					slog.String("agent", a.name),
-					slog.Any("error", err),
-				)
+// stateWatchLoop 监听全局状态变更，被关注的键变化时触发一轮任务生成
+func (a *BaseAgentImpl) stateWatchLoop(changes <-chan state.StateChange) {
+	for {
+		select {
+		case <-a.stopCh:
+			return
+		case change, ok := <-changes:
+			if !ok {
+				return
+			}
+			if !slices.Contains(a.taskGenWatchKeys, change.Key) {
 				continue
 			}
+			a.heartbeat()
+			slog.Info("watched state changed, generating tasks",
+				slog.String("agent", a.name),
+				slog.String("kind", change.Kind),
+				slog.String("key", change.Key),
+			)
+			a.runTaskGeneration(fmt.Sprintf("%s %s = %v", change.Kind, change.Key, change.Value))
+		}
+	}
+}
 
-			for _, task := range tasks {
-				priority := string(task.Priority)
-				if priority == "" {
-					priority = "Medium"
-				}
-				submitter(task, priority)
-				slog.Info("auto-generated task submitted",
-					slog.String("agent", a.name),
-					slog.String("task_id", task.ID),
-					slog.String("title", task.Title),
-				)
-			}
+// runTaskGeneration 执行一轮任务生成并提交到调度器
+func (a *BaseAgentImpl) runTaskGeneration(trigger string) {
+	_, err := a.generateAndSubmit(a.lifecycleCtx(), trigger)
+	switch {
+	case err == nil:
+	case errors.Is(err, ErrTaskGenPaused), errors.Is(err, ErrTaskGenInProgress):
+		slog.Debug("task generation skipped", slog.String("agent", a.name), slog.Any("reason", err))
+	default:
+		slog.Error("task generation failed",
+			slog.String("agent", a.name),
+			slog.Any("error", err),
+		)
+	}
+}
+
+// TriggerTaskGeneration 立即执行一轮任务生成（不等待定时器）并提交到调度器，返回提交的任务 ID
+func (a *BaseAgentImpl) TriggerTaskGeneration(ctx context.Context) ([]string, error) {
+	return a.generateAndSubmit(ctx, "")
+}
+
+// generateAndSubmit 执行一轮任务生成并提交到调度器：受暂停开关约束，同一 Agent 同时只进行一轮，单轮最长 60 秒
+func (a *BaseAgentImpl) generateAndSubmit(ctx context.Context, trigger string) ([]string, error) {
+	a.mu.RLock()
+	submitter := a.taskSubmitter
+	guard := a.taskGenGuard
+	a.mu.RUnlock()
+
+	if submitter == nil {
+		return nil, fmt.Errorf("task submitter is not set")
+	}
+	if guard != nil && !guard() {
+		return nil, ErrTaskGenPaused
+	}
+	if !a.generating.CompareAndSwap(false, true) {
+		return nil, ErrTaskGenInProgress
+	}
+	defer a.generating.Store(false)
+
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	tasks, err := a.generateTasks(ctx, trigger)
+	cancel()
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(tasks))
+	for _, task := range tasks {
+		if a.skipDuplicateTask(task) {
+			continue
 		}
+		priority := string(task.Priority)
+		if priority == "" {
+			priority = "Medium"
+		}
+		submitter(task, priority)
+		ids = append(ids, task.ID)
+		slog.Info("auto-generated task submitted",
+			slog.String("agent", a.name),
+			slog.String("task_id", task.ID),
+			slog.String("title", task.Title),
+		)
 	}
+	return ids, nil
 }
 
 // processMessageAsync 异步处理消息
 func (a *BaseAgentImpl) processMessageAsync(msg *ds.Message) {
+	if a.senderOrder != nil {
+		a.senderOrder.wait(msg, a.stopCh)
+		defer a.senderOrder.leave(msg)
+	}
 	if taskBody, ok := msg.GetTaskCreateBody(); ok {
 		task := &ds.Task{
 			ID:           taskBody.TaskID,
@@ -751,21 +1575,53 @@ func (a *BaseAgentImpl) processMessageAsync(msg *ds.Message) {
 			Description:  taskBody.Description,
 			AssignedTo:   taskBody.AssignedTo,
 			AssignedBy:   taskBody.AssignedBy,
+			Priority:     taskBody.Priority,
+			Type:         taskBody.Type,
 			Dependencies: taskBody.Dependencies,
 			Deliverables: taskBody.Deliverables,
 			Metadata:     taskBody.Metadata,
 		}
+		// 旧版消息体不含优先级，回退到元数据中的 priority
+		if task.Priority == "" {
+			if p, ok := task.Metadata["priority"].(string); ok {
+				task.Priority = ds.TaskPriority(strings.ToLower(p))
+			}
+		}
 		if taskBody.Deadline != nil {
 			if t, err := time.Parse(time.RFC3339, *taskBody.Deadline); err == nil {
 				task.Deadline = &t
 			}
 		}
-		a.ProcessTask(context.Background(), task)
+		if !a.claimTask(task.ID) {
+			slog.Info("task reassigned before start, skipping",
+				slog.String("agent", a.name),
+				slog.String("task_id", task.ID),
+			)
+			return
+		}
+		a.ProcessTask(a.lifecycleCtx(), task)
 	} else {
-		a.ProcessMessage(context.Background(), msg)
+		a.ProcessMessage(a.lifecycleCtx(), msg)
+		a.incrMetric("messages_processed")
 	}
 }
 
+// claimTask 开始执行前领取任务：任务在全局状态中仍分配给自己且未开始执行时标记为执行中。
+// 任务已被调度器收回或改派时返回 false；不在全局状态中的任务直接执行
+func (a *BaseAgentImpl) claimTask(taskID string) bool {
+	if a.globalState == nil || a.globalState.GetTask(taskID) == nil {
+		return true
+	}
+	claimed := false
+	a.globalState.UpdateTask(taskID, func(t *ds.Task) {
+		if t.AssignedTo == a.name && t.Status == ds.TaskStatusAssigned {
+			t.Status = ds.TaskStatusProcessing
+			claimed = true
+		}
+	})
+	return claimed
+}
+
 // CreateExecutionHistory 创建执行历史
 func (a *BaseAgentImpl) CreateExecutionHistory(taskID, messageID, action string, input, output map[string]any) (*state.AgentExecutionHistory, error) {
 	id, err := utils.NewUUID()
@@ -801,50 +1657,118 @@ func (a *BaseAgentImpl) updateExecutionHistory(newHistory *state.AgentExecutionH
 
 // GenerateTasks 通过 LLM 生成该 Agent 需要执行的任务
 func (a *BaseAgentImpl) GenerateTasks(ctx context.Context) ([]*ds.Task, error) {
+	return a.generateTasks(ctx, "")
+}
+
+// generateTasks 生成任务，trigger 非空时作为触发本轮生成的背景信息附加到提示词
+func (a *BaseAgentImpl) generateTasks(ctx context.Context, trigger string) ([]*ds.Task, error) {
 	prompt := fmt.Sprintf(`你是 %s，职责描述：%s
 
-请根据你的角色职责，生成 1-3 个你当前应该执行的工作任务。
+请根据你的角色职责，生成 1-%d 个你当前应该执行的工作任务。
 每个任务应该是具体的、可执行的。
 
 请严格按照以下 JSON 数组格式返回，不要包含任何其他文字：
-[{"title": "任务标题", "description": "任务详细描述", "priority": "Medium"}]
+%s
 
 priority 可选值: Critical, High, Medium, Low
-`, a.name, a.desc)
+type 可选值: analysis, decision, implementation, report
+%s`, a.name, a.GetDesc(), a.maxTasksPerGen, a.taskGenSchema.example(), a.taskGenSchema.hints())
+	if trigger != "" {
+		prompt += fmt.Sprintf("\n本轮生成由以下状态变化触发，请优先针对该变化生成任务：\n%s\n", trigger)
+	}
+
+	if a.genCache != nil {
+		if content, ok := a.genCache.get(prompt); ok {
+			if tasks, err := a.parseLLMTasks(content); err == nil {
+				a.incrMetric("task_gen_cache_hits")
+				return tasks, nil
+			}
+		}
+	}
+
+	// 排队等待全系统的任务生成槽位，平滑对模型服务的并发压力
+	a.mu.RLock()
+	limiter := a.taskGenLimiter
+	a.mu.RUnlock()
+	if err := limiter.Acquire(ctx); err != nil {
+		return nil, fmt.Errorf("wait for task generation slot: %w", err)
+	}
+	defer limiter.Release()
 
 	messages := []*schema.Message{
 		schema.UserMessage(prompt),
 	}
 
-	resp, err := a.llmModel.Generate(ctx, messages)
+	resp, err := a.GetLLMModel().Generate(ctx, messages)
 	if err != nil {
 		return nil, fmt.Errorf("LLM generate failed: %w", err)
 	}
 
-	content := resp.Content
-	if content == "" {
+	// 解析 LLM 返回的 JSON
+	content := a.limitResponse("generate_tasks", resp.Content)
+	tasks, parseErr := a.parseLLMTasks(content)
+	if parseErr == nil {
+		if a.genCache != nil {
+			a.genCache.put(prompt, content)
+		}
+		return tasks, nil
+	}
+	a.incrMetric("task_gen_parse_failures")
+	slog.Warn("failed to parse LLM task response",
+		slog.String("agent", a.name),
+		slog.String("content", content),
+		slog.Any("error", parseErr),
+	)
+	if !a.taskGenReformatRetry {
 		return make([]*ds.Task, 0), nil
 	}
 
-	// 解析 LLM 返回的 JSON
-	tasks, err := a.parseLLMTasks(content)
+	// 附上解析错误与原始输出，要求模型重新按 JSON 格式输出（仅重试一次）
+	messages = append(messages,
+		schema.AssistantMessage(content, nil),
+		schema.UserMessage(fmt.Sprintf(`你上面的输出无法解析为 JSON 数组，解析错误：%v
+
+请将上面的内容重新整理为合法的 JSON 数组，格式为：
+%s
+只返回 JSON，不要包含任何其他文字。`, parseErr, a.taskGenSchema.example())),
+	)
+	resp, err = a.GetLLMModel().Generate(ctx, messages)
 	if err != nil {
-		slog.Warn("failed to parse LLM task response",
+		return nil, fmt.Errorf("LLM reformat generate failed: %w", err)
+	}
+
+	content = a.limitResponse("generate_tasks", resp.Content)
+	tasks, parseErr = a.parseLLMTasks(content)
+	if parseErr != nil {
+		a.incrMetric("task_gen_parse_failures")
+		slog.Warn("failed to parse reformatted LLM task response",
 			slog.String("agent", a.name),
 			slog.String("content", content),
-			slog.Any("error", err),
+			slog.Any("error", parseErr),
 		)
 		return make([]*ds.Task, 0), nil
 	}
+	a.incrMetric("task_gen_reformat_recovered")
+	if a.genCache != nil {
+		a.genCache.put(prompt, content)
+	}
 
 	return tasks, nil
 }
 
+// incrMetric 对性能指标计数加一
+func (a *BaseAgentImpl) incrMetric(key string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.performanceMetrics[key]++
+}
+
 // llmTaskResult LLM 返回的任务结构
 type llmTaskResult struct {
 	Title       string `json:"title"`
 	Description string `json:"description"`
 	Priority    string `json:"priority"`
+	Type        string `json:"type"`
 }
 
 // parseLLMTasks 从 LLM 响应中解析任务列表
@@ -856,9 +1780,13 @@ func (a *BaseAgentImpl) parseLLMTasks(content string) ([]*ds.Task, error) {
 	if err := json.Unmarshal([]byte(jsonStr), &results); err != nil {
 		return nil, fmt.Errorf("json unmarshal failed: %w", err)
 	}
+	var raws []map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(jsonStr), &raws); err != nil {
+		return nil, fmt.Errorf("json unmarshal failed: %w", err)
+	}
 
 	tasks := make([]*ds.Task, 0, len(results))
-	for _, r := range results {
+	for i, r := range results {
 		if r.Title == "" {
 			continue
 		}
@@ -876,11 +1804,26 @@ func (a *BaseAgentImpl) parseLLMTasks(content string) ([]*ds.Task, error) {
 			ds.TaskStatusPending,
 			priority,
 		)
+		task.Type = ds.TaskType(r.Type)
 		task.Metadata["source"] = "llm_generated"
 		task.Metadata["generated_by"] = a.name
+		if err := a.taskGenSchema.apply(task, raws[i]); err != nil {
+			return nil, err
+		}
 		tasks = append(tasks, task)
 	}
 
+	if len(tasks) > a.maxTasksPerGen {
+		slog.Warn("generated tasks exceed per-cycle limit, truncating",
+			slog.String("agent", a.name),
+			slog.Int("generated", len(tasks)),
+			slog.Int("max_tasks_per_gen", a.maxTasksPerGen),
+		)
+		a.incrMetric("task_gen_truncated")
+		tasks = tasks[:a.maxTasksPerGen]
+	}
+	a.resolveDependencies(tasks)
+
 	return tasks, nil
 }
 
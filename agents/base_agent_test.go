@@ -0,0 +1,289 @@
+package agents
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"superman/config"
+	"superman/ds"
+	"superman/mailbox"
+	"superman/state"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// runTestTask 将任务加入全局状态并同步执行
+func runTestTask(t *testing.T, agent *BaseAgentImpl, id string) *ds.Task {
+	t.Helper()
+	task := ds.NewTask(id, "t", "d", agent.GetName(), "boss", ds.TaskStatusAssigned, ds.TaskPriorityMedium)
+	agent.globalState.AddTask(task)
+	if err := agent.ProcessTask(context.Background(), task); err != nil {
+		t.Fatalf("ProcessTask(%s): %v", id, err)
+	}
+	return task
+}
+
+// 配置的系统提示词出现在 Agent 运行时的模型输入中，未配置时使用根据描述生成的默认提示词
+func TestSystemPromptInRunInput(t *testing.T) {
+	llm := newFakeModel("done")
+	agent, _ := newTestAgent(t, llm, config.AgentConfig{SystemPrompt: "回答必须使用正式书面语，并附上数据来源。"})
+	startTestAgent(t, agent)
+	runTestTask(t, agent, "task-1")
+	if prompt := llm.lastPrompt(); !strings.Contains(prompt, "回答必须使用正式书面语") {
+		t.Fatalf("run input lacks the configured system prompt:\n%s", prompt)
+	}
+
+	llm = newFakeModel("done")
+	agent, _ = newTestAgent(t, llm, config.AgentConfig{Desc: "负责财务预算"})
+	startTestAgent(t, agent)
+	runTestTask(t, agent, "task-2")
+	if prompt := llm.lastPrompt(); !strings.Contains(prompt, "职责描述：负责财务预算") {
+		t.Fatalf("run input lacks the default system prompt:\n%s", prompt)
+	}
+}
+
+// 收件箱涌入大量任务消息时，同时执行的任务数不超过配置的并发上限
+func TestAgentLimitsTaskConcurrency(t *testing.T) {
+	var running, peak atomic.Int32
+	release := make(chan struct{})
+	llm := &fakeModel{reply: func([]*schema.Message) (*schema.Message, error) {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for p := peak.Load(); n > p && !peak.CompareAndSwap(p, n); p = peak.Load() {
+		}
+		<-release
+		return schema.AssistantMessage("done", nil), nil
+	}}
+	agent, bus := newTestAgent(t, llm, config.AgentConfig{MaxTasks: 2})
+	startTestAgent(t, agent)
+
+	const total = 6
+	for i := range total {
+		id := fmt.Sprintf("task-%d", i)
+		bus.GetGlobalState().AddTask(ds.NewTask(id, "t", "d", agent.GetName(), "boss", ds.TaskStatusAssigned, ds.TaskPriorityMedium))
+		msg, _ := ds.NewTaskCreateMessage(id, "t", "d", agent.GetName(), "boss", nil, nil, nil, nil)
+		if err := bus.Send(msg); err != nil {
+			t.Fatalf("Send(%s): %v", id, err)
+		}
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for running.Load() < 2 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	time.Sleep(50 * time.Millisecond) // 给超出上限的任务留出启动的机会
+	if got := agent.GetMaxConcurrency(); got != 2 {
+		t.Fatalf("GetMaxConcurrency = %d, want 2", got)
+	}
+	if got := running.Load(); got != 2 {
+		t.Fatalf("%d tasks running with a flooded inbox, want 2", got)
+	}
+	close(release)
+
+	for i := range total {
+		id := fmt.Sprintf("task-%d", i)
+		for bus.GetGlobalState().GetTaskCopy(id).Status != ds.TaskStatusCompleted {
+			if time.Now().After(deadline) {
+				t.Fatalf("task %s status = %s, want completed", id, bus.GetGlobalState().GetTaskCopy(id).Status)
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+	if got := peak.Load(); got > 2 {
+		t.Fatalf("peak concurrent tasks = %d, want at most 2", got)
+	}
+}
+
+// 委派的任务完成后，委派者收到任务完成消息
+func TestCompletedTaskNotifiesDelegator(t *testing.T) {
+	agent, bus := newTestAgent(t, newFakeModel("done"), config.AgentConfig{NotifyCompletion: true})
+	boss := mailbox.NewMailbox(mailbox.DefaultMailboxConfig("boss"))
+	if err := bus.RegisterMailbox("boss", boss); err != nil {
+		t.Fatalf("register mailbox: %v", err)
+	}
+	startTestAgent(t, agent)
+
+	runTestTask(t, agent, "task-1")
+
+	select {
+	case msg := <-boss.Inbox:
+		body, ok := msg.GetTaskCompleteBody()
+		if !ok || body.TaskID != "task-1" || !body.Success || msg.Sender != agent.GetName() {
+			t.Fatalf("delegator got %s from %s: %+v, want a successful completion of task-1", msg.Type, msg.Sender, msg.Body)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("delegator received no completion message")
+	}
+}
+
+// 带告警完成的任务计为成功完成，同时在任务与指标中保留告警
+func TestTaskCompletedWithWarnings(t *testing.T) {
+	var agent *BaseAgentImpl
+	llm := &fakeModel{reply: func([]*schema.Message) (*schema.Message, error) {
+		// 模拟执行过程中调用告警工具
+		if err := agent.globalState.AddTaskWarning("task-1", "revenue data for March is missing"); err != nil {
+			t.Errorf("AddTaskWarning: %v", err)
+		}
+		return schema.AssistantMessage("done", nil), nil
+	}}
+	agent, _ = newTestAgent(t, llm, config.AgentConfig{})
+	var succeeded bool
+	agent.SetOnTaskComplete(func(_, _ string, success bool) { succeeded = success })
+	startTestAgent(t, agent)
+
+	runTestTask(t, agent, "task-1")
+
+	task := agent.globalState.GetTask("task-1")
+	if task.Status != ds.TaskStatusCompletedWithWarnings || !task.IsSucceeded() || !succeeded {
+		t.Fatalf("status = %s, reported success = %v, want completed with warnings counted as success", task.Status, succeeded)
+	}
+	if len(task.Warnings) != 1 || task.Warnings[0] != "revenue data for March is missing" {
+		t.Fatalf("warnings = %v, want the reported warning", task.Warnings)
+	}
+	if got := agent.GetState().PerformanceMetrics["tasks_completed_with_warnings"]; got != 1 {
+		t.Fatalf("tasks_completed_with_warnings = %v, want 1", got)
+	}
+}
+
+// 通过委派子任务完成的任务同时列出执行者与完成子任务的贡献者
+func TestDelegatedSubtaskAddsContributor(t *testing.T) {
+	lead, _ := newTestAgent(t, newFakeModel("done"), config.AgentConfig{Name: "lead"})
+	analyst, _ := newTestAgent(t, newFakeModel("numbers ready"), config.AgentConfig{Name: "analyst"})
+	analyst.SetGlobalState(lead.globalState)
+	startTestAgent(t, lead)
+	startTestAgent(t, analyst)
+
+	parent := ds.NewTask("parent", "budget", "plan the budget", "lead", "boss", ds.TaskStatusAssigned, ds.TaskPriorityMedium)
+	lead.globalState.AddTask(parent)
+	sub := ds.NewTask("sub", "numbers", "collect numbers", "analyst", "lead", ds.TaskStatusAssigned, ds.TaskPriorityMedium)
+	sub.Metadata[ds.MetadataParentTaskID] = parent.ID
+	lead.globalState.AddTask(sub)
+
+	if err := analyst.ProcessTask(context.Background(), sub); err != nil {
+		t.Fatalf("ProcessTask(sub): %v", err)
+	}
+	if err := lead.ProcessTask(context.Background(), parent); err != nil {
+		t.Fatalf("ProcessTask(parent): %v", err)
+	}
+
+	got := lead.globalState.GetTask("parent").Contributors
+	if len(got) != 2 || !slices.Contains(got, "lead") || !slices.Contains(got, "analyst") {
+		t.Fatalf("contributors = %v, want lead and analyst", got)
+	}
+}
+
+// 并发处理各类消息并同时接收消息、读取状态时不死锁，入站消息全部计入消息记录
+func TestConcurrentProcessMessageDoesNotDeadlock(t *testing.T) {
+	agent, _ := newTestAgent(t, newFakeModel("ok"), config.AgentConfig{Name: "operations"})
+	startTestAgent(t, agent)
+
+	newMessages := func(i int) []*ds.Message {
+		query, _ := ds.NewRequestMessage("ceo", "operations", "task_query", nil, nil)
+		status, _ := ds.NewRequestMessage("ceo", "operations", "status_report", fmt.Sprintf("report %d", i), nil)
+		notice, _ := ds.NewNotificationMessage("ceo", "operations", "heads up", "maintenance tonight", "low")
+		resp, _ := ds.NewResponseMessage(fmt.Sprintf("req-%d", i), true, "ok", "")
+		done, _ := ds.NewTaskCompleteMessage(fmt.Sprintf("task-%d", i), true, "", nil)
+		return []*ds.Message{query, status, notice, resp, done}
+	}
+
+	const workers, rounds = 8, 20
+	var wg sync.WaitGroup
+	for w := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range rounds {
+				for _, msg := range newMessages(w*rounds + i) {
+					if err := agent.ProcessMessage(context.Background(), msg); err != nil {
+						t.Errorf("ProcessMessage(%s): %v", msg.Type, err)
+					}
+				}
+				received, _ := ds.NewRequestMessage("ceo", "operations", "status_report", "inbound", nil)
+				if err := agent.ReceiveMessage(received); err != nil {
+					t.Errorf("ReceiveMessage: %v", err)
+				}
+				_ = agent.GetState()
+			}
+		}()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("concurrent ProcessMessage calls deadlocked")
+	}
+	if got := len(agent.GetState().Messages); got != workers*rounds {
+		t.Fatalf("recorded %d inbound messages, want %d", got, workers*rounds)
+	}
+}
+
+// 未设置全局状态的 Agent 启动失败并返回 ErrGlobalStateNotSet，设置后可以正常启动
+func TestStartRequiresGlobalState(t *testing.T) {
+	cfg := config.AgentConfig{Name: "worker", Desc: "test agent", SkillDir: t.TempDir(), TaskGenInitialDelay: "1h"}
+	agent, err := NewBaseAgent(context.Background(), newFakeModel("ok"), mailbox.NewMailboxBus(), cfg)
+	if err != nil {
+		t.Fatalf("NewBaseAgent: %v", err)
+	}
+
+	if err := agent.Start(); !errors.Is(err, ErrGlobalStateNotSet) {
+		t.Fatalf("Start without global state = %v, want ErrGlobalStateNotSet", err)
+	}
+	if agent.IsRunning() {
+		t.Fatal("agent running after a failed start")
+	}
+
+	agent.SetGlobalState(state.NewGlobalState())
+	startTestAgent(t, agent)
+}
+
+// 执行任务期间 Agent 报告忙碌，任务完成后恢复空闲
+func TestBusyWhileExecutingTask(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	llm := &fakeModel{reply: func([]*schema.Message) (*schema.Message, error) {
+		close(started)
+		<-release
+		return schema.AssistantMessage("done", nil), nil
+	}}
+	agent, _ := newTestAgent(t, llm, config.AgentConfig{})
+	startTestAgent(t, agent)
+	if agent.IsBusy() {
+		t.Fatal("idle agent reports busy")
+	}
+
+	task := ds.NewTask("task-1", "t", "d", agent.GetName(), "boss", ds.TaskStatusAssigned, ds.TaskPriorityMedium)
+	agent.globalState.AddTask(task)
+	done := make(chan error, 1)
+	go func() { done <- agent.ProcessTask(context.Background(), task) }()
+	<-started
+	if !agent.IsBusy() {
+		t.Fatal("agent mid-task reports idle")
+	}
+
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatalf("ProcessTask: %v", err)
+	}
+	if agent.IsBusy() {
+		t.Fatal("agent still busy after the task completed")
+	}
+}
+
+// 角色为 CFO 的 Agent 默认具备财务能力，配置的能力在其后追加
+func TestRoleDefaultCapabilities(t *testing.T) {
+	agent, _ := newTestAgent(t, newFakeModel("ok"), config.AgentConfig{Name: "cfo", Role: "cfo", Capabilities: []string{"tax"}})
+	if got, want := agent.GetCapabilities(), []string{"finance", "budget", "accounting", "tax"}; !slices.Equal(got, want) {
+		t.Fatalf("capabilities = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,36 @@
+package agents
+
+import (
+	"log/slog"
+	"slices"
+)
+
+// SetCapabilities 更新 Agent 具备的能力，运行中时立即向调度器重新通告
+func (a *BaseAgentImpl) SetCapabilities(capabilities []string) {
+	a.mu.Lock()
+	a.capabilities = slices.Clone(capabilities)
+	a.mu.Unlock()
+	if a.IsRunning() {
+		a.advertiseCapabilities()
+	}
+}
+
+// GetCapabilities 获取 Agent 具备的能力
+func (a *BaseAgentImpl) GetCapabilities() []string {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return slices.Clone(a.capabilities)
+}
+
+// advertiseCapabilities 通过 MailboxBus 向调度器通告自己的能力；同事的能力通过 list agents 工具查询，不逐一广播
+func (a *BaseAgentImpl) advertiseCapabilities() {
+	if a.mailboxBus == nil {
+		return
+	}
+	capabilities := a.GetCapabilities()
+	a.mailboxBus.AdvertiseCapabilities(a.name, capabilities)
+	slog.Debug("capabilities advertised",
+		slog.String("agent", a.name),
+		slog.Any("capabilities", capabilities),
+	)
+}
@@ -0,0 +1,63 @@
+package agents
+
+import (
+	"fmt"
+	"log/slog"
+
+	"superman/ds"
+)
+
+// submitDelegatedTask 将委派工具创建的子任务提交到调度器
+func (a *BaseAgentImpl) submitDelegatedTask(task *ds.Task) error {
+	a.mu.RLock()
+	submitter := a.taskSubmitter
+	a.mu.RUnlock()
+	if submitter == nil {
+		return fmt.Errorf("task submitter is not set")
+	}
+
+	submitter(task, queuePriority(task.Priority))
+	a.linkSourceMessage(task)
+	a.incrMetric("delegations_created")
+	slog.Info("task delegated",
+		slog.String("agent", a.name),
+		slog.String("to", task.AssignedTo),
+		slog.String("task_id", task.ID),
+		slog.String("parent_task_id", task.ParentTaskID()),
+		slog.Int("depth", task.DelegationDepth()),
+	)
+	return nil
+}
+
+// linkSourceMessage 在全局状态中记录任务与触发它的消息之间的关联
+func (a *BaseAgentImpl) linkSourceMessage(task *ds.Task) {
+	if a.globalState == nil {
+		return
+	}
+	a.globalState.LinkMessageTask(task.SourceMessageID(), task.ID)
+}
+
+// rejectDelegation 记录因超过最大委派深度而被拒绝的委派
+func (a *BaseAgentImpl) rejectDelegation(task *ds.Task, reason string) {
+	a.incrMetric("delegations_rejected")
+	slog.Warn("delegation rejected",
+		slog.String("agent", a.name),
+		slog.String("to", task.AssignedTo),
+		slog.String("parent_task_id", task.ParentTaskID()),
+		slog.String("reason", reason),
+	)
+}
+
+// queuePriority 将任务优先级映射为调度器队列名
+func queuePriority(priority ds.TaskPriority) string {
+	switch priority {
+	case ds.TaskPriorityCritical:
+		return "Critical"
+	case ds.TaskPriorityHigh:
+		return "High"
+	case ds.TaskPriorityLow:
+		return "Low"
+	default:
+		return "Medium"
+	}
+}
@@ -0,0 +1,53 @@
+package agents
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"superman/ds"
+)
+
+// MetadataCheckDeliverables 任务元数据中覆盖 Agent 交付物校验开关的键（bool）
+const MetadataCheckDeliverables = "check_deliverables"
+
+// ErrDeliverablesNotMet 任务执行结束但交付物未满足
+var ErrDeliverablesNotMet = errors.New("deliverables_not_met")
+
+// shouldCheckDeliverables 判断任务完成前是否需要校验交付物，任务元数据优先于 Agent 配置
+func (a *BaseAgentImpl) shouldCheckDeliverables(task *ds.Task) bool {
+	if v, ok := task.Metadata[MetadataCheckDeliverables].(bool); ok {
+		return v
+	}
+	return a.checkDeliverables
+}
+
+// verifyDeliverables 校验任务结果是否满足交付物要求：
+// JSON 结果为对象时要求包含每个交付物对应的键，否则要求最终回复中提及每个交付物；
+// 未声明交付物时要求最终回复非空
+func verifyDeliverables(deliverables []string, final string, result any) error {
+	if len(deliverables) == 0 {
+		if strings.TrimSpace(final) == "" {
+			return fmt.Errorf("%w: empty result", ErrDeliverablesNotMet)
+		}
+		return nil
+	}
+
+	fields, isObject := result.(map[string]any)
+	lower := strings.ToLower(final)
+	var missing []string
+	for _, d := range deliverables {
+		if isObject {
+			if _, ok := fields[d]; ok {
+				continue
+			}
+		} else if strings.Contains(lower, strings.ToLower(d)) {
+			continue
+		}
+		missing = append(missing, d)
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: missing %s", ErrDeliverablesNotMet, strings.Join(missing, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package agents
+
+import (
+	"context"
+	"testing"
+
+	"superman/config"
+	"superman/ds"
+)
+
+// 开启交付物校验后，回复未提及任何交付物的任务以 deliverables_not_met 失败；关闭时同样的回复视为完成
+func TestMissingDeliverablesFailTask(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		check bool
+		want  ds.TaskStatus
+	}{
+		{"enabled", true, ds.TaskStatusFailed},
+		{"disabled", false, ds.TaskStatusCompleted},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			agent, _ := newTestAgent(t, newFakeModel("已经看过了。"), config.AgentConfig{CheckDeliverables: tc.check})
+			startTestAgent(t, agent)
+
+			task := ds.NewTask("task-1", "t", "d", agent.GetName(), "boss", ds.TaskStatusAssigned, ds.TaskPriorityMedium)
+			task.Deliverables = []string{"预算报告"}
+			agent.globalState.AddTask(task)
+			_ = agent.ProcessTask(context.Background(), task)
+
+			got := agent.globalState.GetTask("task-1")
+			if got.Status != tc.want {
+				t.Fatalf("status = %s, want %s", got.Status, tc.want)
+			}
+			if tc.check && got.Metadata["failure_reason"] != ErrDeliverablesNotMet.Error() {
+				t.Fatalf("failure_reason = %v, want %s", got.Metadata["failure_reason"], ErrDeliverablesNotMet)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package agents
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"superman/ds"
+)
+
+// 停止时的收件箱处理方式
+const (
+	DrainModeProcess = "process" // 停止前继续处理收件箱，超时后剩余消息归档
+	DrainModeArchive = "archive" // 停止后将收件箱剩余消息归档
+)
+
+// waitInboxDrained 等待消息处理循环消费完收件箱与等待队列并处理完进行中的消息与任务，最长等待 drainTimeout，
+// 停止的 ctx 先到期时立即返回，避免分批停止时各批的排空时间累加超出整体停止时限
+func (a *BaseAgentImpl) waitInboxDrained(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, a.drainTimeout)
+	defer cancel()
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+
+	for a.undrainedCount() > 0 {
+		select {
+		case <-ctx.Done():
+			slog.Warn("inbox drain timed out",
+				slog.String("agent", a.name),
+				slog.Int("remaining", a.undrainedCount()),
+			)
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// undrainedCount 收件箱、等待队列中未取出的消息与正在处理的消息、任务总数
+func (a *BaseAgentImpl) undrainedCount() int {
+	return a.mailbox.GetInboxCount() + a.pendingCount() + a.GetInFlightMessages() + len(a.taskSem)
+}
+
+// archiveInbox 将收件箱与等待队列中剩余的消息归档，避免停止时丢失
+func (a *BaseAgentImpl) archiveInbox() {
+	archived := 0
+	for _, queue := range []chan *ds.Message{a.taskQueue, a.msgQueue, a.quickQueue} {
+		for len(queue) > 0 {
+			a.mailbox.ArchiveMessage(<-queue)
+			archived++
+		}
+	}
+	for msg := a.mailbox.TryPopInbox(); msg != nil; msg = a.mailbox.TryPopInbox() {
+		a.mailbox.ArchiveMessage(msg)
+		archived++
+	}
+	if archived > 0 {
+		slog.Info("archived undelivered inbox messages on stop",
+			slog.String("agent", a.name),
+			slog.Int("count", archived),
+		)
+	}
+}
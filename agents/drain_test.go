@@ -0,0 +1,174 @@
+package agents
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"superman/config"
+	"superman/ds"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+)
+
+// blockingModel 在 ctx 取消前一直阻塞的模型
+type blockingModel struct{ fakeModel }
+
+func (m *blockingModel) Generate(ctx context.Context, input []*schema.Message, _ ...model.Option) (*schema.Message, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (m *blockingModel) Stream(ctx context.Context, input []*schema.Message, _ ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (m *blockingModel) WithTools([]*schema.ToolInfo) (model.ToolCallingChatModel, error) {
+	return m, nil
+}
+
+// process 模式下停止的 ctx 到期时不再等待收件箱排空
+func TestDrainStopsWaitingWhenContextExpires(t *testing.T) {
+	agent, bus := newTestAgent(t, &blockingModel{}, config.AgentConfig{
+		DrainOnStop:  DrainModeProcess,
+		DrainTimeout: "1m",
+	})
+	if err := agent.Start(); err != nil {
+		t.Fatal(err)
+	}
+	for range 3 {
+		msg, _ := ds.NewMessage("boss", agent.GetName(), ds.MessageTypeSystem, "work")
+		if err := bus.Send(msg); err != nil {
+			t.Fatalf("Send: %v", err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_ = agent.Stop(ctx)
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("Stop took %s, want it bounded by the stop context", elapsed)
+	}
+}
+
+// archive 模式下停止时未处理的消息被归档而不是丢弃
+func TestStopArchivesQueuedMessages(t *testing.T) {
+	agent, bus := newTestAgent(t, &blockingModel{}, config.AgentConfig{DrainOnStop: DrainModeArchive})
+	if err := agent.Start(); err != nil {
+		t.Fatal(err)
+	}
+	for range 5 {
+		msg, _ := ds.NewMessage("boss", agent.GetName(), ds.MessageTypeSystem, "work")
+		if err := bus.Send(msg); err != nil {
+			t.Fatalf("Send: %v", err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := agent.Stop(ctx); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	mb := agent.GetMailbox()
+	if n := mb.GetInboxCount(); n != 0 {
+		t.Fatalf("inbox still holds %d messages after stop", n)
+	}
+	if mb.GetArchiveCount() == 0 {
+		t.Fatal("queued messages were discarded, want them archived")
+	}
+}
+
+// process 模式下停止前处理完收件箱中的消息
+func TestStopProcessesQueuedMessages(t *testing.T) {
+	// 每条消息处理需要一段时间，停止时收件箱中仍有排队的消息
+	llm := &fakeModel{reply: func([]*schema.Message) (*schema.Message, error) {
+		time.Sleep(50 * time.Millisecond)
+		return schema.AssistantMessage("ok", nil), nil
+	}}
+	agent, bus := newTestAgent(t, llm, config.AgentConfig{
+		DrainOnStop:  DrainModeProcess,
+		DrainTimeout: "10s",
+	})
+	if err := agent.Start(); err != nil {
+		t.Fatal(err)
+	}
+	for range 3 {
+		msg, _ := ds.NewMessage("boss", agent.GetName(), ds.MessageTypeSystem, "work")
+		if err := bus.Send(msg); err != nil {
+			t.Fatalf("Send: %v", err)
+		}
+	}
+
+	if err := agent.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	llm.mu.Lock()
+	calls := llm.calls
+	llm.mu.Unlock()
+	if calls != 3 {
+		t.Fatalf("model called %d times, want every queued message processed (3)", calls)
+	}
+	if n := agent.GetMailbox().GetInboxCount(); n != 0 {
+		t.Fatalf("inbox still holds %d messages after stop", n)
+	}
+}
+
+// startedModel 收到调用时发出信号，然后阻塞到 ctx 取消
+type startedModel struct {
+	blockingModel
+	started chan struct{}
+}
+
+// signal 通知调用已开始，重试时不重复通知
+func (m *startedModel) signal() {
+	select {
+	case m.started <- struct{}{}:
+	default:
+	}
+}
+
+func (m *startedModel) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	m.signal()
+	return m.blockingModel.Generate(ctx, input, opts...)
+}
+
+func (m *startedModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	m.signal()
+	return m.blockingModel.Stream(ctx, input, opts...)
+}
+
+func (m *startedModel) WithTools([]*schema.ToolInfo) (model.ToolCallingChatModel, error) {
+	return m, nil
+}
+
+// 模型调用进行中停止 Agent 时立即中止调用，而不是等待模型返回
+func TestStopCancelsInFlightGeneration(t *testing.T) {
+	llm := &startedModel{started: make(chan struct{}, 1)}
+	agent, bus := newTestAgent(t, llm, config.AgentConfig{})
+	if err := agent.Start(); err != nil {
+		t.Fatal(err)
+	}
+	bus.GetGlobalState().AddTask(ds.NewTask("task-1", "t", "d", agent.GetName(), "boss", ds.TaskStatusAssigned, ds.TaskPriorityMedium))
+	msg, _ := ds.NewTaskCreateMessage("task-1", "t", "d", agent.GetName(), "boss", nil, nil, nil, nil)
+	if err := bus.Send(msg); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	select {
+	case <-llm.started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("model was never called")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	start := time.Now()
+	if err := agent.Stop(ctx); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("Stop took %s while the model was generating, want it to return promptly", elapsed)
+	}
+}
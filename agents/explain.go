@@ -0,0 +1,133 @@
+package agents
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"superman/state"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// ErrNothingToExplain 指定 ID 没有可供解释的执行记录与执行历史
+var ErrNothingToExplain = errors.New("no transcript or execution history to explain")
+
+// 解释决策时提供给模型的上下文上限
+const (
+	maxExplainContext = 12000 // 上下文总字节数
+	maxExplainEntry   = 2000  // 单条执行记录或执行历史的字节数
+)
+
+// ExplainDecision 根据任务（或执行、消息）ID 对应的执行记录与执行历史，让模型解释当时的决策理由
+func (a *BaseAgentImpl) ExplainDecision(ctx context.Context, id string) (string, error) {
+	evidence := a.explainContext(id)
+	if evidence == "" {
+		return "", fmt.Errorf("%w: %s", ErrNothingToExplain, id)
+	}
+
+	prompt := fmt.Sprintf(`你是 %s。以下是你处理 %s 时留下的记录，请据此解释你当时的决策过程：做了哪些判断、依据是什么、为什么得出最终结果。
+只依据记录中的内容作答，记录中没有体现的部分请明确说明无法确认，不要编造。
+
+%s`, a.name, id, evidence)
+
+	if timeout := a.explainTimeout(id); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	resp, err := a.GetLLMModel().Generate(ctx, []*schema.Message{schema.UserMessage(prompt)})
+	if err != nil {
+		return "", fmt.Errorf("explain decision failed: %w", err)
+	}
+	explanation := strings.TrimSpace(resp.Content)
+	if explanation == "" {
+		return "", fmt.Errorf("explain decision failed: empty response")
+	}
+	return explanation, nil
+}
+
+// explainTimeout 返回解释决策的模型调用超时：ID 为任务时沿用任务执行的超时，否则使用 llm_timeout
+func (a *BaseAgentImpl) explainTimeout(id string) time.Duration {
+	if a.globalState != nil {
+		if task := a.globalState.GetTask(id); task != nil {
+			return a.taskLLMTimeout(task)
+		}
+	}
+	return a.llmTimeout
+}
+
+// explainContext 汇总任务信息、执行历史与自己的执行记录作为解释的依据，总长度不超过 maxExplainContext；
+// 执行记录过长时保留最近的条目
+func (a *BaseAgentImpl) explainContext(id string) string {
+	var b strings.Builder
+	var transcript []state.TranscriptEntry
+	if a.globalState != nil {
+		if task := a.globalState.GetTask(id); task != nil {
+			fmt.Fprintf(&b, "## 任务\n标题: %s\n描述: %s\n状态: %s\n交付物: %v\n\n",
+				task.Title, truncateUTF8(task.Description, maxExplainEntry), task.Status, task.Deliverables)
+		}
+		// 同一任务的执行记录可能包含其他 Agent（改派前的执行者）与调度器的条目，只解释自己的决策
+		for _, entry := range a.globalState.GetTranscript(id) {
+			if entry.Agent == a.name {
+				transcript = append(transcript, entry)
+			}
+		}
+	}
+
+	var histories []string
+	for _, history := range a.GetExecutionHistory() {
+		if history.TaskID != id && history.ExecutionID != id && history.MessageID != id {
+			continue
+		}
+		output, _ := json.Marshal(history.Output)
+		line := fmt.Sprintf("- [%s] %s 状态: %s 耗时: %s 输出: %s",
+			history.Timestamp.Format(time.RFC3339), history.Action, history.Status, history.Duration, output)
+		if history.ErrorMessage != "" {
+			line += " 错误: " + history.ErrorMessage
+		}
+		histories = append(histories, truncateUTF8(line, maxExplainEntry))
+	}
+	if len(histories) == 0 && len(transcript) == 0 {
+		return ""
+	}
+	if len(histories) > 0 {
+		b.WriteString("## 执行历史\n")
+		for _, line := range histories {
+			if b.Len()+len(line) > maxExplainContext/2 {
+				b.WriteString("- ……（更多历史已省略）\n")
+				break
+			}
+			b.WriteString(line + "\n")
+		}
+		b.WriteString("\n")
+	}
+	if len(transcript) == 0 {
+		return b.String()
+	}
+
+	// 从最近的执行记录向前收集，直到达到上下文上限
+	budget := maxExplainContext - b.Len()
+	var lines []string
+	for i := len(transcript) - 1; i >= 0; i-- {
+		entry := transcript[i]
+		line := fmt.Sprintf("[%d] %s (%s): %s", entry.Seq, entry.Agent, entry.Role, truncateUTF8(entry.Content, maxExplainEntry))
+		if len(line)+1 > budget {
+			break
+		}
+		budget -= len(line) + 1
+		lines = append(lines, line)
+	}
+	b.WriteString("## 执行记录\n")
+	if omitted := len(transcript) - len(lines); omitted > 0 {
+		fmt.Fprintf(&b, "（较早的 %d 条记录已省略）\n", omitted)
+	}
+	for i := len(lines) - 1; i >= 0; i-- {
+		b.WriteString(lines[i] + "\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,42 @@
+package agents
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"superman/config"
+	"superman/state"
+)
+
+// 解释决策只使用自己的执行记录，不包含其他 Agent 与调度器的条目
+func TestExplainDecisionUsesOwnTranscript(t *testing.T) {
+	llm := newFakeModel("because")
+	agent, _ := newTestAgent(t, llm, config.AgentConfig{})
+	gs := agent.GetGlobalState()
+	gs.AppendTranscript("t1", state.TranscriptEntry{Agent: agent.GetName(), Role: "assistant", Content: "my reasoning"})
+	gs.AppendTranscript("t1", state.TranscriptEntry{Agent: "previous", Role: "assistant", Content: "their reasoning"})
+	gs.AppendTranscript("t1", state.TranscriptEntry{Agent: "scheduler", Role: "system", Content: "priority changed"})
+
+	got, err := agent.ExplainDecision(context.Background(), "t1")
+	if err != nil || got != "because" {
+		t.Fatalf("ExplainDecision = %q, %v", got, err)
+	}
+	prompt := llm.lastPrompt()
+	if !strings.Contains(prompt, "my reasoning") {
+		t.Fatalf("prompt is missing the agent's own transcript:\n%s", prompt)
+	}
+	if strings.Contains(prompt, "their reasoning") || strings.Contains(prompt, "priority changed") {
+		t.Fatalf("prompt includes other agents' transcript entries:\n%s", prompt)
+	}
+}
+
+// 其他 Agent 的执行记录不作为可解释的依据
+func TestExplainDecisionWithoutOwnRecords(t *testing.T) {
+	agent, _ := newTestAgent(t, newFakeModel("because"), config.AgentConfig{})
+	agent.GetGlobalState().AppendTranscript("t1", state.TranscriptEntry{Agent: "previous", Role: "assistant", Content: "their reasoning"})
+
+	if _, err := agent.ExplainDecision(context.Background(), "t1"); err == nil {
+		t.Fatal("ExplainDecision succeeded with only another agent's transcript")
+	}
+}
@@ -0,0 +1,101 @@
+package agents
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"syscall"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+	"github.com/meguminnnnnnnnn/go-openai"
+)
+
+// fallbackChatModel 主模型不可用时改用备用模型的 ChatModel 包装
+type fallbackChatModel struct {
+	agent    string
+	primary  model.ToolCallingChatModel
+	fallback model.ToolCallingChatModel
+}
+
+// WithFallbackModel 为主模型包装备用模型：主模型调用因服务不可用失败时使用备用模型重试一次。
+// fallback 为 nil 时原样返回主模型
+func WithFallbackModel(agent string, primary, fallback model.ToolCallingChatModel) model.ToolCallingChatModel {
+	if fallback == nil || primary == nil {
+		return primary
+	}
+	return &fallbackChatModel{agent: agent, primary: primary, fallback: fallback}
+}
+
+// Generate 生成回复，主模型不可用时切换到备用模型
+func (m *fallbackChatModel) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	resp, err := m.primary.Generate(ctx, input, opts...)
+	if err == nil || !m.shouldFallback(ctx, err) {
+		return resp, err
+	}
+	return m.fallback.Generate(ctx, input, opts...)
+}
+
+// Stream 流式生成回复，主模型建立流失败且为不可用错误时切换到备用模型
+func (m *fallbackChatModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	stream, err := m.primary.Stream(ctx, input, opts...)
+	if err == nil || !m.shouldFallback(ctx, err) {
+		return stream, err
+	}
+	return m.fallback.Stream(ctx, input, opts...)
+}
+
+// WithTools 为主、备模型绑定工具
+func (m *fallbackChatModel) WithTools(tools []*schema.ToolInfo) (model.ToolCallingChatModel, error) {
+	primary, err := m.primary.WithTools(tools)
+	if err != nil {
+		return nil, err
+	}
+	fallback, err := m.fallback.WithTools(tools)
+	if err != nil {
+		return nil, err
+	}
+	return &fallbackChatModel{agent: m.agent, primary: primary, fallback: fallback}, nil
+}
+
+// shouldFallback 判断是否切换到备用模型，切换时记录日志
+func (m *fallbackChatModel) shouldFallback(ctx context.Context, err error) bool {
+	if ctx.Err() != nil || !isUnavailableError(err) {
+		return false
+	}
+	slog.Warn("primary model unavailable, switching to fallback model",
+		slog.String("agent", m.agent),
+		slog.Any("error", err),
+	)
+	return true
+}
+
+// isUnavailableError 判断错误是否由模型服务不可用引起（网络错误、限流、5xx 等），
+// 按错误类型与 HTTP 状态码判断，请求本身有误（4xx）时不切换
+func isUnavailableError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) {
+		return true
+	}
+	var apiErr *openai.APIError
+	if errors.As(err, &apiErr) {
+		return isUnavailableStatus(apiErr.HTTPStatusCode)
+	}
+	var reqErr *openai.RequestError
+	if errors.As(err, &reqErr) {
+		return isUnavailableStatus(reqErr.HTTPStatusCode)
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// isUnavailableStatus 限流（429）与服务端错误（5xx）视为服务不可用
+func isUnavailableStatus(code int) bool {
+	return code == http.StatusTooManyRequests || code >= http.StatusInternalServerError
+}
@@ -0,0 +1,30 @@
+package agents
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/meguminnnnnnnnn/go-openai"
+)
+
+// 按错误类型与状态码判断服务不可用，错误文本中恰好出现 500、eof 等字样的请求错误不切换
+func TestIsUnavailableError(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"service unavailable", fmt.Errorf("failed to create chat completion: %w", &openai.APIError{HTTPStatusCode: 503}), true},
+		{"rate limited", &openai.RequestError{HTTPStatusCode: 429, Err: errors.New("too many requests")}, true},
+		{"bad request", &openai.APIError{HTTPStatusCode: 400, Message: "max_tokens must be at most 500"}, false},
+		{"truncated stream", fmt.Errorf("read response: %w", io.ErrUnexpectedEOF), true},
+		{"plain text", errors.New("invalid tool arguments: unexpected eof after 500 bytes"), false},
+	}
+	for _, c := range cases {
+		if got := isUnavailableError(c.err); got != c.want {
+			t.Errorf("%s: isUnavailableError = %v, want %v", c.name, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,258 @@
+package agents
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"superman/config"
+	"superman/ds"
+	"superman/utils"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// 模型先返回无法解析的文字，重新提示后返回合法 JSON 时任务被恢复并记录指标
+func TestGenerateTasksReformatsProseOnce(t *testing.T) {
+	llm := &fakeModel{reply: func(input []*schema.Message) (*schema.Message, error) {
+		if len(input) == 1 {
+			return schema.AssistantMessage("好的，我会先整理本季度的财务报表。", nil), nil
+		}
+		if !strings.Contains(input[len(input)-1].Content, "无法解析") {
+			t.Errorf("reformat prompt lacks the parse error: %q", input[len(input)-1].Content)
+		}
+		return schema.AssistantMessage(`[{"title": "整理财务报表", "description": "本季度", "priority": "High"}]`, nil), nil
+	}}
+	agent, _ := newTestAgent(t, llm, config.AgentConfig{TaskGenReformatRetry: true})
+
+	tasks, err := agent.GenerateTasks(context.Background())
+	if err != nil {
+		t.Fatalf("GenerateTasks: %v", err)
+	}
+	if len(tasks) != 1 || tasks[0].Title != "整理财务报表" {
+		t.Fatalf("tasks = %+v, want the reformatted task", tasks)
+	}
+	if llm.calls != 2 {
+		t.Fatalf("model called %d times, want 2", llm.calls)
+	}
+	metrics := agent.GetState().PerformanceMetrics
+	if metrics["task_gen_parse_failures"] != 1 || metrics["task_gen_reformat_recovered"] != 1 {
+		t.Fatalf("metrics = %v, want one parse failure and one recovery", metrics)
+	}
+}
+
+// 重新提示后仍无法解析时只重试一次，返回空任务列表
+func TestGenerateTasksReformatRetriesOnlyOnce(t *testing.T) {
+	llm := newFakeModel("still prose")
+	agent, _ := newTestAgent(t, llm, config.AgentConfig{TaskGenReformatRetry: true})
+
+	tasks, err := agent.GenerateTasks(context.Background())
+	if err != nil {
+		t.Fatalf("GenerateTasks: %v", err)
+	}
+	if len(tasks) != 0 || llm.calls != 2 {
+		t.Fatalf("got %d tasks after %d calls, want none after 2", len(tasks), llm.calls)
+	}
+}
+
+// 生成的任务携带模型给出的任务类型
+func TestGenerateTasksKeepsType(t *testing.T) {
+	llm := newFakeModel(`[{"title": "季度报告", "description": "汇总", "priority": "Medium", "type": "report"}]`)
+	agent, _ := newTestAgent(t, llm, config.AgentConfig{})
+
+	tasks, err := agent.GenerateTasks(context.Background())
+	if err != nil {
+		t.Fatalf("GenerateTasks: %v", err)
+	}
+	if len(tasks) != 1 || tasks[0].Type != ds.TaskTypeReport {
+		t.Fatalf("tasks = %+v, want one report task", tasks)
+	}
+}
+
+// 开启缓存后提示词未变化的连续两次生成只调用一次模型，描述变化后缓存失效
+func TestGenerateTasksCachesUnchangedPrompt(t *testing.T) {
+	llm := newFakeModel(`[{"title": "季度报告", "description": "汇总", "priority": "Medium"}]`)
+	agent, _ := newTestAgent(t, llm, config.AgentConfig{TaskGenCacheTTL: "1m"})
+
+	first, err := agent.GenerateTasks(context.Background())
+	if err != nil {
+		t.Fatalf("GenerateTasks: %v", err)
+	}
+	second, err := agent.GenerateTasks(context.Background())
+	if err != nil {
+		t.Fatalf("GenerateTasks: %v", err)
+	}
+	if llm.calls != 1 {
+		t.Fatalf("model called %d times, want 1 within the TTL", llm.calls)
+	}
+	if len(second) != 1 || second[0].Title != "季度报告" || second[0].ID == first[0].ID {
+		t.Fatalf("cached tasks = %+v, want the same task with a fresh ID", second)
+	}
+
+	agent.SetDesc("new responsibilities")
+	if _, err := agent.GenerateTasks(context.Background()); err != nil {
+		t.Fatalf("GenerateTasks: %v", err)
+	}
+	if llm.calls != 2 {
+		t.Fatalf("model called %d times after the description changed, want 2", llm.calls)
+	}
+}
+
+// 模型返回超出上限的任务时只保留配置的最大数量
+func TestGenerateTasksCapsTasksPerCycle(t *testing.T) {
+	llm := newFakeModel(`[
+		{"title": "t1", "description": "d", "priority": "Low"},
+		{"title": "t2", "description": "d", "priority": "Low"},
+		{"title": "t3", "description": "d", "priority": "Low"},
+		{"title": "t4", "description": "d", "priority": "Low"},
+		{"title": "t5", "description": "d", "priority": "Low"}
+	]`)
+	agent, _ := newTestAgent(t, llm, config.AgentConfig{MaxTasksPerGen: 2})
+
+	tasks, err := agent.GenerateTasks(context.Background())
+	if err != nil {
+		t.Fatalf("GenerateTasks: %v", err)
+	}
+	if len(tasks) != 2 || tasks[0].Title != "t1" || tasks[1].Title != "t2" {
+		t.Fatalf("tasks = %+v, want the first 2", tasks)
+	}
+	if !strings.Contains(llm.lastPrompt(), "生成 1-2 个") {
+		t.Fatalf("prompt does not ask for at most 2 tasks:\n%s", llm.lastPrompt())
+	}
+	if got := agent.GetState().PerformanceMetrics["task_gen_truncated"]; got != 1 {
+		t.Fatalf("task_gen_truncated = %v, want 1", got)
+	}
+}
+
+// 共享任务生成限制为 2 时，五个 Agent 同时生成任务，同时进行的模型调用不超过 2 个
+func TestTaskGenLimiterBoundsConcurrentGeneration(t *testing.T) {
+	var running, peak atomic.Int32
+	limiter := utils.NewSemaphore(2)
+	agents := make([]*BaseAgentImpl, 5)
+	for i := range agents {
+		llm := &fakeModel{reply: func([]*schema.Message) (*schema.Message, error) {
+			n := running.Add(1)
+			defer running.Add(-1)
+			for p := peak.Load(); n > p && !peak.CompareAndSwap(p, n); p = peak.Load() {
+			}
+			time.Sleep(50 * time.Millisecond)
+			return schema.AssistantMessage(`[{"title": "t", "description": "d", "priority": "Low"}]`, nil), nil
+		}}
+		agents[i], _ = newTestAgent(t, llm, config.AgentConfig{Name: fmt.Sprintf("agent-%d", i)})
+		agents[i].SetTaskGenLimiter(limiter)
+	}
+
+	var wg sync.WaitGroup
+	for _, agent := range agents {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := agent.GenerateTasks(context.Background()); err != nil {
+				t.Errorf("%s GenerateTasks: %v", agent.GetName(), err)
+			}
+		}()
+	}
+	wg.Wait()
+	if got := peak.Load(); got != 2 {
+		t.Fatalf("peak concurrent generations = %d, want 2", got)
+	}
+}
+
+// 配置了首次生成等待时间的 Agent 在等待结束前不生成任务
+func TestTaskGenWaitsForInitialDelay(t *testing.T) {
+	llm := newFakeModel(`[{"title": "t", "description": "d", "priority": "Low"}]`)
+	agent, _ := newTestAgent(t, llm, config.AgentConfig{TaskGenInitialDelay: "400ms", TaskGenInterval: "50ms"})
+	submitted := make(chan time.Time, 1)
+	agent.SetTaskSubmitter(func(*ds.Task, string) {
+		select {
+		case submitted <- time.Now():
+		default:
+		}
+	})
+
+	start := time.Now()
+	startTestAgent(t, agent)
+	select {
+	case at := <-submitted:
+		if elapsed := at.Sub(start); elapsed < 400*time.Millisecond {
+			t.Fatalf("first task generated after %s, want at least the 400ms initial delay", elapsed)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no task generated after the initial delay")
+	}
+}
+
+// 运行时缩短任务生成间隔后生成循环立即按新间隔生成，非正的间隔被拒绝
+func TestSetTaskGenIntervalChangesCadence(t *testing.T) {
+	llm := newFakeModel(`[{"title": "t", "description": "d", "priority": "Low"}]`)
+	agent, _ := newTestAgent(t, llm, config.AgentConfig{TaskGenInitialDelay: "10ms", TaskGenInterval: "1h"})
+	submitted := make(chan struct{}, 10)
+	agent.SetTaskSubmitter(func(*ds.Task, string) {
+		select {
+		case submitted <- struct{}{}:
+		default:
+		}
+	})
+	startTestAgent(t, agent)
+
+	select {
+	case <-submitted:
+		t.Fatal("task generated before the 1h interval elapsed")
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	if err := agent.SetTaskGenInterval(0); err == nil {
+		t.Fatal("SetTaskGenInterval(0) succeeded, want an error")
+	}
+	if err := agent.SetTaskGenInterval(50 * time.Millisecond); err != nil {
+		t.Fatalf("SetTaskGenInterval: %v", err)
+	}
+	if got := agent.GetTaskGenInterval(); got != 50*time.Millisecond {
+		t.Fatalf("GetTaskGenInterval = %s, want 50ms", got)
+	}
+	for i := range 2 {
+		select {
+		case <-submitted:
+		case <-time.After(2 * time.Second):
+			t.Fatalf("generation %d did not happen at the 50ms interval", i+1)
+		}
+	}
+}
+
+// 配置扩展字段后，提示词示例包含这些字段，模型返回的交付物、截止时间与依赖写入生成的任务；不支持的字段在创建 Agent 时报错
+func TestGenerateTasksMapsConfiguredFields(t *testing.T) {
+	llm := newFakeModel(`[
+		{"title": "收集数据", "description": "d", "priority": "High", "deliverables": ["原始数据表"], "deadline": "2026-11-30"},
+		{"title": "撰写报告", "description": "d", "priority": "Medium", "deliverables": ["季度报告", "摘要"], "deadline": "2026-12-15T18:00:00Z", "dependencies": ["收集数据", "ghost"]}
+	]`)
+	agent, _ := newTestAgent(t, llm, config.AgentConfig{TaskGenFields: []string{TaskGenFieldDeliverables, TaskGenFieldDeadline, TaskGenFieldDependencies}})
+
+	tasks, err := agent.GenerateTasks(context.Background())
+	if err != nil {
+		t.Fatalf("GenerateTasks: %v", err)
+	}
+	if prompt := llm.lastPrompt(); !strings.Contains(prompt, `"deliverables": ["交付物"]`) || !strings.Contains(prompt, `"deadline": "YYYY-MM-DD"`) {
+		t.Fatalf("prompt lacks the configured fields:\n%s", prompt)
+	}
+	if len(tasks) != 2 {
+		t.Fatalf("generated %d tasks, want 2", len(tasks))
+	}
+	collect, report := tasks[0], tasks[1]
+	if len(collect.Deliverables) != 1 || collect.Deliverables[0] != "原始数据表" || collect.Deadline == nil || collect.Deadline.Format(time.DateOnly) != "2026-11-30" {
+		t.Fatalf("first task deliverables %v deadline %v, want 原始数据表 by 2026-11-30", collect.Deliverables, collect.Deadline)
+	}
+	if len(report.Deliverables) != 2 || report.Deadline == nil || !report.Deadline.Equal(time.Date(2026, 12, 15, 18, 0, 0, 0, time.UTC)) {
+		t.Fatalf("second task deliverables %v deadline %v, want 2 deliverables by 2026-12-15T18:00Z", report.Deliverables, report.Deadline)
+	}
+	if len(report.Dependencies) != 1 || report.Dependencies[0] != collect.ID {
+		t.Fatalf("dependencies = %v, want only the first task's ID", report.Dependencies)
+	}
+
+	if _, err := NewBaseAgent(context.Background(), llm, nil, config.AgentConfig{Name: "cfo", SkillDir: t.TempDir(), TaskGenFields: []string{"budget"}}); err == nil {
+		t.Fatal("NewBaseAgent accepted an unsupported task_gen_fields entry")
+	}
+}
@@ -0,0 +1,88 @@
+package agents
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// generationCache 任务生成结果缓存：以提示词哈希为键，缓存可解析的模型输出，
+// 命中时重新解析（生成新的任务 ID），避免提示词未变化时重复调用 LLM
+type generationCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]generationCacheEntry
+}
+
+type generationCacheEntry struct {
+	content   string
+	expiresAt time.Time
+}
+
+func newGenerationCache(ttl time.Duration) *generationCache {
+	return &generationCache{
+		ttl:     ttl,
+		entries: make(map[string]generationCacheEntry),
+	}
+}
+
+// key 计算提示词哈希
+func (c *generationCache) key(prompt string) string {
+	sum := sha256.Sum256([]byte(prompt))
+	return hex.EncodeToString(sum[:])
+}
+
+// get 获取未过期的缓存输出
+func (c *generationCache) get(prompt string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	k := c.key(prompt)
+	entry, ok := c.entries[k]
+	if !ok {
+		return "", false
+	}
+	if time.Now().After(entry.expiresAt) {
+		delete(c.entries, k)
+		return "", false
+	}
+	return entry.content, true
+}
+
+// put 缓存模型输出
+func (c *generationCache) put(prompt, content string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	for k, entry := range c.entries {
+		if now.After(entry.expiresAt) {
+			delete(c.entries, k)
+		}
+	}
+	c.entries[c.key(prompt)] = generationCacheEntry{
+		content:   content,
+		expiresAt: now.Add(c.ttl),
+	}
+}
+
+// clear 清空缓存（Agent 状态或描述变化时调用）
+func (c *generationCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[string]generationCacheEntry)
+}
+
+// SetDesc 更新 Agent 职责描述，并清空任务生成缓存
+func (a *BaseAgentImpl) SetDesc(desc string) {
+	a.mu.Lock()
+	a.desc = desc
+	a.mu.Unlock()
+	a.invalidateGenerationCache()
+}
+
+// invalidateGenerationCache 清空任务生成缓存（Agent 状态变化后已缓存的输出可能过时）
+func (a *BaseAgentImpl) invalidateGenerationCache() {
+	if a.genCache != nil {
+		a.genCache.clear()
+	}
+}
@@ -0,0 +1,83 @@
+package agents
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"superman/config"
+	"superman/ds"
+)
+
+// GetState 返回的消息为深拷贝：Agent 处理消息时并发读取不产生数据竞争，修改副本不影响 Agent
+func TestGetStateReturnsDeepCopies(t *testing.T) {
+	agent, _ := newTestAgent(t, newFakeModel("ok"), config.AgentConfig{})
+	startTestAgent(t, agent)
+
+	const total = 20
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for range total {
+			msg, _ := ds.NewRequestMessage("boss", agent.GetName(), "question", "status?", map[string]any{"round": 1})
+			if err := agent.ReceiveMessage(msg); err != nil {
+				t.Errorf("ReceiveMessage: %v", err)
+				return
+			}
+		}
+	}()
+	for agent.GetState().PerformanceMetrics["messages_processed"] < total {
+		if _, err := json.Marshal(agent.GetState()); err != nil {
+			t.Fatalf("Marshal: %v", err)
+		}
+	}
+	wg.Wait()
+
+	body, ok := agent.GetState().Messages[0].GetRequestBody()
+	if !ok {
+		t.Fatal("first message has no request body")
+	}
+	body.Metadata["round"] = 2
+	if again, _ := agent.GetState().Messages[0].GetRequestBody(); again.Metadata["round"] != 1 {
+		t.Fatalf("metadata round = %v after editing a copy, want 1", again.Metadata["round"])
+	}
+}
+
+// 多个发送方并发投递消息时，处理器执行与状态、历史读取交错进行既不死锁也不丢失消息记录（配合 -race 运行）
+func TestConcurrentReceiveAndStateReadsDoNotDeadlock(t *testing.T) {
+	agent, _ := newTestAgent(t, newFakeModel("ok"), config.AgentConfig{MessageConcurrency: 4})
+	startTestAgent(t, agent)
+
+	const senders, perSender = 4, 10
+	var wg sync.WaitGroup
+	for i := range senders {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range perSender {
+				msg, _ := ds.NewNotificationMessage(fmt.Sprintf("peer-%d", i), agent.GetName(), "update", "numbers ready", "normal")
+				if err := agent.ReceiveMessage(msg); err != nil {
+					t.Errorf("ReceiveMessage: %v", err)
+					return
+				}
+			}
+		}()
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for agent.GetState().PerformanceMetrics["messages_processed"] < senders*perSender {
+		if time.Now().After(deadline) {
+			t.Fatalf("processed %v of %d messages, want all handled without deadlock",
+				agent.GetState().PerformanceMetrics["messages_processed"], senders*perSender)
+		}
+		_ = agent.GetExecutionHistory()
+		_ = agent.GetWorkload()
+	}
+	wg.Wait()
+	if got := len(agent.GetState().Messages); got != senders*perSender {
+		t.Fatalf("recorded %d messages, want %d", got, senders*perSender)
+	}
+}
@@ -0,0 +1,111 @@
+package agents
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testing"
+
+	"superman/config"
+	"superman/mailbox"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+)
+
+// fakeModel 按 reply 返回固定回复的模型，记录收到的最后一次输入与绑定的工具
+type fakeModel struct {
+	mu    sync.Mutex
+	reply func(input []*schema.Message) (*schema.Message, error)
+	last  []*schema.Message
+	calls int
+	tools []string
+}
+
+func newFakeModel(content string) *fakeModel {
+	return &fakeModel{reply: func([]*schema.Message) (*schema.Message, error) {
+		return schema.AssistantMessage(content, nil), nil
+	}}
+}
+
+func (m *fakeModel) Generate(ctx context.Context, input []*schema.Message, _ ...model.Option) (*schema.Message, error) {
+	m.mu.Lock()
+	m.last = input
+	m.calls++
+	reply := m.reply
+	m.mu.Unlock()
+	return reply(input)
+}
+
+func (m *fakeModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	msg, err := m.Generate(ctx, input, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return schema.StreamReaderFromArray([]*schema.Message{msg}), nil
+}
+
+func (m *fakeModel) WithTools(infos []*schema.ToolInfo) (model.ToolCallingChatModel, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, info := range infos {
+		if !slices.Contains(m.tools, info.Name) {
+			m.tools = append(m.tools, info.Name)
+		}
+	}
+	return m, nil
+}
+
+// hasTool 判断模型是否绑定过指定名称的工具
+func (m *fakeModel) hasTool(name string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return slices.Contains(m.tools, name)
+}
+
+// lastPrompt 返回最后一次调用的输入文本
+func (m *fakeModel) lastPrompt() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	text := ""
+	for _, msg := range m.last {
+		text += msg.Content + "\n"
+	}
+	return text
+}
+
+// newTestAgent 使用 fakeModel 创建 Agent 并设置全局状态，cfg 的名称与描述为空时使用默认值
+func newTestAgent(t *testing.T, llm model.ToolCallingChatModel, cfg config.AgentConfig) (*BaseAgentImpl, *mailbox.MailboxBus) {
+	t.Helper()
+	if cfg.Name == "" {
+		cfg.Name = "worker"
+	}
+	if cfg.Desc == "" {
+		cfg.Desc = "test agent"
+	}
+	if cfg.SkillDir == "" {
+		cfg.SkillDir = t.TempDir()
+	}
+	if cfg.TaskGenInitialDelay == "" {
+		cfg.TaskGenInitialDelay = "1h"
+	}
+	bus := mailbox.NewMailboxBus()
+	agent, err := NewBaseAgent(context.Background(), llm, bus, cfg, cfg)
+	if err != nil {
+		t.Fatalf("NewBaseAgent: %v", err)
+	}
+	if err := bus.RegisterMailbox(agent.GetName(), agent.GetMailbox()); err != nil {
+		t.Fatalf("register mailbox: %v", err)
+	}
+	agent.SetGlobalState(bus.GetGlobalState())
+	return agent, bus
+}
+
+// startTestAgent 启动 Agent 并在测试结束时停止
+func startTestAgent(t *testing.T, agent *BaseAgentImpl) {
+	t.Helper()
+	if err := agent.Start(); err != nil {
+		t.Fatalf("start agent: %v", err)
+	}
+	t.Cleanup(func() { _ = agent.Stop(context.Background()) })
+}
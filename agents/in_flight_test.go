@@ -0,0 +1,30 @@
+package agents
+
+import (
+	"testing"
+
+	"superman/config"
+	"superman/ds"
+)
+
+// 收件箱中尚未取出的任务消息计入在途任务数，非任务消息不计入
+func TestInFlightTasksCountsInboxTasks(t *testing.T) {
+	agent, bus := newTestAgent(t, newFakeModel("ok"), config.AgentConfig{})
+
+	task, _ := ds.NewTaskCreateMessage("t1", "task", "test task", agent.GetName(), "boss", nil, nil, nil, nil)
+	note, _ := ds.NewMessage("boss", agent.GetName(), ds.MessageTypeSystem, "hello")
+	for _, msg := range []*ds.Message{task, note} {
+		if err := bus.Send(msg); err != nil {
+			t.Fatalf("Send: %v", err)
+		}
+	}
+	if got := agent.GetInFlightTasks(); got != 1 {
+		t.Fatalf("GetInFlightTasks = %d with a task in the inbox, want 1", got)
+	}
+
+	for agent.GetMailbox().TryPopInbox() != nil {
+	}
+	if got := agent.GetInFlightTasks(); got != 0 {
+		t.Fatalf("GetInFlightTasks = %d after draining the inbox, want 0", got)
+	}
+}
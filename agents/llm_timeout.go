@@ -0,0 +1,119 @@
+package agents
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"superman/ds"
+	"superman/state"
+	"superman/utils"
+
+	"github.com/cloudwego/eino/adk"
+	"github.com/cloudwego/eino/schema"
+)
+
+// ErrLLMTimeout 模型调用超过 llm_timeout 未结束
+var ErrLLMTimeout = errors.New("llm_timeout")
+
+// runAgent 运行 agent 并在当前 goroutine 中依次处理事件；timeout 大于 0 时超时后取消运行、记录超时并返回 ErrLLMTimeout
+func (a *BaseAgentImpl) runAgent(ctx context.Context, action string, timeout time.Duration, messages []*schema.Message, handle func(*adk.AgentEvent) error) error {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	runner, gen, err := a.acquireAgent()
+	if err != nil {
+		return err
+	}
+	iter := runner.Run(ctx, &adk.AgentInput{
+		Messages: messages,
+	})
+
+	// 迭代器在独立 goroutine 中读取，模型不响应取消时也能按时返回；迭代器结束后才归还 agent
+	events := make(chan *adk.AgentEvent)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		defer a.releaseAgent(runner, gen)
+		defer close(events)
+		for {
+			event, ok := iter.Next()
+			if !ok {
+				return
+			}
+			select {
+			case events <- event:
+			case <-done:
+				// 调用方已返回，继续消费剩余事件直到迭代器结束
+			}
+		}
+	}()
+
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return nil
+			}
+			if event == nil {
+				continue
+			}
+			if event.Err != nil {
+				if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+					return a.recordLLMTimeout(action, timeout)
+				}
+				return event.Err
+			}
+			if err := handle(event); err != nil {
+				return err
+			}
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) && timeout > 0 {
+				return a.recordLLMTimeout(action, timeout)
+			}
+			return ctx.Err()
+		}
+	}
+}
+
+// recordLLMTimeout 记录模型调用超时到执行历史与指标，返回 ErrLLMTimeout
+func (a *BaseAgentImpl) recordLLMTimeout(action string, timeout time.Duration) error {
+	err := fmt.Errorf("%w: %s exceeded %s", ErrLLMTimeout, action, timeout)
+	a.incrMetric("llm_timeouts")
+	slog.Warn("llm call timed out",
+		slog.String("agent", a.name),
+		slog.String("action", action),
+		slog.Duration("timeout", timeout),
+	)
+
+	id, idErr := utils.NewUUID()
+	if idErr != nil {
+		return err
+	}
+	a.AddExecutionHistory(&state.AgentExecutionHistory{
+		ExecutionID:  id,
+		Timestamp:    time.Now(),
+		Action:       action,
+		Input:        map[string]any{},
+		Output:       map[string]any{},
+		Status:       "timeout",
+		Duration:     timeout,
+		ErrorMessage: err.Error(),
+	})
+	return err
+}
+
+// taskLLMTimeout 返回执行任务时的模型调用超时：任务元数据 llm_timeout 可覆盖 Agent 配置（如超时升级后延长的超时）
+func (a *BaseAgentImpl) taskLLMTimeout(task *ds.Task) time.Duration {
+	if raw, ok := task.Metadata[ds.MetadataLLMTimeout].(string); ok {
+		if d, err := time.ParseDuration(raw); err == nil && d > 0 {
+			return d
+		}
+	}
+	return a.llmTimeout
+}
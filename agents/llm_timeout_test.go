@@ -0,0 +1,41 @@
+package agents
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"superman/config"
+	"superman/ds"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// 消息处理路径上的模型运行超过 llm_timeout 时被取消并返回 ErrLLMTimeout，即使模型不响应取消也按时返回
+func TestSlowMessageRunIsCancelledAtLLMTimeout(t *testing.T) {
+	release := make(chan struct{})
+	t.Cleanup(func() { close(release) })
+	llm := &fakeModel{reply: func([]*schema.Message) (*schema.Message, error) {
+		<-release
+		return schema.AssistantMessage("too late", nil), nil
+	}}
+	agent, _ := newTestAgent(t, llm, config.AgentConfig{LLMTimeout: "100ms"})
+	startTestAgent(t, agent)
+
+	msg, _ := ds.NewMessage("boss", agent.GetName(), ds.MessageTypeSystem, "what is the status?")
+	start := time.Now()
+	err := agent.ProcessMessage(context.Background(), msg)
+	if !errors.Is(err, ErrLLMTimeout) {
+		t.Fatalf("ProcessMessage = %v, want ErrLLMTimeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("ProcessMessage returned after %s, want about the 100ms timeout", elapsed)
+	}
+	if got := agent.GetState().PerformanceMetrics["llm_timeouts"]; got != 1 {
+		t.Fatalf("llm_timeouts = %v, want 1", got)
+	}
+	if history := agent.GetRecentExecutions(1); len(history) != 1 || history[0].Status != "timeout" || history[0].Action != "process_message" {
+		t.Fatalf("last execution = %+v, want a process_message timeout", history)
+	}
+}
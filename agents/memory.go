@@ -0,0 +1,76 @@
+package agents
+
+import (
+	"fmt"
+	"log/slog"
+	"maps"
+	"time"
+
+	"superman/state"
+)
+
+// DefaultMemoryHistorySize 持久化工作记忆时默认保留的执行历史条数
+const DefaultMemoryHistorySize = 100
+
+// SetMemoryStore 设置工作记忆存储并恢复上次保存的绩效指标与执行历史，停止时写回；
+// historySize 为保存的最近执行历史条数上限，<=0 时使用默认值
+func (a *BaseAgentImpl) SetMemoryStore(store state.AgentMemoryStore, historySize int) error {
+	if historySize <= 0 {
+		historySize = DefaultMemoryHistorySize
+	}
+	memory, err := store.LoadAgentMemory(a.name)
+	if err != nil {
+		return fmt.Errorf("agent %s: failed to load memory: %w", a.name, err)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.memoryStore = store
+	a.memoryHistorySize = historySize
+	if memory == nil {
+		return nil
+	}
+	// 本次运行中已产生的指标与历史较新，叠加在恢复的数据之后
+	for k, v := range memory.Metrics {
+		a.performanceMetrics[k] += v
+	}
+	history := append(memory.History, a.executionHistory...)
+	if len(history) > a.historyMaxSize {
+		history = history[len(history)-a.historyMaxSize:]
+	}
+	a.executionHistory = history
+	slog.Info("agent memory restored",
+		slog.String("agent", a.name),
+		slog.Int("history", len(memory.History)),
+		slog.Time("saved_at", memory.SavedAt),
+	)
+	return nil
+}
+
+// saveMemory 将绩效指标与最近的执行历史写入工作记忆存储，失败只记录日志
+func (a *BaseAgentImpl) saveMemory() {
+	a.mu.RLock()
+	store := a.memoryStore
+	if store == nil {
+		a.mu.RUnlock()
+		return
+	}
+	history := a.executionHistory
+	if len(history) > a.memoryHistorySize {
+		history = history[len(history)-a.memoryHistorySize:]
+	}
+	memory := &state.AgentMemory{
+		Agent:   a.name,
+		Metrics: maps.Clone(a.performanceMetrics),
+		History: append([]*state.AgentExecutionHistory(nil), history...),
+		SavedAt: time.Now(),
+	}
+	a.mu.RUnlock()
+
+	if err := store.SaveAgentMemory(memory); err != nil {
+		slog.Error("failed to save agent memory",
+			slog.String("agent", a.name),
+			slog.Any("error", err),
+		)
+	}
+}
@@ -0,0 +1,61 @@
+package agents
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"superman/config"
+	"superman/state"
+)
+
+// memoryStore 内存实现的工作记忆存储
+type memoryStore struct {
+	mu       sync.Mutex
+	memories map[string]*state.AgentMemory
+}
+
+func (s *memoryStore) SaveAgentMemory(m *state.AgentMemory) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.memories == nil {
+		s.memories = make(map[string]*state.AgentMemory)
+	}
+	s.memories[m.Agent] = m
+	return nil
+}
+
+func (s *memoryStore) LoadAgentMemory(agent string) (*state.AgentMemory, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.memories[agent], nil
+}
+
+// 停止时写入的绩效指标与执行历史在同名 Agent 重新创建后恢复
+func TestMemorySurvivesRestart(t *testing.T) {
+	store := &memoryStore{}
+
+	agent, _ := newTestAgent(t, newFakeModel("ok"), config.AgentConfig{})
+	if err := agent.SetMemoryStore(store, 0); err != nil {
+		t.Fatalf("SetMemoryStore: %v", err)
+	}
+	if err := agent.Start(); err != nil {
+		t.Fatalf("start agent: %v", err)
+	}
+	agent.incrMetric("tasks_completed")
+	agent.AddExecutionHistory(&state.AgentExecutionHistory{TaskID: "task-1"})
+	if err := agent.Stop(context.Background()); err != nil {
+		t.Fatalf("stop agent: %v", err)
+	}
+
+	restarted, _ := newTestAgent(t, newFakeModel("ok"), config.AgentConfig{})
+	if err := restarted.SetMemoryStore(store, 0); err != nil {
+		t.Fatalf("SetMemoryStore after restart: %v", err)
+	}
+	if got := restarted.GetState().PerformanceMetrics["tasks_completed"]; got != 1 {
+		t.Fatalf("tasks_completed = %v after restart, want 1", got)
+	}
+	if history := restarted.GetExecutionHistoryByTaskID("task-1"); len(history) != 1 {
+		t.Fatalf("history for task-1 = %d entries after restart, want 1", len(history))
+	}
+}
@@ -0,0 +1,30 @@
+package agents
+
+import (
+	"errors"
+	"log/slog"
+
+	"github.com/cloudwego/eino/adk"
+	"github.com/cloudwego/eino/components/model"
+)
+
+// SetLLMModel 运行时替换 Agent 使用的模型（如预算紧张时改用更便宜的模型）。
+// agent 在构建时绑定了模型，因此用新模型重建 agent；进行中的调用继续使用旧模型，之后的调用使用新模型
+func (a *BaseAgentImpl) SetLLMModel(m model.ToolCallingChatModel) error {
+	if m == nil {
+		return errors.New("model must not be nil")
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.buildAgent != nil {
+		agent, err := a.buildAgent(m)
+		if err != nil {
+			return err
+		}
+		a.idleAgents = []adk.ResumableAgent{agent}
+		a.agentGen++
+	}
+	a.llmModel = m
+	slog.Info("agent model changed", slog.String("agent", a.name))
+	return nil
+}
@@ -0,0 +1,49 @@
+package agents
+
+import (
+	"context"
+	"testing"
+
+	"superman/config"
+)
+
+// 运行时替换模型后，任务执行与任务生成都改用新模型，旧模型不再被调用
+func TestSetLLMModelSwitchesGeneration(t *testing.T) {
+	calls := func(m *fakeModel) int {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		return m.calls
+	}
+	expensive := newFakeModel(`[{"title": "旧模型任务", "description": "d", "priority": "Medium"}]`)
+	cheap := newFakeModel(`[{"title": "新模型任务", "description": "d", "priority": "Medium"}]`)
+	agent, _ := newTestAgent(t, expensive, config.AgentConfig{})
+	startTestAgent(t, agent)
+
+	runTestTask(t, agent, "before")
+	if calls(expensive) == 0 {
+		t.Fatal("task before the swap did not use the original model")
+	}
+	if err := agent.SetLLMModel(nil); err == nil {
+		t.Fatal("SetLLMModel accepted a nil model")
+	}
+	if err := agent.SetLLMModel(cheap); err != nil {
+		t.Fatalf("SetLLMModel: %v", err)
+	}
+	before := calls(expensive)
+
+	runTestTask(t, agent, "after")
+	tasks, err := agent.GenerateTasks(context.Background())
+	if err != nil {
+		t.Fatalf("GenerateTasks: %v", err)
+	}
+	if len(tasks) != 1 || tasks[0].Title != "新模型任务" {
+		t.Fatalf("generated tasks = %+v, want the new model's task", tasks)
+	}
+	if calls(cheap) < 2 || calls(expensive) != before {
+		t.Fatalf("new model calls = %d, old model calls %d -> %d; want execution and generation on the new model only",
+			calls(cheap), before, calls(expensive))
+	}
+	if agent.GetLLMModel() != cheap {
+		t.Fatal("GetLLMModel does not return the new model")
+	}
+}
@@ -0,0 +1,31 @@
+package agents
+
+import "superman/ds"
+
+// isQuickMessage 判断消息是否无需运行模型即可处理（请求、响应、通知、任务完成通知），此类消息走快速通道
+func isQuickMessage(msg *ds.Message) bool {
+	switch msg.Type {
+	case ds.MessageTypeRequest, ds.MessageTypeResponse, ds.MessageTypeNotification, ds.MessageTypeTaskComplete:
+		return true
+	}
+	return false
+}
+
+// quickWorker 快速通道工作协程：依次处理队列中的消息，多个工作协程并发消费同一队列
+func (a *BaseAgentImpl) quickWorker() {
+	for {
+		select {
+		case <-a.stopCh:
+			return
+		case msg := <-a.quickQueue:
+			a.processQuick(msg)
+		}
+	}
+}
+
+// processQuick 处理一条快速通道消息并维护处理中计数
+func (a *BaseAgentImpl) processQuick(msg *ds.Message) {
+	a.quickBusy.Add(1)
+	defer a.quickBusy.Add(-1)
+	a.processRecovered(msg)
+}
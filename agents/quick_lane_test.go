@@ -0,0 +1,54 @@
+package agents
+
+import (
+	"testing"
+	"time"
+
+	"superman/config"
+	"superman/ds"
+)
+
+// 一条消息触发的长任务和一条耗时消息占满执行槽位时，状态查询请求仍经快速通道及时处理
+func TestStatusQueryHandledDuringLongTask(t *testing.T) {
+	agent, bus := newTestAgent(t, &blockingModel{}, config.AgentConfig{})
+	startTestAgent(t, agent)
+
+	create, err := ds.NewTaskCreateMessage("long-task", "t", "d", agent.GetName(), "boss", nil, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	slow, err := ds.NewMessage("boss", agent.GetName(), ds.MessageTypeSystem, "think hard")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, msg := range []*ds.Message{create, slow} {
+		if err := bus.Send(msg); err != nil {
+			t.Fatalf("Send: %v", err)
+		}
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for agent.GetInFlightTasks() == 0 || agent.GetInFlightMessages() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("long task and slow message never started")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	query, err := ds.NewRequestMessage("boss", agent.GetName(), "task_query", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sent := time.Now()
+	if err := bus.Send(query); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	for agent.GetState().PerformanceMetrics["messages_processed"] == 0 {
+		if time.Since(sent) > 2*time.Second {
+			t.Fatal("status query not handled while the long task was running")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if waited := time.Since(sent); waited > 200*time.Millisecond {
+		t.Fatalf("status query handled after %s, want promptly", waited)
+	}
+}
@@ -0,0 +1,64 @@
+package agents
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"superman/ds"
+)
+
+// ErrRequestTimeout 请求消息未在 request_timeout 内处理完成
+var ErrRequestTimeout = errors.New("request_timeout")
+
+// handleRequestWithTimeout 由模型处理请求消息（run），在 requestTimeout 内未完成时取消运行并向请求方回复失败响应。
+// 内置请求（如 task_query）无需模型、不经过这里
+func (a *BaseAgentImpl) handleRequestWithTimeout(ctx context.Context, msg *ds.Message, run func(context.Context) error) error {
+	if a.requestTimeout <= 0 {
+		return run(ctx)
+	}
+
+	parent := ctx
+	ctx, cancel := context.WithTimeout(ctx, a.requestTimeout)
+	defer cancel()
+	err := run(ctx)
+	if err == nil || parent.Err() != nil || !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return err
+	}
+	err = fmt.Errorf("%w: request %s not handled within %s", ErrRequestTimeout, msg.ID, a.requestTimeout)
+	a.incrMetric("request_timeouts")
+	a.sendTimeoutResponse(msg, err)
+	return err
+}
+
+// sendTimeoutResponse 向请求方发送 success=false 的超时响应，请求方不是本公司的 Agent 时跳过
+func (a *BaseAgentImpl) sendTimeoutResponse(msg *ds.Message, timeoutErr error) {
+	if a.mailboxBus == nil || msg.Sender == "" || msg.Sender == a.name {
+		return
+	}
+	if _, err := a.mailboxBus.GetMailbox(msg.Sender); err != nil {
+		return
+	}
+
+	resp, err := ds.NewResponseMessage(msg.ID, false, nil, timeoutErr.Error())
+	if err != nil {
+		slog.Error("failed to build timeout response", slog.String("request_id", msg.ID), slog.Any("error", err))
+		return
+	}
+	resp.Sender = a.name
+	resp.Receiver = msg.Sender
+	if err := a.mailboxBus.Send(resp); err != nil {
+		slog.Error("failed to send timeout response",
+			slog.String("request_id", msg.ID),
+			slog.String("receiver", msg.Sender),
+			slog.Any("error", err),
+		)
+		return
+	}
+	slog.Warn("request timed out, failure response sent",
+		slog.String("agent", a.name),
+		slog.String("request_id", msg.ID),
+		slog.String("receiver", msg.Sender),
+	)
+}
@@ -0,0 +1,67 @@
+package agents
+
+import (
+	"testing"
+	"time"
+
+	"superman/config"
+	"superman/ds"
+	"superman/mailbox"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// 模型处理请求超时后向请求方回复 success=false 的响应
+func TestSlowRequestGetsTimeoutResponse(t *testing.T) {
+	release := make(chan struct{})
+	t.Cleanup(func() { close(release) })
+	llm := &fakeModel{reply: func([]*schema.Message) (*schema.Message, error) {
+		<-release
+		return schema.AssistantMessage("too late", nil), nil
+	}}
+	agent, bus := newTestAgent(t, llm, config.AgentConfig{RequestTimeout: "50ms"})
+	boss := mailbox.NewMailbox(mailbox.DefaultMailboxConfig("boss"))
+	if err := bus.RegisterMailbox("boss", boss); err != nil {
+		t.Fatalf("register mailbox: %v", err)
+	}
+	startTestAgent(t, agent)
+
+	msg, _ := ds.NewMessage("boss", agent.GetName(), ds.MessageTypeRequest, "summarize the quarter")
+	if err := bus.Send(msg); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	select {
+	case reply := <-boss.Inbox:
+		body, ok := reply.GetResponseBody()
+		if !ok || body.Success || body.RequestID != msg.ID {
+			t.Fatalf("reply = %+v, want a failed response to %s", reply.Body, msg.ID)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no timeout response was sent to the requester")
+	}
+}
+
+// 无需模型的内置请求不受请求超时影响，也不会回复失败响应
+func TestBuiltinRequestSkipsRequestTimeout(t *testing.T) {
+	agent, bus := newTestAgent(t, newFakeModel("ok"), config.AgentConfig{RequestTimeout: "1ns"})
+	boss := mailbox.NewMailbox(mailbox.DefaultMailboxConfig("boss"))
+	if err := bus.RegisterMailbox("boss", boss); err != nil {
+		t.Fatalf("register mailbox: %v", err)
+	}
+	startTestAgent(t, agent)
+
+	msg, _ := ds.NewRequestMessage("boss", agent.GetName(), "task_query", nil, nil)
+	if err := bus.Send(msg); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	waitMetric(t, agent, "messages_processed", 1)
+	for len(boss.Inbox) > 0 {
+		if reply := <-boss.Inbox; reply.Type == ds.MessageTypeResponse {
+			t.Fatalf("builtin request got response %+v", reply.Body)
+		}
+	}
+	if n := agent.GetState().PerformanceMetrics["request_timeouts"]; n != 0 {
+		t.Fatalf("request_timeouts = %v, want 0", n)
+	}
+}
@@ -0,0 +1,45 @@
+package agents
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// 回复格式
+const (
+	ResponseFormatText = "text"
+	ResponseFormatJSON = "json"
+)
+
+// MetadataTaskResult 任务元数据中记录 JSON 模式解析结果的键
+const MetadataTaskResult = "result"
+
+// jsonFormatInstruction JSON 模式附加到任务输入的格式约束
+const jsonFormatInstruction = `
+
+输出要求：最终回复必须是一个合法的 JSON 值（通常为对象），不要包含 JSON 以外的任何文字或 Markdown 标记。`
+
+// parseJSONResponse 解析模型的 JSON 回复，兼容 Markdown code block 与前后多余文字
+func parseJSONResponse(content string) (any, error) {
+	var result any
+	err := json.Unmarshal([]byte(extractJSON(content)), &result)
+	if err == nil {
+		return result, nil
+	}
+	start := strings.Index(content, "{")
+	end := strings.LastIndex(content, "}")
+	if start != -1 && end > start {
+		if json.Unmarshal([]byte(content[start:end+1]), &result) == nil {
+			return result, nil
+		}
+	}
+	return nil, fmt.Errorf("response is not valid JSON: %w", err)
+}
+
+// jsonRetryPrompt JSON 解析失败时要求模型重新输出的提示
+func jsonRetryPrompt(parseErr error) string {
+	return fmt.Sprintf(`你上面的最终回复无法解析为 JSON，解析错误：%v
+
+请将你的结果重新整理为一个合法的 JSON 值，只返回 JSON，不要包含任何其他文字。`, parseErr)
+}
@@ -0,0 +1,32 @@
+package agents
+
+import (
+	"strings"
+	"testing"
+
+	"superman/config"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// JSON 模式的 Agent 首次回复不是 JSON 时重试一次，任务结果保存解析后的 JSON
+func TestJSONModeResultIsValidJSON(t *testing.T) {
+	llm := &fakeModel{reply: func(input []*schema.Message) (*schema.Message, error) {
+		if !strings.Contains(input[len(input)-1].Content, "无法解析为 JSON") {
+			return schema.AssistantMessage("预算已审核，没有问题。", nil), nil
+		}
+		return schema.AssistantMessage("```json\n{\"approved\": true, \"total\": 1200}\n```", nil), nil
+	}}
+	agent, _ := newTestAgent(t, llm, config.AgentConfig{ResponseFormat: ResponseFormatJSON})
+	startTestAgent(t, agent)
+
+	runTestTask(t, agent, "task-1")
+
+	result, ok := agent.globalState.GetTask("task-1").Metadata[MetadataTaskResult].(map[string]any)
+	if !ok || result["approved"] != true || result["total"] != 1200.0 {
+		t.Fatalf("task result = %#v, want the parsed JSON object", agent.globalState.GetTask("task-1").Metadata[MetadataTaskResult])
+	}
+	if got := agent.GetState().PerformanceMetrics["json_response_parse_failures"]; got != 1 {
+		t.Fatalf("json_response_parse_failures = %v, want 1", got)
+	}
+}
@@ -0,0 +1,100 @@
+package agents
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/cloudwego/eino/adk"
+)
+
+// truncatedMarker 截断标记，%d 为被丢弃的字节数
+const truncatedMarker = "\n...[truncated %d bytes]"
+
+// limitResponse 将超过最大长度的模型输出截断并追加截断标记，同时记录截断次数
+func (a *BaseAgentImpl) limitResponse(source, content string) string {
+	if a.maxResponseSize <= 0 || len(content) <= a.maxResponseSize {
+		return content
+	}
+	dropped := len(content) - a.maxResponseSize
+	a.recordTruncation(source, dropped)
+	return truncateUTF8(content, a.maxResponseSize) + fmt.Sprintf(truncatedMarker, dropped)
+}
+
+// readOutput 读取事件输出的文本内容。流式输出逐块读取，超过最大长度的部分直接丢弃而不缓存
+func (a *BaseAgentImpl) readOutput(source string, output *adk.MessageVariant) (string, error) {
+	if output == nil {
+		return "", nil
+	}
+	if !output.IsStreaming {
+		if output.Message == nil {
+			return "", nil
+		}
+		return a.limitResponse(source, output.Message.Content), nil
+	}
+	if output.MessageStream == nil {
+		return "", nil
+	}
+
+	stream := output.MessageStream
+	defer stream.Close()
+
+	var sb strings.Builder
+	dropped := 0
+	for {
+		chunk, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return sb.String(), err
+		}
+		if chunk == nil {
+			continue
+		}
+		content := chunk.Content
+		if a.maxResponseSize > 0 {
+			remaining := a.maxResponseSize - sb.Len()
+			if remaining <= 0 {
+				dropped += len(content)
+				continue
+			}
+			if len(content) > remaining {
+				kept := truncateUTF8(content, remaining)
+				dropped += len(content) - len(kept)
+				content = kept
+			}
+		}
+		sb.WriteString(content)
+	}
+	if dropped > 0 {
+		a.recordTruncation(source, dropped)
+		fmt.Fprintf(&sb, truncatedMarker, dropped)
+	}
+	return sb.String(), nil
+}
+
+// recordTruncation 记录一次模型输出截断
+func (a *BaseAgentImpl) recordTruncation(source string, dropped int) {
+	a.incrMetric("llm_responses_truncated")
+	slog.Warn("LLM response exceeds max size, truncated",
+		slog.String("agent", a.name),
+		slog.String("source", source),
+		slog.Int("max_size", a.maxResponseSize),
+		slog.Int("dropped_bytes", dropped),
+	)
+}
+
+// truncateUTF8 截取不超过 n 字节的前缀，不切断多字节字符
+func truncateUTF8(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
@@ -0,0 +1,52 @@
+package agents
+
+import (
+	"strings"
+	"testing"
+
+	"superman/config"
+
+	"github.com/cloudwego/eino/adk"
+	"github.com/cloudwego/eino/schema"
+)
+
+// 超长的任务执行输出被截断到配置的最大长度并追加截断标记
+func TestOversizedTaskOutputIsTruncated(t *testing.T) {
+	huge := strings.Repeat("a", 1<<20)
+	agent, _ := newTestAgent(t, newFakeModel(huge), config.AgentConfig{MaxResponseSize: 1024})
+	startTestAgent(t, agent)
+
+	runTestTask(t, agent, "task-1")
+
+	transcript := agent.globalState.GetTranscript("task-1")
+	if len(transcript) == 0 {
+		t.Fatal("no transcript recorded")
+	}
+	output := transcript[len(transcript)-1].Content
+	if !strings.HasPrefix(output, strings.Repeat("a", 1024)+"\n...[truncated ") || len(output) > 1024+64 {
+		t.Fatalf("output is %d bytes, want the first 1024 bytes and a truncation marker", len(output))
+	}
+	if got := agent.GetState().PerformanceMetrics["llm_responses_truncated"]; got != 1 {
+		t.Fatalf("llm_responses_truncated = %v, want 1", got)
+	}
+}
+
+// 流式输出超过上限后的分块直接丢弃，不在内存中累积
+func TestStreamingOutputStopsBufferingAtLimit(t *testing.T) {
+	agent, _ := newTestAgent(t, newFakeModel("ok"), config.AgentConfig{MaxResponseSize: 10})
+	chunks := make([]*schema.Message, 1000)
+	for i := range chunks {
+		chunks[i] = schema.AssistantMessage("你好世界", nil)
+	}
+	output, err := agent.readOutput("test", &adk.MessageVariant{
+		IsStreaming:   true,
+		MessageStream: schema.StreamReaderFromArray(chunks),
+	})
+	if err != nil {
+		t.Fatalf("readOutput: %v", err)
+	}
+	// 每个分块 12 字节：第一块保留完整的前 3 个字符（9 字节），其余全部丢弃
+	if want := "你好世" + "\n...[truncated 11991 bytes]"; output != want {
+		t.Fatalf("output = %q, want %q", output, want)
+	}
+}
@@ -0,0 +1,92 @@
+package agents
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"superman/ds"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// MetadataSelfRating 任务元数据中记录 Agent 自评结果的键
+const MetadataSelfRating = "self_rating"
+
+// lowSelfRating 自评分数不高于该值的任务视为低置信度结果，需要人工复核
+const lowSelfRating = 2
+
+// SelfRating Agent 对自身任务产出质量的自评
+type SelfRating struct {
+	Score     int    `json:"score"`     // 1-5 分
+	Rationale string `json:"rationale"` // 评分理由
+}
+
+// reflectOnTask 让模型对任务产出质量打分（1-5）并给出理由
+func (a *BaseAgentImpl) reflectOnTask(ctx context.Context, task *ds.Task, output string) (*SelfRating, error) {
+	prompt := fmt.Sprintf(`你刚刚完成了以下任务，请客观评估你的产出质量。
+
+任务: %s
+描述: %s
+交付物: %v
+
+你的产出:
+%s
+
+请给出 1-5 分的质量评分（1 很差，5 优秀）及简要理由，严格按照以下 JSON 格式返回，不要包含任何其他文字：
+{"score": 4, "rationale": "评分理由"}`, task.Title, task.Description, task.Deliverables, output)
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	resp, err := a.GetLLMModel().Generate(ctx, []*schema.Message{schema.UserMessage(prompt)})
+	if err != nil {
+		return nil, fmt.Errorf("self reflection failed: %w", err)
+	}
+
+	var rating SelfRating
+	if err := json.Unmarshal([]byte(extractJSON(resp.Content)), &rating); err != nil {
+		return nil, fmt.Errorf("parse self rating: %w", err)
+	}
+	if rating.Score < 1 || rating.Score > 5 {
+		return nil, fmt.Errorf("self rating score %d out of range 1-5", rating.Score)
+	}
+	return &rating, nil
+}
+
+// recordSelfRating 执行自评并写入执行历史与任务元数据，自评失败只记录日志不影响任务结果；
+// history 须为尚未写入执行历史列表的 Output，写入后由 updateExecutionHistory 在锁内替换
+func (a *BaseAgentImpl) recordSelfRating(ctx context.Context, task *ds.Task, output string, history map[string]any) {
+	rating, err := a.reflectOnTask(ctx, task, output)
+	if err != nil {
+		a.incrMetric("self_reflection_failures")
+		slog.Warn("self reflection failed",
+			slog.String("agent", a.name),
+			slog.String("task_id", task.ID),
+			slog.Any("error", err),
+		)
+		return
+	}
+
+	history[MetadataSelfRating] = rating.Score
+	history["self_rating_rationale"] = rating.Rationale
+	if a.globalState != nil {
+		a.globalState.UpdateTask(task.ID, func(t *ds.Task) {
+			if t.Metadata == nil {
+				t.Metadata = make(map[string]any)
+			}
+			t.Metadata[MetadataSelfRating] = rating
+		})
+	}
+	if rating.Score <= lowSelfRating {
+		a.incrMetric("low_self_rated_tasks")
+		slog.Warn("task self-rated as low quality, review recommended",
+			slog.String("agent", a.name),
+			slog.String("task_id", task.ID),
+			slog.Int("score", rating.Score),
+			slog.String("rationale", rating.Rationale),
+		)
+	}
+}
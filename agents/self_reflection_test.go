@@ -0,0 +1,65 @@
+package agents
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"superman/config"
+	"superman/ds"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// 自评结果写入执行历史时，并发读取执行历史不应与之竞争（go test -race）
+func TestSelfRatingDoesNotRaceWithHistoryReaders(t *testing.T) {
+	llm := &fakeModel{reply: func(input []*schema.Message) (*schema.Message, error) {
+		if strings.Contains(input[len(input)-1].Content, "质量评分") {
+			return schema.AssistantMessage(`{"score": 4, "rationale": "ok"}`, nil), nil
+		}
+		return schema.AssistantMessage("done", nil), nil
+	}}
+	agent, bus := newTestAgent(t, llm, config.AgentConfig{SelfReflection: true})
+	startTestAgent(t, agent)
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			agent.GetExecutionStats()
+			for _, h := range agent.GetExecutionHistory() {
+				_, _ = json.Marshal(h.Output)
+			}
+		}
+	}()
+
+	for i := 0; i < 5; i++ {
+		task := ds.NewTask(fmt.Sprintf("task-%d", i), "t", "d", agent.GetName(), "boss", ds.TaskStatusAssigned, ds.TaskPriorityMedium)
+		bus.GetGlobalState().AddTask(task)
+		if err := agent.ProcessTask(context.Background(), task); err != nil {
+			t.Fatalf("ProcessTask: %v", err)
+		}
+	}
+	close(stop)
+	wg.Wait()
+
+	var rated int
+	for _, h := range agent.GetExecutionHistory() {
+		if h.Output[MetadataSelfRating] == 4 {
+			rated++
+		}
+	}
+	if rated != 5 {
+		t.Fatalf("self-rated histories = %d, want 5", rated)
+	}
+}
@@ -0,0 +1,77 @@
+package agents
+
+import (
+	"sync"
+
+	"superman/ds"
+)
+
+// senderLink 一条消息在同一发送者处理链中的位置：等待 prev 关闭后处理，处理完成后关闭 done
+type senderLink struct {
+	prev <-chan struct{}
+	done chan struct{}
+}
+
+// senderOrder 开启按发送者保序时，同一发送者的消息按进入收件箱的顺序（即 Seq 顺序）依次处理，
+// 不同发送者之间仍并发处理
+type senderOrder struct {
+	mu    sync.Mutex
+	tails map[string]chan struct{}   // 发送者 -> 最后一条消息的 done
+	links map[*ds.Message]senderLink // 已分流、尚未处理完成的消息
+}
+
+func newSenderOrder() *senderOrder {
+	return &senderOrder{
+		tails: make(map[string]chan struct{}),
+		links: make(map[*ds.Message]senderLink),
+	}
+}
+
+// enter 按收件箱顺序将消息接入发送者的处理链，只能由消息处理循环调用
+func (o *senderOrder) enter(msg *ds.Message) {
+	if msg.Sender == "" {
+		return
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	done := make(chan struct{})
+	o.links[msg] = senderLink{prev: o.tails[msg.Sender], done: done}
+	o.tails[msg.Sender] = done
+}
+
+// wait 等待同一发送者的前一条消息处理完成；stop 关闭时不再等待
+func (o *senderOrder) wait(msg *ds.Message, stop <-chan struct{}) {
+	o.mu.Lock()
+	link, ok := o.links[msg]
+	o.mu.Unlock()
+	if !ok || link.prev == nil {
+		return
+	}
+	select {
+	case <-link.prev:
+	case <-stop:
+	}
+}
+
+// leave 标记消息处理完成（或放回收件箱），放行同一发送者的下一条消息
+func (o *senderOrder) leave(msg *ds.Message) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	link, ok := o.links[msg]
+	if !ok {
+		return
+	}
+	delete(o.links, msg)
+	close(link.done)
+	if o.tails[msg.Sender] == link.done {
+		delete(o.tails, msg.Sender)
+	}
+}
+
+// reset 清空处理链，启动时调用
+func (o *senderOrder) reset() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	clear(o.tails)
+	clear(o.links)
+}
@@ -0,0 +1,63 @@
+package agents
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"superman/config"
+	"superman/ds"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// 开启按发送者保序时，同一发送者的消息即使并发槽位充足也按发送顺序依次处理
+func TestOrderedDeliveryProcessesSenderMessagesInOrder(t *testing.T) {
+	var mu sync.Mutex
+	var order []string
+	llm := &fakeModel{reply: func(input []*schema.Message) (*schema.Message, error) {
+		content := input[len(input)-1].Content
+		// 第一条消息处理最慢，不保序时后续消息会先完成
+		if strings.HasSuffix(content, "msg-0") {
+			time.Sleep(100 * time.Millisecond)
+		}
+		mu.Lock()
+		order = append(order, content)
+		mu.Unlock()
+		return schema.AssistantMessage("ok", nil), nil
+	}}
+	agent, bus := newTestAgent(t, llm, config.AgentConfig{OrderedDelivery: true, MessageConcurrency: 4})
+	startTestAgent(t, agent)
+
+	const n = 4
+	for i := range n {
+		msg, err := ds.NewMessage("boss", agent.GetName(), ds.MessageTypeSystem, fmt.Sprintf("msg-%d", i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := bus.Send(msg); err != nil {
+			t.Fatalf("Send: %v", err)
+		}
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		mu.Lock()
+		done := len(order) == n
+		mu.Unlock()
+		if done {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("processed %v before timeout", order)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	for i, content := range order {
+		if !strings.HasSuffix(content, fmt.Sprintf("msg-%d", i)) {
+			t.Fatalf("processing order %v, want msg-0..msg-%d", order, n-1)
+		}
+	}
+}
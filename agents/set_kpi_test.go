@@ -0,0 +1,40 @@
+package agents
+
+import (
+	"testing"
+
+	"superman/config"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// 高管通过 set kpi 工具记录的 KPI 写入全局状态；层级低于高管的 Agent 没有该工具
+func TestSetKPIToolOnlyForExecutives(t *testing.T) {
+	cfoLevel, staffLevel := 1, 3
+	llm := &fakeModel{reply: func(input []*schema.Message) (*schema.Message, error) {
+		if input[len(input)-1].Role == schema.Tool {
+			return schema.AssistantMessage("done", nil), nil
+		}
+		return schema.AssistantMessage("", []schema.ToolCall{{
+			ID:       "call-1",
+			Function: schema.FunctionCall{Name: "set kpi", Arguments: `{"key": "gross_margin", "value": 0.42}`},
+		}}), nil
+	}}
+	cfo, _ := newTestAgent(t, llm, config.AgentConfig{Name: "cfo", Hierarchy: &cfoLevel})
+	startTestAgent(t, cfo)
+	runTestTask(t, cfo, "task-1")
+	if !llm.hasTool("set kpi") {
+		t.Fatal("cfo lacks the set kpi tool")
+	}
+	if got := cfo.globalState.GetKPI("gross_margin"); got != 0.42 {
+		t.Fatalf("gross_margin = %v, want 0.42", got)
+	}
+
+	staffModel := newFakeModel("ok")
+	staff, _ := newTestAgent(t, staffModel, config.AgentConfig{Name: "analyst", Hierarchy: &staffLevel})
+	startTestAgent(t, staff)
+	runTestTask(t, staff, "task-2")
+	if !staffModel.hasTool("send message") || staffModel.hasTool("set kpi") {
+		t.Fatalf("analyst tools = %v, want the common tools without set kpi", staffModel.tools)
+	}
+}
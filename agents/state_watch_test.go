@@ -0,0 +1,42 @@
+package agents
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"superman/config"
+	"superman/ds"
+)
+
+// 状态监听循环崩溃重启后订阅仍然有效，后续变更继续触发任务生成
+func TestStateWatchSurvivesRestart(t *testing.T) {
+	agent, _ := newTestAgent(t, newFakeModel("[]"), config.AgentConfig{
+		RestartOnCrash:   true,
+		TaskGenWatchKeys: []string{"revenue"},
+	})
+	agent.SetManualTaskGen(true)
+	agent.supervisor.backoff = time.Millisecond
+	agent.SetTaskSubmitter(func(*ds.Task, string) {})
+	var checks atomic.Int32
+	agent.SetTaskGenGuard(func() bool {
+		if checks.Add(1) == 1 {
+			panic("guard failed")
+		}
+		return false
+	})
+	startTestAgent(t, agent)
+
+	gs := agent.GetGlobalState()
+	gs.SetKPI("revenue", 1)
+	waitMetric(t, agent, "restarts", 1)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for checks.Load() < 2 && time.Now().Before(deadline) {
+		gs.SetKPI("revenue", 2)
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := checks.Load(); got < 2 {
+		t.Fatalf("task generation triggered %d times, want a trigger after the restart", got)
+	}
+}
@@ -0,0 +1,161 @@
+package agents
+
+import (
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"time"
+
+	"superman/ds"
+)
+
+// defaultHeartbeatTimeout 消息循环无心跳超过该时间视为失活
+const defaultHeartbeatTimeout = 30 * time.Second
+
+// supervisorConfig Agent 崩溃重启策略
+type supervisorConfig struct {
+	enabled          bool          // 是否在崩溃后自动重启
+	maxRestarts      int           // 每个循环允许的最大重启次数
+	backoff          time.Duration // 首次重启前的等待时间，之后每次翻倍
+	heartbeatTimeout time.Duration // 消息循环无心跳超过该时间视为失活
+}
+
+// heartbeatInterval 心跳与失活检查的间隔，为失活超时的三分之一
+func (c supervisorConfig) heartbeatInterval() time.Duration {
+	timeout := c.heartbeatTimeout
+	if timeout <= 0 {
+		timeout = defaultHeartbeatTimeout
+	}
+	return timeout / 3
+}
+
+// superviseLoop 以监督方式运行 Agent 的后台循环：循环 panic 视为崩溃，
+// 开启重启策略时按指数退避重新启动，超过最大重启次数后放弃并记录错误
+func (a *BaseAgentImpl) superviseLoop(loop string, run func()) {
+	defer a.wg.Done()
+
+	backoff := a.supervisor.backoff
+	for restarts := 0; ; restarts++ {
+		err := a.runRecovered(run)
+		if err == nil {
+			return
+		}
+
+		a.incrMetric("crashes")
+		slog.Error("agent loop crashed",
+			slog.String("agent", a.name),
+			slog.String("loop", loop),
+			slog.Any("error", err),
+		)
+
+		if !a.supervisor.enabled {
+			return
+		}
+		if restarts >= a.supervisor.maxRestarts {
+			slog.Error("agent loop exceeded max restarts, giving up",
+				slog.String("agent", a.name),
+				slog.String("loop", loop),
+				slog.Int("max_restarts", a.supervisor.maxRestarts),
+			)
+			return
+		}
+
+		select {
+		case <-a.stopCh:
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+
+		a.incrMetric("restarts")
+		slog.Warn("agent loop restarted",
+			slog.String("agent", a.name),
+			slog.String("loop", loop),
+			slog.Int("restart", restarts+1),
+		)
+	}
+}
+
+// runRecovered 运行函数并将 panic 转换为错误
+func (a *BaseAgentImpl) runRecovered(run func()) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v\n%s", r, debug.Stack())
+		}
+	}()
+	run()
+	return nil
+}
+
+// processRecovered 处理一条消息，处理过程中的 panic 只记录为崩溃，不影响其他消息与后台循环
+func (a *BaseAgentImpl) processRecovered(msg *ds.Message) {
+	if err := a.runRecovered(func() { a.processMessageAsync(msg) }); err != nil {
+		a.incrMetric("crashes")
+		messageID := ""
+		if msg != nil {
+			messageID = msg.ID
+		}
+		slog.Error("agent message handler crashed",
+			slog.String("agent", a.name),
+			slog.String("message_id", messageID),
+			slog.Any("error", err),
+		)
+	}
+}
+
+// heartbeatMonitor 检查消息循环的心跳：超过失活超时没有心跳时（循环崩溃后放弃重启或卡住）
+// 重新启动消息循环，重启次数计入最大重启次数，超过后放弃并记录错误
+func (a *BaseAgentImpl) heartbeatMonitor() {
+	defer a.wg.Done()
+
+	interval := a.supervisor.heartbeatInterval()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for restarts := 0; ; {
+		select {
+		case <-a.stopCh:
+			return
+		case <-ticker.C:
+		}
+		stale := time.Since(a.GetLastHeartbeat())
+		if stale < a.supervisor.heartbeatTimeout {
+			continue
+		}
+
+		a.incrMetric("stale_heartbeats")
+		if restarts >= a.supervisor.maxRestarts {
+			slog.Error("agent heartbeat stale, exceeded max restarts, giving up",
+				slog.String("agent", a.name),
+				slog.Duration("since_heartbeat", stale),
+				slog.Int("max_restarts", a.supervisor.maxRestarts),
+			)
+			return
+		}
+		restarts++
+
+		// 重新计时，避免新循环启动前再次判定失活
+		a.heartbeat()
+		a.incrMetric("restarts")
+		slog.Warn("agent heartbeat stale, restarting message loop",
+			slog.String("agent", a.name),
+			slog.Duration("since_heartbeat", stale),
+			slog.Int("restart", restarts),
+		)
+		a.wg.Add(1)
+		go a.superviseLoop("message_processing", a.messageProcessingLoop)
+	}
+}
+
+// heartbeat 记录后台循环的存活时间
+func (a *BaseAgentImpl) heartbeat() {
+	a.lastHeartbeat.Store(time.Now().UnixNano())
+}
+
+// GetLastHeartbeat 获取后台循环最近一次心跳时间
+func (a *BaseAgentImpl) GetLastHeartbeat() time.Time {
+	ns := a.lastHeartbeat.Load()
+	if ns == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, ns)
+}
@@ -0,0 +1,60 @@
+package agents
+
+import (
+	"testing"
+	"time"
+
+	"superman/config"
+	"superman/ds"
+)
+
+// waitMetric 等待 Agent 的性能指标达到 want
+func waitMetric(t *testing.T, agent *BaseAgentImpl, key string, want float64) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if agent.GetState().PerformanceMetrics[key] >= want {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("metric %s = %v, want %v", key, agent.GetState().PerformanceMetrics[key], want)
+}
+
+// 消息处理中的 panic 只记为崩溃，Agent 继续处理后续消息
+func TestMessageHandlerPanicIsRecovered(t *testing.T) {
+	llm := newFakeModel("ok")
+	agent, bus := newTestAgent(t, llm, config.AgentConfig{})
+	startTestAgent(t, agent)
+
+	agent.msgQueue <- nil
+	waitMetric(t, agent, "crashes", 1)
+
+	msg, _ := ds.NewMessage("boss", agent.GetName(), ds.MessageTypeSystem, "still alive?")
+	if err := bus.Send(msg); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	waitMetric(t, agent, "messages_processed", 1)
+}
+
+// 消息循环崩溃且不再重启后心跳失活，监控重新启动循环，Agent 恢复处理消息
+func TestStaleHeartbeatRestartsMessageLoop(t *testing.T) {
+	llm := newFakeModel("ok")
+	agent, bus := newTestAgent(t, llm, config.AgentConfig{RestartOnCrash: true})
+	agent.supervisor.maxRestarts = 1
+	agent.supervisor.backoff = time.Millisecond
+	agent.supervisor.heartbeatTimeout = 60 * time.Millisecond
+	startTestAgent(t, agent)
+
+	// 空消息使分流循环 panic：第一次崩溃后重启，第二次超过最大重启次数，循环退出
+	agent.mailbox.Inbox <- nil
+	agent.mailbox.Inbox <- nil
+	waitMetric(t, agent, "crashes", 2)
+	waitMetric(t, agent, "stale_heartbeats", 1)
+
+	msg, _ := ds.NewMessage("boss", agent.GetName(), ds.MessageTypeSystem, "resume")
+	if err := bus.Send(msg); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	waitMetric(t, agent, "messages_processed", 1)
+}
@@ -0,0 +1,49 @@
+package agents
+
+import (
+	"testing"
+	"time"
+
+	"superman/config"
+	"superman/ds"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// 经任务创建消息投递的高优先级任务在 Agent 侧执行时保留高优先级；旧版消息体回退到元数据中的 priority
+func TestTaskCreateMessageKeepsPriority(t *testing.T) {
+	llm := newFakeModel("done")
+	agent, bus := newTestAgent(t, llm, config.AgentConfig{})
+	seen := make(chan ds.TaskPriority, 2)
+	llm.reply = func([]*schema.Message) (*schema.Message, error) {
+		for _, task := range agent.GetState().CurrentTasks {
+			seen <- task.Priority
+		}
+		return schema.AssistantMessage("done", nil), nil
+	}
+	startTestAgent(t, agent)
+
+	for _, tc := range []struct {
+		name string
+		body *ds.TaskCreateBody
+	}{
+		{"body", &ds.TaskCreateBody{TaskID: "t1", Title: "audit", AssignedTo: agent.GetName(), AssignedBy: "ceo", Priority: ds.TaskPriorityHigh}},
+		{"legacy metadata", &ds.TaskCreateBody{TaskID: "t2", Title: "audit", AssignedTo: agent.GetName(), AssignedBy: "ceo", Metadata: map[string]any{"priority": "High"}}},
+	} {
+		msg, err := ds.NewMessage("scheduler", agent.GetName(), ds.MessageTypeTaskCreate, tc.body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := bus.Send(msg); err != nil {
+			t.Fatalf("Send: %v", err)
+		}
+		select {
+		case got := <-seen:
+			if got != ds.TaskPriorityHigh {
+				t.Fatalf("%s: executing task priority = %q, want %q", tc.name, got, ds.TaskPriorityHigh)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("%s: task was never executed", tc.name)
+		}
+	}
+}
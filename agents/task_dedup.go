@@ -0,0 +1,96 @@
+package agents
+
+import (
+	"log/slog"
+	"strings"
+	"unicode"
+
+	"superman/ds"
+)
+
+// MetadataDuplicateCount 任务元数据中记录被跳过的重复生成次数的键
+const MetadataDuplicateCount = "duplicate_count"
+
+// findDuplicateTask 在本 Agent 未结束的任务中查找与 task 高度相似的任务：
+// 标题归一化后相同，或标题与描述的字符二元组相似度不低于阈值
+func (a *BaseAgentImpl) findDuplicateTask(task *ds.Task) *ds.Task {
+	if a.taskGenDedup <= 0 || a.globalState == nil {
+		return nil
+	}
+
+	title := normalizeTaskText(task.Title)
+	grams := bigrams(title + normalizeTaskText(task.Description))
+	for _, existing := range a.globalState.GetTasks() {
+		if existing.AssignedTo != a.name || existing.IsCompleted() {
+			continue
+		}
+		existingTitle := normalizeTaskText(existing.Title)
+		if title != "" && title == existingTitle {
+			return existing
+		}
+		if jaccard(grams, bigrams(existingTitle+normalizeTaskText(existing.Description))) >= a.taskGenDedup {
+			return existing
+		}
+	}
+	return nil
+}
+
+// skipDuplicateTask 生成的任务与已有任务重复时跳过，并在已有任务上累加重复计数
+func (a *BaseAgentImpl) skipDuplicateTask(task *ds.Task) bool {
+	existing := a.findDuplicateTask(task)
+	if existing == nil {
+		return false
+	}
+	a.globalState.UpdateTask(existing.ID, func(t *ds.Task) {
+		if t.Metadata == nil {
+			t.Metadata = make(map[string]any)
+		}
+		count, _ := t.Metadata[MetadataDuplicateCount].(int)
+		t.Metadata[MetadataDuplicateCount] = count + 1
+	})
+	a.incrMetric("task_gen_duplicates_skipped")
+	slog.Info("generated task duplicates an existing task, skipped",
+		slog.String("agent", a.name),
+		slog.String("title", task.Title),
+		slog.String("existing_task_id", existing.ID),
+	)
+	return true
+}
+
+// normalizeTaskText 归一化文本：转小写，只保留字母与数字
+func normalizeTaskText(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// bigrams 返回文本的字符二元组集合（兼容不以空格分词的中文）
+func bigrams(s string) map[string]struct{} {
+	runes := []rune(s)
+	set := make(map[string]struct{}, len(runes))
+	if len(runes) == 1 {
+		set[s] = struct{}{}
+	}
+	for i := 0; i+1 < len(runes); i++ {
+		set[string(runes[i:i+2])] = struct{}{}
+	}
+	return set
+}
+
+// jaccard 计算两个集合的 Jaccard 相似度
+func jaccard(a, b map[string]struct{}) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	inter := 0
+	for k := range a {
+		if _, ok := b[k]; ok {
+			inter++
+		}
+	}
+	return float64(inter) / float64(len(a)+len(b)-inter)
+}
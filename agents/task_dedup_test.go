@@ -0,0 +1,47 @@
+package agents
+
+import (
+	"context"
+	"testing"
+
+	"superman/config"
+	"superman/ds"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// 开启去重后，与未结束任务相似的生成任务不再提交，已有任务的 duplicate_count 加一；已结束的任务不参与去重
+func TestRepeatedGeneratedTaskIsSkipped(t *testing.T) {
+	replies := []string{
+		`[{"title": "Optimize performance", "description": "Profile the API and fix hot spots", "priority": "Medium"}]`,
+		`[{"title": "optimize performance!", "description": "Profile the API and fix the hot spots", "priority": "Medium"}]`,
+		`[{"title": "Optimize performance", "description": "Profile the API and fix hot spots", "priority": "Medium"}]`,
+	}
+	llm := &fakeModel{reply: func([]*schema.Message) (*schema.Message, error) {
+		reply := replies[0]
+		replies = replies[1:]
+		return schema.AssistantMessage(reply, nil), nil
+	}}
+	agent, _ := newTestAgent(t, llm, config.AgentConfig{TaskGenDedup: 0.8})
+	agent.SetTaskSubmitter(func(task *ds.Task, _ string) {
+		agent.globalState.AddTask(task)
+	})
+
+	first, err := agent.TriggerTaskGeneration(context.Background())
+	if err != nil || len(first) != 1 {
+		t.Fatalf("first generation = %v (err %v), want one task", first, err)
+	}
+	second, err := agent.TriggerTaskGeneration(context.Background())
+	if err != nil || len(second) != 0 {
+		t.Fatalf("second generation = %v (err %v), want the duplicate skipped", second, err)
+	}
+	if got := agent.globalState.GetTask(first[0]).Metadata[MetadataDuplicateCount]; got != 1 {
+		t.Fatalf("duplicate_count = %v, want 1", got)
+	}
+
+	agent.globalState.UpdateTask(first[0], func(t *ds.Task) { t.Status = ds.TaskStatusCompleted })
+	third, err := agent.TriggerTaskGeneration(context.Background())
+	if err != nil || len(third) != 1 {
+		t.Fatalf("third generation = %v (err %v), want a new task once the original completed", third, err)
+	}
+}
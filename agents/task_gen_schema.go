@@ -0,0 +1,166 @@
+package agents
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"superman/ds"
+)
+
+// 任务生成输出可配置的扩展字段
+const (
+	TaskGenFieldDeliverables = "deliverables"          // 交付物列表
+	TaskGenFieldDependencies = "dependencies"          // 依赖的任务（同批任务的标题或已有任务 ID）
+	TaskGenFieldDeadline     = "deadline"              // 截止时间，RFC3339 或 YYYY-MM-DD
+	TaskGenFieldTags         = "tags"                  // 任务标签
+	TaskGenFieldCapabilities = "required_capabilities" // 执行任务所需能力
+)
+
+// taskGenField 扩展字段在提示词中的示例值与说明，以及写入任务的方式
+type taskGenField struct {
+	example string
+	hint    string
+	apply   func(task *ds.Task, raw json.RawMessage) error
+}
+
+var taskGenFields = map[string]taskGenField{
+	TaskGenFieldDeliverables: {
+		example: `["交付物"]`,
+		hint:    "deliverables 为任务完成时应产出的交付物列表",
+		apply: func(task *ds.Task, raw json.RawMessage) error {
+			return json.Unmarshal(raw, &task.Deliverables)
+		},
+	},
+	TaskGenFieldDependencies: {
+		example: `[]`,
+		hint:    "dependencies 为需先完成的任务，填写本次生成的其他任务标题或已有任务 ID，无依赖时为空数组",
+		apply: func(task *ds.Task, raw json.RawMessage) error {
+			return json.Unmarshal(raw, &task.Dependencies)
+		},
+	},
+	TaskGenFieldDeadline: {
+		example: `"YYYY-MM-DD"`,
+		hint:    "deadline 为截止时间，格式 YYYY-MM-DD 或 RFC3339，无截止时间时省略",
+		apply: func(task *ds.Task, raw json.RawMessage) error {
+			var s string
+			if err := json.Unmarshal(raw, &s); err != nil {
+				return err
+			}
+			if s == "" {
+				return nil
+			}
+			deadline, err := time.Parse(time.RFC3339, s)
+			if err != nil {
+				if deadline, err = time.ParseInLocation(time.DateOnly, s, time.Local); err != nil {
+					return fmt.Errorf("invalid deadline %q, expected YYYY-MM-DD or RFC3339", s)
+				}
+			}
+			task.SetDeadline(&deadline)
+			return nil
+		},
+	},
+	TaskGenFieldTags: {
+		example: `["标签"]`,
+		hint:    "tags 为任务标签",
+		apply: func(task *ds.Task, raw json.RawMessage) error {
+			return json.Unmarshal(raw, &task.Tags)
+		},
+	},
+	TaskGenFieldCapabilities: {
+		example: `["能力"]`,
+		hint:    "required_capabilities 为执行任务所需的能力",
+		apply: func(task *ds.Task, raw json.RawMessage) error {
+			return json.Unmarshal(raw, &task.RequiredCapabilities)
+		},
+	},
+}
+
+// taskGenSchema 任务生成的期望输出格式：基础字段加配置的扩展字段
+type taskGenSchema struct {
+	fields []string
+}
+
+// newTaskGenSchema 根据配置的扩展字段创建输出格式，字段不受支持时返回错误
+func newTaskGenSchema(fields []string) (taskGenSchema, error) {
+	seen := make(map[string]bool, len(fields))
+	schema := taskGenSchema{}
+	for _, field := range fields {
+		if _, ok := taskGenFields[field]; !ok {
+			return taskGenSchema{}, fmt.Errorf("unsupported task_gen_fields entry %q", field)
+		}
+		if !seen[field] {
+			seen[field] = true
+			schema.fields = append(schema.fields, field)
+		}
+	}
+	return schema, nil
+}
+
+// example 返回提示词中的 JSON 数组示例
+func (s taskGenSchema) example() string {
+	var b strings.Builder
+	b.WriteString(`[{"title": "任务标题", "description": "任务详细描述", "priority": "Medium", "type": "analysis"`)
+	for _, field := range s.fields {
+		fmt.Fprintf(&b, `, %q: %s`, field, taskGenFields[field].example)
+	}
+	b.WriteString("}]")
+	return b.String()
+}
+
+// hints 返回扩展字段的说明，每个字段一行
+func (s taskGenSchema) hints() string {
+	var b strings.Builder
+	for _, field := range s.fields {
+		b.WriteString(taskGenFields[field].hint)
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// apply 校验并将扩展字段写入任务，字段类型不符时返回错误
+func (s taskGenSchema) apply(task *ds.Task, raw map[string]json.RawMessage) error {
+	for _, field := range s.fields {
+		value, ok := raw[field]
+		if !ok || string(value) == "null" {
+			continue
+		}
+		if err := taskGenFields[field].apply(task, value); err != nil {
+			return fmt.Errorf("task %q: field %s: %w", task.Title, field, err)
+		}
+	}
+	return nil
+}
+
+// resolveDependencies 将依赖中引用的同批任务标题替换为对应任务 ID，
+// 既不是同批任务也不是已有任务的依赖无法满足，丢弃以免任务永远等待
+func (a *BaseAgentImpl) resolveDependencies(tasks []*ds.Task) {
+	byTitle := make(map[string]string, len(tasks))
+	for _, task := range tasks {
+		byTitle[task.Title] = task.ID
+	}
+	gs := a.GetGlobalState()
+	for _, task := range tasks {
+		resolved := make([]string, 0, len(task.Dependencies))
+		for _, dep := range task.Dependencies {
+			if id, ok := byTitle[dep]; ok {
+				if id != task.ID {
+					resolved = append(resolved, id)
+				}
+				continue
+			}
+			if gs != nil && gs.GetTask(dep) != nil {
+				resolved = append(resolved, dep)
+				continue
+			}
+			slog.Warn("dropping unknown dependency of generated task",
+				slog.String("agent", a.name),
+				slog.String("task", task.Title),
+				slog.String("dependency", dep),
+			)
+		}
+		task.Dependencies = resolved
+	}
+}
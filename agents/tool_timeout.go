@@ -0,0 +1,92 @@
+package agents
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"superman/state"
+	"superman/utils"
+
+	"github.com/cloudwego/eino/compose"
+)
+
+// toolTimeoutMiddleware 为每次工具调用设置超时：超时后取消该调用，并把超时结果返回给模型让任务继续；
+// 工具超时或出错都会记录到执行历史
+func (a *BaseAgentImpl) toolTimeoutMiddleware() compose.ToolMiddleware {
+	return compose.ToolMiddleware{
+		Invokable: func(next compose.InvokableToolEndpoint) compose.InvokableToolEndpoint {
+			return func(ctx context.Context, input *compose.ToolInput) (*compose.ToolOutput, error) {
+				if a.toolTimeout <= 0 {
+					output, err := next(ctx, input)
+					if err != nil {
+						a.recordToolFailure(input, "failed", err)
+					}
+					return output, err
+				}
+
+				callCtx, cancel := context.WithTimeout(ctx, a.toolTimeout)
+				defer cancel()
+
+				type result struct {
+					output *compose.ToolOutput
+					err    error
+				}
+				done := make(chan result, 1)
+				go func() {
+					output, err := next(callCtx, input)
+					done <- result{output, err}
+				}()
+
+				select {
+				case r := <-done:
+					if r.err != nil {
+						a.recordToolFailure(input, "failed", r.err)
+					}
+					return r.output, r.err
+				case <-callCtx.Done():
+					// 整个任务被取消时直接返回，只有单个工具超时才让任务继续
+					if ctx.Err() != nil {
+						return nil, ctx.Err()
+					}
+					err := fmt.Errorf("tool %s timed out after %s: %w", input.Name, a.toolTimeout, callCtx.Err())
+					a.recordToolFailure(input, "timeout", err)
+					return &compose.ToolOutput{
+						Result: fmt.Sprintf("工具 %s 调用超时（%s），已取消。请不要重复相同的调用，换一种方式继续完成任务。", input.Name, a.toolTimeout),
+					}, nil
+				}
+			}
+		},
+	}
+}
+
+// recordToolFailure 记录工具调用失败（超时或出错）到执行历史与指标
+func (a *BaseAgentImpl) recordToolFailure(input *compose.ToolInput, status string, err error) {
+	if errors.Is(err, context.DeadlineExceeded) {
+		a.incrMetric("tool_timeouts")
+	} else {
+		a.incrMetric("tool_failures")
+	}
+	slog.Warn("tool call failed",
+		slog.String("agent", a.name),
+		slog.String("tool", input.Name),
+		slog.String("status", status),
+		slog.Any("error", err),
+	)
+
+	id, idErr := utils.NewUUID()
+	if idErr != nil {
+		return
+	}
+	a.AddExecutionHistory(&state.AgentExecutionHistory{
+		ExecutionID:  id,
+		Timestamp:    time.Now(),
+		Action:       "tool_call",
+		Input:        map[string]any{"tool": input.Name, "arguments": input.Arguments, "call_id": input.CallID},
+		Output:       map[string]any{},
+		Status:       status,
+		ErrorMessage: err.Error(),
+	})
+}
@@ -0,0 +1,63 @@
+package agents
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"superman/config"
+	"superman/ds"
+	"superman/mailbox"
+
+	"github.com/cloudwego/eino/schema"
+)
+
+// 工具调用超过 tool_timeout 时被取消，超时结果交给模型后任务继续完成，超时记录在执行历史中
+func TestSlowToolIsCancelledAtToolTimeout(t *testing.T) {
+	var toolResult string
+	llm := &fakeModel{reply: func(input []*schema.Message) (*schema.Message, error) {
+		if last := input[len(input)-1]; last.Role == schema.Tool {
+			toolResult = last.Content
+			return schema.AssistantMessage("done", nil), nil
+		}
+		return schema.AssistantMessage("", []schema.ToolCall{{
+			ID:       "call-1",
+			Function: schema.FunctionCall{Name: "send message", Arguments: `{"receivers": ["peer"], "body": "hi"}`},
+		}}), nil
+	}}
+	agent, bus := newTestAgent(t, llm, config.AgentConfig{ToolTimeout: "100ms"})
+	// peer 的收件箱已满，发送消息会阻塞到投递超时（5s）
+	peerConfig := mailbox.DefaultMailboxConfig("peer")
+	peerConfig.InboxBufferSize = 1
+	peer := mailbox.NewMailbox(peerConfig)
+	if err := bus.RegisterMailbox("peer", peer); err != nil {
+		t.Fatalf("register mailbox: %v", err)
+	}
+	filler, _ := ds.NewMessage("boss", "peer", ds.MessageTypeSystem, "backlog")
+	if err := bus.Send(filler); err != nil {
+		t.Fatalf("fill peer inbox: %v", err)
+	}
+	startTestAgent(t, agent)
+
+	start := time.Now()
+	runTestTask(t, agent, "task-1")
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("task took %s, want the slow tool cut off at the 100ms tool timeout", elapsed)
+	}
+	if status := agent.globalState.GetTask("task-1").Status; status != ds.TaskStatusCompleted {
+		t.Fatalf("task status = %s, want completed after the tool timeout", status)
+	}
+	if !strings.Contains(toolResult, "调用超时") {
+		t.Fatalf("tool result = %q, want the timeout notice passed to the model", toolResult)
+	}
+
+	var timeouts int
+	for _, h := range agent.GetRecentExecutions(10) {
+		if h.Action == "tool_call" && h.Status == "timeout" {
+			timeouts++
+		}
+	}
+	if timeouts != 1 {
+		t.Fatalf("recorded %d tool timeouts, want 1", timeouts)
+	}
+}
@@ -0,0 +1,219 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"superman/agents"
+	"superman/company"
+	"superman/config"
+	"superman/ds"
+	"superman/infra"
+	"superman/mailbox"
+	"superman/state"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+)
+
+// taskGenModel 总是返回一条任务生成结果的模型
+type taskGenModel struct{}
+
+const taskGenReply = `[{"title": "季度报告", "description": "汇总本季度数据", "priority": "Medium"}]`
+
+func (taskGenModel) Generate(context.Context, []*schema.Message, ...model.Option) (*schema.Message, error) {
+	return schema.AssistantMessage(taskGenReply, nil), nil
+}
+
+func (taskGenModel) Stream(context.Context, []*schema.Message, ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	return schema.StreamReaderFromArray([]*schema.Message{schema.AssistantMessage(taskGenReply, nil)}), nil
+}
+
+func (m taskGenModel) WithTools([]*schema.ToolInfo) (model.ToolCallingChatModel, error) {
+	return m, nil
+}
+
+// 手动触发任务生成立即返回提交的任务 ID；未知 Agent 返回 404，任务生成暂停时返回 409
+func TestAgentGenerateSubmitsTasks(t *testing.T) {
+	registry := &infra.Registry{
+		LLM:           map[string]model.ToolCallingChatModel{"fake": taskGenModel{}},
+		ShutdownHooks: infra.NewShutdownHooks(0),
+	}
+	co, err := company.NewCompany(context.Background(), registry, config.CompanyConfig{ID: "acme", Agents: []config.AgentConfig{{
+		Name:                "cfo",
+		Desc:                "负责财务",
+		Model:               "fake",
+		SkillDir:            t.TempDir(),
+		TaskGenInitialDelay: "1h",
+	}}})
+	if err != nil {
+		t.Fatalf("NewCompany: %v", err)
+	}
+	server, _ := newTestServer(t, co)
+
+	resp := doJSON(t, server, http.MethodPost, "/api/agents/cfo/generate", nil, http.StatusOK)
+	ids, _ := resp["task_ids"].([]any)
+	if len(ids) != 1 {
+		t.Fatalf("response = %v, want one generated task ID", resp)
+	}
+	task := co.GlobalState.GetTask(ids[0].(string))
+	if task == nil || task.Title != "季度报告" || co.Scheduler.GetQueueLength() != 1 {
+		t.Fatalf("task = %v, queue = %d, want the generated task submitted", task, co.Scheduler.GetQueueLength())
+	}
+
+	doJSON(t, server, http.MethodPost, "/api/agents/nobody/generate", nil, http.StatusNotFound)
+	co.Scheduler.PauseTaskGeneration()
+	doJSON(t, server, http.MethodPost, "/api/agents/cfo/generate", nil, http.StatusConflict)
+}
+
+// 执行历史查询的 limit 超出 Agent 配置的上限时按上限返回，未指定时使用默认条数，非法值返回 400
+func TestAgentHistoryLimitIsClamped(t *testing.T) {
+	bus := mailbox.NewMailboxBus()
+	agent, err := agents.NewBaseAgent(context.Background(), taskGenModel{}, bus, config.AgentConfig{
+		Name:            "cfo",
+		Desc:            "负责财务",
+		SkillDir:        t.TempDir(),
+		MaxHistoryQuery: 3,
+	})
+	if err != nil {
+		t.Fatalf("NewBaseAgent: %v", err)
+	}
+	for i := range 5 {
+		agent.AddExecutionHistory(&state.AgentExecutionHistory{ExecutionID: fmt.Sprintf("exec-%d", i), Timestamp: time.Now()})
+	}
+	server, _ := newTestServer(t, &company.Company{
+		ID:          "acme",
+		MailboxBus:  bus,
+		GlobalState: bus.GetGlobalState(),
+		Agents:      map[string]agents.Agent{"cfo": agent},
+	})
+
+	resp := doJSON(t, server, http.MethodGet, "/api/agents/cfo/history?limit=1000000", nil, http.StatusOK)
+	history, _ := resp["history"].([]any)
+	if resp["limit"] != 3.0 || len(history) != 3 {
+		t.Fatalf("limit = %v with %d records, want the cap of 3", resp["limit"], len(history))
+	}
+	if latest := history[len(history)-1].(map[string]any)["execution_id"]; latest != "exec-4" {
+		t.Fatalf("last record = %v, want the most recent execution exec-4", latest)
+	}
+	if resp := doJSON(t, server, http.MethodGet, "/api/agents/cfo/history", nil, http.StatusOK); resp["limit"] != 3.0 {
+		t.Fatalf("default limit = %v, want it clamped to 3", resp["limit"])
+	}
+	doJSON(t, server, http.MethodGet, "/api/agents/cfo/history?limit=-1", nil, http.StatusBadRequest)
+
+	if got := agent.GetExecutionHistoryByTimeRange(time.Now().Add(-time.Hour), time.Now()); len(got) != 3 {
+		t.Fatalf("time range query returned %d records, want the cap of 3", len(got))
+	}
+}
+
+// 采样若干次后，trend=N 为每个 Agent 返回最近 N 个采样点，且不超过保留上限；未请求趋势时不返回采样
+func TestStatusTrendReturnsRecentSamples(t *testing.T) {
+	registry := &infra.Registry{
+		LLM:           map[string]model.ToolCallingChatModel{"fake": taskGenModel{}},
+		ShutdownHooks: infra.NewShutdownHooks(0),
+	}
+	agentConfig := func(name string) config.AgentConfig {
+		return config.AgentConfig{Name: name, Desc: "test agent", Model: "fake", SkillDir: t.TempDir(), TaskGenInitialDelay: "1h"}
+	}
+	co, err := company.NewCompany(context.Background(), registry, config.CompanyConfig{
+		ID:                 "acme",
+		Agents:             []config.AgentConfig{agentConfig("ceo"), agentConfig("cfo")},
+		StatusTrendSamples: 3,
+	})
+	if err != nil {
+		t.Fatalf("NewCompany: %v", err)
+	}
+	server, _ := newTestServer(t, co)
+
+	start := time.Now()
+	for i := range 5 {
+		if i == 4 {
+			msg, _ := ds.NewMessage("boss", "cfo", ds.MessageTypeSystem, "pending")
+			if err := co.MailboxBus.Send(msg); err != nil {
+				t.Fatalf("Send: %v", err)
+			}
+		}
+		co.SampleStatus(start.Add(time.Duration(i) * time.Second))
+	}
+
+	trendOf := func(resp map[string]any, name string) []any {
+		for _, raw := range resp["agents"].([]any) {
+			if agent := raw.(map[string]any); agent["name"] == name {
+				trend, _ := agent["trend"].([]any)
+				return trend
+			}
+		}
+		t.Fatalf("agent %s missing from %v", name, resp["agents"])
+		return nil
+	}
+	resp := doJSON(t, server, http.MethodGet, "/api/status?trend=2", nil, http.StatusOK)
+	for _, name := range []string{"ceo", "cfo"} {
+		if got := len(trendOf(resp, name)); got != 2 {
+			t.Fatalf("%s trend has %d points, want 2", name, got)
+		}
+	}
+	if last := trendOf(resp, "cfo")[1].(map[string]any); last["queue_depth"] != 1.0 {
+		t.Fatalf("latest cfo sample = %v, want queue depth 1", last)
+	}
+
+	resp = doJSON(t, server, http.MethodGet, "/api/status?trend=10", nil, http.StatusOK)
+	if got := len(trendOf(resp, "ceo")); got != 3 {
+		t.Fatalf("ceo trend has %d points, want the 3 retained samples", got)
+	}
+	resp = doJSON(t, server, http.MethodGet, "/api/status", nil, http.StatusOK)
+	if trend := trendOf(resp, "ceo"); trend != nil {
+		t.Fatalf("trend = %v without trend query, want none", trend)
+	}
+	doJSON(t, server, http.MethodGet, "/api/status?trend=0", nil, http.StatusBadRequest)
+}
+
+// echoModel 把收到的最后一条消息原样作为回复的模型
+type echoModel struct{}
+
+func (echoModel) Generate(_ context.Context, input []*schema.Message, _ ...model.Option) (*schema.Message, error) {
+	return schema.AssistantMessage("根据记录："+input[len(input)-1].Content, nil), nil
+}
+
+func (m echoModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	msg, _ := m.Generate(ctx, input, opts...)
+	return schema.StreamReaderFromArray([]*schema.Message{msg}), nil
+}
+
+func (m echoModel) WithTools([]*schema.ToolInfo) (model.ToolCallingChatModel, error) {
+	return m, nil
+}
+
+// 已完成任务的解释基于存储的执行记录返回非空说明；没有记录的 ID 与未知 Agent 返回 404
+func TestAgentExplainReferencesTranscript(t *testing.T) {
+	registry := &infra.Registry{
+		LLM:           map[string]model.ToolCallingChatModel{"fake": echoModel{}},
+		ShutdownHooks: infra.NewShutdownHooks(0),
+	}
+	co, err := company.NewCompany(context.Background(), registry, config.CompanyConfig{ID: "acme", Agents: []config.AgentConfig{{
+		Name:                "cfo",
+		Desc:                "负责财务",
+		Model:               "fake",
+		SkillDir:            t.TempDir(),
+		TaskGenInitialDelay: "1h",
+	}}})
+	if err != nil {
+		t.Fatalf("NewCompany: %v", err)
+	}
+	server, _ := newTestServer(t, co)
+
+	co.GlobalState.AddTask(ds.NewTask("t1", "季度预算", "编制下季度预算", "cfo", "ceo", ds.TaskStatusCompleted, ds.TaskPriorityMedium))
+	co.GlobalState.AppendTranscript("t1", state.TranscriptEntry{Agent: "cfo", Role: "assistant", Content: "营销费用削减 10%"})
+
+	resp := doJSON(t, server, http.MethodPost, "/api/agents/cfo/explain", map[string]string{"id": "t1"}, http.StatusOK)
+	explanation, _ := resp["explanation"].(string)
+	if explanation == "" || !strings.Contains(explanation, "营销费用削减 10%") {
+		t.Fatalf("explanation = %q, want one referencing the stored transcript", explanation)
+	}
+
+	doJSON(t, server, http.MethodPost, "/api/agents/cfo/explain", map[string]string{"id": "missing"}, http.StatusNotFound)
+	doJSON(t, server, http.MethodPost, "/api/agents/nobody/explain", map[string]string{"id": "t1"}, http.StatusNotFound)
+}
@@ -0,0 +1,46 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"superman/company"
+	"superman/state"
+)
+
+// newTestServer 以 co 作为默认公司创建服务，co 为 nil 时使用只含全局状态的公司
+func newTestServer(t *testing.T, co *company.Company) (*Server, *company.Company) {
+	t.Helper()
+	if co == nil {
+		co = &company.Company{ID: "acme", GlobalState: state.NewGlobalState()}
+	}
+	Initialize(map[string]*company.Company{co.ID: co}, co.ID)
+	t.Cleanup(func() { Initialize(nil, "") })
+	return NewServer(), co
+}
+
+// doJSON 发送请求（body 非 nil 时编码为 JSON），状态码不符时失败，返回解码后的响应
+func doJSON(t *testing.T, server *Server, method, path string, body any, wantStatus int) map[string]any {
+	t.Helper()
+	var data []byte
+	if body != nil {
+		var err error
+		if data, err = json.Marshal(body); err != nil {
+			t.Fatalf("marshal request: %v", err)
+		}
+	}
+	req := httptest.NewRequest(method, path, bytes.NewReader(data))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	server.engine.ServeHTTP(w, req)
+	if w.Code != wantStatus {
+		t.Fatalf("%s %s status = %d, want %d: %s", method, path, w.Code, wantStatus, w.Body.String())
+	}
+	var resp map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode %s %s response: %v", method, path, err)
+	}
+	return resp
+}
@@ -0,0 +1,70 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"superman/agents"
+	"superman/infra"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/gin-gonic/gin"
+)
+
+// modelHealth 已配置模型及其健康探测，未设置时模型列表为空
+var modelHealth *infra.ModelHealth
+
+// chatModels 已配置的模型（模型名 -> 实例），运行时替换 Agent 模型时按名称查找
+var chatModels map[string]model.ToolCallingChatModel
+
+// SetModelHealth 设置模型健康探测
+func SetModelHealth(h *infra.ModelHealth) {
+	modelHealth = h
+}
+
+// SetChatModels 设置可供 Agent 运行时切换的模型
+func SetChatModels(models map[string]model.ToolCallingChatModel) {
+	chatModels = models
+}
+
+// modelsHandler 列出已配置的模型（不含密钥）及健康状态，探测结果短时间缓存
+func (s *Server) modelsHandler(c *gin.Context) {
+	if modelHealth == nil {
+		c.JSON(http.StatusOK, gin.H{"models": []infra.ModelStatus{}})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"models": modelHealth.Check(c.Request.Context())})
+}
+
+// setAgentModelHandler 运行时替换 Agent 使用的模型，模型名需为已配置的模型
+func (s *Server) setAgentModelHandler(c *gin.Context) {
+	var req AgentModelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	name := c.Param("name")
+	llm, ok := chatModels[req.Model]
+	if !ok {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: fmt.Sprintf("model %s not configured", req.Model)})
+		return
+	}
+	if req.FallbackModel != "" {
+		fallback, ok := chatModels[req.FallbackModel]
+		if !ok {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: fmt.Sprintf("fallback model %s not configured", req.FallbackModel)})
+			return
+		}
+		llm = agents.WithFallbackModel(name, llm, fallback)
+	}
+	agent, ok := currentCompany(c).Agents[name]
+	if !ok {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: fmt.Sprintf("agent %s not found", name)})
+		return
+	}
+	if err := agent.SetLLMModel(llm); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"agent": name, "model": req.Model, "fallback_model": req.FallbackModel})
+}
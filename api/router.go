@@ -1,14 +1,63 @@
 package api
 
+import "github.com/gin-gonic/gin"
+
 func (s *Server) configureRoutes() {
 	s.engine.GET("/health", s.healthHandler)
 	s.engine.GET("/ready", s.readyHandler)
+	s.engine.GET("/version", s.versionHandler)
 
 	api := s.engine.Group("/api")
-	api.POST("/send", s.sendHandler)
-	api.GET("/status", s.statusHandler)
-	api.GET("/agents", s.agentsHandler)
-	api.GET("/tasks", s.tasksHandler)
-	api.GET("/messages", s.messagesHandler)
+	api.GET("/companies", s.companiesHandler)
+	api.GET("/models", s.modelsHandler)
 	api.POST("/shutdown", s.shutdownHandler)
+	s.configureCompanyRoutes(api.Group("", s.defaultCompany))
+
+	// 多租户路由：/api/companies/{company_id}/...
+	s.configureCompanyRoutes(api.Group("/companies/:company_id", s.resolveCompany))
+}
+
+// configureCompanyRoutes 注册公司级路由
+func (s *Server) configureCompanyRoutes(g *gin.RouterGroup) {
+	g.POST("/send", s.sendHandler)
+	g.GET("/status", s.statusHandler)
+	g.GET("/agents", s.agentsHandler)
+	g.GET("/agents/:name/tasks", s.agentTasksHandler)
+	g.GET("/agents/:name/history", s.agentHistoryHandler)
+	g.GET("/agents/:name/load", s.agentLoadHandler)
+	g.PUT("/agents/:name/max-tasks", s.setAgentMaxTasksHandler)
+	g.GET("/agents/:name/task-gen-interval", s.agentTaskGenIntervalHandler)
+	g.PUT("/agents/:name/task-gen-interval", s.setAgentTaskGenIntervalHandler)
+	g.PUT("/agents/:name/model", s.setAgentModelHandler)
+	g.POST("/agents/:name/generate", s.agentGenerateHandler)
+	g.POST("/agents/:name/explain", s.agentExplainHandler)
+	g.GET("/stats", s.statsHandler)
+	g.GET("/report", s.reportHandler)
+	g.GET("/state", s.stateHandler)
+	g.GET("/tasks", s.tasksHandler)
+	g.GET("/tasks/search", s.taskSearchHandler)
+	g.POST("/tasks/status", s.taskStatusHandler)
+	g.POST("/tasks/preview", s.taskPreviewHandler)
+	g.GET("/tasks/:id", s.taskDetailHandler)
+	g.PATCH("/tasks/:id", s.taskPatchHandler)
+	g.GET("/tasks/:id/result", s.taskResultHandler)
+	g.GET("/tasks/:id/stream", s.taskStreamHandler)
+	g.POST("/tasks/:id/comments", s.taskCommentHandler)
+	g.POST("/tasks/:id/retry", s.taskRetryHandler)
+	g.GET("/messages", s.messagesHandler)
+	g.GET("/dead-letters", s.deadLettersHandler)
+	g.GET("/dead-letters/permanent", s.permanentFailuresHandler)
+	g.POST("/dead-letters/:id/requeue", s.requeueDeadLetterHandler)
+	g.GET("/events", s.eventsHandler)
+	g.GET("/scheduler/graph.dot", s.dependencyGraphHandler)
+	g.GET("/scheduler/tick-interval", s.tickIntervalHandler)
+	g.PUT("/scheduler/tick-interval", s.setTickIntervalHandler)
+	g.GET("/scheduler/waiting", s.waitingTasksHandler)
+	g.GET("/scheduler/metrics", s.schedulerMetricsHandler)
+	g.GET("/scheduler/pressure", s.schedulerPressureHandler)
+	g.DELETE("/scheduler/queue/:priority", s.clearQueueHandler)
+	g.GET("/timer/status", s.timerStatusHandler)
+	g.GET("/taskgen", s.taskGenStatusHandler)
+	g.POST("/taskgen/pause", s.taskGenPauseHandler)
+	g.POST("/taskgen/resume", s.taskGenResumeHandler)
 }
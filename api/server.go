@@ -2,31 +2,38 @@ package api
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
-	"os"
-	"os/signal"
-	"syscall"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"superman/agents"
+	"superman/company"
 	"superman/ds"
 	"superman/mailbox"
 	"superman/scheduler"
-	"superman/workflow"
+	"superman/state"
 
 	"github.com/gin-gonic/gin"
 )
 
 var (
-	agentMap          map[string]agents.Agent
-	mailboxBus        *mailbox.MailboxBus
-	orchestrator      workflow.Orchestrator
-	schedulerInstance *scheduler.AutoScheduler
-	timerEngine       interface{}
-	stopFunc          context.CancelFunc
+	companies        map[string]*company.Company
+	defaultCompanyID string
+
+	// shutdownCh 通过 API 请求停止时关闭，停止流程由进程入口统一执行
+	shutdownCh   = make(chan struct{})
+	shutdownOnce sync.Once
 )
 
+// companyContextKey 请求上下文中当前公司的键
+const companyContextKey = "company"
+
 type Server struct {
 	engine *gin.Engine
 }
@@ -35,24 +42,28 @@ type SendRequest struct {
 	Sender   string `json:"sender" binding:"required"`
 	Receiver string `json:"receiver" binding:"required"`
 	Message  string `json:"message" binding:"required"`
+	Priority string `json:"priority"` // 可选：Critical、High、Medium、Low，指定时消息作为该优先级的任务提交到调度器
 }
 
 type SendResponse struct {
 	Success bool   `json:"success"`
 	Message string `json:"message,omitempty"`
+	TaskID  string `json:"task_id,omitempty"`
 	Error   string `json:"error,omitempty"`
 }
 
 type StatusResponse struct {
-	SchedulerQueue int            `json:"scheduler_queue"`
-	Priorities     map[string]int `json:"priorities"`
-	Agents         []AgentStatus  `json:"agents"`
+	SchedulerQueue int                     `json:"scheduler_queue"`
+	Priorities     map[string]int          `json:"priorities"`
+	Agents         []AgentStatus           `json:"agents"`
+	DeadLetters    mailbox.DeadLetterStats `json:"dead_letters"`
 }
 
 type AgentStatus struct {
-	Name     string  `json:"name"`
-	Workload float64 `json:"workload"`
-	Running  bool    `json:"running"`
+	Name     string                 `json:"name"`
+	Workload float64                `json:"workload"`
+	Running  bool                   `json:"running"`
+	Trend    []company.StatusSample `json:"trend,omitempty"` // 最近的负载与队列深度采样，请求 trend=N 时返回
 }
 
 type AgentInfo struct {
@@ -60,6 +71,9 @@ type AgentInfo struct {
 	Desc     string  `json:"desc"`
 	Running  bool    `json:"running"`
 	Workload float64 `json:"workload"`
+	Busy     bool    `json:"busy"`
+
+	Mailbox map[string]interface{} `json:"mailbox,omitempty"`
 }
 
 type AgentsResponse struct {
@@ -67,6 +81,65 @@ type AgentsResponse struct {
 	Agents []AgentInfo `json:"agents"`
 }
 
+type TickIntervalRequest struct {
+	Interval string `json:"interval" binding:"required"`
+}
+
+type AgentTaskGenIntervalRequest struct {
+	Interval string `json:"interval" binding:"required"`
+}
+
+type AgentMaxTasksRequest struct {
+	MaxTasks int `json:"max_tasks" binding:"required,min=1"`
+}
+
+type AgentModelRequest struct {
+	Model         string `json:"model" binding:"required"`
+	FallbackModel string `json:"fallback_model"` // 可选：主模型不可用时改用的备用模型
+}
+
+type AgentExplainRequest struct {
+	ID string `json:"id" binding:"required"` // 任务 ID，或执行历史中的执行 ID、消息 ID
+}
+
+type TaskCommentRequest struct {
+	Author string `json:"author" binding:"required"`
+	Body   string `json:"body" binding:"required"`
+}
+
+// TaskPatchRequest 修改排队中任务的请求
+type TaskPatchRequest struct {
+	Priority           string   `json:"priority"` // 人工调整优先级：Critical, High, Medium, Low
+	AddDependencies    []string `json:"add_dependencies"`
+	RemoveDependencies []string `json:"remove_dependencies"`
+	Tags               []string `json:"tags"` // 替换任务标签，未提供时不修改
+}
+
+type TaskRetryRequest struct {
+	Priority string `json:"priority"` // 重新入队的优先级：Critical, High, Medium, Low，为空沿用任务优先级
+}
+
+// TaskPreviewRequest 路由预览的假设任务
+type TaskPreviewRequest struct {
+	Title                string         `json:"title"`
+	Description          string         `json:"description"`
+	Priority             string         `json:"priority"` // Critical, High, Medium, Low，默认 Medium
+	AssignedTo           string         `json:"assigned_to"`
+	RequiredCapabilities []string       `json:"required_capabilities"`
+	Effort               float64        `json:"effort"` // 预估工作量，为空时按调度器的估算方式估算
+	Metadata             map[string]any `json:"metadata"`
+}
+
+type TaskStatusRequest struct {
+	IDs []string `json:"ids" binding:"required"`
+}
+
+type TaskStatusInfo struct {
+	Status     string  `json:"status"`
+	AssignedTo string  `json:"assigned_to"`
+	Progress   float64 `json:"progress"`
+}
+
 type ErrorResponse struct {
 	Error string `json:"error"`
 }
@@ -98,8 +171,48 @@ func (s *Server) healthHandler(c *gin.Context) {
 	})
 }
 
+// defaultCompany 将请求绑定到默认公司
+func (s *Server) defaultCompany(c *gin.Context) {
+	co, exists := companies[defaultCompanyID]
+	if !exists {
+		c.AbortWithStatusJSON(http.StatusServiceUnavailable, ErrorResponse{Error: "System not initialized"})
+		return
+	}
+	c.Set(companyContextKey, co)
+	c.Next()
+}
+
+// resolveCompany 根据路径中的 company_id 将请求绑定到对应公司
+func (s *Server) resolveCompany(c *gin.Context) {
+	id := c.Param("company_id")
+	co, exists := companies[id]
+	if !exists {
+		c.AbortWithStatusJSON(http.StatusNotFound, ErrorResponse{Error: fmt.Sprintf("company %s not found", id)})
+		return
+	}
+	c.Set(companyContextKey, co)
+	c.Next()
+}
+
+// currentCompany 获取当前请求所属的公司
+func currentCompany(c *gin.Context) *company.Company {
+	return c.MustGet(companyContextKey).(*company.Company)
+}
+
+func (s *Server) companiesHandler(c *gin.Context) {
+	result := make([]gin.H, 0, len(companies))
+	for id, co := range companies {
+		result = append(result, gin.H{
+			"id":          id,
+			"agent_count": len(co.Agents),
+			"default":     id == defaultCompanyID,
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{"companies": result})
+}
+
 func (s *Server) readyHandler(c *gin.Context) {
-	if mailboxBus == nil || orchestrator == nil || schedulerInstance == nil {
+	if len(companies) == 0 {
 		c.JSON(http.StatusServiceUnavailable, ErrorResponse{Error: "System not initialized"})
 		return
 	}
@@ -128,6 +241,10 @@ func (s *Server) sendHandler(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "message is required"})
 		return
 	}
+	if req.Priority != "" {
+		s.sendAsTask(c, req)
+		return
+	}
 
 	msg, err := ds.NewRequestMessage(
 		req.Sender,
@@ -141,7 +258,7 @@ func (s *Server) sendHandler(c *gin.Context) {
 		return
 	}
 
-	err = mailboxBus.Send(msg)
+	err = currentCompany(c).MailboxBus.Send(msg)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("failed to send message: %v", err)})
 		return
@@ -153,11 +270,72 @@ func (s *Server) sendHandler(c *gin.Context) {
 	})
 }
 
+// sendAsTask 将带优先级的用户消息作为任务提交到调度器，按优先级排队处理
+func (s *Server) sendAsTask(c *gin.Context, req SendRequest) {
+	priority, ok := normalizePriority(req.Priority)
+	if !ok {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: fmt.Sprintf("invalid priority %q", req.Priority)})
+		return
+	}
+
+	co := currentCompany(c)
+	if _, exists := co.Agents[req.Receiver]; !exists {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: fmt.Sprintf("agent %s not found", req.Receiver)})
+		return
+	}
+
+	title := req.Message
+	if runes := []rune(title); len(runes) > 50 {
+		title = string(runes[:50]) + "..."
+	}
+	task := ds.NewTask(
+		ds.GenerateTaskID(),
+		title,
+		req.Message,
+		req.Receiver,
+		req.Sender,
+		ds.TaskStatusPending,
+		ds.TaskPriority(strings.ToLower(priority)),
+	)
+	task.Metadata["source"] = "user"
+	co.Scheduler.AddTask(task, priority)
+
+	c.JSON(http.StatusOK, SendResponse{
+		Success: true,
+		Message: fmt.Sprintf("Task submitted from %s to %s with priority %s", req.Sender, req.Receiver, priority),
+		TaskID:  task.ID,
+	})
+}
+
+// normalizePriority 将不区分大小写的优先级规范为调度器队列名
+func normalizePriority(priority string) (string, bool) {
+	for _, p := range []string{scheduler.PriorityCritical, scheduler.PriorityHigh, scheduler.PriorityMedium, scheduler.PriorityLow} {
+		if strings.EqualFold(p, priority) {
+			return p, true
+		}
+	}
+	return "", false
+}
+
+// statusHandler 返回调度队列与 Agent 状态，trend=N 时附带每个 Agent 最近 N 个采样点
 func (s *Server) statusHandler(c *gin.Context) {
+	trendPoints := 0
+	if raw := c.Query("trend"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "trend must be a positive integer"})
+			return
+		}
+		trendPoints = n
+	}
+
+	co := currentCompany(c)
+	schedulerInstance := co.Scheduler
 	response := StatusResponse{
 		SchedulerQueue: schedulerInstance.GetQueueLength(),
 		Priorities:     make(map[string]int),
 		Agents:         make([]AgentStatus, 0),
+		DeadLetters:    co.MailboxBus.GetDeadLetterQueue().Stats(),
 	}
 
 	for _, priority := range []string{
@@ -169,18 +347,27 @@ func (s *Server) statusHandler(c *gin.Context) {
 		response.Priorities[priority] = schedulerInstance.GetQueueLengthByPriority(priority)
 	}
 
-	for name, agent := range agentMap {
-		response.Agents = append(response.Agents, AgentStatus{
+	var trend map[string][]company.StatusSample
+	if trendPoints > 0 {
+		trend = co.StatusTrend(trendPoints)
+	}
+	for name, agent := range co.Agents {
+		status := AgentStatus{
 			Name:     name,
 			Workload: agent.GetWorkload(),
 			Running:  agent.IsRunning(),
-		})
+		}
+		if trend != nil {
+			status.Trend = trend[name]
+		}
+		response.Agents = append(response.Agents, status)
 	}
 
 	c.JSON(http.StatusOK, response)
 }
 
 func (s *Server) agentsHandler(c *gin.Context) {
+	agentMap := currentCompany(c).Agents
 	response := AgentsResponse{
 		Total:  len(agentMap),
 		Agents: make([]AgentInfo, 0),
@@ -192,6 +379,8 @@ func (s *Server) agentsHandler(c *gin.Context) {
 			Desc:     agent.GetDesc(),
 			Running:  agent.IsRunning(),
 			Workload: agent.GetWorkload(),
+			Busy:     agent.IsBusy(),
+			Mailbox:  agent.GetMailbox().GetMailboxStats(),
 		})
 	}
 
@@ -199,10 +388,7 @@ func (s *Server) agentsHandler(c *gin.Context) {
 }
 
 func (s *Server) shutdownHandler(c *gin.Context) {
-	go func() {
-		shutdown(timerEngine, schedulerInstance, agentMap)
-		os.Exit(0)
-	}()
+	shutdownOnce.Do(func() { close(shutdownCh) })
 
 	c.JSON(http.StatusOK, gin.H{
 		"status":  "shutting_down",
@@ -210,96 +396,717 @@ func (s *Server) shutdownHandler(c *gin.Context) {
 	})
 }
 
+// RunServer 启动 HTTP 服务并阻塞；信号与 /shutdown 请求的停止流程由调用方处理
 func RunServer(port string) error {
 	server := NewServer()
+	if err := server.Start(port); err != nil && err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
 
-	errCh := make(chan error, 1)
-	go func() {
-		if err := server.Start(port); err != nil && err != http.ErrServerClosed {
-			errCh <- err
+// ShutdownRequested 返回通过 /shutdown 请求停止时关闭的通道
+func ShutdownRequested() <-chan struct{} {
+	return shutdownCh
+}
+
+// Initialize 注入公司实例，defaultID 对应的公司服务于不带公司前缀的 /api 路由
+func Initialize(companyMap map[string]*company.Company, defaultID string) {
+	companies = companyMap
+	defaultCompanyID = defaultID
+}
+
+func (s *Server) tasksHandler(c *gin.Context) {
+	filter, err := taskFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	matched := currentCompany(c).GlobalState.QueryTasks(filter)
+	tasks := make([]gin.H, len(matched))
+	for i, task := range matched {
+		tasks[i] = taskSummary(task)
+	}
+	c.JSON(http.StatusOK, gin.H{"tasks": tasks})
+}
+
+func (s *Server) taskSearchHandler(c *gin.Context) {
+	query := c.Query("q")
+	if strings.TrimSpace(query) == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "query parameter q is required"})
+		return
+	}
+
+	filter, err := taskFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	results := currentCompany(c).GlobalState.SearchTasks(query, filter.Match)
+	tasks := make([]gin.H, len(results))
+	for i, result := range results {
+		tasks[i] = taskSummary(result.Task)
+		tasks[i]["score"] = result.Score
+	}
+	c.JSON(http.StatusOK, gin.H{"query": query, "tasks": tasks})
+}
+
+// taskFilter 根据查询参数 assignee、status、priority、type、tag、created_after、created_before（RFC3339）
+// 构造任务查询条件（不区分大小写，未指定时不过滤）
+func taskFilter(c *gin.Context) (state.TaskFilter, error) {
+	filter := state.TaskFilter{
+		AssignedTo: c.Query("assignee"),
+		Status:     ds.TaskStatus(c.Query("status")),
+		Priority:   ds.TaskPriority(c.Query("priority")),
+		Type:       ds.TaskType(c.Query("type")),
+		Tag:        c.Query("tag"),
+	}
+	for param, target := range map[string]*time.Time{
+		"created_after":  &filter.CreatedAfter,
+		"created_before": &filter.CreatedBefore,
+	} {
+		value := c.Query(param)
+		if value == "" {
+			continue
 		}
-	}()
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return filter, fmt.Errorf("invalid %s %q, expected RFC3339 time", param, value)
+		}
+		*target = t
+	}
+	return filter, nil
+}
 
-	select {
-	case err := <-errCh:
-		return err
-	case <-time.After(2 * time.Second):
+// taskSummary 任务列表项
+func taskSummary(task *ds.Task) gin.H {
+	return gin.H{
+		"id":           task.ID,
+		"title":        task.Title,
+		"priority":     string(task.Priority),
+		"status":       string(task.Status),
+		"type":         string(task.Type),
+		"assigned_to":  task.AssignedTo,
+		"tags":         task.Tags,
+		"created_at":   task.CreatedAt.Format("2006-01-02 15:04:05"),
+		"dependencies": task.Dependencies,
+		"comments":     task.Comments,
+		"warnings":     task.Warnings,
+		"contributors": task.Contributors,
+
+		"priority_history": task.PriorityHistory,
 	}
+}
 
-	sigCh := make(chan os.Signal, 1)
-	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+// stateHandler 按 fields（逗号分隔，如 kpis,announcements）返回全局状态的部分内容，未指定时返回可选字段列表
+func (s *Server) stateHandler(c *gin.Context) {
+	param := strings.TrimSpace(c.Query("fields"))
+	if param == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":  "query parameter fields is required",
+			"fields": state.SelectableFields(),
+		})
+		return
+	}
 
-	go func() {
-		<-sigCh
-		fmt.Println("\nReceived shutdown signal")
-		fmt.Printf("Server shutdown error: %v\n", server.Stop(nil))
-		shutdown(timerEngine, schedulerInstance, agentMap)
-		os.Exit(0)
-	}()
+	selectable := state.SelectableFields()
+	var fields []string
+	for _, field := range strings.Split(param, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		if !slices.Contains(selectable, field) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":  fmt.Sprintf("unknown field %q", field),
+				"fields": selectable,
+			})
+			return
+		}
+		fields = append(fields, field)
+	}
+	c.JSON(http.StatusOK, currentCompany(c).GlobalState.Select(fields))
+}
 
-	fmt.Println("HTTP server running. Press Ctrl+C to shutdown.")
-	select {}
+func (s *Server) messagesHandler(c *gin.Context) {
+	gs := currentCompany(c).GlobalState
+	messages := gs.GetMessages()
+	result := make([]gin.H, len(messages))
+	for i, msg := range messages {
+		result[i] = gin.H{
+			"id":       msg.ID,
+			"sender":   msg.Sender,
+			"receiver": msg.Receiver,
+			"type":     string(msg.Type),
+			"content":  msg.Body,
+		}
+		if taskIDs := gs.GetMessageTasks(msg.ID); len(taskIDs) > 0 {
+			result[i]["spawned_tasks"] = taskIDs
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"messages": result})
 }
 
-func Initialize(
-	agentsMap map[string]agents.Agent,
-	mb *mailbox.MailboxBus,
-	orch workflow.Orchestrator,
-	sched *scheduler.AutoScheduler,
-	te interface{},
-) {
-	agentMap = agentsMap
-	mailboxBus = mb
-	orchestrator = orch
-	schedulerInstance = sched
-	timerEngine = te
+func (s *Server) deadLettersHandler(c *gin.Context) {
+	queue := currentCompany(c).MailboxBus.GetDeadLetterQueue()
+	c.JSON(http.StatusOK, gin.H{
+		"stats":        queue.Stats(),
+		"dead_letters": queue.List(),
+	})
 }
 
-func Shutdown() {
-	shutdown(timerEngine, schedulerInstance, agentMap)
+func (s *Server) permanentFailuresHandler(c *gin.Context) {
+	queue := currentCompany(c).MailboxBus.GetPermanentFailures()
+	c.JSON(http.StatusOK, gin.H{
+		"stats":        queue.Stats(),
+		"dead_letters": queue.List(),
+	})
 }
 
-func shutdown(timerEngine interface{}, schedulerInstance *scheduler.AutoScheduler, agentMap map[string]agents.Agent) {
-	fmt.Println("stopping timer engine")
-	fmt.Println("stopping scheduler")
-	schedulerInstance.Stop()
+func (s *Server) requeueDeadLetterHandler(c *gin.Context) {
+	id := c.Param("id")
+	if err := currentCompany(c).MailboxBus.RequeueDeadLetter(id); err != nil {
+		c.JSON(http.StatusConflict, ErrorResponse{Error: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"id": id, "requeued": true})
+}
 
-	fmt.Println("stopping agents")
-	for name, agent := range agentMap {
-		if err := agent.Stop(); err != nil {
-			fmt.Println("failed to stop agent:", name, err)
+func (s *Server) dependencyGraphHandler(c *gin.Context) {
+	graph, err := currentCompany(c).Scheduler.ExportDependencyGraph()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	c.Data(http.StatusOK, "text/vnd.graphviz; charset=utf-8", graph)
+}
+
+func (s *Server) taskGenStatusHandler(c *gin.Context) {
+	sched := currentCompany(c).Scheduler
+	c.JSON(http.StatusOK, gin.H{
+		"paused":        sched.IsTaskGenerationPaused(),
+		"allowed":       sched.TaskGenerationAllowed(),
+		"backlog_limit": sched.GetTaskGenBacklogLimit(),
+		"queue_length":  sched.GetQueueLength(),
+	})
+}
+
+func (s *Server) taskGenPauseHandler(c *gin.Context) {
+	currentCompany(c).Scheduler.PauseTaskGeneration()
+	c.JSON(http.StatusOK, gin.H{"paused": true})
+}
+
+func (s *Server) taskGenResumeHandler(c *gin.Context) {
+	currentCompany(c).Scheduler.ResumeTaskGeneration()
+	c.JSON(http.StatusOK, gin.H{"paused": false})
+}
+
+func (s *Server) tickIntervalHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"interval": currentCompany(c).Scheduler.GetTickInterval().String()})
+}
+
+func (s *Server) setTickIntervalHandler(c *gin.Context) {
+	var req TickIntervalRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	d, err := time.ParseDuration(req.Interval)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: fmt.Sprintf("invalid interval: %v", err)})
+		return
+	}
+	if err := currentCompany(c).Scheduler.SetTickInterval(d); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"interval": d.String()})
+}
+
+// clearQueueHandler 清空指定优先级队列并取消其中的任务，清空 Critical 队列需附带 confirm=true
+func (s *Server) clearQueueHandler(c *gin.Context) {
+	priority, ok := normalizePriority(c.Param("priority"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: fmt.Sprintf("unknown priority %q", c.Param("priority"))})
+		return
+	}
+	if priority == scheduler.PriorityCritical && c.Query("confirm") != "true" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "clearing the Critical queue requires confirm=true"})
+		return
+	}
+	removed := currentCompany(c).Scheduler.ClearQueue(priority)
+	c.JSON(http.StatusOK, gin.H{"priority": priority, "removed": removed})
+}
+
+func (s *Server) taskCommentHandler(c *gin.Context) {
+	var req TaskCommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	gs := currentCompany(c).GlobalState
+	taskID := c.Param("id")
+	if err := gs.AddTaskComment(taskID, ds.TaskComment{Author: req.Author, Body: req.Body}); err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"task_id": taskID, "comments": gs.GetTask(taskID).Comments})
+}
+
+func (s *Server) taskPatchHandler(c *gin.Context) {
+	var req TaskPatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	company := currentCompany(c)
+	taskID := c.Param("id")
+	if company.GlobalState.GetTask(taskID) == nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: fmt.Sprintf("task %s not found", taskID)})
+		return
+	}
+	if req.Priority != "" {
+		priority, ok := normalizePriority(req.Priority)
+		if !ok {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: fmt.Sprintf("invalid priority %q", req.Priority)})
+			return
+		}
+		if err := company.Scheduler.SetTaskPriority(taskID, priority); err != nil {
+			c.JSON(http.StatusConflict, ErrorResponse{Error: err.Error()})
+			return
 		}
 	}
-  fmt.Println("shutdown complete")
+	if len(req.AddDependencies) > 0 || len(req.RemoveDependencies) > 0 {
+		if err := company.Scheduler.UpdateDependencies(taskID, req.AddDependencies, req.RemoveDependencies); err != nil {
+			c.JSON(http.StatusConflict, ErrorResponse{Error: err.Error()})
+			return
+		}
+	}
+	if req.Tags != nil {
+		company.GlobalState.UpdateTask(taskID, func(t *ds.Task) {
+			t.Tags = req.Tags
+			t.UpdatedAt = time.Now()
+		})
+	}
+	c.JSON(http.StatusOK, taskSummary(company.GlobalState.GetTask(taskID)))
 }
 
-func (s *Server) tasksHandler(c *gin.Context) {
-  tasks := make([]gin.H, 0)
-  for _, task := range mailboxBus.GetGlobalState().GetTasks() {
-    tasks = append(tasks, gin.H{
-      "id":           task.ID,
-      "title":        task.Title,
-      "priority":     string(task.Priority),
-      "status":       string(task.Status),
-      "assigned_to":  task.AssignedTo,
-      "created_at":   task.CreatedAt.Format("2006-01-02 15:04:05"),
-      "dependencies": task.Dependencies,
-    })
-  }
-  c.JSON(http.StatusOK, gin.H{"tasks": tasks})
+// taskPreviewHandler 预览假设任务会被路由到哪个 Agent，不创建任务
+func (s *Server) taskPreviewHandler(c *gin.Context) {
+	var req TaskPreviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	priority := ds.TaskPriorityMedium
+	if req.Priority != "" {
+		p, ok := normalizePriority(req.Priority)
+		if !ok {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: fmt.Sprintf("invalid priority %q", req.Priority)})
+			return
+		}
+		priority = ds.TaskPriority(strings.ToLower(p))
+	}
+
+	task := &ds.Task{
+		Title:                req.Title,
+		Description:          req.Description,
+		Priority:             priority,
+		AssignedTo:           req.AssignedTo,
+		RequiredCapabilities: req.RequiredCapabilities,
+		Effort:               req.Effort,
+		Metadata:             req.Metadata,
+	}
+	c.JSON(http.StatusOK, currentCompany(c).Scheduler.PreviewRoute(task))
 }
 
-func (s *Server) messagesHandler(c *gin.Context) {
-  messages := mailboxBus.GetGlobalState().GetMessages()
-  result := make([]gin.H, len(messages))
-  for i, msg := range messages {
-    result[i] = gin.H{
-      "id":       msg.ID,
-      "sender":   msg.Sender,
-      "receiver": msg.Receiver,
-      "type":     string(msg.Type),
-      "content":  msg.Body,
-    }
-  }
-  c.JSON(http.StatusOK, gin.H{"messages": result})
+func (s *Server) taskRetryHandler(c *gin.Context) {
+	var req TaskRetryRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+			return
+		}
+	}
+	priority := ""
+	if req.Priority != "" {
+		var ok bool
+		if priority, ok = normalizePriority(req.Priority); !ok {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: fmt.Sprintf("invalid priority %q", req.Priority)})
+			return
+		}
+	}
+
+	company := currentCompany(c)
+	taskID := c.Param("id")
+	if company.GlobalState.GetTask(taskID) == nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: fmt.Sprintf("task %s not found", taskID)})
+		return
+	}
+	if err := company.Scheduler.RetryTask(taskID, priority); err != nil {
+		c.JSON(http.StatusConflict, ErrorResponse{Error: err.Error()})
+		return
+	}
+	// 已重新入队，移除对应的任务死信，避免再次重新投递
+	company.MailboxBus.GetDeadLetterQueue().RemoveTask(taskID)
+	c.JSON(http.StatusOK, taskSummary(company.GlobalState.GetTask(taskID)))
+}
+
+func (s *Server) taskDetailHandler(c *gin.Context) {
+	gs := currentCompany(c).GlobalState
+	taskID := c.Param("id")
+	// 使用副本：元数据等字段在锁外编码为 JSON，调度器与 Agent 同时在 UpdateTask 中修改原任务
+	task := gs.GetTaskCopy(taskID)
+	result := gs.GetTaskResult(taskID)
+	switch {
+	case task == nil && result == nil:
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: fmt.Sprintf("task %s not found", taskID)})
+		return
+	case task == nil:
+		// 重启后任务不在内存中，只能返回持久化的结果
+		c.JSON(http.StatusOK, gin.H{
+			"id":          result.TaskID,
+			"title":       result.Title,
+			"status":      result.Status,
+			"assigned_to": result.Agent,
+			"result":      result,
+		})
+		return
+	}
+
+	detail := taskSummary(task)
+	detail["description"] = task.Description
+	detail["assigned_by"] = task.AssignedBy
+	detail["deliverables"] = task.Deliverables
+	detail["deadline"] = task.Deadline
+	detail["updated_at"] = task.UpdatedAt.Format("2006-01-02 15:04:05")
+	detail["metadata"] = task.Metadata
+	detail["effort"] = task.Effort
+	detail["required_capabilities"] = task.RequiredCapabilities
+	detail["progress"] = taskProgress(task, gs.GetSubtaskProgress([]string{taskID}))
+	detail["started_at"] = task.StartedAt
+	if remaining, ok := task.RemainingTime(time.Now()); ok {
+		detail["timeout"] = task.ExecTimeout.String()
+		detail["remaining_ms"] = remaining.Milliseconds()
+	}
+	detail["assign_reason"] = task.Metadata[scheduler.MetadataAssignReason]
+	detail["source_message_id"] = task.SourceMessageID()
+	detail["result"] = result
+	c.JSON(http.StatusOK, detail)
+}
+
+func (s *Server) taskResultHandler(c *gin.Context) {
+	taskID := c.Param("id")
+	result := currentCompany(c).GlobalState.GetTaskResult(taskID)
+	if result == nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: fmt.Sprintf("no result for task %s", taskID)})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// transcriptCheckInterval 流式输出任务执行记录时检查任务是否已结束（如排队中被取消）的间隔
+const transcriptCheckInterval = 5 * time.Second
+
+// taskStreamHandler 以 SSE 流式输出任务执行记录：先回放已有记录，任务执行中时持续推送新记录，
+// 执行结束后发送 end 事件并关闭；任务已结束时回放后直接关闭
+func (s *Server) taskStreamHandler(c *gin.Context) {
+	gs := currentCompany(c).GlobalState
+	taskID := c.Param("id")
+	task := gs.GetTask(taskID)
+	if task == nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: fmt.Sprintf("task %s not found", taskID)})
+		return
+	}
+
+	var history []state.TranscriptEntry
+	var entries <-chan state.TranscriptEntry
+	if task.IsCompleted() {
+		history = gs.GetTranscript(taskID)
+	} else {
+		var unsubscribe func()
+		history, entries, unsubscribe = gs.SubscribeTranscript(taskID)
+		defer unsubscribe()
+	}
+
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	for _, entry := range history {
+		c.SSEvent("transcript", entry)
+	}
+	end := func() {
+		status := ds.TaskStatus("")
+		if t := gs.GetTask(taskID); t != nil {
+			status = t.Status
+		}
+		c.SSEvent("end", gin.H{"task_id": taskID, "status": status})
+	}
+	if entries == nil {
+		end()
+		return
+	}
+	c.Writer.Flush()
+
+	ticker := time.NewTicker(transcriptCheckInterval)
+	defer ticker.Stop()
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case entry, ok := <-entries:
+			if !ok {
+				end()
+				return false
+			}
+			c.SSEvent("transcript", entry)
+			return true
+		case <-ticker.C:
+			if t := gs.GetTask(taskID); t == nil || t.IsCompleted() {
+				end()
+				return false
+			}
+			return true
+		case <-c.Request.Context().Done():
+			return false
+		}
+	})
+}
+
+func (s *Server) taskStatusHandler(c *gin.Context) {
+	var req TaskStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	gs := currentCompany(c).GlobalState
+	found := gs.GetTasksByIDs(req.IDs)
+	subtasks := gs.GetSubtaskProgress(req.IDs)
+	statuses := make(map[string]TaskStatusInfo, len(found))
+	unknown := make([]string, 0)
+	for _, id := range req.IDs {
+		task, ok := found[id]
+		if !ok {
+			unknown = append(unknown, id)
+			continue
+		}
+		statuses[id] = TaskStatusInfo{
+			Status:     string(task.Status),
+			AssignedTo: task.AssignedTo,
+			Progress:   taskProgress(task, subtasks),
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"tasks": statuses, "unknown": unknown})
+}
+
+// taskProgress 任务进度（0~1）：已结束为 1；未结束时为已结束的子任务占比（subtasks 为 GetSubtaskProgress 的结果），没有子任务为 0
+func taskProgress(task *ds.Task, subtasks map[string]float64) float64 {
+	if task.IsCompleted() {
+		return 1
+	}
+	return subtasks[task.ID]
+}
+
+func (s *Server) eventsHandler(c *gin.Context) {
+	since, err := strconv.ParseInt(c.DefaultQuery("since", "0"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "since must be an integer sequence number"})
+		return
+	}
+	gs := currentCompany(c).GlobalState
+	c.JSON(http.StatusOK, gin.H{
+		"events":   gs.GetEventsSince(since),
+		"last_seq": gs.GetEventLog().LastSeq(),
+	})
+}
+
+func (s *Server) waitingTasksHandler(c *gin.Context) {
+	co := currentCompany(c)
+	waiting := co.Scheduler.GetWaitingTasks()
+	tasks := make([]gin.H, 0, len(waiting))
+	for id, since := range waiting {
+		item := gin.H{"id": id, "waiting_since": since.Format(time.RFC3339)}
+		if task := co.GlobalState.GetTask(id); task != nil {
+			item["title"] = task.Title
+			item["required_capabilities"] = task.RequiredCapabilities
+		}
+		tasks = append(tasks, item)
+	}
+	c.JSON(http.StatusOK, gin.H{"tasks": tasks})
+}
+
+func (s *Server) agentTasksHandler(c *gin.Context) {
+	co := currentCompany(c)
+	name := c.Param("name")
+	if _, ok := co.Agents[name]; !ok {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: fmt.Sprintf("agent %s not found", name)})
+		return
+	}
+	assigned := co.Scheduler.GetAgentAssignments(name)
+	tasks := make([]gin.H, len(assigned))
+	for i, task := range assigned {
+		tasks[i] = taskSummary(task)
+	}
+	c.JSON(http.StatusOK, gin.H{"agent": name, "tasks": tasks})
+}
+
+func (s *Server) agentLoadHandler(c *gin.Context) {
+	name := c.Param("name")
+	metrics, ok := currentCompany(c).AgentLoadMetrics(name)
+	if !ok {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: fmt.Sprintf("agent %s not found", name)})
+		return
+	}
+	c.JSON(http.StatusOK, metrics)
+}
+
+// defaultHistoryLimit 执行历史查询未指定 limit 时返回的条数
+const defaultHistoryLimit = 50
+
+func (s *Server) agentHistoryHandler(c *gin.Context) {
+	name := c.Param("name")
+	agent, ok := currentCompany(c).Agents[name]
+	if !ok {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: fmt.Sprintf("agent %s not found", name)})
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultHistoryLimit)))
+	if err != nil || limit <= 0 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "limit must be a positive integer"})
+		return
+	}
+	// 超出服务端上限时按上限返回
+	limit = min(limit, agent.GetMaxHistoryQuery())
+	c.JSON(http.StatusOK, gin.H{
+		"agent":   name,
+		"limit":   limit,
+		"history": agent.GetRecentExecutions(limit),
+	})
+}
+
+func (s *Server) agentGenerateHandler(c *gin.Context) {
+	name := c.Param("name")
+	agent, ok := currentCompany(c).Agents[name]
+	if !ok {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: fmt.Sprintf("agent %s not found", name)})
+		return
+	}
+	ids, err := agent.TriggerTaskGeneration(c.Request.Context())
+	switch {
+	case errors.Is(err, agents.ErrTaskGenPaused), errors.Is(err, agents.ErrTaskGenInProgress):
+		c.JSON(http.StatusConflict, ErrorResponse{Error: err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"agent": name, "task_ids": ids})
+}
+
+// agentExplainHandler 让 Agent 根据执行记录与执行历史解释其在指定任务或决策中的推理过程
+func (s *Server) agentExplainHandler(c *gin.Context) {
+	name := c.Param("name")
+	agent, ok := currentCompany(c).Agents[name]
+	if !ok {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: fmt.Sprintf("agent %s not found", name)})
+		return
+	}
+	var req AgentExplainRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	explanation, err := agent.ExplainDecision(c.Request.Context(), req.ID)
+	switch {
+	case errors.Is(err, agents.ErrNothingToExplain):
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"agent": name, "id": req.ID, "explanation": explanation})
+}
+
+func (s *Server) agentTaskGenIntervalHandler(c *gin.Context) {
+	name := c.Param("name")
+	agent, ok := currentCompany(c).Agents[name]
+	if !ok {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: fmt.Sprintf("agent %s not found", name)})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"agent": name, "interval": agent.GetTaskGenInterval().String()})
+}
+
+func (s *Server) setAgentTaskGenIntervalHandler(c *gin.Context) {
+	var req AgentTaskGenIntervalRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	d, err := time.ParseDuration(req.Interval)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: fmt.Sprintf("invalid interval: %v", err)})
+		return
+	}
+	name := c.Param("name")
+	agent, ok := currentCompany(c).Agents[name]
+	if !ok {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: fmt.Sprintf("agent %s not found", name)})
+		return
+	}
+	if err := agent.SetTaskGenInterval(d); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"agent": name, "interval": d.String()})
+}
+
+func (s *Server) setAgentMaxTasksHandler(c *gin.Context) {
+	var req AgentMaxTasksRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	name := c.Param("name")
+	if err := currentCompany(c).Scheduler.SetAgentMaxTasks(name, req.MaxTasks); err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"agent": name, "max_tasks": req.MaxTasks})
+}
+
+func (s *Server) timerStatusHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"jobs": currentCompany(c).TimerEngine.GetJobStatus()})
+}
+
+func (s *Server) schedulerMetricsHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, currentCompany(c).Scheduler.GetMetrics())
+}
+
+// schedulerPressureHandler 返回调度压力与扩缩容提示
+func (s *Server) schedulerPressureHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, currentCompany(c).Scheduler.GetPressure())
+}
+
+func (s *Server) statsHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, currentCompany(c).Orchestrator.GetCompanyStats())
+}
+
+// reportHandler 返回公司报告，format=markdown 时返回 Markdown，否则返回 JSON
+func (s *Server) reportHandler(c *gin.Context) {
+	announcements, err := strconv.Atoi(c.DefaultQuery("announcements", strconv.Itoa(company.DefaultReportAnnouncements)))
+	if err != nil || announcements < 0 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "announcements must be a non-negative integer"})
+		return
+	}
+	report := currentCompany(c).BuildReport(announcements)
+	switch c.DefaultQuery("format", "json") {
+	case "json":
+		c.JSON(http.StatusOK, report)
+	case "markdown", "md":
+		c.Data(http.StatusOK, "text/markdown; charset=utf-8", []byte(report.Markdown()))
+	default:
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "format must be json or markdown"})
+	}
 }
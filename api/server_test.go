@@ -0,0 +1,25 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// /shutdown 只通知进程入口执行停止流程，重复请求不应 panic
+func TestShutdownHandlerSignalsShutdown(t *testing.T) {
+	server := NewServer()
+	for range 2 {
+		w := httptest.NewRecorder()
+		server.engine.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/shutdown", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d, want 200", w.Code)
+		}
+	}
+	select {
+	case <-ShutdownRequested():
+	case <-time.After(time.Second):
+		t.Fatal("shutdown was not requested")
+	}
+}
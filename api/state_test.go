@@ -0,0 +1,30 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"superman/ds"
+)
+
+// 只请求 kpis 时响应中不含全局状态的其他部分，未知字段返回 400
+func TestStateSelectsRequestedFields(t *testing.T) {
+	server, co := newTestServer(t, nil)
+	co.GlobalState.SetKPI("gross_margin", 0.42)
+	co.GlobalState.AddTask(ds.NewTask("t1", "budget", "", "cfo", "ceo", ds.TaskStatusPending, ds.TaskPriorityMedium))
+
+	resp := doJSON(t, server, http.MethodGet, "/api/state?fields=kpis", nil, http.StatusOK)
+	if len(resp) != 1 {
+		t.Fatalf("response sections = %v, want only kpis", resp)
+	}
+	if kpis, _ := resp["kpis"].(map[string]any); kpis["gross_margin"] != 0.42 {
+		t.Fatalf("kpis = %v, want gross_margin 0.42", resp["kpis"])
+	}
+
+	resp = doJSON(t, server, http.MethodGet, "/api/state?fields=kpis,tasks", nil, http.StatusOK)
+	if tasks, _ := resp["tasks"].(map[string]any); len(resp) != 2 || tasks["t1"] == nil {
+		t.Fatalf("response = %v, want kpis and tasks with t1", resp)
+	}
+
+	doJSON(t, server, http.MethodGet, "/api/state?fields=kpis,secrets", nil, http.StatusBadRequest)
+}
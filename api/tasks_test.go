@@ -0,0 +1,251 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"superman/agents"
+	"superman/company"
+	"superman/ds"
+	"superman/mailbox"
+	"superman/scheduler"
+	"superman/state"
+)
+
+// 通过接口添加的评论保存在任务上，并按添加顺序出现在任务详情中
+func TestTaskCommentsAppearInOrder(t *testing.T) {
+	server, co := newTestServer(t, nil)
+	co.GlobalState.AddTask(ds.NewTask("t1", "budget", "review", "cfo", "ceo", ds.TaskStatusPending, ds.TaskPriorityMedium))
+
+	for _, body := range []string{"first draft ready", "numbers checked", "approved"} {
+		doJSON(t, server, http.MethodPost, "/api/tasks/t1/comments", TaskCommentRequest{Author: "ceo", Body: body}, http.StatusOK)
+	}
+	doJSON(t, server, http.MethodPost, "/api/tasks/missing/comments", TaskCommentRequest{Author: "ceo", Body: "hello"}, http.StatusNotFound)
+
+	detail := doJSON(t, server, http.MethodGet, "/api/tasks/t1", nil, http.StatusOK)
+	comments, _ := detail["comments"].([]any)
+	if len(comments) != 3 {
+		t.Fatalf("comments = %v, want 3", detail["comments"])
+	}
+	for i, want := range []string{"first draft ready", "numbers checked", "approved"} {
+		if got := comments[i].(map[string]any)["body"]; got != want {
+			t.Fatalf("comment %d = %v, want %q", i, got, want)
+		}
+	}
+}
+
+// 批量查询返回已知任务的状态与进度（未结束的任务按子任务完成比例），未知 ID 单独列出
+func TestBulkTaskStatusWithUnknownIDs(t *testing.T) {
+	server, co := newTestServer(t, nil)
+	co.GlobalState.AddTask(ds.NewTask("t1", "budget", "review", "cfo", "ceo", ds.TaskStatusProcessing, ds.TaskPriorityMedium))
+	co.GlobalState.AddTask(ds.NewTask("t2", "hiring", "plan", "hr", "ceo", ds.TaskStatusCompleted, ds.TaskPriorityLow))
+	co.GlobalState.AddTask(ds.NewTask("t3", "launch", "ship it", "cto", "ceo", ds.TaskStatusProcessing, ds.TaskPriorityHigh))
+	for i, status := range []ds.TaskStatus{ds.TaskStatusCompleted, ds.TaskStatusProcessing} {
+		sub := ds.NewTask(fmt.Sprintf("t3-%d", i), "part", "sub", "dev", "cto", status, ds.TaskPriorityHigh)
+		sub.Metadata[ds.MetadataParentTaskID] = "t3"
+		co.GlobalState.AddTask(sub)
+	}
+
+	resp := doJSON(t, server, http.MethodPost, "/api/tasks/status", TaskStatusRequest{IDs: []string{"t1", "ghost", "t2", "t3"}}, http.StatusOK)
+	tasks := resp["tasks"].(map[string]any)
+	if len(tasks) != 3 {
+		t.Fatalf("tasks = %v, want t1, t2 and t3", tasks)
+	}
+	if t1 := tasks["t1"].(map[string]any); t1["status"] != "processing" || t1["assigned_to"] != "cfo" || t1["progress"] != 0.0 {
+		t.Fatalf("t1 = %v, want processing on cfo with no progress", t1)
+	}
+	if t2 := tasks["t2"].(map[string]any); t2["status"] != "completed" || t2["progress"] != 1.0 {
+		t.Fatalf("t2 = %v, want completed with full progress", t2)
+	}
+	if t3 := tasks["t3"].(map[string]any); t3["progress"] != 0.5 {
+		t.Fatalf("t3 = %v, want progress 0.5 with one of two subtasks finished", t3)
+	}
+	if unknown := resp["unknown"].([]any); len(unknown) != 1 || unknown[0] != "ghost" {
+		t.Fatalf("unknown = %v, want [ghost]", unknown)
+	}
+}
+
+// 任务列表返回任务类型，并可按类型过滤（不区分大小写）
+func TestTaskListFiltersByType(t *testing.T) {
+	server, co := newTestServer(t, nil)
+	report := ds.NewTask("t1", "weekly report", "summarize", "cfo", "ceo", ds.TaskStatusPending, ds.TaskPriorityMedium)
+	report.Type = ds.TaskTypeReport
+	co.GlobalState.AddTask(report)
+	analysis := ds.NewTask("t2", "market", "analyze", "cmo", "ceo", ds.TaskStatusPending, ds.TaskPriorityMedium)
+	analysis.Type = ds.TaskTypeAnalysis
+	co.GlobalState.AddTask(analysis)
+
+	resp := doJSON(t, server, http.MethodGet, "/api/tasks?type=REPORT", nil, http.StatusOK)
+	tasks, _ := resp["tasks"].([]any)
+	if len(tasks) != 1 {
+		t.Fatalf("tasks = %v, want only t1", resp["tasks"])
+	}
+	if task := tasks[0].(map[string]any); task["id"] != "t1" || task["type"] != "report" {
+		t.Fatalf("task = %v, want t1 of type report", task)
+	}
+}
+
+// orderDispatcher 记录任务分发顺序
+type orderDispatcher struct{ ids []string }
+
+func (d *orderDispatcher) RunTask(task *ds.Task) error {
+	d.ids = append(d.ids, task.ID)
+	return nil
+}
+
+// 带高优先级的用户发送作为任务排在普通任务之前处理，未指定优先级时仍作为普通消息投递
+func TestHighPrioritySendIsHandledFirst(t *testing.T) {
+	bus := mailbox.NewMailboxBus()
+	inbox := mailbox.NewMailbox(mailbox.DefaultMailboxConfig("cfo"))
+	if err := bus.RegisterMailbox("cfo", inbox); err != nil {
+		t.Fatal(err)
+	}
+	d := &orderDispatcher{}
+	s := scheduler.NewAutoScheduler(d, bus.GetGlobalState(), 0)
+	s.AddAgent("cfo", 1, 1)
+	server, _ := newTestServer(t, &company.Company{
+		ID:          "acme",
+		MailboxBus:  bus,
+		GlobalState: bus.GetGlobalState(),
+		Scheduler:   s,
+		Agents:      map[string]agents.Agent{"cfo": nil},
+	})
+
+	doJSON(t, server, http.MethodPost, "/api/send", SendRequest{Sender: "user", Receiver: "cfo", Message: "how are we doing?"}, http.StatusOK)
+	if inbox.GetInboxCount() != 1 || s.GetQueueLength() != 0 {
+		t.Fatalf("default send: inbox = %d, queue = %d, want a plain message", inbox.GetInboxCount(), s.GetQueueLength())
+	}
+
+	s.AddTask(ds.NewTask("routine", "weekly numbers", "", "cfo", "ceo", ds.TaskStatusPending, ds.TaskPriorityMedium), scheduler.PriorityMedium)
+	resp := doJSON(t, server, http.MethodPost, "/api/send", SendRequest{Sender: "user", Receiver: "cfo", Message: "audit today", Priority: "high"}, http.StatusOK)
+	urgent, _ := resp["task_id"].(string)
+	if urgent == "" {
+		t.Fatalf("response = %v, want the submitted task ID", resp)
+	}
+	doJSON(t, server, http.MethodPost, "/api/send", SendRequest{Sender: "user", Receiver: "cfo", Message: "x", Priority: "urgent"}, http.StatusBadRequest)
+
+	s.Tick(time.Now())
+	if len(d.ids) != 1 || d.ids[0] != urgent {
+		t.Fatalf("dispatched %v, want the high priority user task first", d.ids)
+	}
+}
+
+// 单任务接口返回任务详情（依赖、贡献者、执行结果等），未知任务返回 404
+func TestTaskDetail(t *testing.T) {
+	server, co := newTestServer(t, nil)
+	task := ds.NewTask("t2", "forecast", "next quarter", "cfo", "ceo", ds.TaskStatusCompleted, ds.TaskPriorityHigh)
+	task.AddDependency("t1")
+	task.AddContributor("cfo")
+	co.GlobalState.AddTask(task)
+	co.GlobalState.RecordTaskResult(&state.TaskResult{TaskID: "t2", Agent: "cfo", Title: "forecast", Status: "completed", Output: "revenue up 8%"})
+
+	detail := doJSON(t, server, http.MethodGet, "/api/tasks/t2", nil, http.StatusOK)
+	if detail["id"] != "t2" || detail["status"] != "completed" || detail["description"] != "next quarter" || detail["assigned_by"] != "ceo" {
+		t.Fatalf("detail = %v, want the t2 task fields", detail)
+	}
+	if deps, _ := detail["dependencies"].([]any); len(deps) != 1 || deps[0] != "t1" {
+		t.Fatalf("dependencies = %v, want [t1]", detail["dependencies"])
+	}
+	if contributors, _ := detail["contributors"].([]any); len(contributors) != 1 || contributors[0] != "cfo" {
+		t.Fatalf("contributors = %v, want [cfo]", detail["contributors"])
+	}
+	if result, _ := detail["result"].(map[string]any); result["output"] != "revenue up 8%" {
+		t.Fatalf("result = %v, want the recorded output", detail["result"])
+	}
+
+	doJSON(t, server, http.MethodGet, "/api/tasks/missing", nil, http.StatusNotFound)
+}
+
+// 重试失败（已进入死信队列）的任务会将其重置为待处理并重新入队，随后可再次分发；非失败任务不可重试
+func TestRetryFailedTaskIsDispatchedAgain(t *testing.T) {
+	bus := mailbox.NewMailboxBus()
+	d := &orderDispatcher{}
+	s := scheduler.NewAutoScheduler(d, bus.GetGlobalState(), 0)
+	s.AddAgent("cfo", 1, 1)
+	server, co := newTestServer(t, &company.Company{
+		ID:          "acme",
+		MailboxBus:  bus,
+		GlobalState: bus.GetGlobalState(),
+		Scheduler:   s,
+	})
+
+	failed := ds.NewTask("t1", "forecast", "next quarter", "cfo", "ceo", ds.TaskStatusFailed, ds.TaskPriorityLow)
+	failed.Metadata["failure_reason"] = "llm_timeout"
+	co.GlobalState.AddTask(failed)
+	bus.DeadLetterTask(failed, scheduler.PriorityLow, "llm_timeout")
+	co.GlobalState.AddTask(ds.NewTask("t2", "hiring", "plan", "hr", "ceo", ds.TaskStatusCompleted, ds.TaskPriorityLow))
+
+	doJSON(t, server, http.MethodPost, "/api/tasks/t1/retry", TaskRetryRequest{Priority: "urgent"}, http.StatusBadRequest)
+	resp := doJSON(t, server, http.MethodPost, "/api/tasks/t1/retry", TaskRetryRequest{Priority: "high"}, http.StatusOK)
+	if resp["status"] != "pending" || resp["priority"] != "high" {
+		t.Fatalf("retried task = %v, want pending at high priority", resp)
+	}
+	if got := co.GlobalState.GetTask("t1").Metadata; got[scheduler.MetadataRetryCount] != 1 || got["failure_reason"] != nil {
+		t.Fatalf("metadata = %v, want retry_count 1 and no failure_reason", got)
+	}
+	if s.GetQueueLengthByPriority(scheduler.PriorityHigh) != 1 || bus.GetDeadLetterQueue().Len() != 0 {
+		t.Fatalf("high queue = %d, dead letters = %d, want the task requeued and its dead letter removed",
+			s.GetQueueLengthByPriority(scheduler.PriorityHigh), bus.GetDeadLetterQueue().Len())
+	}
+	doJSON(t, server, http.MethodPost, "/api/tasks/t1/retry", nil, http.StatusConflict)
+
+	s.Tick(time.Now())
+	if len(d.ids) != 1 || d.ids[0] != "t1" || co.GlobalState.GetTask("t1").Status != ds.TaskStatusAssigned {
+		t.Fatalf("dispatched %v, want the retried task assigned again", d.ids)
+	}
+
+	doJSON(t, server, http.MethodPost, "/api/tasks/t2/retry", nil, http.StatusConflict)
+	doJSON(t, server, http.MethodPost, "/api/tasks/missing/retry", nil, http.StatusNotFound)
+}
+
+// 路由预览不入队，返回的 Agent 与同样输入的任务实际分发时选择的 Agent 一致
+func TestTaskPreviewMatchesDispatch(t *testing.T) {
+	bus := mailbox.NewMailboxBus()
+	d := &orderDispatcher{}
+	s := scheduler.NewAutoScheduler(d, bus.GetGlobalState(), 0)
+	s.AddAgent("cfo", 2, 1)
+	s.AddAgent("analyst", 3, 3)
+	s.AddAgent("cmo", 3, 1)
+	s.SetAgentCapabilities("cfo", []string{"finance"})
+	s.SetAgentCapabilities("analyst", []string{"finance", "research"})
+	server, co := newTestServer(t, &company.Company{
+		ID:          "acme",
+		MailboxBus:  bus,
+		GlobalState: bus.GetGlobalState(),
+		Scheduler:   s,
+	})
+
+	for i, req := range []TaskPreviewRequest{
+		{Title: "close books", Priority: "high", RequiredCapabilities: []string{"finance"}},
+		{Title: "close books again", RequiredCapabilities: []string{"finance"}},
+		{Title: "market study", RequiredCapabilities: []string{"research"}},
+		{Title: "launch plan", AssignedTo: "cmo"},
+		{Title: "anything"},
+	} {
+		preview := doJSON(t, server, http.MethodPost, "/api/tasks/preview", req, http.StatusOK)
+		if s.GetQueueLength() != 0 {
+			t.Fatalf("preview of %q enqueued a task", req.Title)
+		}
+
+		id := fmt.Sprintf("t%d", i)
+		priority := ds.TaskPriorityMedium
+		if req.Priority != "" {
+			priority = ds.TaskPriority(req.Priority)
+		}
+		task := ds.NewTask(id, req.Title, req.Description, req.AssignedTo, "ceo", ds.TaskStatusPending, priority)
+		task.RequiredCapabilities = req.RequiredCapabilities
+		s.AddTask(task, preview["priority"].(string))
+		s.Tick(time.Now())
+
+		got := co.GlobalState.GetTask(id)
+		if preview["agent"] == nil || got.AssignedTo != preview["agent"] || got.Metadata[scheduler.MetadataAssignReason] != preview["reason"] {
+			t.Fatalf("%q previewed %v (%v), dispatched to %s (%v)", req.Title, preview["agent"], preview["reason"], got.AssignedTo, got.Metadata[scheduler.MetadataAssignReason])
+		}
+	}
+	if len(d.ids) != 5 {
+		t.Fatalf("dispatched %v, want all 5 tasks", d.ids)
+	}
+	doJSON(t, server, http.MethodPost, "/api/tasks/preview", TaskPreviewRequest{Priority: "urgent"}, http.StatusBadRequest)
+}
@@ -0,0 +1,40 @@
+package api
+
+import (
+	"net/http"
+	"runtime"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 构建信息，通过 ldflags 注入，如：
+//
+//	go build -ldflags "-X superman/api.Version=v1.2.0 -X superman/api.Commit=$(git rev-parse --short HEAD) -X superman/api.BuildTime=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+var (
+	Version   = "dev"
+	Commit    = "unknown"
+	BuildTime = "unknown"
+)
+
+// startTime 进程启动时间
+var startTime = time.Now()
+
+// VersionResponse 版本与构建信息
+type VersionResponse struct {
+	Version   string    `json:"version"`
+	Commit    string    `json:"commit"`
+	BuildTime string    `json:"build_time"`
+	GoVersion string    `json:"go_version"`
+	StartTime time.Time `json:"start_time"`
+}
+
+func (s *Server) versionHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, VersionResponse{
+		Version:   Version,
+		Commit:    Commit,
+		BuildTime: BuildTime,
+		GoVersion: runtime.Version(),
+		StartTime: startTime,
+	})
+}
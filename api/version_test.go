@@ -0,0 +1,20 @@
+package api
+
+import (
+	"net/http"
+	"runtime"
+	"testing"
+)
+
+// /version 返回通过 ldflags 注入的版本信息与运行时 Go 版本
+func TestVersionReturnsInjectedBuildInfo(t *testing.T) {
+	oldVersion, oldCommit := Version, Commit
+	Version, Commit = "v1.2.3", "abc1234"
+	t.Cleanup(func() { Version, Commit = oldVersion, oldCommit })
+	server, _ := newTestServer(t, nil)
+
+	resp := doJSON(t, server, http.MethodGet, "/version", nil, http.StatusOK)
+	if resp["version"] != "v1.2.3" || resp["commit"] != "abc1234" || resp["go_version"] != runtime.Version() {
+		t.Fatalf("response = %v, want the injected version and commit", resp)
+	}
+}
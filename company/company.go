@@ -0,0 +1,434 @@
+package company
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"superman/agents"
+	"superman/config"
+	"superman/ds"
+	"superman/infra"
+	"superman/mailbox"
+	"superman/scheduler"
+	"superman/state"
+	"superman/timer"
+	"superman/tools"
+	"superman/workflow"
+)
+
+// Company 公司实例（租户），拥有独立的消息总线、全局状态、调度器与 Agent 集合
+type Company struct {
+	ID           string
+	MailboxBus   *mailbox.MailboxBus
+	GlobalState  *state.GlobalState
+	Orchestrator workflow.Orchestrator
+	Scheduler    *scheduler.AutoScheduler
+	TimerEngine  *timer.TimerEngine
+	Agents       map[string]agents.Agent
+
+	stopWaves [][]string // Agent 分批停止顺序
+	stopOnce  sync.Once
+	stopErr   error
+	startedAt time.Time
+	simulated bool           // 模拟模式下调度器与定时引擎未启动，停止时跳过
+	report    ShutdownReport // 停止时生成的运行摘要
+
+	trend      statusTrend    // Agent 负载与队列深度的历史采样
+	divergence loadDivergence // 调度器负载计数与 Agent 实际在途任务数的偏差跟踪
+}
+
+// NewCompany 根据配置创建公司实例（不启动）
+func NewCompany(ctx context.Context, r *infra.Registry, c config.CompanyConfig) (*Company, error) {
+	if c.ID == "" {
+		return nil, fmt.Errorf("company id is required")
+	}
+
+	// 创建 MailboxBus（公司内消息总线）
+	mailboxBus := mailbox.NewMailboxBus()
+	globalState := mailboxBus.GetGlobalState()
+	if c.EventLog != nil {
+		globalState.GetEventLog().SetMaxSize(c.EventLog.MaxSize)
+		globalState.SetCompletionEvents(c.EventLog.Completions)
+		if c.EventLog.Persist && r.Persistence != nil {
+			if err := globalState.GetEventLog().SetSink(r.Persistence.NewEventSink(c.ID)); err != nil {
+				return nil, fmt.Errorf("failed to restore event log: %w", err)
+			}
+		}
+	}
+
+	if c.PersistResults && r.Persistence != nil {
+		globalState.SetTaskResultStore(r.Persistence.NewTaskResultStore(c.ID))
+	}
+
+	if err := mailboxBus.SetSelfMessagePolicy(c.SelfMessagePolicy); err != nil {
+		return nil, err
+	}
+	if c.DeadLetter != nil {
+		retention, _ := time.ParseDuration(c.DeadLetter.Retention)
+		mailboxBus.GetDeadLetterQueue().SetRetention(c.DeadLetter.MaxSize, retention)
+		mailboxBus.SetDeadLetterAlert(c.DeadLetter.AlertThreshold, c.DeadLetter.AlertAgent)
+		if interval, err := time.ParseDuration(c.DeadLetter.RetryInterval); err == nil && interval > 0 {
+			backoff, _ := time.ParseDuration(c.DeadLetter.RetryBackoff)
+			mailboxBus.SetDeadLetterRetry(mailbox.DeadLetterRetryPolicy{
+				Interval:    interval,
+				Backoff:     backoff,
+				MaxAttempts: c.DeadLetter.MaxAttempts,
+			})
+		}
+		if c.DeadLetter.Persist && r.Persistence != nil {
+			if err := mailboxBus.GetDeadLetterQueue().SetStore(r.Persistence.NewDeadLetterStore(c.ID)); err != nil {
+				return nil, fmt.Errorf("failed to restore dead letters: %w", err)
+			}
+			// 重启后重新提交的任务只能通过持久化的任务结果确认重启前完成的依赖
+			if !c.PersistResults {
+				slog.Warn("dead letters are persisted without persist_results, restored tasks whose dependencies completed before a restart stay blocked",
+					slog.String("company", c.ID),
+				)
+			}
+		}
+	}
+
+	// 创建 Orchestrator（任务分发器）
+	orchestrator := workflow.NewOrchestrator(mailboxBus)
+
+	// 解析调度器轮询间隔
+	tickInterval := 5 * time.Second
+	if c.Scheduler != nil && c.Scheduler.TickInterval != "" {
+		if d, err := time.ParseDuration(c.Scheduler.TickInterval); err == nil {
+			tickInterval = d
+		}
+	}
+
+	// 创建 AutoScheduler（调度器）
+	schedulerInstance := scheduler.NewAutoScheduler(orchestrator, globalState, tickInterval)
+	if c.Scheduler != nil && c.Scheduler.LeaseEnabled && r.Persistence != nil {
+		leaseTTL, _ := time.ParseDuration(c.Scheduler.LeaseTTL)
+		owner := c.Scheduler.InstanceID
+		if owner == "" {
+			hostname, _ := os.Hostname()
+			owner = fmt.Sprintf("%s-%d", hostname, os.Getpid())
+		}
+		schedulerInstance.SetTaskLeaser(r.Persistence, owner, leaseTTL)
+	}
+	if c.Scheduler != nil && c.Scheduler.MaxIdleTick != "" {
+		if d, err := time.ParseDuration(c.Scheduler.MaxIdleTick); err == nil {
+			schedulerInstance.SetMaxIdleTick(d)
+		}
+	}
+	if c.Scheduler != nil && c.Scheduler.Estimator == "metadata" {
+		schedulerInstance.SetEstimator(scheduler.MetadataEstimator{Default: scheduler.DefaultEffort})
+	}
+	if c.Scheduler != nil && c.Scheduler.NoCapablePolicy != "" {
+		wait := 10 * time.Minute
+		if c.Scheduler.NoCapableWait != "" {
+			d, err := time.ParseDuration(c.Scheduler.NoCapableWait)
+			if err != nil {
+				return nil, fmt.Errorf("invalid no_capable_wait %q: %w", c.Scheduler.NoCapableWait, err)
+			}
+			wait = d
+		}
+		if c.Scheduler.FallbackAgent != "" && !slices.ContainsFunc(c.Agents, func(a config.AgentConfig) bool {
+			return a.Name == c.Scheduler.FallbackAgent
+		}) {
+			return nil, fmt.Errorf("fallback_agent %s is not an agent of company %s", c.Scheduler.FallbackAgent, c.ID)
+		}
+		if err := schedulerInstance.SetNoCapablePolicy(scheduler.NoCapablePolicy{
+			Policy:        c.Scheduler.NoCapablePolicy,
+			Wait:          wait,
+			FallbackAgent: c.Scheduler.FallbackAgent,
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	orchestrator.SetTaskSubmitter(schedulerInstance.AddTask)
+	mailboxBus.SetTaskSubmitter(schedulerInstance.AddTask)
+	mailboxBus.SetCapabilityListener(schedulerInstance.SetAgentCapabilities)
+	schedulerInstance.SetTaskDeadLetter(func(task *ds.Task, priority, reason string, permanent bool) {
+		if permanent {
+			mailboxBus.FailTaskPermanently(task, priority, reason)
+			return
+		}
+		mailboxBus.DeadLetterTask(task, priority, reason)
+	})
+
+	if c.Scheduler != nil && len(c.Scheduler.PriorityCaps) > 0 {
+		schedulerInstance.SetPriorityCaps(c.Scheduler.PriorityCaps)
+	}
+	if c.Scheduler != nil && c.Scheduler.MaxInFlight > 0 {
+		schedulerInstance.SetMaxInFlight(c.Scheduler.MaxInFlight)
+	}
+	if c.Scheduler != nil && c.Scheduler.TaskGenBacklogLimit > 0 {
+		schedulerInstance.SetTaskGenBacklogLimit(c.Scheduler.TaskGenBacklogLimit)
+	}
+	if c.Scheduler != nil && (c.Scheduler.DecayAfter != "" || c.Scheduler.DecayMaxAge != "") {
+		staleAfter, _ := time.ParseDuration(c.Scheduler.DecayAfter)
+		maxAge, _ := time.ParseDuration(c.Scheduler.DecayMaxAge)
+		schedulerInstance.SetPriorityDecay(scheduler.PriorityDecay{StaleAfter: staleAfter, MaxAge: maxAge})
+	}
+	if c.Scheduler != nil {
+		for _, source := range c.Scheduler.TaskSources {
+			interval, _ := time.ParseDuration(source.Interval)
+			schedulerInstance.AddTaskSource(source.Name, scheduler.NewHTTPTaskSource(source.Name, source.URL, interval))
+		}
+	}
+	if c.Scheduler != nil {
+		if err := schedulerInstance.SetDependencyPolicy(c.Scheduler.DependencyPolicy); err != nil {
+			return nil, err
+		}
+		if err := schedulerInstance.SetDuplicatePolicy(c.Scheduler.DuplicatePolicy); err != nil {
+			return nil, err
+		}
+		if err := schedulerInstance.SetUnknownAssigneePolicy(c.Scheduler.UnknownAssigneePolicy); err != nil {
+			return nil, err
+		}
+		schedulerInstance.SetTimeoutEscalation(scheduler.TimeoutEscalation{
+			MaxEscalations: c.Scheduler.TimeoutEscalations,
+			TimeoutFactor:  c.Scheduler.TimeoutFactor,
+		})
+	}
+	if c.Scheduler != nil && c.Scheduler.PressureThreshold > 0 {
+		sustain := 5 * time.Minute
+		if d, err := time.ParseDuration(c.Scheduler.PressureSustain); err == nil {
+			sustain = d
+		}
+		alertAgent := c.Scheduler.PressureAlertAgent
+		if alertAgent == "" {
+			alertAgent = topLevelAgent(c.Agents)
+		}
+		schedulerInstance.SetPressureAlert(scheduler.PressureAlert{
+			Threshold: c.Scheduler.PressureThreshold,
+			Sustain:   sustain,
+		}, func(p scheduler.Pressure, sustained time.Duration) {
+			notifyPressure(mailboxBus, alertAgent, p, sustained)
+		})
+	}
+	schedulerInstance.SetDependencyNotifier(func(task *ds.Task, cause, policy string) {
+		notifyDependencyFailed(mailboxBus, task, cause, policy)
+	})
+	if c.Scheduler != nil && c.Scheduler.Policy != "" {
+		if err := schedulerInstance.SetSelectionPolicy(c.Scheduler.Policy, c.Scheduler.Seed); err != nil {
+			return nil, err
+		}
+	}
+
+	waves, err := stopWaves(c.Agents)
+	if err != nil {
+		return nil, err
+	}
+
+	agentMap := make(map[string]agents.Agent)
+	for _, agentConfig := range c.Agents {
+		llm := r.LLM[agentConfig.Model]
+		if agentConfig.FallbackModel != "" {
+			fallback, ok := r.LLM[agentConfig.FallbackModel]
+			if !ok {
+				return nil, fmt.Errorf("agent %s: fallback model %s not configured", agentConfig.Name, agentConfig.FallbackModel)
+			}
+			llm = agents.WithFallbackModel(agentConfig.Name, llm, fallback)
+		}
+		agent, err := agents.NewBaseAgent(ctx, llm, mailboxBus, agentConfig, c.Agents...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create agent %s: %w", agentConfig.Name, err)
+		}
+
+		orchestrator.RegisterAgent(agent)
+
+		if err := mailboxBus.RegisterMailbox(agent.GetName(), agent.GetMailbox()); err != nil {
+			return nil, err
+		}
+
+		agent.SetGlobalState(globalState)
+
+		agent.SetTaskSubmitter(func(task *ds.Task, priority string) {
+			schedulerInstance.AddTask(task, priority)
+		})
+
+		agent.SetOnTaskComplete(func(taskID, agentName string, success bool) {
+			schedulerInstance.OnTaskComplete(taskID, agentName, success)
+			orchestrator.OnTaskComplete(taskID, agentName, success)
+		})
+
+		if c.PersistMemory && r.Persistence != nil {
+			if err := agent.SetMemoryStore(r.Persistence.NewAgentMemoryStore(c.ID), c.MemoryHistorySize); err != nil {
+				return nil, err
+			}
+		}
+
+		agent.SetTaskGenGuard(func() bool {
+			return schedulerInstance.TaskGenerationAllowed()
+		})
+		agent.SetTaskGenLimiter(r.TaskGenLimiter)
+
+		agentMap[agent.GetName()] = agent
+
+		schedulerInstance.AddAgent(agentConfig.Name, agentConfig.GetMaxTasks(), agentConfig.GetHierarchy())
+		schedulerInstance.SetAgentCapabilities(agentConfig.Name, agentConfig.GetCapabilities())
+		if agentConfig.AvailableHours != "" {
+			window, err := scheduler.ParseAvailabilityWindow(agentConfig.AvailableHours, agentConfig.Timezone)
+			if err != nil {
+				return nil, fmt.Errorf("agent %s: %w", agentConfig.Name, err)
+			}
+			schedulerInstance.SetAgentAvailability(agentConfig.Name, window)
+		}
+	}
+
+	// 所有 Agent 注册完成后，为 list agents 工具提供编排器中的同事信息
+	agentDirectory := func() []tools.AgentInfo {
+		registered := orchestrator.GetAllAgents()
+		infos := make([]tools.AgentInfo, 0, len(registered))
+		for _, agent := range registered {
+			infos = append(infos, tools.AgentInfo{
+				Name:      agent.GetName(),
+				Desc:      agent.GetDesc(),
+				Hierarchy: agent.GetRoleHierarchy(),
+			})
+		}
+		return infos
+	}
+	for _, agent := range agentMap {
+		agent.SetAgentDirectory(agentDirectory)
+	}
+
+	timerEngine := timer.NewTimerEngine(schedulerInstance, c.Timer)
+	if r.Persistence != nil {
+		if err := timerEngine.SetLastRunStore(c.ID, r.Persistence); err != nil {
+			return nil, fmt.Errorf("failed to restore timer jobs: %w", err)
+		}
+	}
+
+	co := &Company{
+		ID:           c.ID,
+		MailboxBus:   mailboxBus,
+		GlobalState:  globalState,
+		Orchestrator: orchestrator,
+		Scheduler:    schedulerInstance,
+		TimerEngine:  timerEngine,
+		Agents:       agentMap,
+		stopWaves:    waves,
+	}
+	sampleInterval, _ := time.ParseDuration(c.StatusSampleInterval)
+	co.SetStatusTrend(sampleInterval, c.StatusTrendSamples)
+	divergenceWarn, _ := time.ParseDuration(c.LoadDivergenceWarn)
+	co.SetLoadDivergenceWarn(divergenceWarn)
+	return co, nil
+}
+
+// Start 启动公司内所有 Agent、调度器与定时引擎
+func (c *Company) Start() error {
+	for name, agent := range c.Agents {
+		if err := agent.Start(); err != nil {
+			return fmt.Errorf("failed to start agent %s: %w", name, err)
+		}
+	}
+	c.Scheduler.Start()
+	c.TimerEngine.Start()
+	c.MailboxBus.StartDeadLetterRetry()
+	c.startStatusTrend()
+	c.startLoadDivergence()
+	c.startedAt = time.Now()
+
+	slog.Info("company started",
+		slog.String("company", c.ID),
+		slog.Int("agent_count", len(c.Agents)),
+	)
+	return nil
+}
+
+// StartSimulated 以模拟模式启动：只启动 Agent（不含定时任务生成循环），
+// 调度轮询、定时任务与任务生成由模拟驱动在虚拟时间上同步触发
+func (c *Company) StartSimulated() error {
+	for name, agent := range c.Agents {
+		agent.SetManualTaskGen(true)
+		if err := agent.Start(); err != nil {
+			return fmt.Errorf("failed to start agent %s: %w", name, err)
+		}
+	}
+	c.simulated = true
+	c.startedAt = time.Now()
+
+	slog.Info("company started in simulation mode",
+		slog.String("company", c.ID),
+		slog.Int("agent_count", len(c.Agents)),
+	)
+	return nil
+}
+
+// Stop 停止公司内的定时引擎、调度器与所有 Agent（可重复调用），
+// ctx 到期时不再等待未退出的 Agent，返回的错误中列出这些 Agent
+func (c *Company) Stop(ctx context.Context) error {
+	c.stopOnce.Do(func() {
+		c.stopErr = c.stop(ctx)
+		c.report = c.Report()
+		logReport(c.report)
+	})
+	return c.stopErr
+}
+
+// ShutdownReport 获取停止时生成的运行摘要，未停止时返回零值
+func (c *Company) ShutdownReport() ShutdownReport {
+	return c.report
+}
+
+func (c *Company) stop(ctx context.Context) error {
+	if !c.simulated {
+		slog.Info("stopping timer engine", slog.String("company", c.ID))
+		c.TimerEngine.Stop()
+
+		slog.Info("stopping scheduler", slog.String("company", c.ID))
+		c.Scheduler.Stop()
+
+		c.MailboxBus.StopDeadLetterRetry()
+		c.stopStatusTrend()
+		c.stopLoadDivergence()
+	}
+
+	// 按依赖分批停止：下属先停，避免其在停止过程中向已停止的上级汇报
+	var (
+		mu    sync.Mutex
+		stuck []string
+	)
+	for _, wave := range c.stopWaves {
+		slog.Info("stopping agents", slog.String("company", c.ID), slog.Any("agents", wave))
+		var wg sync.WaitGroup
+		for _, name := range wave {
+			agent, ok := c.Agents[name]
+			if !ok {
+				continue
+			}
+			wg.Add(1)
+			go func(name string, agent agents.Agent) {
+				defer wg.Done()
+				if err := agent.Stop(ctx); err != nil {
+					slog.Error("failed to stop agent",
+						slog.String("company", c.ID),
+						slog.String("agent", name),
+						slog.Any("error", err),
+					)
+					if ctx.Err() != nil {
+						mu.Lock()
+						stuck = append(stuck, name)
+						mu.Unlock()
+					}
+				}
+			}(name, agent)
+		}
+		wg.Wait()
+	}
+
+	// Agent 停止后再关闭事件持久化，写完停止过程中产生的事件
+	c.GlobalState.GetEventLog().Close()
+
+	if len(stuck) > 0 {
+		sort.Strings(stuck)
+		return fmt.Errorf("company %s: agents did not stop before shutdown timeout: %s", c.ID, strings.Join(stuck, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,400 @@
+package company
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"superman/agents"
+	"superman/config"
+	"superman/ds"
+	"superman/infra"
+	"superman/scheduler"
+	"superman/state"
+	"superman/tools"
+	"superman/workflow"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+)
+
+// fakeModel 总是返回固定回复的模型
+type fakeModel struct{}
+
+func (fakeModel) Generate(context.Context, []*schema.Message, ...model.Option) (*schema.Message, error) {
+	return schema.AssistantMessage("ok", nil), nil
+}
+
+func (fakeModel) Stream(context.Context, []*schema.Message, ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	return schema.StreamReaderFromArray([]*schema.Message{schema.AssistantMessage("ok", nil)}), nil
+}
+
+func (m fakeModel) WithTools([]*schema.ToolInfo) (model.ToolCallingChatModel, error) {
+	return m, nil
+}
+
+// newTestRegistry 创建只含 fakeModel 的 Registry，不连接数据库
+func newTestRegistry() *infra.Registry {
+	return &infra.Registry{
+		LLM:           map[string]model.ToolCallingChatModel{"fake": fakeModel{}},
+		ShutdownHooks: infra.NewShutdownHooks(0),
+	}
+}
+
+// testAgentConfig 返回使用 fakeModel 的 Agent 配置
+func testAgentConfig(t *testing.T, name string) config.AgentConfig {
+	return config.AgentConfig{
+		Name:                name,
+		Desc:                "test agent",
+		Model:               "fake",
+		SkillDir:            t.TempDir(),
+		TaskGenInitialDelay: "1h",
+	}
+}
+
+// newTestCompany 创建不启动的公司
+func newTestCompany(t *testing.T, c config.CompanyConfig) *Company {
+	t.Helper()
+	co, err := NewCompany(context.Background(), newTestRegistry(), c)
+	if err != nil {
+		t.Fatalf("NewCompany(%s): %v", c.ID, err)
+	}
+	return co
+}
+
+// inboxCount 获取公司中指定 Agent 收件箱的消息数
+func inboxCount(t *testing.T, co *Company, agent string) int {
+	t.Helper()
+	mb, err := co.MailboxBus.GetMailbox(agent)
+	if err != nil {
+		t.Fatalf("GetMailbox(%s): %v", agent, err)
+	}
+	return mb.GetInboxCount()
+}
+
+// 同名 Agent 在不同公司中互不可见：公司 A 的消息与任务不会出现在公司 B
+func TestCompaniesAreIsolated(t *testing.T) {
+	a := newTestCompany(t, config.CompanyConfig{ID: "a", Agents: []config.AgentConfig{testAgentConfig(t, "worker")}})
+	b := newTestCompany(t, config.CompanyConfig{ID: "b", Agents: []config.AgentConfig{testAgentConfig(t, "worker")}})
+
+	msg, err := ds.NewMessage("boss", "worker", ds.MessageTypeSystem, "only for company a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := a.MailboxBus.Send(msg); err != nil {
+		t.Fatalf("Send in company a: %v", err)
+	}
+	if got := inboxCount(t, a, "worker"); got != 1 {
+		t.Fatalf("company a inbox = %d, want 1", got)
+	}
+	if got := inboxCount(t, b, "worker"); got != 0 {
+		t.Fatalf("company b inbox = %d, want 0", got)
+	}
+
+	task := ds.NewTask("t1", "task", "test task", "", "boss", ds.TaskStatusPending, ds.TaskPriorityMedium)
+	a.Scheduler.AddTask(task, "medium")
+	if b.GlobalState.GetTask("t1") != nil || b.Scheduler.GetQueueLength() != 0 {
+		t.Fatal("task added in company a is visible in company b")
+	}
+}
+
+// 无可胜任 Agent 的策略配置在创建公司时校验
+func TestNewCompanyValidatesNoCapablePolicy(t *testing.T) {
+	for name, sc := range map[string]config.SchedulerConfig{
+		"bad wait":       {NoCapablePolicy: "fail", NoCapableWait: "soon"},
+		"unknown agent":  {NoCapablePolicy: "fallback", FallbackAgent: "ghost"},
+		"missing agent":  {NoCapablePolicy: "fallback"},
+		"unknown policy": {NoCapablePolicy: "retry"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := NewCompany(context.Background(), newTestRegistry(), config.CompanyConfig{
+				ID:        "acme",
+				Agents:    []config.AgentConfig{testAgentConfig(t, "worker")},
+				Scheduler: &sc,
+			})
+			if err == nil {
+				t.Fatal("NewCompany succeeded, want a config error")
+			}
+		})
+	}
+
+	_, err := NewCompany(context.Background(), newTestRegistry(), config.CompanyConfig{
+		ID:        "acme",
+		Agents:    []config.AgentConfig{testAgentConfig(t, "worker")},
+		Scheduler: &config.SchedulerConfig{NoCapablePolicy: "fallback", FallbackAgent: "worker", NoCapableWait: "1m"},
+	})
+	if err != nil {
+		t.Fatalf("NewCompany with a valid fallback: %v", err)
+	}
+}
+
+// Agent 启动时向调度器通告能力，调度器的能力视图随之更新，运行中变更能力同样生效
+func TestAgentStartAdvertisesCapabilities(t *testing.T) {
+	co := newTestCompany(t, config.CompanyConfig{ID: "acme", Agents: []config.AgentConfig{testAgentConfig(t, "worker")}})
+	agent := co.Agents["worker"]
+
+	agent.SetCapabilities([]string{"go"})
+	if load, _ := co.Scheduler.GetAgentLoad("worker"); slices.Contains(load.Capabilities, "go") {
+		t.Fatal("capabilities reached the scheduler before the agent started")
+	}
+	if err := agent.Start(); err != nil {
+		t.Fatalf("start agent: %v", err)
+	}
+	t.Cleanup(func() { _ = agent.Stop(context.Background()) })
+	if load, _ := co.Scheduler.GetAgentLoad("worker"); !slices.Equal(load.Capabilities, []string{"go"}) {
+		t.Fatalf("scheduler capabilities = %v after start, want [go]", load.Capabilities)
+	}
+
+	agent.SetCapabilities([]string{"go", "sql"})
+	if load, _ := co.Scheduler.GetAgentLoad("worker"); !slices.Equal(load.Capabilities, []string{"go", "sql"}) {
+		t.Fatalf("scheduler capabilities = %v after update, want [go sql]", load.Capabilities)
+	}
+}
+
+// 暂停任务生成后所有 Agent 都不再产生自驱任务，恢复后重新允许生成
+func TestPausedTaskGenerationAcrossAgents(t *testing.T) {
+	co := newTestCompany(t, config.CompanyConfig{ID: "acme", Agents: []config.AgentConfig{
+		testAgentConfig(t, "ceo"),
+		testAgentConfig(t, "cfo"),
+		testAgentConfig(t, "cto"),
+	}})
+
+	co.Scheduler.PauseTaskGeneration()
+	for name, agent := range co.Agents {
+		ids, err := agent.TriggerTaskGeneration(context.Background())
+		if !errors.Is(err, agents.ErrTaskGenPaused) || len(ids) != 0 {
+			t.Fatalf("%s generated %v (err %v) while paused, want ErrTaskGenPaused", name, ids, err)
+		}
+	}
+	if got := co.Scheduler.GetQueueLength(); got != 0 {
+		t.Fatalf("queue length = %d while paused, want 0", got)
+	}
+
+	co.Scheduler.ResumeTaskGeneration()
+	if _, err := co.Agents["ceo"].TriggerTaskGeneration(context.Background()); err != nil {
+		t.Fatalf("TriggerTaskGeneration after resume: %v", err)
+	}
+}
+
+// 任务类型在调度分发后随任务创建消息送达 Agent
+func TestTaskTypeSurvivesDispatch(t *testing.T) {
+	co := newTestCompany(t, config.CompanyConfig{ID: "acme", Agents: []config.AgentConfig{testAgentConfig(t, "worker")}})
+
+	task := ds.NewTask("t1", "weekly report", "summarize the week", "", "boss", ds.TaskStatusPending, ds.TaskPriorityMedium)
+	task.Type = ds.TaskTypeReport
+	co.Scheduler.AddTask(task, scheduler.PriorityMedium)
+	co.Scheduler.Tick(time.Now())
+
+	mb, err := co.MailboxBus.GetMailbox("worker")
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := mb.TryPopInbox()
+	if msg == nil {
+		t.Fatal("task was not dispatched to worker")
+	}
+	body, ok := msg.GetTaskCreateBody()
+	if !ok || body.TaskID != "t1" {
+		t.Fatalf("message %+v is not the task create message for t1", msg)
+	}
+	if body.Type != ds.TaskTypeReport {
+		t.Fatalf("dispatched type = %q, want %q", body.Type, ds.TaskTypeReport)
+	}
+	if got := co.GlobalState.GetTask("t1").Type; got != ds.TaskTypeReport {
+		t.Fatalf("stored type = %q, want %q", got, ds.TaskTypeReport)
+	}
+}
+
+// listAgentsModel 首次调用时请求 list agents 工具，之后记录工具返回的内容
+type listAgentsModel struct {
+	mu     sync.Mutex
+	result string
+}
+
+func (m *listAgentsModel) Generate(_ context.Context, input []*schema.Message, _ ...model.Option) (*schema.Message, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	last := input[len(input)-1]
+	if last.Role == schema.Tool {
+		m.result = last.Content
+		return schema.AssistantMessage("done", nil), nil
+	}
+	return schema.AssistantMessage("", []schema.ToolCall{{
+		ID:       "call-1",
+		Function: schema.FunctionCall{Name: "list agents", Arguments: "{}"},
+	}}), nil
+}
+
+func (m *listAgentsModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	msg, err := m.Generate(ctx, input, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return schema.StreamReaderFromArray([]*schema.Message{msg}), nil
+}
+
+func (m *listAgentsModel) WithTools([]*schema.ToolInfo) (model.ToolCallingChatModel, error) {
+	return m, nil
+}
+
+// list agents 工具返回公司中所有已注册 Agent 的名称、描述与层级
+func TestListAgentsToolReturnsRegisteredAgents(t *testing.T) {
+	llm := &listAgentsModel{}
+	r := newTestRegistry()
+	r.LLM["fake"] = llm
+	ceo, analyst := testAgentConfig(t, "ceo"), testAgentConfig(t, "analyst")
+	ceo.Desc, analyst.Desc = "runs the company", "crunches numbers"
+	top, staff := 0, 3
+	ceo.Hierarchy, analyst.Hierarchy = &top, &staff
+	co, err := NewCompany(context.Background(), r, config.CompanyConfig{ID: "acme", Agents: []config.AgentConfig{ceo, analyst}})
+	if err != nil {
+		t.Fatalf("NewCompany: %v", err)
+	}
+	agent := co.Agents["ceo"]
+	if err := agent.Start(); err != nil {
+		t.Fatalf("start agent: %v", err)
+	}
+	t.Cleanup(func() { _ = agent.Stop(context.Background()) })
+
+	task := ds.NewTask("t1", "staffing", "who can help?", "ceo", "boss", ds.TaskStatusAssigned, ds.TaskPriorityMedium)
+	co.GlobalState.AddTask(task)
+	if err := agent.ProcessTask(context.Background(), task); err != nil {
+		t.Fatalf("ProcessTask: %v", err)
+	}
+
+	llm.mu.Lock()
+	result := llm.result
+	llm.mu.Unlock()
+	var resp tools.ListAgentsResponse
+	if err := json.Unmarshal([]byte(result), &resp); err != nil {
+		t.Fatalf("tool result %q: %v", result, err)
+	}
+	want := []tools.AgentInfo{
+		{Name: "ceo", Desc: "runs the company", Hierarchy: 0},
+		{Name: "analyst", Desc: "crunches numbers", Hierarchy: 3},
+	}
+	if !slices.Equal(resp.Agents, want) {
+		t.Fatalf("list agents = %+v, want %+v", resp.Agents, want)
+	}
+}
+
+// 公司执行统计等于各 Agent 执行统计之和
+func TestCompanyStatsSumAgentStats(t *testing.T) {
+	co := newTestCompany(t, config.CompanyConfig{ID: "acme", Agents: []config.AgentConfig{
+		testAgentConfig(t, "ceo"),
+		testAgentConfig(t, "cfo"),
+	}})
+	runs := map[string]int{"ceo": 2, "cfo": 1}
+	for name, n := range runs {
+		agent := co.Agents[name]
+		if err := agent.Start(); err != nil {
+			t.Fatalf("start %s: %v", name, err)
+		}
+		t.Cleanup(func() { _ = agent.Stop(context.Background()) })
+		for i := range n {
+			task := ds.NewTask(fmt.Sprintf("%s-%d", name, i), "t", "d", name, "boss", ds.TaskStatusAssigned, ds.TaskPriorityMedium)
+			co.GlobalState.AddTask(task)
+			if err := agent.ProcessTask(context.Background(), task); err != nil {
+				t.Fatalf("ProcessTask(%s): %v", task.ID, err)
+			}
+		}
+	}
+
+	stats := co.Orchestrator.GetCompanyStats()
+	if stats.TotalExecutions != 3 || stats.SuccessCount != 3 || stats.FailedCount != 0 || stats.SuccessRate != 1 {
+		t.Fatalf("company stats = %+v, want 3 successful executions", stats)
+	}
+	var weighted time.Duration
+	for _, agentStats := range stats.Agents {
+		raw := co.Agents[agentStats.Name].GetExecutionStats()
+		if agentStats.TotalExecutions != runs[agentStats.Name] || agentStats.TotalExecutions != raw["total_executions"] ||
+			agentStats.SuccessCount != raw["success_count"] || agentStats.AvgDuration != raw["avg_duration"] {
+			t.Fatalf("%s stats = %+v, want to match %v", agentStats.Name, agentStats, raw)
+		}
+		weighted += agentStats.AvgDuration * time.Duration(agentStats.TotalExecutions)
+	}
+	if len(stats.Agents) != 2 || stats.AvgDuration != weighted/3 {
+		t.Fatalf("company stats = %+v, want both agents and the execution-weighted average duration", stats)
+	}
+}
+
+// 扇出到三个 Agent 的任务在所有分支完成后把结果汇总到父任务
+func TestFanOutJoinsIntoParent(t *testing.T) {
+	names := []string{"cfo", "cto", "hr"}
+	cfgs := make([]config.AgentConfig, len(names))
+	for i, name := range names {
+		cfgs[i] = testAgentConfig(t, name)
+	}
+	co := newTestCompany(t, config.CompanyConfig{ID: "acme", Agents: cfgs})
+	for _, name := range names {
+		agent := co.Agents[name]
+		if err := agent.Start(); err != nil {
+			t.Fatalf("start %s: %v", name, err)
+		}
+		t.Cleanup(func() { _ = agent.Stop(context.Background()) })
+	}
+
+	parent := ds.NewTask("plan", "annual plan", "combine department plans", "ceo", "boss", ds.TaskStatusPending, ds.TaskPriorityHigh)
+	branches := make([]*ds.Task, len(names))
+	for i, name := range names {
+		branches[i] = ds.NewTask("plan-"+name, name+" plan", "department plan", name, "", ds.TaskStatusPending, ds.TaskPriorityHigh)
+	}
+	if err := co.Orchestrator.FanOut(parent, branches); err != nil {
+		t.Fatalf("FanOut: %v", err)
+	}
+	if got := co.GlobalState.GetTask("plan").Status; got != ds.TaskStatusProcessing {
+		t.Fatalf("parent status = %s before the branches finish, want processing", got)
+	}
+	co.Scheduler.Tick(time.Now())
+
+	// 按状态查询在全局状态锁内比较，避免与分支完成回调的写入竞争
+	completed := state.TaskFilter{AssignedTo: "ceo", Status: ds.TaskStatusCompleted}
+	deadline := time.Now().Add(5 * time.Second)
+	for len(co.GlobalState.QueryTasks(completed)) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("parent task did not complete after all branches")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	results, _ := co.GlobalState.GetTask("plan").Metadata[workflow.MetadataFanOutResults].([]workflow.FanOutBranchResult)
+	if len(results) != len(names) {
+		t.Fatalf("joined results = %+v, want one per branch", results)
+	}
+	for i, r := range results {
+		if r.TaskID != "plan-"+names[i] || r.Agent != names[i] || !r.Success {
+			t.Fatalf("result %d = %+v, want a successful %s branch", i, r, names[i])
+		}
+	}
+}
+
+// 排队任务数超过积压上限时生成被抑制且不产生新任务，积压清空后恢复生成
+func TestTaskGenerationSuppressedByBacklog(t *testing.T) {
+	co := newTestCompany(t, config.CompanyConfig{
+		ID:        "acme",
+		Agents:    []config.AgentConfig{testAgentConfig(t, "ceo")},
+		Scheduler: &config.SchedulerConfig{TaskGenBacklogLimit: 2},
+	})
+
+	for i := range 3 {
+		id := fmt.Sprintf("backlog-%d", i)
+		co.Scheduler.AddTask(ds.NewTask(id, id, "queued work", "", "boss", ds.TaskStatusPending, ds.TaskPriorityMedium), scheduler.PriorityMedium)
+	}
+
+	ids, err := co.Agents["ceo"].TriggerTaskGeneration(context.Background())
+	if !errors.Is(err, agents.ErrTaskGenPaused) || len(ids) != 0 {
+		t.Fatalf("generated %v (err %v) above backlog limit, want ErrTaskGenPaused", ids, err)
+	}
+	if got := co.Scheduler.GetQueueLength(); got != 3 {
+		t.Fatalf("queue length = %d after suppressed generation, want 3", got)
+	}
+
+	co.Scheduler.ClearQueue(scheduler.PriorityMedium)
+	if _, err := co.Agents["ceo"].TriggerTaskGeneration(context.Background()); err != nil {
+		t.Fatalf("TriggerTaskGeneration after backlog drained: %v", err)
+	}
+}
@@ -0,0 +1,44 @@
+package company
+
+import (
+	"fmt"
+	"log/slog"
+
+	"superman/ds"
+	"superman/mailbox"
+	"superman/scheduler"
+)
+
+// notifyDependencyFailed 通知下游任务的执行者与委派者：依赖任务取消或失败后任务已被级联取消或放行
+func notifyDependencyFailed(bus *mailbox.MailboxBus, task *ds.Task, cause, policy string) {
+	title := "任务因依赖失效已取消"
+	content := fmt.Sprintf("任务 %s（%s）依赖的任务 %s 已取消或失败，该任务已被取消", task.ID, task.Title, cause)
+	if policy == scheduler.DependencyPolicyProceed {
+		title = "任务依赖已失效"
+		content = fmt.Sprintf("任务 %s（%s）依赖的任务 %s 已取消或失败，已移除该依赖，任务将继续执行", task.ID, task.Title, cause)
+	}
+
+	receivers := []string{task.AssignedTo}
+	if task.AssignedBy != task.AssignedTo {
+		receivers = append(receivers, task.AssignedBy)
+	}
+	for _, receiver := range receivers {
+		if receiver == "" {
+			continue
+		}
+		if _, err := bus.GetMailbox(receiver); err != nil {
+			continue
+		}
+		msg, err := ds.NewNotificationMessage("system", receiver, title, content, "medium")
+		if err != nil {
+			continue
+		}
+		if err := bus.Send(msg); err != nil {
+			slog.Warn("failed to send dependency notification",
+				slog.String("task_id", task.ID),
+				slog.String("agent", receiver),
+				slog.Any("error", err),
+			)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package company
+
+import (
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultLoadDivergenceWarn 负载计数偏差持续超过该时长时记录告警
+const DefaultLoadDivergenceWarn = 2 * time.Minute
+
+// maxLoadCheckInterval 负载偏差检查的最大间隔
+const maxLoadCheckInterval = 15 * time.Second
+
+// AgentLoadMetrics 调度器记录的 Agent 负载与 Agent 实际在途任务数的对比，
+// 任务分发与完成之间会短暂不一致，Delta 持续非零说明负载计数出现偏差
+type AgentLoadMetrics struct {
+	Agent         string     `json:"agent"`
+	SchedulerLoad int        `json:"scheduler_load"`           // 调度器记录的 CurrentLoad
+	MaxTasks      int        `json:"max_tasks"`                // 调度器记录的最大并发任务数
+	InFlight      int        `json:"in_flight"`                // 已投递给 Agent 且未完成的任务数（含收件箱中未取出的）
+	Delta         int        `json:"delta"`                    // SchedulerLoad - InFlight
+	DivergedSince *time.Time `json:"diverged_since,omitempty"` // Delta 开始持续非零的时间
+}
+
+// loadDivergence 各 Agent 负载计数偏差的持续时间跟踪
+type loadDivergence struct {
+	mu     sync.Mutex
+	warn   time.Duration
+	since  map[string]time.Time // Agent -> Delta 开始非零的时间
+	warned map[string]bool      // Agent -> 本次偏差是否已告警
+	stopCh chan struct{}
+	wg     sync.WaitGroup
+}
+
+// SetLoadDivergenceWarn 设置负载计数偏差持续多久后告警，d<=0 使用 DefaultLoadDivergenceWarn；需在 Start 前调用
+func (c *Company) SetLoadDivergenceWarn(d time.Duration) {
+	if d <= 0 {
+		d = DefaultLoadDivergenceWarn
+	}
+	c.divergence.mu.Lock()
+	defer c.divergence.mu.Unlock()
+	c.divergence.warn = d
+}
+
+// AgentLoadMetrics 获取指定 Agent 的负载对比，Agent 不存在时返回 false
+func (c *Company) AgentLoadMetrics(name string) (AgentLoadMetrics, bool) {
+	agent, ok := c.Agents[name]
+	if !ok {
+		return AgentLoadMetrics{}, false
+	}
+	m := AgentLoadMetrics{Agent: name, InFlight: agent.GetInFlightTasks()}
+	if load, ok := c.Scheduler.GetAgentLoad(name); ok {
+		m.SchedulerLoad = load.CurrentLoad
+		m.MaxTasks = load.MaxTasks
+	}
+	m.Delta = m.SchedulerLoad - m.InFlight
+
+	c.divergence.mu.Lock()
+	if since, ok := c.divergence.since[name]; ok && m.Delta != 0 {
+		m.DivergedSince = &since
+	}
+	c.divergence.mu.Unlock()
+	return m, true
+}
+
+// CheckLoadDivergence 对比各 Agent 的负载计数，偏差持续超过告警时长时记录一次告警，
+// 返回当前存在偏差的 Agent（按名称排序）
+func (c *Company) CheckLoadDivergence(now time.Time) []AgentLoadMetrics {
+	names := make([]string, 0, len(c.Agents))
+	for name := range c.Agents {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	diverged := make([]AgentLoadMetrics, 0)
+	for _, name := range names {
+		m, _ := c.AgentLoadMetrics(name)
+
+		c.divergence.mu.Lock()
+		if c.divergence.since == nil {
+			c.divergence.since = make(map[string]time.Time)
+			c.divergence.warned = make(map[string]bool)
+		}
+		if m.Delta == 0 {
+			delete(c.divergence.since, name)
+			delete(c.divergence.warned, name)
+			c.divergence.mu.Unlock()
+			continue
+		}
+		since, ok := c.divergence.since[name]
+		if !ok {
+			since = now
+			c.divergence.since[name] = since
+		}
+		warn := c.divergence.warn
+		if warn <= 0 {
+			warn = DefaultLoadDivergenceWarn
+		}
+		fire := !c.divergence.warned[name] && now.Sub(since) >= warn
+		if fire {
+			c.divergence.warned[name] = true
+		}
+		c.divergence.mu.Unlock()
+
+		m.DivergedSince = &since
+		diverged = append(diverged, m)
+		if fire {
+			slog.Warn("agent load accounting diverged",
+				slog.String("company", c.ID),
+				slog.String("agent", name),
+				slog.Int("scheduler_load", m.SchedulerLoad),
+				slog.Int("in_flight", m.InFlight),
+				slog.Int("delta", m.Delta),
+				slog.Duration("sustained", now.Sub(since)),
+			)
+		}
+	}
+	return diverged
+}
+
+// startLoadDivergence 启动后台负载偏差检查循环
+func (c *Company) startLoadDivergence() {
+	c.divergence.mu.Lock()
+	if c.divergence.stopCh != nil {
+		c.divergence.mu.Unlock()
+		return
+	}
+	interval := c.divergence.warn
+	if interval <= 0 {
+		interval = DefaultLoadDivergenceWarn
+	}
+	interval = max(min(interval/4, maxLoadCheckInterval), time.Millisecond)
+	stopCh := make(chan struct{})
+	c.divergence.stopCh = stopCh
+	c.divergence.mu.Unlock()
+
+	c.divergence.wg.Add(1)
+	go func() {
+		defer c.divergence.wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stopCh:
+				return
+			case now := <-ticker.C:
+				c.CheckLoadDivergence(now)
+			}
+		}
+	}()
+}
+
+// stopLoadDivergence 停止后台负载偏差检查循环
+func (c *Company) stopLoadDivergence() {
+	c.divergence.mu.Lock()
+	stopCh := c.divergence.stopCh
+	c.divergence.stopCh = nil
+	c.divergence.mu.Unlock()
+	if stopCh != nil {
+		close(stopCh)
+		c.divergence.wg.Wait()
+	}
+}
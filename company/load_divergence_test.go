@@ -0,0 +1,65 @@
+package company
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"superman/config"
+	"superman/ds"
+	"superman/scheduler"
+)
+
+// 调度器负载计数与 Agent 在途任务数不一致时报告偏差及其开始时间；任务完成后偏差归零
+func TestLoadDivergenceReportsAccountingMismatch(t *testing.T) {
+	co := newTestCompany(t, config.CompanyConfig{ID: "acme", Agents: []config.AgentConfig{testAgentConfig(t, "worker")}})
+	mb, err := co.MailboxBus.GetMailbox("worker")
+	if err != nil {
+		t.Fatal(err)
+	}
+	submit := func(id string) {
+		co.Scheduler.AddTask(ds.NewTask(id, "t", "d", "worker", "boss", ds.TaskStatusPending, ds.TaskPriorityMedium), scheduler.PriorityMedium)
+	}
+
+	submit("lost")
+	co.Scheduler.Tick(time.Now())
+	if m, _ := co.AgentLoadMetrics("worker"); m.SchedulerLoad != 1 || m.InFlight != 1 || m.Delta != 0 {
+		t.Fatalf("metrics after dispatch = %+v, want load 1, in flight 1, delta 0", m)
+	}
+	// 任务消息在 Agent 处理前丢失：调度器仍计入负载，Agent 没有在途任务
+	if mb.TryPopInbox() == nil {
+		t.Fatal("task was not dispatched to worker")
+	}
+	start := time.Now()
+	for _, now := range []time.Time{start, start.Add(DefaultLoadDivergenceWarn)} {
+		got := co.CheckLoadDivergence(now)
+		if len(got) != 1 || got[0].Agent != "worker" || got[0].Delta != 1 || got[0].DivergedSince == nil || !got[0].DivergedSince.Equal(start) {
+			t.Fatalf("divergence at %s = %+v, want worker with delta 1 since %s", now, got, start)
+		}
+	}
+	if m, _ := co.AgentLoadMetrics("worker"); m.Delta != 1 || m.DivergedSince == nil {
+		t.Fatalf("metrics = %+v, want the sustained mismatch reported", m)
+	}
+	co.Scheduler.OnTaskComplete("lost", "worker", false)
+
+	if err := co.Start(); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	t.Cleanup(func() { _ = co.Stop(context.Background()) })
+	submit("t1")
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		executed := len(co.Agents["worker"].GetExecutionHistoryByTaskID("t1")) > 0
+		m, _ := co.AgentLoadMetrics("worker")
+		if executed && m.SchedulerLoad == 0 && m.InFlight == 0 && m.Delta == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("metrics = %+v after t1, want delta back to 0 once the task completes", m)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if got := co.CheckLoadDivergence(time.Now()); len(got) != 0 {
+		t.Fatalf("divergence after completion = %+v, want none", got)
+	}
+}
@@ -0,0 +1,44 @@
+package company
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"superman/config"
+	"superman/ds"
+	"superman/mailbox"
+	"superman/scheduler"
+)
+
+// notifyPressure 向告警 Agent 发送调度压力持续过高的高优先级通知
+func notifyPressure(bus *mailbox.MailboxBus, agent string, p scheduler.Pressure, sustained time.Duration) {
+	if agent == "" {
+		return
+	}
+	content := fmt.Sprintf("调度压力 %.2f 已持续 %s 不低于阈值 %.2f：排队 %d 个任务，在途 %d 个，Agent 总容量 %d，排队任务平均已等待 %s。建议增加 Agent 或提高并发上限",
+		p.Value, sustained.Round(time.Second), p.Threshold, p.QueueDepth, p.InFlight, p.Capacity,
+		(time.Duration(p.AvgWaitMs) * time.Millisecond).Round(time.Second))
+	msg, err := ds.NewNotificationMessage("system", agent, "调度压力告警", content, "high")
+	if err != nil {
+		return
+	}
+	if err := bus.Send(msg); err != nil {
+		slog.Error("failed to send pressure alert",
+			slog.String("agent", agent),
+			slog.Any("error", err),
+		)
+	}
+}
+
+// topLevelAgent 返回层级最高（数值最小）的 Agent 名称，同层级时取配置中靠前者
+func topLevelAgent(agents []config.AgentConfig) string {
+	top := ""
+	best := 0
+	for _, agent := range agents {
+		if top == "" || agent.GetHierarchy() < best {
+			top, best = agent.Name, agent.GetHierarchy()
+		}
+	}
+	return top
+}
@@ -0,0 +1,44 @@
+package company
+
+import (
+	"log/slog"
+
+	"superman/config"
+)
+
+// ApplyConfig 在线应用配置中可安全热更新的部分（目前为 Agent 的 max_tasks）；
+// 新增或移除 Agent 等结构性变更不会生效，只记录告警，需要重启
+func (c *Company) ApplyConfig(cfg config.CompanyConfig) {
+	configured := make(map[string]bool, len(cfg.Agents))
+	for _, agentConfig := range cfg.Agents {
+		configured[agentConfig.Name] = true
+		if _, exists := c.Agents[agentConfig.Name]; !exists {
+			slog.Warn("new agent in reloaded config ignored, restart required",
+				slog.String("company", c.ID),
+				slog.String("agent", agentConfig.Name),
+			)
+			continue
+		}
+
+		load, ok := c.Scheduler.GetAgentLoad(agentConfig.Name)
+		if !ok || load.MaxTasks == agentConfig.GetMaxTasks() {
+			continue
+		}
+		if err := c.Scheduler.SetAgentMaxTasks(agentConfig.Name, agentConfig.GetMaxTasks()); err != nil {
+			slog.Error("failed to apply max_tasks from reloaded config",
+				slog.String("company", c.ID),
+				slog.String("agent", agentConfig.Name),
+				slog.Any("error", err),
+			)
+		}
+	}
+
+	for name := range c.Agents {
+		if !configured[name] {
+			slog.Warn("agent removed from reloaded config ignored, restart required",
+				slog.String("company", c.ID),
+				slog.String("agent", name),
+			)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package company
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"superman/config"
+)
+
+// 修改被监听配置文件中的 max_tasks 后，调度器中对应 Agent 的并发上限随之更新；新增的 Agent 不会在线加入
+func TestReloadedMaxTasksUpdatesScheduler(t *testing.T) {
+	agentConfig := testAgentConfig(t, "cfo")
+	agentConfig.MaxTasks = 2
+	co := newTestCompany(t, config.CompanyConfig{ID: config.DefaultCompanyID, Agents: []config.AgentConfig{agentConfig}})
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	write := func(data string, mtime time.Time) {
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatalf("write config: %v", err)
+		}
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatalf("chtimes: %v", err)
+		}
+	}
+	now := time.Now()
+	write("agents:\n  - name: cfo\n    max_tasks: 2\n", now)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	go func() {
+		defer close(done)
+		config.Watch(ctx, []string{path}, 10*time.Millisecond, func(c *config.Config) {
+			for _, companyConfig := range c.GetCompanies() {
+				if companyConfig.ID == co.ID {
+					co.ApplyConfig(companyConfig)
+				}
+			}
+		})
+	}()
+
+	// Watch 以启动时的修改时间为基准，每轮推进修改时间，确保修改发生在监听开始之后
+	deadline := time.Now().Add(2 * time.Second)
+	for i := 1; ; i++ {
+		write("agents:\n  - name: cfo\n    max_tasks: 5\n  - name: cmo\n    max_tasks: 3\n", now.Add(time.Duration(i)*time.Second))
+		time.Sleep(20 * time.Millisecond)
+		if load, _ := co.Scheduler.GetAgentLoad("cfo"); load.MaxTasks == 5 {
+			break
+		}
+		if time.Now().After(deadline) {
+			load, _ := co.Scheduler.GetAgentLoad("cfo")
+			t.Fatalf("cfo max tasks = %d after reload, want 5", load.MaxTasks)
+		}
+	}
+	if _, ok := co.Scheduler.GetAgentLoad("cmo"); ok {
+		t.Fatal("agent cmo added by the reload, want structural changes ignored")
+	}
+}
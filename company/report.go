@@ -0,0 +1,212 @@
+package company
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"superman/ds"
+	"superman/scheduler"
+	"superman/state"
+)
+
+// DefaultReportAnnouncements 公司报告默认包含的最近公告条数
+const DefaultReportAnnouncements = 10
+
+// CompanyReport 公司运行报告，汇总 Agent 统计、KPI、最近公告、任务吞吐与未完成事项，用作定期管理摘要
+type CompanyReport struct {
+	Company       string                     `json:"company"`
+	GeneratedAt   time.Time                  `json:"generated_at"`
+	Uptime        string                     `json:"uptime,omitempty"`
+	Agents        []AgentSummary             `json:"agents"`
+	KPIs          map[string]float64         `json:"kpis"`
+	Announcements []state.Announcement       `json:"announcements"`
+	Throughput    Throughput                 `json:"throughput"`
+	Scheduler     scheduler.SchedulerMetrics `json:"scheduler"`
+	OpenItems     []OpenItem                 `json:"open_items"`
+}
+
+// AgentSummary 报告中单个 Agent 的统计
+type AgentSummary struct {
+	Name              string             `json:"name"`
+	Running           bool               `json:"running"`
+	Workload          float64            `json:"workload"`
+	Executions        int                `json:"executions"`
+	SuccessRate       float64            `json:"success_rate"`
+	AvgDuration       time.Duration      `json:"avg_duration"`
+	TasksCompleted    int                `json:"tasks_completed"`
+	TasksFailed       int                `json:"tasks_failed"`
+	TasksOpen         int                `json:"tasks_open"`
+	MessagesProcessed int                `json:"messages_processed"`
+	Metrics           map[string]float64 `json:"metrics,omitempty"`
+}
+
+// Throughput 任务吞吐统计
+type Throughput struct {
+	Completed     int     `json:"completed"`
+	Failed        int     `json:"failed"`
+	Cancelled     int     `json:"cancelled"`
+	Open          int     `json:"open"`
+	CompletedLast int     `json:"completed_last_24h"` // 最近 24 小时内完成的任务数
+	PerHour       float64 `json:"per_hour"`           // 启动以来平均每小时完成的任务数
+}
+
+// OpenItem 未完成的任务
+type OpenItem struct {
+	TaskID     string          `json:"task_id"`
+	Title      string          `json:"title"`
+	Status     ds.TaskStatus   `json:"status"`
+	Priority   ds.TaskPriority `json:"priority"`
+	AssignedTo string          `json:"assigned_to,omitempty"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+// BuildReport 根据全局状态、调度器指标与各 Agent 统计生成公司报告，announcements 为包含的最近公告条数
+func (c *Company) BuildReport(announcements int) CompanyReport {
+	now := time.Now()
+	report := CompanyReport{
+		Company:     c.ID,
+		GeneratedAt: now,
+		KPIs:        c.GlobalState.GetKPIs(),
+		OpenItems:   make([]OpenItem, 0),
+	}
+	if !c.startedAt.IsZero() {
+		report.Uptime = now.Sub(c.startedAt).Round(time.Second).String()
+	}
+	if c.Scheduler != nil {
+		report.Scheduler = c.Scheduler.GetMetrics()
+	}
+
+	all := c.GlobalState.GetAnnouncements()
+	if announcements > 0 && len(all) > announcements {
+		all = all[len(all)-announcements:]
+	}
+	report.Announcements = all
+
+	summaries := make(map[string]*AgentSummary, len(c.Agents))
+	for _, stats := range c.Orchestrator.GetCompanyStats().Agents {
+		agent, ok := c.Agents[stats.Name]
+		if !ok {
+			continue
+		}
+		metrics := agent.GetState().PerformanceMetrics
+		summaries[stats.Name] = &AgentSummary{
+			Name:              stats.Name,
+			Running:           agent.IsRunning(),
+			Workload:          agent.GetWorkload(),
+			Executions:        stats.TotalExecutions,
+			SuccessRate:       stats.SuccessRate,
+			AvgDuration:       stats.AvgDuration,
+			MessagesProcessed: int(metrics["messages_processed"]),
+			Metrics:           metrics,
+		}
+	}
+
+	for _, task := range c.GlobalState.GetAllTasks() {
+		summary := summaries[task.AssignedTo]
+		switch task.Status {
+		case ds.TaskStatusCompleted, ds.TaskStatusCompletedWithWarnings:
+			report.Throughput.Completed++
+			if now.Sub(task.UpdatedAt) <= 24*time.Hour {
+				report.Throughput.CompletedLast++
+			}
+			if summary != nil {
+				summary.TasksCompleted++
+			}
+		case ds.TaskStatusFailed:
+			report.Throughput.Failed++
+			if summary != nil {
+				summary.TasksFailed++
+			}
+		case ds.TaskStatusCancelled:
+			report.Throughput.Cancelled++
+		default:
+			report.Throughput.Open++
+			if summary != nil {
+				summary.TasksOpen++
+			}
+			report.OpenItems = append(report.OpenItems, OpenItem{
+				TaskID:     task.ID,
+				Title:      task.Title,
+				Status:     task.Status,
+				Priority:   task.Priority,
+				AssignedTo: task.AssignedTo,
+				CreatedAt:  task.CreatedAt,
+			})
+		}
+	}
+	if hours := now.Sub(c.startedAt).Hours(); !c.startedAt.IsZero() && hours > 0 {
+		report.Throughput.PerHour = float64(report.Throughput.Completed) / hours
+	}
+	sort.Slice(report.OpenItems, func(i, j int) bool {
+		return report.OpenItems[i].CreatedAt.Before(report.OpenItems[j].CreatedAt)
+	})
+
+	report.Agents = make([]AgentSummary, 0, len(summaries))
+	for _, summary := range summaries {
+		report.Agents = append(report.Agents, *summary)
+	}
+	sort.Slice(report.Agents, func(i, j int) bool { return report.Agents[i].Name < report.Agents[j].Name })
+	return report
+}
+
+// Markdown 将报告渲染为便于阅读的 Markdown
+func (r CompanyReport) Markdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s 公司报告\n\n", r.Company)
+	fmt.Fprintf(&b, "生成时间：%s\n", r.GeneratedAt.Format(time.RFC3339))
+	if r.Uptime != "" {
+		fmt.Fprintf(&b, "运行时长：%s\n", r.Uptime)
+	}
+
+	b.WriteString("\n## Agent\n\n")
+	b.WriteString("| Agent | 运行 | 负载 | 执行次数 | 成功率 | 平均耗时 | 完成 | 失败 | 进行中 | 处理消息 |\n")
+	b.WriteString("|---|---|---|---|---|---|---|---|---|---|\n")
+	for _, a := range r.Agents {
+		fmt.Fprintf(&b, "| %s | %t | %.1f | %d | %.0f%% | %s | %d | %d | %d | %d |\n",
+			a.Name, a.Running, a.Workload, a.Executions, a.SuccessRate*100, a.AvgDuration.Round(time.Millisecond),
+			a.TasksCompleted, a.TasksFailed, a.TasksOpen, a.MessagesProcessed)
+	}
+
+	b.WriteString("\n## KPI\n\n")
+	if len(r.KPIs) == 0 {
+		b.WriteString("暂无\n")
+	} else {
+		names := make([]string, 0, len(r.KPIs))
+		for name := range r.KPIs {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Fprintf(&b, "- %s: %g\n", name, r.KPIs[name])
+		}
+	}
+
+	b.WriteString("\n## 最近公告\n\n")
+	if len(r.Announcements) == 0 {
+		b.WriteString("暂无\n")
+	}
+	for _, a := range r.Announcements {
+		fmt.Fprintf(&b, "- [%s] %s %s：%s\n", a.Severity, a.Timestamp.Format(time.RFC3339), a.Author, a.Text)
+	}
+
+	t := r.Throughput
+	b.WriteString("\n## 任务吞吐\n\n")
+	fmt.Fprintf(&b, "- 已完成：%d（最近 24 小时 %d，平均每小时 %.2f）\n", t.Completed, t.CompletedLast, t.PerHour)
+	fmt.Fprintf(&b, "- 失败：%d\n- 已取消：%d\n- 未完成：%d\n", t.Failed, t.Cancelled, t.Open)
+	fmt.Fprintf(&b, "- 排队中：%d\n", r.Scheduler.QueueLength)
+
+	b.WriteString("\n## 未完成事项\n\n")
+	if len(r.OpenItems) == 0 {
+		b.WriteString("暂无\n")
+	}
+	for _, item := range r.OpenItems {
+		assignee := item.AssignedTo
+		if assignee == "" {
+			assignee = "未分配"
+		}
+		fmt.Fprintf(&b, "- %s [%s/%s] %s（%s）\n", item.TaskID, item.Status, item.Priority, item.Title, assignee)
+	}
+	return b.String()
+}
@@ -0,0 +1,69 @@
+package company
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"superman/config"
+	"superman/ds"
+	"superman/state"
+)
+
+// 已有执行记录、KPI 与公告的公司生成的报告包含各 Agent 统计、KPI 取值、最近公告与未完成事项
+func TestReportIncludesAgentStatsAndKPIs(t *testing.T) {
+	strict := testAgentConfig(t, "cfo")
+	strict.CheckDeliverables = true
+	co := newTestCompany(t, config.CompanyConfig{ID: "acme", Agents: []config.AgentConfig{testAgentConfig(t, "ceo"), strict}})
+	for name, agent := range co.Agents {
+		if err := agent.Start(); err != nil {
+			t.Fatalf("start %s: %v", name, err)
+		}
+		t.Cleanup(func() { _ = agent.Stop(context.Background()) })
+	}
+
+	process := func(id, agent string, deliverables ...string) {
+		task := ds.NewTask(id, "t", "d", agent, "boss", ds.TaskStatusAssigned, ds.TaskPriorityMedium)
+		task.Deliverables = deliverables
+		co.GlobalState.AddTask(task)
+		_ = co.Agents[agent].ProcessTask(context.Background(), task)
+	}
+	process("ceo-1", "ceo")
+	process("ceo-2", "ceo")
+	process("cfo-1", "cfo", "预算报告")
+	co.GlobalState.AddTask(ds.NewTask("open", "hire analyst", "d", "cfo", "ceo", ds.TaskStatusPending, ds.TaskPriorityHigh))
+	co.GlobalState.SetKPI("nps", 42.5)
+	for _, text := range []string{"kickoff", "q3 plan", "office move"} {
+		co.GlobalState.AddAnnouncement("ceo", state.SeverityInfo, text)
+	}
+
+	report := co.BuildReport(2)
+	if len(report.Agents) != 2 || report.Agents[0].Name != "ceo" || report.Agents[1].Name != "cfo" {
+		t.Fatalf("agents = %+v, want ceo and cfo", report.Agents)
+	}
+	if ceo := report.Agents[0]; ceo.Executions != 2 || ceo.SuccessRate != 1 || ceo.TasksCompleted != 2 || !ceo.Running {
+		t.Fatalf("ceo summary = %+v, want 2 successful executions", ceo)
+	}
+	if cfo := report.Agents[1]; cfo.TasksFailed != 1 || cfo.TasksOpen != 1 {
+		t.Fatalf("cfo summary = %+v, want 1 failed and 1 open task", cfo)
+	}
+	if report.KPIs["nps"] != 42.5 {
+		t.Fatalf("kpis = %v, want nps 42.5", report.KPIs)
+	}
+	if got := report.Throughput; got.Completed != 2 || got.Failed != 1 || got.Open != 1 {
+		t.Fatalf("throughput = %+v, want 2 completed, 1 failed, 1 open", got)
+	}
+	if len(report.Announcements) != 2 || report.Announcements[1].Text != "office move" {
+		t.Fatalf("announcements = %+v, want the 2 most recent", report.Announcements)
+	}
+	if len(report.OpenItems) != 1 || report.OpenItems[0].TaskID != "open" {
+		t.Fatalf("open items = %+v, want the pending task", report.OpenItems)
+	}
+
+	md := report.Markdown()
+	for _, want := range []string{"# acme 公司报告", "| ceo | true |", "- nps: 42.5", "office move", "- open [pending/high] hire analyst（cfo）"} {
+		if !strings.Contains(md, want) {
+			t.Fatalf("markdown report missing %q:\n%s", want, md)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package company
+
+import (
+	"log/slog"
+	"sort"
+	"time"
+
+	"superman/ds"
+)
+
+// ShutdownReport 公司停止时的运行摘要
+type ShutdownReport struct {
+	Company           string                 `json:"company"`
+	StartedAt         time.Time              `json:"started_at"`
+	StoppedAt         time.Time              `json:"stopped_at"`
+	Uptime            string                 `json:"uptime"`
+	TasksCompleted    int                    `json:"tasks_completed"`
+	TasksFailed       int                    `json:"tasks_failed"`
+	TasksCancelled    int                    `json:"tasks_cancelled"`
+	TasksAbandoned    int                    `json:"tasks_abandoned"` // 停止时仍未结束（排队、已分配或执行中）的任务
+	MessagesProcessed int                    `json:"messages_processed"`
+	Agents            map[string]AgentReport `json:"agents"`
+}
+
+// AgentReport 单个 Agent 的运行摘要
+type AgentReport struct {
+	TasksCompleted    int `json:"tasks_completed"`
+	TasksFailed       int `json:"tasks_failed"`
+	MessagesProcessed int `json:"messages_processed"`
+	Executions        int `json:"executions"`
+}
+
+// Report 根据全局状态与各 Agent 统计汇总运行摘要
+func (c *Company) Report() ShutdownReport {
+	stoppedAt := time.Now()
+	report := ShutdownReport{
+		Company:   c.ID,
+		StartedAt: c.startedAt,
+		StoppedAt: stoppedAt,
+		Agents:    make(map[string]AgentReport, len(c.Agents)),
+	}
+	if !c.startedAt.IsZero() {
+		report.Uptime = stoppedAt.Sub(c.startedAt).Round(time.Second).String()
+	}
+
+	for name, agent := range c.Agents {
+		report.Agents[name] = AgentReport{
+			MessagesProcessed: int(agent.GetState().PerformanceMetrics["messages_processed"]),
+			Executions:        len(agent.GetExecutionHistory()),
+		}
+	}
+
+	for _, task := range c.GlobalState.GetAllTasks() {
+		agentReport, tracked := report.Agents[task.AssignedTo]
+		switch task.Status {
+		case ds.TaskStatusCompleted, ds.TaskStatusCompletedWithWarnings:
+			report.TasksCompleted++
+			agentReport.TasksCompleted++
+		case ds.TaskStatusFailed:
+			report.TasksFailed++
+			agentReport.TasksFailed++
+		case ds.TaskStatusCancelled:
+			report.TasksCancelled++
+		default:
+			report.TasksAbandoned++
+		}
+		if tracked {
+			report.Agents[task.AssignedTo] = agentReport
+		}
+	}
+	for _, agentReport := range report.Agents {
+		report.MessagesProcessed += agentReport.MessagesProcessed
+	}
+	return report
+}
+
+// logReport 将运行摘要写入日志
+func logReport(report ShutdownReport) {
+	names := make([]string, 0, len(report.Agents))
+	for name := range report.Agents {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	slog.Info("shutdown report",
+		slog.String("company", report.Company),
+		slog.String("uptime", report.Uptime),
+		slog.Int("tasks_completed", report.TasksCompleted),
+		slog.Int("tasks_failed", report.TasksFailed),
+		slog.Int("tasks_cancelled", report.TasksCancelled),
+		slog.Int("tasks_abandoned", report.TasksAbandoned),
+		slog.Int("messages_processed", report.MessagesProcessed),
+	)
+	for _, name := range names {
+		agentReport := report.Agents[name]
+		slog.Info("shutdown report agent",
+			slog.String("company", report.Company),
+			slog.String("agent", name),
+			slog.Int("tasks_completed", agentReport.TasksCompleted),
+			slog.Int("tasks_failed", agentReport.TasksFailed),
+			slog.Int("messages_processed", agentReport.MessagesProcessed),
+			slog.Int("executions", agentReport.Executions),
+		)
+	}
+}
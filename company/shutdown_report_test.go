@@ -0,0 +1,50 @@
+package company
+
+import (
+	"context"
+	"testing"
+
+	"superman/config"
+	"superman/ds"
+)
+
+// 处理若干任务后停止公司，运行摘要中的完成、失败与未完成任务数与实际结果一致
+func TestShutdownReportCountsTaskOutcomes(t *testing.T) {
+	strict := testAgentConfig(t, "cfo")
+	strict.CheckDeliverables = true
+	co := newTestCompany(t, config.CompanyConfig{ID: "acme", Agents: []config.AgentConfig{testAgentConfig(t, "ceo"), strict}})
+	if err := co.Start(); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+
+	process := func(id, agent string, deliverables ...string) {
+		task := ds.NewTask(id, "t", "d", agent, "boss", ds.TaskStatusAssigned, ds.TaskPriorityMedium)
+		task.Deliverables = deliverables
+		co.GlobalState.AddTask(task)
+		_ = co.Agents[agent].ProcessTask(context.Background(), task)
+	}
+	process("ceo-1", "ceo")
+	process("ceo-2", "ceo")
+	process("cfo-1", "cfo")
+	// fakeModel 的回复不提及交付物，开启校验的 cfo 将任务判为失败
+	process("cfo-2", "cfo", "预算报告")
+	co.GlobalState.AddTask(ds.NewTask("queued", "t", "d", "", "boss", ds.TaskStatusPending, ds.TaskPriorityLow))
+
+	if got := co.ShutdownReport(); got.Company != "" {
+		t.Fatalf("report before stop = %+v, want zero value", got)
+	}
+	if err := co.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+
+	report := co.ShutdownReport()
+	if report.Company != "acme" || report.TasksCompleted != 3 || report.TasksFailed != 1 || report.TasksAbandoned != 1 || report.Uptime == "" {
+		t.Fatalf("report = %+v, want 3 completed, 1 failed, 1 abandoned", report)
+	}
+	if ceo := report.Agents["ceo"]; ceo.TasksCompleted != 2 || ceo.TasksFailed != 0 || ceo.Executions != 2 {
+		t.Fatalf("ceo report = %+v, want 2 completed executions", ceo)
+	}
+	if cfo := report.Agents["cfo"]; cfo.TasksCompleted != 1 || cfo.TasksFailed != 1 {
+		t.Fatalf("cfo report = %+v, want 1 completed and 1 failed", cfo)
+	}
+}
@@ -0,0 +1,114 @@
+package company
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultTrendSamples 每个 Agent 默认保留的状态采样点数
+const DefaultTrendSamples = 60
+
+// StatusSample 某一时刻 Agent 的负载采样
+type StatusSample struct {
+	Time       time.Time `json:"time"`
+	Workload   float64   `json:"workload"`
+	QueueDepth int       `json:"queue_depth"` // 收件箱中待处理的消息数
+}
+
+// statusTrend 各 Agent 最近若干次状态采样，超出上限时丢弃最早的采样
+type statusTrend struct {
+	mu       sync.RWMutex
+	interval time.Duration
+	max      int
+	samples  map[string][]StatusSample
+	stopCh   chan struct{}
+	wg       sync.WaitGroup
+}
+
+// SetStatusTrend 设置状态采样间隔与每个 Agent 保留的采样点数（max<=0 使用 DefaultTrendSamples），
+// interval<=0 关闭后台采样；需在 Start 前调用
+func (c *Company) SetStatusTrend(interval time.Duration, max int) {
+	c.trend.mu.Lock()
+	defer c.trend.mu.Unlock()
+	c.trend.interval = interval
+	c.trend.max = max
+}
+
+// SampleStatus 采集一次各 Agent 的负载与队列深度
+func (c *Company) SampleStatus(now time.Time) {
+	c.trend.mu.Lock()
+	defer c.trend.mu.Unlock()
+	max := c.trend.max
+	if max <= 0 {
+		max = DefaultTrendSamples
+	}
+	if c.trend.samples == nil {
+		c.trend.samples = make(map[string][]StatusSample)
+	}
+	for name, agent := range c.Agents {
+		sample := StatusSample{Time: now, Workload: agent.GetWorkload()}
+		if mb := agent.GetMailbox(); mb != nil {
+			sample.QueueDepth = mb.GetInboxCount()
+		}
+		samples := append(c.trend.samples[name], sample)
+		if len(samples) > max {
+			samples = append([]StatusSample(nil), samples[len(samples)-max:]...)
+		}
+		c.trend.samples[name] = samples
+	}
+}
+
+// StatusTrend 返回各 Agent 最近 n 个采样点（按时间升序），n<=0 返回全部保留的采样
+func (c *Company) StatusTrend(n int) map[string][]StatusSample {
+	c.trend.mu.RLock()
+	defer c.trend.mu.RUnlock()
+	result := make(map[string][]StatusSample, len(c.trend.samples))
+	for name, samples := range c.trend.samples {
+		if n > 0 && len(samples) > n {
+			samples = samples[len(samples)-n:]
+		}
+		result[name] = append([]StatusSample(nil), samples...)
+	}
+	return result
+}
+
+// startStatusTrend 启动后台采样循环，未配置采样间隔时不启动
+func (c *Company) startStatusTrend() {
+	c.trend.mu.Lock()
+	interval := c.trend.interval
+	if interval <= 0 || c.trend.stopCh != nil {
+		c.trend.mu.Unlock()
+		return
+	}
+	stopCh := make(chan struct{})
+	c.trend.stopCh = stopCh
+	c.trend.mu.Unlock()
+
+	c.trend.wg.Add(1)
+	go func() {
+		defer c.trend.wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		c.SampleStatus(time.Now())
+		for {
+			select {
+			case <-stopCh:
+				return
+			case now := <-ticker.C:
+				c.SampleStatus(now)
+			}
+		}
+	}()
+}
+
+// stopStatusTrend 停止后台采样循环
+func (c *Company) stopStatusTrend() {
+	c.trend.mu.Lock()
+	stopCh := c.trend.stopCh
+	c.trend.stopCh = nil
+	c.trend.mu.Unlock()
+	if stopCh != nil {
+		close(stopCh)
+		c.trend.wg.Wait()
+	}
+}
@@ -0,0 +1,76 @@
+package company
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+
+	"superman/config"
+)
+
+// stopWaves 计算 Agent 的分批停止顺序：依赖方（depends_on 中声明依赖其他 Agent 的一方）先于被依赖方停止，
+// 无依赖约束时层级低（数值大）的先停止；同一批的 Agent 并发停止。存在循环依赖时剩余 Agent 作为最后一批
+func stopWaves(agentConfigs []config.AgentConfig) ([][]string, error) {
+	hierarchy := make(map[string]int, len(agentConfigs))
+	for _, ac := range agentConfigs {
+		hierarchy[ac.Name] = ac.GetHierarchy()
+	}
+
+	// dependents[x] 为依赖 x 的 Agent 中尚未停止的数量
+	dependents := make(map[string]int, len(agentConfigs))
+	dependencies := make(map[string][]string, len(agentConfigs))
+	for _, ac := range agentConfigs {
+		for _, dep := range ac.DependsOn {
+			if _, ok := hierarchy[dep]; !ok {
+				return nil, fmt.Errorf("agent %s: depends_on references unknown agent %s", ac.Name, dep)
+			}
+			if dep == ac.Name {
+				continue
+			}
+			dependencies[ac.Name] = append(dependencies[ac.Name], dep)
+			dependents[dep]++
+		}
+	}
+
+	remaining := make(map[string]bool, len(agentConfigs))
+	for name := range hierarchy {
+		remaining[name] = true
+	}
+
+	var waves [][]string
+	for len(remaining) > 0 {
+		// 可停止的 Agent：所有依赖它的 Agent 都已停止
+		deepest := -1
+		for name := range remaining {
+			if dependents[name] == 0 && hierarchy[name] > deepest {
+				deepest = hierarchy[name]
+			}
+		}
+
+		var wave []string
+		for name := range remaining {
+			if dependents[name] == 0 && hierarchy[name] == deepest {
+				wave = append(wave, name)
+			}
+		}
+		if len(wave) == 0 {
+			for name := range remaining {
+				wave = append(wave, name)
+			}
+			sort.Strings(wave)
+			slog.Warn("cyclic depends_on among agents, stopping them together", slog.Any("agents", wave))
+			waves = append(waves, wave)
+			break
+		}
+
+		sort.Strings(wave)
+		for _, name := range wave {
+			delete(remaining, name)
+			for _, dep := range dependencies[name] {
+				dependents[dep]--
+			}
+		}
+		waves = append(waves, wave)
+	}
+	return waves, nil
+}
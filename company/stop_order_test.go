@@ -0,0 +1,32 @@
+package company
+
+import (
+	"reflect"
+	"testing"
+
+	"superman/config"
+)
+
+// 声明的停止依赖优先于层级：依赖方先于被依赖方停止，其余 Agent 按层级由低到高停止
+func TestStopWavesStopDependentFirst(t *testing.T) {
+	level := func(h int) *int { return &h }
+	waves, err := stopWaves([]config.AgentConfig{
+		{Name: "ceo", Hierarchy: level(0)},
+		{Name: "cto", Hierarchy: level(1), DependsOn: []string{"auditor"}},
+		{Name: "cfo", Hierarchy: level(1)},
+		{Name: "auditor", Hierarchy: level(3)},
+		{Name: "analyst", Hierarchy: level(3)},
+	})
+	if err != nil {
+		t.Fatalf("stopWaves: %v", err)
+	}
+	// 没有 depends_on 时 auditor 会与 analyst 一起最先停止
+	want := [][]string{{"analyst"}, {"cfo", "cto"}, {"auditor"}, {"ceo"}}
+	if !reflect.DeepEqual(waves, want) {
+		t.Fatalf("stop waves = %v, want %v", waves, want)
+	}
+
+	if _, err := stopWaves([]config.AgentConfig{{Name: "cto", DependsOn: []string{"ghost"}}}); err == nil {
+		t.Fatal("stopWaves accepted depends_on referencing an unknown agent")
+	}
+}
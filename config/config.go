@@ -1,17 +1,58 @@
 package config
 
 import (
+	"fmt"
+	"log/slog"
 	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
 
 type Config struct {
-	LLM       []LLMConfig      `yaml:"llm"`
-	DB        *DBConfig        `yaml:"db"`
-	Agents    []AgentConfig    `yaml:"agents"`
-	Scheduler *SchedulerConfig `yaml:"scheduler"`
-	Timer     *TimerConfig     `yaml:"timer"`
+	LLM        []LLMConfig       `yaml:"llm"`
+	DB         *DBConfig         `yaml:"db"`
+	Agents     []AgentConfig     `yaml:"agents"`
+	Scheduler  *SchedulerConfig  `yaml:"scheduler"`
+	Timer      *TimerConfig      `yaml:"timer"`
+	EventLog   *EventLogConfig   `yaml:"event_log"`
+	DeadLetter *DeadLetterConfig `yaml:"dead_letter"`
+	Companies  []CompanyConfig   `yaml:"companies"` // 额外的公司（租户），顶层 agents/scheduler/timer 构成默认公司
+
+	MaxConcurrentTaskGen int `yaml:"max_concurrent_task_gen"` // 全系统（所有公司与 Agent）同时进行的任务生成数上限，0 不限制
+
+	ShutdownTimeout string `yaml:"shutdown_timeout"` // 优雅停止的最长等待时间，超时后强制退出，如 "30s"，默认 "30s"
+	ReloadInterval  string `yaml:"reload_interval"`  // 配置文件变更检查间隔，如 "10s"，变更后在线应用 max_tasks 等可热更新配置，为空不检查
+	ShutdownReport  string `yaml:"shutdown_report"`  // 停止时将各公司的运行摘要（任务完成/失败/未完成数、消息数、各 Agent 统计、运行时长）以 JSON 写入该文件，为空只写日志
+
+	ShutdownHookTimeout string `yaml:"shutdown_hook_timeout"` // 单个停止钩子（持久化刷新、报告写入等）的最长执行时间，如 "10s"，默认 "10s"
+}
+
+// DefaultCompanyID 默认公司（租户）ID
+const DefaultCompanyID = "default"
+
+// CompanyConfig 公司（租户）配置，每个公司拥有独立的消息总线、全局状态与调度器
+type CompanyConfig struct {
+	ID         string            `yaml:"id"`
+	Agents     []AgentConfig     `yaml:"agents"`
+	Scheduler  *SchedulerConfig  `yaml:"scheduler"`
+	Timer      *TimerConfig      `yaml:"timer"`
+	EventLog   *EventLogConfig   `yaml:"event_log"`
+	DeadLetter *DeadLetterConfig `yaml:"dead_letter"`
+
+	PersistResults bool `yaml:"persist_results"` // 将任务结果（最终回复与结构化结果）写入数据库，重启后仍可查询
+
+	PersistMemory     bool `yaml:"persist_memory"`      // 停止时将各 Agent 的绩效指标与最近执行历史写入数据库，启动时按 Agent 名称恢复
+	MemoryHistorySize int  `yaml:"memory_history_size"` // 每个 Agent 保存的最近执行历史条数，默认 100
+
+	SelfMessagePolicy string `yaml:"self_message_policy"` // Agent 给自己发消息时的处理：reject（默认，返回错误）、drop（丢弃）、allow（照常投递）
+
+	StatusSampleInterval string `yaml:"status_sample_interval"` // Agent 负载与队列深度的采样间隔，如 "30s"，供 /status?trend=N 返回趋势，为空不采样
+	StatusTrendSamples   int    `yaml:"status_trend_samples"`   // 每个 Agent 保留的采样点数，默认 60
+
+	LoadDivergenceWarn string `yaml:"load_divergence_warn"` // 调度器负载计数与 Agent 实际在途任务数持续不一致超过该时长时记录告警，如 "2m"，默认 "2m"
 }
 
 type LLMConfig struct {
@@ -25,24 +66,203 @@ type DBConfig struct {
 }
 
 type AgentConfig struct {
-	Name            string  `yaml:"name"`
-	Desc            string  `yaml:"desc"`
-	Model           string  `yaml:"model"`
-	Temperature     float64 `yaml:"temperature"`
-	Hierarchy       int     `yaml:"hierarchy"`
-	SkillDir        string  `yaml:"skill_dir"`
-	TaskGenInterval string  `yaml:"task_gen_interval"` // 任务生成间隔，如 "30m"，默认 "30m"
-	MaxTasks        int     `yaml:"max_tasks"`         // 最大并发任务数，默认 3
+	Name                 string   `yaml:"name"`
+	Desc                 string   `yaml:"desc"`
+	Model                string   `yaml:"model"`
+	FallbackModel        string   `yaml:"fallback_model"` // 主模型不可用（网络错误、限流、5xx）时改用的备用模型，为空不启用
+	Temperature          float64  `yaml:"temperature"`
+	Role                 string   `yaml:"role"`      // 角色，如 ceo、cto、rd，用于推导默认层级
+	Hierarchy            *int     `yaml:"hierarchy"` // 层级，0 为最高层；未配置时使用角色默认层级，显式配置的 0 不会被覆盖
+	SkillDir             string   `yaml:"skill_dir"`
+	TaskGenInterval      string   `yaml:"task_gen_interval"`       // 任务生成间隔，如 "30m"，默认 "30m"
+	TaskGenInitialDelay  string   `yaml:"task_gen_initial_delay"`  // 启动后首次任务生成前的等待时间，如 "1m"，默认 "10s"
+	MaxTasks             int      `yaml:"max_tasks"`               // 最大并发任务数，默认 3
+	MessageConcurrency   int      `yaml:"message_concurrency"`     // 同时处理的非任务消息数上限，与 max_tasks 相互独立，任务占满时仍能响应消息，默认 1
+	QuickWorkers         int      `yaml:"quick_workers"`           // 处理无需运行模型的消息（请求、响应、通知、任务完成）的工作协程数，使状态查询不被耗时消息阻塞，默认 2
+	MessageRateLimit     float64  `yaml:"message_rate_limit"`      // send message 工具每分钟可发送的消息数（每个接收者计一条），超出时工具返回错误，0 不限制
+	MessageBurst         int      `yaml:"message_burst"`           // 短时间内可连续发送的消息数，默认等于 message_rate_limit
+	TaskGenJitter        float64  `yaml:"task_gen_jitter"`         // 任务生成间隔抖动比例，如 0.1 表示 ±10%，默认 0
+	TaskGenReformatRetry bool     `yaml:"task_gen_reformat_retry"` // 任务生成输出无法解析时，携带解析错误重新提示模型一次
+	TaskGenCacheTTL      string   `yaml:"task_gen_cache_ttl"`      // 任务生成结果缓存有效期，提示词未变化时复用上次输出，如 "1h"，为空不缓存
+	MaxTasksPerGen       int      `yaml:"max_tasks_per_gen"`       // 每轮任务生成的最大任务数，超出部分丢弃，默认 3
+	TaskGenDedup         float64  `yaml:"task_gen_dedup"`          // 生成任务与本 Agent 未结束任务的相似度阈值（0-1，标题归一化后相同视为 1），达到时跳过并累加已有任务的 duplicate_count，0 不去重
+	TaskGenWatchKeys     []string `yaml:"task_gen_watch_keys"`     // 全局状态（KPI、系统健康度）中这些键变化时立即触发一轮任务生成
+	TaskGenFields        []string `yaml:"task_gen_fields"`         // 任务生成输出中额外要求的字段：deliverables、dependencies、deadline、tags、required_capabilities，校验后写入任务
+	NamespaceSkills      bool     `yaml:"namespace_skills"`        // 技能目录按 Agent 名称隔离，实际目录为 <skill_dir>/<name>
+	SystemPrompt         string   `yaml:"system_prompt"`           // Agent 系统提示词（语气、约束、输出格式等），为空时根据 desc 生成
+	ResponseFormat       string   `yaml:"response_format"`         // 任务回复格式：text（默认）、json（要求输出合法 JSON，解析结果写入任务元数据 result）
+	SelfReflection       bool     `yaml:"self_reflection"`         // 任务完成后让模型对产出质量自评（1-5 分及理由），写入执行历史与任务元数据 self_rating，会额外消耗一次模型调用
+	CheckDeliverables    bool     `yaml:"check_deliverables"`      // 任务完成前校验交付物（JSON 结果含对应键或回复中提及），未满足时任务以 deliverables_not_met 失败；任务元数据 check_deliverables 可覆盖
+	OrderedDelivery      bool     `yaml:"ordered_delivery"`        // 按发送者保序：同一发送者的消息按发送顺序编号（seq）、依次进入收件箱并依次处理，不同发送者之间按到达顺序并发处理
+	MessageDedupWindow   string   `yaml:"message_dedup_window"`    // 收件箱消息去重窗口，如 "5m"，为空不去重
+	MaxMessageBodySize   int      `yaml:"max_message_body_size"`   // 入站消息体最大字节数，默认 65536
+	OversizePolicy       string   `yaml:"oversize_policy"`         // 入站消息体超限时的处理：reject（默认，拒收）、truncate（截断文本内容并在元数据中记录）
+	RequestTimeout       string   `yaml:"request_timeout"`         // 由模型处理的请求消息的处理超时，如 "2m"，超时后向请求方回复 success=false 的响应，为空不限制
+	LLMTimeout           string   `yaml:"llm_timeout"`             // 单次模型运行（消息处理、任务执行）超时，如 "5m"，超时后取消运行并记录，任务以 llm_timeout 失败，为空不限制
+	ToolTimeout          string   `yaml:"tool_timeout"`            // 单次工具调用超时，如 "30s"，超时后取消该调用并告知模型，任务继续执行，为空不限制
+	MaxResponseSize      int      `yaml:"max_response_size"`       // 模型单次输出最大字节数，超出部分截断并追加标记，默认 262144
+	MaxHistoryQuery      int      `yaml:"max_history_query"`       // 单次查询执行历史返回的最大条数，超出时只返回最近的记录，默认 500
+	DeadLetterRejected   bool     `yaml:"dead_letter_rejected"`    // 被拒收的入站消息放入死信队列
+	AllowedSenders       []string `yaml:"allowed_senders"`         // 只接收这些发送者的消息（scheduler、system 等系统发送者除外），为空接收所有发送者
+	DeniedSenders        []string `yaml:"denied_senders"`          // 拒收这些发送者的消息，优先于 allowed_senders
+	RestartOnCrash       bool     `yaml:"restart_on_crash"`        // 后台循环崩溃（panic）后自动重启
+	MaxRestarts          int      `yaml:"max_restarts"`            // 每个后台循环的最大重启次数，默认 3
+	RestartBackoff       string   `yaml:"restart_backoff"`         // 首次重启前的等待时间，之后每次翻倍，默认 "1s"
+	HeartbeatTimeout     string   `yaml:"heartbeat_timeout"`       // 开启 restart_on_crash 时，消息循环超过该时间没有心跳视为失活并重新启动，默认 "30s"
+	NotifyCompletion     bool     `yaml:"notify_completion"`       // 任务完成后向委派者（assigned_by）发送任务完成消息
+	MaxDelegationDepth   int      `yaml:"max_delegation_depth"`    // 委派链最大深度（直接委派为 1），超出时拒绝委派，默认 3
+	AlertToTask          bool     `yaml:"alert_to_task"`           // 收到 high/critical 通知时自动创建分配给自己的高优先级处置任务，否则只记录日志
+	AvailableHours       string   `yaml:"available_hours"`         // 每日可接任务时间段，如 "09:00-18:00"，跨夜如 "22:00-06:00"，为空全天可用
+	Timezone             string   `yaml:"timezone"`                // available_hours 使用的时区，如 "Asia/Shanghai"，默认本地时区
+	Capabilities         []string `yaml:"capabilities"`            // Agent 具备的能力，用于匹配任务的 required_capabilities，与角色默认能力合并
+	DrainOnStop          string   `yaml:"drain_on_stop"`           // 停止时收件箱剩余消息的处理方式：process（处理完再停止）、archive（归档），为空直接丢弃
+	DrainTimeout         string   `yaml:"drain_timeout"`           // process 模式的最长等待时间，默认 "30s"
+	MaxArchive           int      `yaml:"max_archive"`             // 信箱归档保留的最大消息数，超出时丢弃最早的消息，默认 1000，-1 不归档（内存受限部署）
+	DependsOn            []string `yaml:"depends_on"`              // 停止顺序依赖：本 Agent 依赖（如向其汇报）的 Agent，停止时本 Agent 先于它们停止；未声明时按层级由低到高停止
+}
+
+// GetMaxTasks 返回 Agent 最大并发任务数，未配置时默认 3
+func (c AgentConfig) GetMaxTasks() int {
+	if c.MaxTasks <= 0 {
+		return 3
+	}
+	return c.MaxTasks
+}
+
+// GetMaxTasksPerGen 返回每轮任务生成的最大任务数，未配置时默认 3
+func (c AgentConfig) GetMaxTasksPerGen() int {
+	if c.MaxTasksPerGen <= 0 {
+		return 3
+	}
+	return c.MaxTasksPerGen
+}
+
+// GetMaxRestarts 返回后台循环最大重启次数，未配置时默认 3
+func (c AgentConfig) GetMaxRestarts() int {
+	if c.MaxRestarts <= 0 {
+		return 3
+	}
+	return c.MaxRestarts
+}
+
+// GetMaxMessageBodySize 返回入站消息体最大字节数，未配置时默认 64KB
+func (c AgentConfig) GetMaxMessageBodySize() int {
+	if c.MaxMessageBodySize <= 0 {
+		return 64 * 1024
+	}
+	return c.MaxMessageBodySize
+}
+
+// GetMaxResponseSize 返回模型单次输出最大字节数，未配置时默认 256KB
+func (c AgentConfig) GetMaxResponseSize() int {
+	if c.MaxResponseSize <= 0 {
+		return 256 * 1024
+	}
+	return c.MaxResponseSize
+}
+
+// GetQuickWorkers 返回快速通道工作协程数，未配置时默认 2
+func (c AgentConfig) GetQuickWorkers() int {
+	if c.QuickWorkers <= 0 {
+		return 2
+	}
+	return c.QuickWorkers
+}
+
+// GetMessageConcurrency 返回同时处理的非任务消息数上限，未配置时默认 1
+func (c AgentConfig) GetMessageConcurrency() int {
+	if c.MessageConcurrency <= 0 {
+		return 1
+	}
+	return c.MessageConcurrency
+}
+
+// GetMaxDelegationDepth 返回委派链最大深度，未配置时默认 3
+func (c AgentConfig) GetMaxDelegationDepth() int {
+	if c.MaxDelegationDepth <= 0 {
+		return 3
+	}
+	return c.MaxDelegationDepth
+}
+
+// GetMaxHistoryQuery 返回单次查询执行历史的最大条数，未配置时默认 500
+func (c AgentConfig) GetMaxHistoryQuery() int {
+	if c.MaxHistoryQuery <= 0 {
+		return 500
+	}
+	return c.MaxHistoryQuery
 }
 
 // SchedulerConfig 调度器配置
 type SchedulerConfig struct {
 	TickInterval string `yaml:"tick_interval"` // 调度轮询间隔，如 "5s"，默认 "5s"
+	MaxIdleTick  string `yaml:"max_idle_tick"` // 队列为空时轮询间隔逐轮翻倍的上限，如 "1m"，有任务入队时恢复 tick_interval，为空不退避
+	LeaseEnabled bool   `yaml:"lease_enabled"` // 启用数据库任务租约，多实例共享数据库时避免重复分发
+	LeaseTTL     string `yaml:"lease_ttl"`     // 租约有效期，如 "10m"，默认 "10m"
+	InstanceID   string `yaml:"instance_id"`   // 调度器实例 ID，默认 主机名-进程号
+	Estimator    string `yaml:"estimator"`     // 任务工作量估算方式：constant（默认）、metadata（读取任务元数据 effort）
+
+	NoCapablePolicy string `yaml:"no_capable_policy"` // 无可胜任 Agent 时的策略：wait（默认）、fail、fallback、escalate
+	NoCapableWait   string `yaml:"no_capable_wait"`   // 执行策略前的等待时间，如 "10m"，默认 "10m"
+	FallbackAgent   string `yaml:"fallback_agent"`    // fallback 策略的目标 Agent
+
+	PriorityCaps map[string]int `yaml:"priority_caps"` // 各优先级同时在途任务数上限，如 {Low: 2}，未配置的优先级不限制
+	MaxInFlight  int            `yaml:"max_in_flight"` // 全系统同时在途任务数上限（如受模型配额限制），与 Agent 并发上限同时生效，0 不限制
+
+	Policy string `yaml:"policy"` // Agent 选择策略：least_loaded（默认）、weighted_random（按负载反比加权随机）
+	Seed   int64  `yaml:"seed"`   // weighted_random 的随机种子，用于复现选择序列，0 表示使用当前时间
+
+	DecayAfter  string `yaml:"decay_after"`   // 自动生成任务排队超过该时长后优先级降一级，之后每经过该时长再降一级，如 "2h"，为空不衰减
+	DecayMaxAge string `yaml:"decay_max_age"` // 自动生成任务排队超过该时长后取消，如 "24h"，为空不取消
+
+	TaskSources []TaskSourceConfig `yaml:"task_sources"` // 外部任务来源，其任务直接进入调度队列
+
+	DuplicatePolicy  string `yaml:"duplicate_policy"`  // 重复添加排队中的同 ID 任务时的处理：skip（默认，忽略）、update（替换排队中的任务）；已分发的任务始终忽略
+	DependencyPolicy string `yaml:"dependency_policy"` // 依赖任务取消或失败后下游任务的处理：wait（默认，保持排队）、cancel（级联取消全部下游任务）、proceed（移除该依赖继续执行），任务元数据 dependency_policy 可覆盖
+
+	UnknownAssigneePolicy string `yaml:"unknown_assignee_policy"` // 任务指定的执行者不存在时的处理：reroute（默认，清除指定执行者后按能力与负载重新选择）、fail（以 unknown_assignee 失败）
+
+	TimeoutEscalations int     `yaml:"timeout_escalations"` // 模型调用超时的任务改派给更高层级或更大容量 Agent 的最多次数，0（默认）直接失败
+	TimeoutFactor      float64 `yaml:"timeout_factor"`      // 每次升级时超时时间的倍数，默认 2
+
+	PressureThreshold  float64 `yaml:"pressure_threshold"`   // 调度压力（(排队 + 在途任务数) / Agent 总容量）告警阈值，如 1.5，0 不告警
+	PressureSustain    string  `yaml:"pressure_sustain"`     // 压力持续不低于阈值多久后告警，如 "10m"，默认 "5m"
+	PressureAlertAgent string  `yaml:"pressure_alert_agent"` // 接收压力告警的 Agent，默认层级最高的 Agent
+
+	TaskGenBacklogLimit int `yaml:"task_gen_backlog_limit"` // 调度队列排队任务数超过该值时暂停自驱任务生成，回落后自动恢复，0 不限制
+}
+
+// TaskSourceConfig 外部任务来源配置（HTTP 拉取）
+type TaskSourceConfig struct {
+	Name     string `yaml:"name"`     // 来源名称，写入任务元数据 source=http:<name>
+	URL      string `yaml:"url"`      // 拉取地址，GET 返回任务 JSON 数组
+	Interval string `yaml:"interval"` // 拉取间隔，如 "1m"，默认 "30s"
+}
+
+// EventLogConfig 事件日志配置
+type EventLogConfig struct {
+	MaxSize     int  `yaml:"max_size"`    // 内存中保留的最大事件数，默认 10000
+	Persist     bool `yaml:"persist"`     // 同时将事件写入数据库
+	Completions bool `yaml:"completions"` // 任务结束时追加 task.completed 事件（含耗时、执行者与结果）
+}
+
+// DeadLetterConfig 死信队列配置
+type DeadLetterConfig struct {
+	MaxSize        int    `yaml:"max_size"`        // 保留的最大死信数，超出时淘汰最旧的，默认 1000
+	Retention      string `yaml:"retention"`       // 死信保留期，如 "24h"，为空不按时间淘汰
+	AlertThreshold int    `yaml:"alert_threshold"` // 死信积压达到该数量时告警，0 不告警
+	AlertAgent     string `yaml:"alert_agent"`     // 接收告警通知的 Agent
+	Persist        bool   `yaml:"persist"`         // 将死信写入数据库，重启后恢复
+
+	RetryInterval string `yaml:"retry_interval"` // 自动重新投递到期死信的扫描间隔，如 "1m"，为空不自动重新投递；开启后收件箱已满的消息也进入死信队列
+	RetryBackoff  string `yaml:"retry_backoff"`  // 首次重新投递前的等待时间，之后每次失败翻倍（最长 1h），默认等于 retry_interval
+	MaxAttempts   int    `yaml:"max_attempts"`   // 最多重新投递次数，用尽后移入永久失败队列并通知 alert_agent，默认 5
 }
 
 // TimerConfig 定时器配置
 type TimerConfig struct {
 	Enabled bool       `yaml:"enabled"`
+	Jitter  float64    `yaml:"jitter"` // 任务间隔抖动比例，如 0.1 表示 ±10%，默认 0
 	Jobs    []TimerJob `yaml:"jobs"`
 }
 
@@ -54,20 +274,115 @@ type TimerJob struct {
 	Task        TimerTaskConfig `yaml:"task"`
 }
 
-// TimerTaskConfig 定时任务模板
+// TimerTaskConfig 定时任务模板，标题与描述支持 Go 模板变量，如 {{.Date}}、{{.AgentLoad}}（见 timer.TemplateData）
 type TimerTaskConfig struct {
 	Title       string `yaml:"title"`
 	Description string `yaml:"description"`
 	Priority    string `yaml:"priority"` // Critical, High, Medium, Low
+	Type        string `yaml:"type"`     // analysis, decision, implementation, report
 }
 
 var AppConfig Config
 
-func InitConfig() error {
-	data, err := os.ReadFile("config.yaml")
+// GetCompanies 返回所有公司配置，默认公司（由顶层配置构成）排在首位
+func (c *Config) GetCompanies() []CompanyConfig {
+	companies := []CompanyConfig{{
+		ID:         DefaultCompanyID,
+		Agents:     c.Agents,
+		Scheduler:  c.Scheduler,
+		Timer:      c.Timer,
+		EventLog:   c.EventLog,
+		DeadLetter: c.DeadLetter,
+	}}
+	return append(companies, c.Companies...)
+}
+
+// GetShutdownTimeout 返回优雅停止的最长等待时间，未配置或非法时默认 30s
+func (c *Config) GetShutdownTimeout() time.Duration {
+	if d, err := time.ParseDuration(c.ShutdownTimeout); err == nil && d > 0 {
+		return d
+	}
+	return 30 * time.Second
+}
+
+// GetShutdownHookTimeout 返回单个停止钩子的最长执行时间，未配置或非法时默认 10s
+func (c *Config) GetShutdownHookTimeout() time.Duration {
+	if d, err := time.ParseDuration(c.ShutdownHookTimeout); err == nil && d > 0 {
+		return d
+	}
+	return 10 * time.Second
+}
+
+// DefaultConfigFile 默认基础配置文件
+const DefaultConfigFile = "config.yaml"
+
+// InitConfig 加载配置文件到 AppConfig，未指定文件时使用 config.yaml 及 APP_ENV 对应的覆盖文件
+func InitConfig(files ...string) error {
+	if len(files) == 0 {
+		files = ConfigFiles(DefaultConfigFile)
+	}
+	c, err := LoadConfig(files...)
 	if err != nil {
 		return err
 	}
-	err = yaml.Unmarshal(data, &AppConfig)
-	return err
+	AppConfig = *c
+	return AppConfig.Validate()
+}
+
+// ConfigFiles 返回基础配置文件及 APP_ENV 对应的覆盖文件（如 config.prod.yaml，存在时才加入）
+func ConfigFiles(base string) []string {
+	files := []string{base}
+	env := os.Getenv("APP_ENV")
+	if env == "" {
+		return files
+	}
+	ext := filepath.Ext(base)
+	override := strings.TrimSuffix(base, ext) + "." + env + ext
+	if _, err := os.Stat(override); err != nil {
+		slog.Warn("config override file not found, skipping",
+			slog.String("env", env),
+			slog.String("file", override),
+		)
+		return files
+	}
+	return append(files, override)
+}
+
+// LoadConfig 依次加载配置文件并合并，后面文件中的键覆盖前面的同名键（列表整体替换）
+func LoadConfig(files ...string) (*Config, error) {
+	merged := make(map[string]any)
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		var layer map[string]any
+		if err := yaml.Unmarshal(data, &layer); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", file, err)
+		}
+		mergeMaps(merged, layer)
+	}
+
+	data, err := yaml.Marshal(merged)
+	if err != nil {
+		return nil, err
+	}
+	var c Config
+	if err := yaml.Unmarshal(data, &c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// mergeMaps 将 src 深度合并到 dst
+func mergeMaps(dst, src map[string]any) {
+	for k, v := range src {
+		srcMap, srcIsMap := v.(map[string]any)
+		dstMap, dstIsMap := dst[k].(map[string]any)
+		if srcIsMap && dstIsMap {
+			mergeMaps(dstMap, srcMap)
+			continue
+		}
+		dst[k] = v
+	}
 }
@@ -0,0 +1,52 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeConfig 在临时目录写入配置文件并返回路径
+func writeConfig(t *testing.T, dir, name, data string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatalf("write %s: %v", name, err)
+	}
+	return path
+}
+
+// 覆盖文件只改变其中出现的键，其余配置沿用基础文件
+func TestLoadConfigOverrideInheritsOtherKeys(t *testing.T) {
+	dir := t.TempDir()
+	base := writeConfig(t, dir, "config.yaml", `shutdown_timeout: 45s
+scheduler:
+  policy: weighted_random
+  max_in_flight: 3
+agents:
+  - name: ceo
+    desc: chief executive
+`)
+	writeConfig(t, dir, "config.prod.yaml", `scheduler:
+  max_in_flight: 8
+`)
+	t.Setenv("APP_ENV", "prod")
+
+	files := ConfigFiles(base)
+	if len(files) != 2 {
+		t.Fatalf("ConfigFiles = %v, want the base and prod override", files)
+	}
+	c, err := LoadConfig(files...)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if c.Scheduler == nil || c.Scheduler.MaxInFlight != 8 {
+		t.Fatalf("scheduler = %+v, want max_in_flight overridden to 8", c.Scheduler)
+	}
+	if c.Scheduler.Policy != "weighted_random" || c.ShutdownTimeout != "45s" {
+		t.Fatalf("policy = %q, shutdown_timeout = %q, want values inherited from the base file", c.Scheduler.Policy, c.ShutdownTimeout)
+	}
+	if len(c.Agents) != 1 || c.Agents[0].Name != "ceo" {
+		t.Fatalf("agents = %+v, want the base file's agents", c.Agents)
+	}
+}
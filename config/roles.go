@@ -0,0 +1,69 @@
+package config
+
+import (
+	"slices"
+	"strings"
+)
+
+// roleHierarchies 角色默认层级（数值越小层级越高）
+var roleHierarchies = map[string]int{
+	"chairman":         0,
+	"ceo":              1,
+	"cto":              2,
+	"cpo":              2,
+	"cmo":              2,
+	"cfo":              2,
+	"hr":               2,
+	"rd":               3,
+	"data_analyst":     3,
+	"customer_support": 3,
+	"operations":       3,
+}
+
+// roleCapabilities 角色默认具备的能力，与配置的 capabilities 合并
+var roleCapabilities = map[string][]string{
+	"chairman":         {"strategy", "governance"},
+	"ceo":              {"strategy", "management", "decision"},
+	"cto":              {"architecture", "security", "engineering"},
+	"cpo":              {"product", "roadmap", "user_research"},
+	"cmo":              {"marketing", "brand", "growth"},
+	"cfo":              {"finance", "budget", "accounting"},
+	"hr":               {"recruiting", "people", "training"},
+	"rd":               {"engineering", "coding", "testing"},
+	"data_analyst":     {"data_analysis", "reporting", "sql"},
+	"customer_support": {"customer_support", "feedback"},
+	"operations":       {"operations", "monitoring", "process"},
+}
+
+// RoleCapabilities 返回角色默认具备的能力，未知角色返回 nil
+func RoleCapabilities(role string) []string {
+	return slices.Clone(roleCapabilities[strings.ToLower(role)])
+}
+
+// RoleHierarchy 返回角色的默认层级，未知角色返回 false
+func RoleHierarchy(role string) (int, bool) {
+	h, ok := roleHierarchies[strings.ToLower(role)]
+	return h, ok
+}
+
+// GetHierarchy 返回 Agent 层级：显式配置时（包括 0）使用配置值，否则使用角色默认层级，均无时为 0
+func (c AgentConfig) GetHierarchy() int {
+	if c.Hierarchy != nil {
+		return *c.Hierarchy
+	}
+	if h, ok := RoleHierarchy(c.Role); ok {
+		return h
+	}
+	return 0
+}
+
+// GetCapabilities 返回 Agent 具备的能力：角色默认能力在前，合并配置的 capabilities（去重）
+func (c AgentConfig) GetCapabilities() []string {
+	capabilities := RoleCapabilities(c.Role)
+	for _, capability := range c.Capabilities {
+		if !slices.Contains(capabilities, capability) {
+			capabilities = append(capabilities, capability)
+		}
+	}
+	return capabilities
+}
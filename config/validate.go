@@ -0,0 +1,91 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"sort"
+)
+
+// Validate 校验配置，非法配置返回错误，对可能造成冲突但不致命的配置输出告警
+func (c *Config) Validate() error {
+	for _, company := range c.GetCompanies() {
+		if err := validateHierarchies(company); err != nil {
+			return err
+		}
+		for _, agent := range company.Agents {
+			switch agent.ResponseFormat {
+			case "", "text", "json":
+			default:
+				return fmt.Errorf("agent %s: invalid response_format %q, expected text or json", agent.Name, agent.ResponseFormat)
+			}
+		}
+		for dir, names := range DuplicateSkillDirs(company.Agents) {
+			slog.Warn("multiple agents share the same skill directory, consider namespace_skills",
+				slog.String("company", company.ID),
+				slog.String("skill_dir", dir),
+				slog.Any("agents", names),
+			)
+		}
+	}
+	return nil
+}
+
+// ResolvedSkillDir 返回 Agent 实际使用的技能目录，开启 NamespaceSkills 时为 <skill_dir>/<name>
+func (c AgentConfig) ResolvedSkillDir() string {
+	if c.NamespaceSkills {
+		return filepath.Join(c.SkillDir, c.Name)
+	}
+	return c.SkillDir
+}
+
+// DuplicateSkillDirs 找出被多个 Agent 共用的技能目录，返回 目录 -> Agent 名称列表
+func DuplicateSkillDirs(agents []AgentConfig) map[string][]string {
+	byDir := make(map[string][]string)
+	for _, agent := range agents {
+		dir := agent.ResolvedSkillDir()
+		if dir == "" {
+			continue
+		}
+		dir = filepath.Clean(dir)
+		byDir[dir] = append(byDir[dir], agent.Name)
+	}
+
+	duplicates := make(map[string][]string)
+	for dir, names := range byDir {
+		if len(names) > 1 {
+			sort.Strings(names)
+			duplicates[dir] = names
+		}
+	}
+	return duplicates
+}
+
+// validateHierarchies 校验层级非负，并对未知角色、多个最高层（0）Agent 输出告警
+func validateHierarchies(company CompanyConfig) error {
+	var topLevel []string
+	for _, agent := range company.Agents {
+		if agent.Hierarchy != nil && *agent.Hierarchy < 0 {
+			return fmt.Errorf("company %s: agent %s has negative hierarchy %d", company.ID, agent.Name, *agent.Hierarchy)
+		}
+		if agent.Role != "" {
+			if _, ok := RoleHierarchy(agent.Role); !ok {
+				slog.Warn("unknown agent role, no default hierarchy applied",
+					slog.String("company", company.ID),
+					slog.String("agent", agent.Name),
+					slog.String("role", agent.Role),
+				)
+			}
+		}
+		if agent.GetHierarchy() == 0 {
+			topLevel = append(topLevel, agent.Name)
+		}
+	}
+	if len(topLevel) > 1 {
+		slog.Warn("multiple top-level (hierarchy 0) agents",
+			slog.String("company", company.ID),
+			slog.Any("agents", topLevel),
+		)
+	}
+	return nil
+}
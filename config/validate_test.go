@@ -0,0 +1,68 @@
+package config
+
+import (
+	"slices"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// 负数层级在配置校验时被拒绝
+func TestValidateRejectsNegativeHierarchy(t *testing.T) {
+	var c Config
+	if err := yaml.Unmarshal([]byte("agents:\n  - name: intern\n    hierarchy: -1\n"), &c); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Validate(); err == nil {
+		t.Fatal("Validate accepted a negative hierarchy")
+	}
+}
+
+// 显式配置的层级（包括 0）优先于角色默认层级，未配置时使用角色默认层级
+func TestGetHierarchy(t *testing.T) {
+	var c Config
+	data := "agents:\n  - name: founder\n    role: rd\n    hierarchy: 0\n  - name: dev\n    role: rd\n  - name: temp\n"
+	if err := yaml.Unmarshal([]byte(data), &c); err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range []int{0, 3, 0} {
+		if got := c.Agents[i].GetHierarchy(); got != want {
+			t.Errorf("%s: GetHierarchy = %d, want %d", c.Agents[i].Name, got, want)
+		}
+	}
+}
+
+// 多个 Agent 共用同一技能目录时被标记，按 Agent 名称隔离后不再重复
+func TestDuplicateSkillDirs(t *testing.T) {
+	agents := []AgentConfig{
+		{Name: "cto", SkillDir: "skills/shared"},
+		{Name: "cfo", SkillDir: "skills/shared/"},
+		{Name: "hr", SkillDir: "skills/hr"},
+		{Name: "ops", SkillDir: "skills/shared", NamespaceSkills: true},
+	}
+	dups := DuplicateSkillDirs(agents)
+	if len(dups) != 1 {
+		t.Fatalf("duplicates = %v, want only skills/shared", dups)
+	}
+	if names := dups["skills/shared"]; len(names) != 2 || names[0] != "cfo" || names[1] != "cto" {
+		t.Fatalf("agents sharing skills/shared = %v, want [cfo cto]", names)
+	}
+}
+
+// CFO 默认具备财务能力，配置的 capabilities 在角色默认能力之后追加且不重复；未知角色只使用配置的能力
+func TestGetCapabilitiesMergesRoleDefaults(t *testing.T) {
+	var c Config
+	data := "agents:\n  - name: cfo\n    role: CFO\n  - name: controller\n    role: cfo\n    capabilities: [budget, tax]\n  - name: temp\n    capabilities: [filing]\n"
+	if err := yaml.Unmarshal([]byte(data), &c); err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range [][]string{
+		{"finance", "budget", "accounting"},
+		{"finance", "budget", "accounting", "tax"},
+		{"filing"},
+	} {
+		if got := c.Agents[i].GetCapabilities(); !slices.Equal(got, want) {
+			t.Errorf("%s: GetCapabilities = %v, want %v", c.Agents[i].Name, got, want)
+		}
+	}
+}
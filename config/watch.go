@@ -0,0 +1,65 @@
+package config
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"time"
+)
+
+// Watch 按 interval 轮询配置文件的修改时间，任一文件变化时重新加载并校验配置，成功后调用 onChange；
+// 加载或校验失败时保留当前配置并记录日志。ctx 取消时返回
+func Watch(ctx context.Context, files []string, interval time.Duration, onChange func(c *Config)) {
+	if len(files) == 0 {
+		files = ConfigFiles(DefaultConfigFile)
+	}
+	last := modTimes(files)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		current := modTimes(files)
+		if sameModTimes(last, current) {
+			continue
+		}
+		last = current
+
+		c, err := LoadConfig(files...)
+		if err == nil {
+			err = c.Validate()
+		}
+		if err != nil {
+			slog.Error("config reload failed, keeping current config", slog.Any("error", err))
+			continue
+		}
+		slog.Info("config reloaded", slog.Any("files", files))
+		onChange(c)
+	}
+}
+
+// modTimes 返回各文件的修改时间，文件不存在时为零值
+func modTimes(files []string) []time.Time {
+	times := make([]time.Time, len(files))
+	for i, file := range files {
+		if info, err := os.Stat(file); err == nil {
+			times[i] = info.ModTime()
+		}
+	}
+	return times
+}
+
+// sameModTimes 比较两组修改时间是否一致
+func sameModTimes(a, b []time.Time) bool {
+	for i := range a {
+		if !a[i].Equal(b[i]) {
+			return false
+		}
+	}
+	return true
+}
@@ -2,7 +2,9 @@ package ds
 
 import (
 	"encoding/json"
+	"fmt"
 	"superman/utils"
+	"unicode/utf8"
 )
 
 // MessageType 消息类型
@@ -21,6 +23,25 @@ const (
 	MessageTypeSystem         MessageType = "system"          // 系统消息
 )
 
+// knownMessageTypes 合法的消息类型
+var knownMessageTypes = map[MessageType]bool{
+	MessageTypeTaskCreate:     true,
+	MessageTypeTaskUpdate:     true,
+	MessageTypeTaskComplete:   true,
+	MessageTypeTaskAssign:     true,
+	MessageTypeTaskQuery:      true,
+	MessageTypeTaskDependency: true,
+	MessageTypeRequest:        true,
+	MessageTypeResponse:       true,
+	MessageTypeNotification:   true,
+	MessageTypeSystem:         true,
+}
+
+// IsValid 检查消息类型是否合法
+func (t MessageType) IsValid() bool {
+	return knownMessageTypes[t]
+}
+
 // MessageBody 消息体接口
 type MessageBody interface{}
 
@@ -31,6 +52,8 @@ type TaskCreateBody struct {
 	Description  string         `json:"description"`
 	AssignedTo   string         `json:"assigned_to"`
 	AssignedBy   string         `json:"assigned_by"`
+	Priority     TaskPriority   `json:"priority,omitempty"`
+	Type         TaskType       `json:"type,omitempty"`
 	Dependencies []string       `json:"dependencies"`
 	Deliverables []string       `json:"deliverables"`
 	Deadline     *string        `json:"deadline,omitempty"`
@@ -90,6 +113,7 @@ type Message struct {
 	Receiver string      `json:"receiver"`
 	Type     MessageType `json:"type"`
 	Body     any         `json:"body"`
+	Seq      uint64      `json:"seq,omitempty"` // 同一发送者发往同一接收者的消息序号（接收方开启按发送者保序时由 MailboxBus 填写）
 }
 
 // NewMessage 创建新的消息（通用）
@@ -192,6 +216,44 @@ func (m *Message) UnmarshalBody(v any) error {
 	return json.Unmarshal(m.Body.(json.RawMessage), v)
 }
 
+// DecodeBody 将 json.RawMessage 消息体（如从持久化存储恢复的消息）按消息类型解析为对应的消息体类型，
+// 其余类型的消息体解析为通用结构（字符串消息体仍为 string）
+func (m *Message) DecodeBody() error {
+	raw, ok := m.Body.(json.RawMessage)
+	if !ok {
+		return nil
+	}
+	var body any
+	switch m.Type {
+	case MessageTypeTaskCreate:
+		body = &TaskCreateBody{}
+	case MessageTypeTaskUpdate:
+		body = &TaskUpdateBody{}
+	case MessageTypeTaskComplete:
+		body = &TaskCompleteBody{}
+	case MessageTypeTaskAssign:
+		body = &TaskAssignBody{}
+	case MessageTypeRequest:
+		body = &RequestBody{}
+	case MessageTypeResponse:
+		body = &ResponseBody{}
+	case MessageTypeNotification:
+		body = &NotificationBody{}
+	default:
+		var generic any
+		if err := json.Unmarshal(raw, &generic); err != nil {
+			return err
+		}
+		m.Body = generic
+		return nil
+	}
+	if err := json.Unmarshal(raw, body); err != nil {
+		return fmt.Errorf("message %s: decode %s body: %w", m.ID, m.Type, err)
+	}
+	m.Body = body
+	return nil
+}
+
 // GetTaskCreateBody 获取任务创建消息体
 func (m *Message) GetTaskCreateBody() (*TaskCreateBody, bool) {
 	if body, ok := m.Body.(*TaskCreateBody); ok {
@@ -253,3 +315,182 @@ func (m *Message) GetNotificationBody() (*NotificationBody, bool) {
 	}
 	return nil, false
 }
+
+// BodySize 计算消息体大小（字节），字符串按长度计算，其余按 JSON 序列化后的长度计算
+func (m *Message) BodySize() int {
+	switch body := m.Body.(type) {
+	case nil:
+		return 0
+	case string:
+		return len(body)
+	case json.RawMessage:
+		return len(body)
+	}
+	data, err := json.Marshal(m.Body)
+	if err != nil {
+		return 0
+	}
+	return len(data)
+}
+
+// Copy 深拷贝消息，消息体中的元数据、列表与嵌套的 map 同样复制，副本可在原消息被修改时安全读取
+func (m *Message) Copy() *Message {
+	msgCopy := *m
+	switch body := m.Body.(type) {
+	case *TaskCreateBody:
+		b := *body
+		b.Dependencies = copyStrings(body.Dependencies)
+		b.Deliverables = copyStrings(body.Deliverables)
+		if body.Deadline != nil {
+			deadline := *body.Deadline
+			b.Deadline = &deadline
+		}
+		b.Metadata = copyMap(body.Metadata)
+		msgCopy.Body = &b
+	case *TaskUpdateBody:
+		b := *body
+		b.OldValue = copyValue(body.OldValue)
+		b.NewValue = copyValue(body.NewValue)
+		b.Metadata = copyMap(body.Metadata)
+		msgCopy.Body = &b
+	case *TaskCompleteBody:
+		b := *body
+		b.Metadata = copyMap(body.Metadata)
+		msgCopy.Body = &b
+	case *TaskAssignBody:
+		b := *body
+		msgCopy.Body = &b
+	case *RequestBody:
+		b := *body
+		b.Content = copyValue(body.Content)
+		b.Metadata = copyMap(body.Metadata)
+		msgCopy.Body = &b
+	case *ResponseBody:
+		b := *body
+		b.Content = copyValue(body.Content)
+		msgCopy.Body = &b
+	case *NotificationBody:
+		b := *body
+		msgCopy.Body = &b
+	default:
+		msgCopy.Body = copyValue(m.Body)
+	}
+	return &msgCopy
+}
+
+// copyValue 深拷贝 JSON 形态的值（map、列表、原始 JSON），其他值原样返回
+func copyValue(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		return copyMap(v)
+	case []any:
+		result := make([]any, len(v))
+		for i, item := range v {
+			result[i] = copyValue(item)
+		}
+		return result
+	case []string:
+		return copyStrings(v)
+	case json.RawMessage:
+		return append(json.RawMessage(nil), v...)
+	}
+	return v
+}
+
+// copyMap 深拷贝 map，nil 保持为 nil
+func copyMap(m map[string]any) map[string]any {
+	if m == nil {
+		return nil
+	}
+	result := make(map[string]any, len(m))
+	for k, v := range m {
+		result[k] = copyValue(v)
+	}
+	return result
+}
+
+// copyStrings 复制字符串列表，nil 保持为 nil
+func copyStrings(s []string) []string {
+	if s == nil {
+		return nil
+	}
+	return append([]string(nil), s...)
+}
+
+// Validate 校验消息：发送者非空、类型合法、消息体不超过 maxBodySize（<=0 不限制）
+func (m *Message) Validate(maxBodySize int) error {
+	if m.Sender == "" {
+		return fmt.Errorf("message %s has empty sender", m.ID)
+	}
+	if !m.Type.IsValid() {
+		return fmt.Errorf("message %s has unknown type %q", m.ID, m.Type)
+	}
+	if maxBodySize > 0 {
+		if size := m.BodySize(); size > maxBodySize {
+			return fmt.Errorf("message %s body size %d exceeds limit %d", m.ID, size, maxBodySize)
+		}
+	}
+	return nil
+}
+
+// 消息截断时写入请求元数据的键
+const (
+	MetadataTruncated    = "truncated"     // 内容是否被截断
+	MetadataOriginalSize = "original_size" // 截断前的消息体字节数
+)
+
+// truncationMarker 截断内容末尾追加的标记
+const truncationMarker = "...[truncated]"
+
+// TruncateBody 将超过 maxBodySize 的消息体截断到限制以内：字符串消息体、请求与通知的文本内容可截断，
+// 请求消息在元数据中记录截断信息。无法截断（结构化内容）或截断后仍超限时返回 false
+func (m *Message) TruncateBody(maxBodySize int) bool {
+	size := m.BodySize()
+	if maxBodySize <= 0 || size <= maxBodySize {
+		return true
+	}
+	var text string
+	var setText func(string)
+	switch body := m.Body.(type) {
+	case string:
+		text, setText = body, func(s string) { m.Body = s }
+	case *RequestBody:
+		content, ok := body.Content.(string)
+		if !ok {
+			return false
+		}
+		if body.Metadata == nil {
+			body.Metadata = make(map[string]any)
+		}
+		body.Metadata[MetadataTruncated] = true
+		body.Metadata[MetadataOriginalSize] = size
+		text, setText = content, func(s string) { body.Content = s }
+	case *NotificationBody:
+		text, setText = body.Content, func(s string) { body.Content = s }
+	default:
+		return false
+	}
+
+	// 截断标记、元数据与 JSON 转义都会改变消息体大小，按截断后仍超出的字节数继续截断
+	drop := 0
+	for over := m.BodySize() - maxBodySize; over > 0; over = m.BodySize() - maxBodySize {
+		if drop >= len(text) {
+			return false
+		}
+		drop += over
+		setText(truncateContent(text, drop))
+	}
+	return true
+}
+
+// truncateContent 去掉文本末尾 drop 个字节（不切断多字节字符）并追加截断标记
+func truncateContent(s string, drop int) string {
+	keep := len(s) - drop
+	if keep < 0 {
+		keep = 0
+	}
+	for keep > 0 && !utf8.RuneStart(s[keep]) {
+		keep--
+	}
+	return s[:keep] + truncationMarker
+}
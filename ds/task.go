@@ -15,6 +15,8 @@ const (
 	TaskStatusCompleted  TaskStatus = "completed"  // 已完成
 	TaskStatusFailed     TaskStatus = "failed"     // 失败
 	TaskStatusCancelled  TaskStatus = "cancelled"  // 已取消
+
+	TaskStatusCompletedWithWarnings TaskStatus = "completed_with_warnings" // 已完成但存在告警
 )
 
 // TaskPriority 任务优先级
@@ -27,6 +29,28 @@ const (
 	TaskPriorityLow      TaskPriority = "low"      // 低
 )
 
+// MetadataParentTaskID 子任务元数据中记录父任务 ID 的键，子任务完成后执行者计入父任务的贡献者
+const MetadataParentTaskID = "parent_task_id"
+
+// MetadataDelegationDepth 委派任务元数据中记录委派链深度的键，直接委派的任务为 1
+const MetadataDelegationDepth = "delegation_depth"
+
+// MetadataSourceMessageID 由消息触发创建的任务元数据中记录来源消息 ID 的键
+const MetadataSourceMessageID = "source_message_id"
+
+// MetadataLLMTimeout 任务元数据中覆盖执行 Agent 模型调用超时的键（time.ParseDuration 格式），任务因超时失败时记录实际生效的超时
+const MetadataLLMTimeout = "llm_timeout"
+
+// TaskType 任务类型
+type TaskType string
+
+const (
+	TaskTypeAnalysis       TaskType = "analysis"       // 分析
+	TaskTypeDecision       TaskType = "decision"       // 决策
+	TaskTypeImplementation TaskType = "implementation" // 实施
+	TaskTypeReport         TaskType = "report"         // 报告
+)
+
 // Task 代表一个任务
 type Task struct {
 	ID           string         `json:"id" gorm:"primaryKey"`
@@ -36,19 +60,53 @@ type Task struct {
 	AssignedBy   string         `json:"assigned_by"`
 	Status       TaskStatus     `json:"status"`
 	Priority     TaskPriority   `json:"priority"`
+	Type         TaskType       `json:"type,omitempty"`
 	Dependencies []string       `json:"dependencies"`
 	Deliverables []string       `json:"deliverables"`
 	Deadline     *time.Time     `json:"deadline,omitempty"`
 	CreatedAt    time.Time      `json:"created_at"`
 	UpdatedAt    time.Time      `json:"updated_at"`
 	Metadata     map[string]any `json:"metadata,omitempty"`
+	Effort       float64        `json:"effort,omitempty"`       // 预估工作量（1 表示一个标准任务）
+	Comments     []TaskComment  `json:"comments,omitempty"`     // 评论记录（按时间顺序）
+	Warnings     []string       `json:"warnings,omitempty"`     // 执行中标记的告警，存在时任务以 completed_with_warnings 结束
+	Contributors []string       `json:"contributors,omitempty"` // 对任务结果有贡献的 Agent（执行者及完成子任务的 Agent）
+	Tags         []string       `json:"tags,omitempty"`         // 任务标签，用于分类查询
+
+	RequiredCapabilities []string         `json:"required_capabilities,omitempty"` // 执行任务所需的 Agent 能力
+	PriorityHistory      []PriorityChange `json:"priority_history,omitempty"`      // 优先级变更记录（按时间顺序）
+
+	StartedAt   *time.Time    `json:"started_at,omitempty"`   // 最近一次开始执行（运行模型）的时间
+	ExecTimeout time.Duration `json:"exec_timeout,omitempty"` // 本次执行生效的超时，0 不限制
+}
+
+// 优先级变更原因
+const (
+	PriorityChangeInheritance = "inheritance" // 被高优先级任务依赖而继承
+	PriorityChangeManual      = "manual"      // 人工调整
+	PriorityChangeDecay       = "decay"       // 自动生成任务排队过久自动降低
+)
+
+// PriorityChange 任务优先级变更记录
+type PriorityChange struct {
+	From      string    `json:"from"`
+	To        string    `json:"to"`
+	Reason    string    `json:"reason"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// TaskComment 任务评论
+type TaskComment struct {
+	Author    string    `json:"author"`
+	Body      string    `json:"body"`
+	Timestamp time.Time `json:"timestamp"`
 }
 
 // NewTask 创建新任务
 func NewTask(taskID, title, description, assignedTo, assignedBy string, status TaskStatus, priority TaskPriority) *Task {
 	now := time.Now()
 	return &Task{
-		ID:       taskID,
+		ID:           taskID,
 		Title:        title,
 		Description:  description,
 		AssignedTo:   assignedTo,
@@ -143,6 +201,55 @@ func (t *Task) SetMetadata(metadata map[string]any) {
 	t.UpdatedAt = time.Now()
 }
 
+// AddComment 添加评论，未设置时间时使用当前时间
+func (t *Task) AddComment(comment TaskComment) {
+	if comment.Timestamp.IsZero() {
+		comment.Timestamp = time.Now()
+	}
+	t.Comments = append(t.Comments, comment)
+	t.UpdatedAt = time.Now()
+}
+
+// AddContributor 添加贡献者（去重）
+func (t *Task) AddContributor(agent string) {
+	for _, c := range t.Contributors {
+		if c == agent {
+			return
+		}
+	}
+	t.Contributors = append(t.Contributors, agent)
+	t.UpdatedAt = time.Now()
+}
+
+// ParentTaskID 返回父任务 ID，非子任务返回空字符串
+func (t *Task) ParentTaskID() string {
+	parentID, _ := t.Metadata[MetadataParentTaskID].(string)
+	return parentID
+}
+
+// SourceMessageID 返回触发创建该任务的消息 ID，非消息触发的任务返回空字符串
+func (t *Task) SourceMessageID() string {
+	msgID, _ := t.Metadata[MetadataSourceMessageID].(string)
+	return msgID
+}
+
+// DelegationDepth 返回任务所处的委派链深度，非委派任务返回 0
+func (t *Task) DelegationDepth() int {
+	switch depth := t.Metadata[MetadataDelegationDepth].(type) {
+	case int:
+		return depth
+	case float64: // 经 JSON 持久化后为 float64
+		return int(depth)
+	}
+	return 0
+}
+
+// AddWarning 添加告警
+func (t *Task) AddWarning(warning string) {
+	t.Warnings = append(t.Warnings, warning)
+	t.UpdatedAt = time.Now()
+}
+
 // SetStatus 设置状态
 func (t *Task) SetStatus(status TaskStatus) {
 	t.Status = status
@@ -155,6 +262,16 @@ func (t *Task) SetPriority(priority TaskPriority) {
 	t.UpdatedAt = time.Now()
 }
 
+// RecordPriorityChange 追加一条优先级变更记录
+func (t *Task) RecordPriorityChange(from, to, reason string) {
+	t.PriorityHistory = append(t.PriorityHistory, PriorityChange{
+		From:      from,
+		To:        to,
+		Reason:    reason,
+		Timestamp: time.Now(),
+	})
+}
+
 // Copy 创建任务副本
 func (t *Task) Copy() *Task {
 	metadataCopy := make(map[string]any)
@@ -166,32 +283,81 @@ func (t *Task) Copy() *Task {
 	deliverablesCopy := make([]string, len(t.Deliverables))
 	copy(deliverablesCopy, t.Deliverables)
 
+	var commentsCopy []TaskComment
+	if t.Comments != nil {
+		commentsCopy = make([]TaskComment, len(t.Comments))
+		copy(commentsCopy, t.Comments)
+	}
+
+	var warningsCopy []string
+	if t.Warnings != nil {
+		warningsCopy = make([]string, len(t.Warnings))
+		copy(warningsCopy, t.Warnings)
+	}
+
+	var contributorsCopy []string
+	if t.Contributors != nil {
+		contributorsCopy = make([]string, len(t.Contributors))
+		copy(contributorsCopy, t.Contributors)
+	}
+
 	var deadlineCopy *time.Time
 	if t.Deadline != nil {
 		deadlineCopy = &time.Time{}
 		*deadlineCopy = *t.Deadline
 	}
 
+	var startedAtCopy *time.Time
+	if t.StartedAt != nil {
+		startedAt := *t.StartedAt
+		startedAtCopy = &startedAt
+	}
+
 	return &Task{
-		ID:       t.ID,
+		ID:           t.ID,
 		Title:        t.Title,
 		Description:  t.Description,
 		AssignedTo:   t.AssignedTo,
 		AssignedBy:   t.AssignedBy,
 		Status:       t.Status,
 		Priority:     t.Priority,
+		Type:         t.Type,
 		Dependencies: dependenciesCopy,
 		Deliverables: deliverablesCopy,
 		Deadline:     deadlineCopy,
 		CreatedAt:    t.CreatedAt,
 		UpdatedAt:    t.UpdatedAt,
 		Metadata:     metadataCopy,
+		Effort:       t.Effort,
+		Comments:     commentsCopy,
+		Warnings:     warningsCopy,
+		Contributors: contributorsCopy,
+		Tags:         append([]string(nil), t.Tags...),
+
+		RequiredCapabilities: append([]string(nil), t.RequiredCapabilities...),
+		PriorityHistory:      append([]PriorityChange(nil), t.PriorityHistory...),
+
+		StartedAt:   startedAtCopy,
+		ExecTimeout: t.ExecTimeout,
+	}
+}
+
+// RemainingTime 返回执行中任务距超时的剩余时间（已超时为 0）；未开始执行、未设置超时或已结束时返回 false
+func (t *Task) RemainingTime(now time.Time) (time.Duration, bool) {
+	if t.StartedAt == nil || t.ExecTimeout <= 0 || t.IsCompleted() {
+		return 0, false
 	}
+	return max(t.StartedAt.Add(t.ExecTimeout).Sub(now), 0), true
 }
 
 // IsCompleted 检查任务是否完成
 func (t *Task) IsCompleted() bool {
-	return t.Status == TaskStatusCompleted || t.Status == TaskStatusFailed || t.Status == TaskStatusCancelled
+	return t.IsSucceeded() || t.Status == TaskStatusFailed || t.Status == TaskStatusCancelled
+}
+
+// IsSucceeded 检查任务是否成功完成（含带告警完成）
+func (t *Task) IsSucceeded() bool {
+	return t.Status == TaskStatusCompleted || t.Status == TaskStatusCompletedWithWarnings
 }
 
 // IsPending 检查任务是否待处理
@@ -8,6 +8,9 @@ import (
 	"github.com/cloudwego/eino/components/model"
 )
 
+// LLMProvider 当前接入的模型服务商
+const LLMProvider = "qwen"
+
 func NewLLM(ctx context.Context, c *config.LLMConfig) (model.ToolCallingChatModel, error) {
 	model, err := qwen.NewChatModel(ctx, &qwen.ChatModelConfig{
 		Model:   c.Model,
@@ -0,0 +1,145 @@
+package infra
+
+import (
+	"context"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+)
+
+// 模型健康探测参数
+const (
+	DefaultModelHealthTTL = 30 * time.Second // 探测结果缓存时间
+	modelProbeTimeout     = 10 * time.Second // 单个模型探测超时
+)
+
+// ModelInfo 已配置模型的信息（不含密钥）
+type ModelInfo struct {
+	Name     string `json:"name"`
+	Provider string `json:"provider"`
+	BaseURL  string `json:"base_url"`
+}
+
+// ModelStatus 模型信息及最近一次健康探测结果
+type ModelStatus struct {
+	ModelInfo
+	Reachable bool      `json:"reachable"`
+	Error     string    `json:"error,omitempty"`
+	LatencyMs int64     `json:"latency_ms"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+type modelEntry struct {
+	info ModelInfo
+	llm  model.BaseChatModel
+}
+
+// ModelHealth 模型健康探测：向每个模型发起一次极小的生成请求，结果缓存一段时间
+type ModelHealth struct {
+	ttl     time.Duration
+	probeMu sync.Mutex // 同一时间只进行一轮探测
+
+	mu        sync.RWMutex
+	models    []modelEntry
+	cache     []ModelStatus
+	checkedAt time.Time
+}
+
+// NewModelHealth 创建模型健康探测，ttl <= 0 时使用默认缓存时间
+func NewModelHealth(ttl time.Duration) *ModelHealth {
+	if ttl <= 0 {
+		ttl = DefaultModelHealthTTL
+	}
+	return &ModelHealth{ttl: ttl}
+}
+
+// Register 注册需要探测的模型，baseURL 中的用户信息会被移除
+func (h *ModelHealth) Register(name, provider, baseURL string, llm model.BaseChatModel) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.models = append(h.models, modelEntry{
+		info: ModelInfo{Name: name, Provider: provider, BaseURL: redactURL(baseURL)},
+		llm:  llm,
+	})
+	sort.Slice(h.models, func(i, j int) bool { return h.models[i].info.Name < h.models[j].info.Name })
+	h.cache = nil
+}
+
+// Check 获取各模型的健康状态，缓存过期时并发探测所有模型。
+// 探测结果由所有请求共享，探测不随 ctx 取消（如发起请求的客户端断开），只受单个模型的探测超时限制
+func (h *ModelHealth) Check(ctx context.Context) []ModelStatus {
+	if cached, ok := h.cached(); ok {
+		return cached
+	}
+
+	h.probeMu.Lock()
+	defer h.probeMu.Unlock()
+	// 等待期间其他请求可能已完成探测
+	if cached, ok := h.cached(); ok {
+		return cached
+	}
+
+	h.mu.RLock()
+	models := append([]modelEntry(nil), h.models...)
+	h.mu.RUnlock()
+
+	probeCtx := context.WithoutCancel(ctx)
+	result := make([]ModelStatus, len(models))
+	var wg sync.WaitGroup
+	for i, m := range models {
+		wg.Add(1)
+		go func(i int, m modelEntry) {
+			defer wg.Done()
+			result[i] = probeModel(probeCtx, m)
+		}(i, m)
+	}
+	wg.Wait()
+
+	h.mu.Lock()
+	h.cache = result
+	h.checkedAt = time.Now()
+	h.mu.Unlock()
+	return append([]ModelStatus(nil), result...)
+}
+
+// cached 获取未过期的探测结果
+func (h *ModelHealth) cached() ([]ModelStatus, bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if h.cache == nil || time.Since(h.checkedAt) > h.ttl {
+		return nil, false
+	}
+	return append([]ModelStatus(nil), h.cache...), true
+}
+
+// probeModel 向模型发起一次极小的生成请求，超过 modelProbeTimeout 视为不可达
+func probeModel(ctx context.Context, m modelEntry) ModelStatus {
+	ctx, cancel := context.WithTimeout(ctx, modelProbeTimeout)
+	defer cancel()
+
+	status := ModelStatus{ModelInfo: m.info}
+	start := time.Now()
+	_, err := m.llm.Generate(ctx, []*schema.Message{schema.UserMessage("ping")}, model.WithMaxTokens(1))
+	status.LatencyMs = time.Since(start).Milliseconds()
+	status.CheckedAt = time.Now()
+	if err != nil {
+		status.Error = err.Error()
+		return status
+	}
+	status.Reachable = true
+	return status
+}
+
+// redactURL 移除 URL 中的用户信息，无法解析时原样返回
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.User == nil {
+		return raw
+	}
+	u.User = nil
+	return u.String()
+}
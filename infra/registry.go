@@ -3,31 +3,55 @@ package infra
 import (
 	"context"
 	"superman/config"
+	"superman/persistence"
+	"superman/utils"
 
 	"github.com/cloudwego/eino/components/model"
 	"gorm.io/gorm"
 )
 
 type Registry struct {
-	DB  *gorm.DB
-	LLM map[string]model.ToolCallingChatModel
+	DB          *gorm.DB
+	Persistence *persistence.Persistence
+	LLM         map[string]model.ToolCallingChatModel
+	ModelHealth *ModelHealth
+
+	// TaskGenLimiter 全系统任务生成并发限制，nil 不限制
+	TaskGenLimiter *utils.Semaphore
+
+	// ShutdownHooks 停止时按顺序执行的清理钩子
+	ShutdownHooks *ShutdownHooks
 }
 
 func NewRegistry(ctx context.Context, c *config.Config) (*Registry, error) {
 	r := &Registry{
-		LLM: make(map[string]model.ToolCallingChatModel),
+		LLM:            make(map[string]model.ToolCallingChatModel),
+		ModelHealth:    NewModelHealth(DefaultModelHealthTTL),
+		TaskGenLimiter: utils.NewSemaphore(c.MaxConcurrentTaskGen),
+		ShutdownHooks:  NewShutdownHooks(c.GetShutdownHookTimeout()),
 	}
 	db, err := NewDB(ctx, c.DB)
 	if err != nil {
 		return nil, err
 	}
 	r.DB = db
+	p, err := persistence.NewPersistence(db)
+	if err != nil {
+		return nil, err
+	}
+	r.Persistence = p
 	for _, llmConfig := range c.LLM {
 		llm, err := NewLLM(ctx, &llmConfig)
 		if err != nil {
 			return nil, err
 		}
 		r.LLM[llmConfig.Model] = llm
+		r.ModelHealth.Register(llmConfig.Model, LLMProvider, llmConfig.BaseURL, llm)
 	}
 	return r, nil
 }
+
+// RegisterShutdownHook 注册停止钩子，停止时在所有公司停止后按 order 升序依次执行，每个钩子有独立超时
+func (r *Registry) RegisterShutdownHook(name string, fn ShutdownHookFunc, order int) {
+	r.ShutdownHooks.Register(name, fn, order)
+}
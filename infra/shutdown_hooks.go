@@ -0,0 +1,102 @@
+package infra
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultShutdownHookTimeout 单个停止钩子的默认超时
+const DefaultShutdownHookTimeout = 10 * time.Second
+
+// ShutdownHookFunc 停止钩子，ctx 在钩子超时或整体停止超时时取消
+type ShutdownHookFunc func(ctx context.Context) error
+
+// shutdownHook 已注册的停止钩子
+type shutdownHook struct {
+	name  string
+	fn    ShutdownHookFunc
+	order int
+	seq   int // 注册顺序，order 相同时先注册先执行
+}
+
+// ShutdownHooks 停止钩子注册表：停止时按 order 升序依次执行，单个钩子失败、超时或 panic 不影响后续钩子
+type ShutdownHooks struct {
+	mu      sync.Mutex
+	hooks   []shutdownHook
+	timeout time.Duration
+}
+
+// NewShutdownHooks 创建停止钩子注册表，timeout<=0 时使用 DefaultShutdownHookTimeout
+func NewShutdownHooks(timeout time.Duration) *ShutdownHooks {
+	if timeout <= 0 {
+		timeout = DefaultShutdownHookTimeout
+	}
+	return &ShutdownHooks{timeout: timeout}
+}
+
+// Register 注册停止钩子，order 越小越先执行
+func (h *ShutdownHooks) Register(name string, fn ShutdownHookFunc, order int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.hooks = append(h.hooks, shutdownHook{name: name, fn: fn, order: order, seq: len(h.hooks)})
+}
+
+// Run 按顺序执行全部钩子（只执行一次，重复调用直接返回），返回各钩子错误的合并
+func (h *ShutdownHooks) Run(ctx context.Context) error {
+	h.mu.Lock()
+	hooks := h.hooks
+	h.hooks = nil
+	h.mu.Unlock()
+	sort.SliceStable(hooks, func(i, j int) bool {
+		if hooks[i].order != hooks[j].order {
+			return hooks[i].order < hooks[j].order
+		}
+		return hooks[i].seq < hooks[j].seq
+	})
+
+	var errs []error
+	for _, hook := range hooks {
+		start := time.Now()
+		if err := h.run(ctx, hook); err != nil {
+			slog.Error("shutdown hook failed",
+				slog.String("hook", hook.name),
+				slog.Duration("elapsed", time.Since(start)),
+				slog.Any("error", err),
+			)
+			errs = append(errs, fmt.Errorf("shutdown hook %s: %w", hook.name, err))
+			continue
+		}
+		slog.Info("shutdown hook completed",
+			slog.String("hook", hook.name),
+			slog.Duration("elapsed", time.Since(start)),
+		)
+	}
+	return errors.Join(errs...)
+}
+
+// run 在超时内执行单个钩子；钩子不响应取消时按时返回，钩子在后台继续运行
+func (h *ShutdownHooks) run(ctx context.Context, hook shutdownHook) error {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- fmt.Errorf("panic: %v", r)
+			}
+		}()
+		done <- hook.fn(ctx)
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,44 @@
+package infra
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+// 钩子按 order 与注册顺序执行，失败、panic 与超时不影响后续钩子，重复 Run 不再执行
+func TestShutdownHooksRunOnceInOrder(t *testing.T) {
+	r := &Registry{ShutdownHooks: NewShutdownHooks(50 * time.Millisecond)}
+	var ran []string
+	record := func(name string, err error) ShutdownHookFunc {
+		return func(context.Context) error {
+			ran = append(ran, name)
+			return err
+		}
+	}
+	r.RegisterShutdownHook("flush", record("flush", nil), 1)
+	r.RegisterShutdownHook("report", record("report", errors.New("disk full")), 0)
+	r.RegisterShutdownHook("panic", func(context.Context) error { panic("boom") }, 0)
+	r.RegisterShutdownHook("slow", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}, 0)
+	r.RegisterShutdownHook("close", record("close", nil), 1)
+
+	err := r.ShutdownHooks.Run(context.Background())
+	if err == nil {
+		t.Fatal("Run returned nil, want the joined hook errors")
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Run error %v does not include the timed out hook", err)
+	}
+	if want := []string{"report", "flush", "close"}; !slices.Equal(ran, want) {
+		t.Fatalf("ran %v, want %v", ran, want)
+	}
+
+	if err := r.ShutdownHooks.Run(context.Background()); err != nil || len(ran) != 3 {
+		t.Fatalf("second Run = %v, ran %v; want no hooks run again", err, ran)
+	}
+}
@@ -0,0 +1,341 @@
+package mailbox
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+
+	"superman/ds"
+	"superman/utils"
+)
+
+// 死信类型
+const (
+	DeadLetterKindMessage = "message" // 无法投递或被拒收的消息
+	DeadLetterKindTask    = "task"    // 无法分发而失败的任务
+)
+
+// DeadLetter 死信：无法投递或被拒收的消息，或无法分发的任务
+type DeadLetter struct {
+	ID        string      `json:"id"`
+	Kind      string      `json:"kind"`
+	Message   *ds.Message `json:"message,omitempty"`
+	Task      *ds.Task    `json:"task,omitempty"`
+	Priority  string      `json:"priority,omitempty"` // 任务死信的队列优先级，重新投递时沿用
+	Reason    string      `json:"reason"`
+	CreatedAt time.Time   `json:"created_at"`
+
+	// 自动重新投递记录
+	Attempts    int        `json:"attempts,omitempty"`     // 已自动重新投递的次数
+	NextAttempt *time.Time `json:"next_attempt,omitempty"` // 下次重新投递时间，为空时为产生时间加首次退避时间
+}
+
+// DeadLetterStore 死信持久化存储
+type DeadLetterStore interface {
+	SaveDeadLetter(dl *DeadLetter) error
+	DeleteDeadLetter(id string) error
+	LoadDeadLetters() ([]*DeadLetter, error)
+}
+
+// DeadLetterStats 死信统计
+type DeadLetterStats struct {
+	Current int   `json:"current"` // 当前保留的死信数
+	Total   int64 `json:"total"`   // 累计产生的死信数
+	Expired int64 `json:"expired"` // 因超出保留期或容量被淘汰的死信数
+}
+
+// DeadLetterQueue 死信队列（有界，超出时淘汰最旧的死信；可选按保留期淘汰）
+type DeadLetterQueue struct {
+	mu      sync.RWMutex
+	items   []*DeadLetter
+	maxSize int
+	maxAge  time.Duration // 死信保留期，0 表示不按时间淘汰
+	total   int64
+	expired int64
+
+	store   DeadLetterStore // 持久化存储（可选）
+	storeMu sync.Mutex      // 按加锁顺序串行写入存储，写入时不持有 mu
+
+	// 积压告警：死信数达到阈值时触发一次，回落到阈值以下后重新生效
+	alertThreshold int
+	onAlert        func(count int)
+	alerted        bool
+}
+
+// NewDeadLetterQueue 创建死信队列
+func NewDeadLetterQueue(maxSize int) *DeadLetterQueue {
+	if maxSize <= 0 {
+		maxSize = 1000
+	}
+	return &DeadLetterQueue{
+		items:   make([]*DeadLetter, 0),
+		maxSize: maxSize,
+	}
+}
+
+// SetRetention 设置死信保留策略：最大数量（<=0 时不变）与保留期（0 表示不按时间淘汰）
+func (q *DeadLetterQueue) SetRetention(maxSize int, maxAge time.Duration) {
+	var w storeWrites
+	q.mu.Lock()
+	if maxSize > 0 {
+		q.maxSize = maxSize
+	}
+	q.maxAge = maxAge
+	q.prune(time.Now(), &w)
+	q.unlockAndWrite(&w)
+}
+
+// SetAlert 设置积压告警：死信数达到 threshold 时调用 fn，threshold <= 0 关闭告警
+func (q *DeadLetterQueue) SetAlert(threshold int, fn func(count int)) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.alertThreshold = threshold
+	q.onAlert = fn
+	q.alerted = false
+}
+
+// SetStore 设置持久化存储并恢复其中保存的死信
+func (q *DeadLetterQueue) SetStore(store DeadLetterStore) error {
+	items, err := store.LoadDeadLetters()
+	if err != nil {
+		return err
+	}
+
+	var w storeWrites
+	q.mu.Lock()
+	q.store = store
+	q.items = append(items, q.items...)
+	q.prune(time.Now(), &w)
+	q.unlockAndWrite(&w)
+	return nil
+}
+
+// Add 添加消息死信
+func (q *DeadLetterQueue) Add(msg *ds.Message, reason string) *DeadLetter {
+	return q.add(&DeadLetter{
+		ID:      newDeadLetterID(msg.ID),
+		Kind:    DeadLetterKindMessage,
+		Message: msg,
+		Reason:  reason,
+	})
+}
+
+// AddTask 添加任务死信，priority 为任务所在的队列优先级
+func (q *DeadLetterQueue) AddTask(task *ds.Task, priority, reason string) *DeadLetter {
+	return q.add(&DeadLetter{
+		ID:       newDeadLetterID(task.ID),
+		Kind:     DeadLetterKindTask,
+		Task:     task,
+		Priority: priority,
+		Reason:   reason,
+		Attempts: deadLetterAttempts(task),
+	})
+}
+
+// newDeadLetterID 生成死信 ID，失败时使用原消息或任务 ID
+func newDeadLetterID(fallback string) string {
+	id, err := utils.NewUUID()
+	if err != nil {
+		return fallback
+	}
+	return id
+}
+
+// add 添加死信并持久化
+func (q *DeadLetterQueue) add(dl *DeadLetter) *DeadLetter {
+	dl.CreatedAt = time.Now()
+
+	var w storeWrites
+	q.mu.Lock()
+	q.items = append(q.items, dl)
+	q.total++
+	w.save(dl)
+	q.prune(dl.CreatedAt, &w)
+	alert, count := q.checkAlert()
+	q.unlockAndWrite(&w)
+
+	if alert != nil {
+		alert(count)
+	}
+	return dl
+}
+
+// restore 放回已有的死信，保留其产生时间与重新投递记录
+func (q *DeadLetterQueue) restore(dl *DeadLetter) {
+	var w storeWrites
+	q.mu.Lock()
+	q.items = append(q.items, dl)
+	w.save(dl)
+	q.prune(time.Now(), &w)
+	alert, count := q.checkAlert()
+	q.unlockAndWrite(&w)
+
+	if alert != nil {
+		alert(count)
+	}
+}
+
+// takeDue 移除并返回到达重新投递时间的死信，未记录下次投递时间的死信在产生 backoff 后到期
+func (q *DeadLetterQueue) takeDue(now time.Time, backoff time.Duration) []*DeadLetter {
+	var w storeWrites
+	q.mu.Lock()
+	defer q.unlockAndWrite(&w)
+	var due []*DeadLetter
+	kept := q.items[:0]
+	for _, dl := range q.items {
+		at := dl.CreatedAt.Add(backoff)
+		if dl.NextAttempt != nil {
+			at = *dl.NextAttempt
+		}
+		if now.Before(at) {
+			kept = append(kept, dl)
+			continue
+		}
+		w.remove(dl.ID)
+		due = append(due, dl)
+	}
+	q.items = kept
+	if len(due) > 0 {
+		q.checkAlert()
+	}
+	return due
+}
+
+// List 获取所有死信
+func (q *DeadLetterQueue) List() []*DeadLetter {
+	var w storeWrites
+	q.mu.Lock()
+	defer q.unlockAndWrite(&w)
+	q.prune(time.Now(), &w)
+	result := make([]*DeadLetter, len(q.items))
+	copy(result, q.items)
+	return result
+}
+
+// Remove 移除并返回指定死信，不存在时返回 nil
+func (q *DeadLetterQueue) Remove(id string) *DeadLetter {
+	var w storeWrites
+	q.mu.Lock()
+	defer q.unlockAndWrite(&w)
+	for i, dl := range q.items {
+		if dl.ID == id {
+			q.items = append(q.items[:i], q.items[i+1:]...)
+			w.remove(dl.ID)
+			q.checkAlert()
+			return dl
+		}
+	}
+	return nil
+}
+
+// RemoveTask 移除指定任务的所有任务死信，返回移除的数量
+func (q *DeadLetterQueue) RemoveTask(taskID string) int {
+	var w storeWrites
+	q.mu.Lock()
+	defer q.unlockAndWrite(&w)
+	kept := q.items[:0]
+	removed := 0
+	for _, dl := range q.items {
+		if dl.Kind == DeadLetterKindTask && dl.Task != nil && dl.Task.ID == taskID {
+			w.remove(dl.ID)
+			removed++
+			continue
+		}
+		kept = append(kept, dl)
+	}
+	q.items = kept
+	if removed > 0 {
+		q.checkAlert()
+	}
+	return removed
+}
+
+// Len 获取死信数量
+func (q *DeadLetterQueue) Len() int {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	return len(q.items)
+}
+
+// Stats 获取死信统计
+func (q *DeadLetterQueue) Stats() DeadLetterStats {
+	var w storeWrites
+	q.mu.Lock()
+	defer q.unlockAndWrite(&w)
+	q.prune(time.Now(), &w)
+	return DeadLetterStats{
+		Current: len(q.items),
+		Total:   q.total,
+		Expired: q.expired,
+	}
+}
+
+// prune 淘汰超出保留期与容量的死信，存储中的删除记入 w（调用方持有锁）
+func (q *DeadLetterQueue) prune(now time.Time, w *storeWrites) {
+	drop := 0
+	if q.maxAge > 0 {
+		for drop < len(q.items) && now.Sub(q.items[drop].CreatedAt) > q.maxAge {
+			drop++
+		}
+	}
+	if over := len(q.items) - drop - q.maxSize; over > 0 {
+		drop += over
+	}
+	if drop > 0 {
+		for _, dl := range q.items[:drop] {
+			w.remove(dl.ID)
+		}
+		q.items = q.items[drop:]
+		q.expired += int64(drop)
+	}
+}
+
+// storeWrites 持有 mu 期间收集的存储写入，解锁后执行
+type storeWrites struct {
+	saves   []*DeadLetter
+	removes []string
+}
+
+func (w *storeWrites) save(dl *DeadLetter) { w.saves = append(w.saves, dl) }
+func (w *storeWrites) remove(id string)    { w.removes = append(w.removes, id) }
+
+// unlockAndWrite 释放 mu 后将收集的写入同步到存储（先保存后删除），失败只记录日志；
+// 释放 mu 前先取得 storeMu，保证各次写入按加锁顺序执行，同时不阻塞队列的读写
+func (q *DeadLetterQueue) unlockAndWrite(w *storeWrites) {
+	store := q.store
+	if store == nil || (len(w.saves) == 0 && len(w.removes) == 0) {
+		q.mu.Unlock()
+		return
+	}
+	q.storeMu.Lock()
+	q.mu.Unlock()
+	defer q.storeMu.Unlock()
+
+	for _, dl := range w.saves {
+		if err := store.SaveDeadLetter(dl); err != nil {
+			slog.Warn("failed to persist dead letter", slog.String("id", dl.ID), slog.Any("error", err))
+		}
+	}
+	for _, id := range w.removes {
+		if err := store.DeleteDeadLetter(id); err != nil {
+			slog.Warn("failed to delete persisted dead letter", slog.String("id", id), slog.Any("error", err))
+		}
+	}
+}
+
+// checkAlert 检查是否需要触发积压告警，返回待调用的告警函数（调用方持有锁，解锁后再调用）
+func (q *DeadLetterQueue) checkAlert() (func(int), int) {
+	if q.alertThreshold <= 0 || q.onAlert == nil {
+		return nil, 0
+	}
+	count := len(q.items)
+	if count < q.alertThreshold {
+		q.alerted = false
+		return nil, 0
+	}
+	if q.alerted {
+		return nil, 0
+	}
+	q.alerted = true
+	return q.onAlert, count
+}
@@ -0,0 +1,215 @@
+package mailbox
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"superman/ds"
+)
+
+// ErrMailboxFull 收件箱已满，消息未能入箱
+var ErrMailboxFull = errors.New("mailbox is full")
+
+// MetadataDeadLetterAttempts 任务元数据中记录任务死信已自动重新提交次数的键，任务再次进入死信队列时沿用
+const MetadataDeadLetterAttempts = "dead_letter_attempts"
+
+// 死信自动重新投递的默认参数
+const (
+	DefaultDeadLetterMaxAttempts = 5
+	DefaultDeadLetterMaxBackoff  = time.Hour
+)
+
+// DeadLetterRetryPolicy 死信自动重新投递策略
+type DeadLetterRetryPolicy struct {
+	Interval    time.Duration // 扫描到期死信的间隔，<= 0 关闭自动重新投递
+	Backoff     time.Duration // 首次重新投递前的等待时间，之后每次失败翻倍，默认等于 Interval
+	MaxBackoff  time.Duration // 等待时间上限，默认 1h
+	MaxAttempts int           // 最多重新投递次数，用尽后移入永久失败队列并告警，默认 5
+}
+
+// SetDeadLetterRetry 设置死信自动重新投递策略，StartDeadLetterRetry 后生效
+func (b *MailboxBus) SetDeadLetterRetry(policy DeadLetterRetryPolicy) {
+	if policy.Backoff <= 0 {
+		policy.Backoff = policy.Interval
+	}
+	if policy.MaxBackoff <= 0 {
+		policy.MaxBackoff = DefaultDeadLetterMaxBackoff
+	}
+	if policy.MaxAttempts <= 0 {
+		policy.MaxAttempts = DefaultDeadLetterMaxAttempts
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.retryPolicy = policy
+}
+
+// retryEnabled 是否开启了死信自动重新投递
+func (b *MailboxBus) retryEnabled() bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.retryPolicy.Interval > 0
+}
+
+// StartDeadLetterRetry 启动死信自动重新投递循环，未开启时不做任何事
+func (b *MailboxBus) StartDeadLetterRetry() {
+	b.mu.Lock()
+	interval := b.retryPolicy.Interval
+	if interval <= 0 || b.retryStop != nil {
+		b.mu.Unlock()
+		return
+	}
+	stop := make(chan struct{})
+	b.retryStop = stop
+	b.mu.Unlock()
+
+	b.retryWG.Add(1)
+	go func() {
+		defer b.retryWG.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				b.RetryDeadLetters(time.Now())
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// StopDeadLetterRetry 停止死信自动重新投递循环
+func (b *MailboxBus) StopDeadLetterRetry() {
+	b.mu.Lock()
+	stop := b.retryStop
+	b.retryStop = nil
+	b.mu.Unlock()
+	if stop != nil {
+		close(stop)
+		b.retryWG.Wait()
+	}
+}
+
+// RetryDeadLetters 重新投递到期的死信：消息投递给原接收者，任务重新提交到调度器；
+// 消息投递失败时按退避时间放回死信队列，重试次数用尽的死信移入永久失败队列并告警。返回成功重新投递的数量
+func (b *MailboxBus) RetryDeadLetters(now time.Time) int {
+	b.mu.RLock()
+	policy := b.retryPolicy
+	b.mu.RUnlock()
+	if policy.MaxAttempts <= 0 {
+		return 0
+	}
+
+	redelivered := 0
+	for _, dl := range b.deadLetters.takeDue(now, policy.Backoff) {
+		if dl.Attempts >= policy.MaxAttempts {
+			b.failPermanently(dl)
+			continue
+		}
+		dl.Attempts++
+
+		if dl.Kind == DeadLetterKindTask {
+			if dl.Task.Metadata == nil {
+				dl.Task.Metadata = make(map[string]any)
+			}
+			dl.Task.Metadata[MetadataDeadLetterAttempts] = dl.Attempts
+			if err := b.resubmitTask(dl); err == nil {
+				redelivered++
+			}
+			continue
+		}
+
+		// 重新投递期间接收方再次拒收不产生新死信，由本次重试统一处理
+		b.redelivering.Store(dl.Message.ID, true)
+		err := b.Send(dl.Message)
+		b.redelivering.Delete(dl.Message.ID)
+		if err == nil {
+			redelivered++
+			slog.Info("dead letter redelivered",
+				slog.String("id", dl.ID),
+				slog.String("msg_id", dl.Message.ID),
+				slog.Int("attempts", dl.Attempts),
+			)
+			continue
+		}
+
+		dl.Reason = err.Error()
+		if dl.Attempts >= policy.MaxAttempts {
+			b.failPermanently(dl)
+			continue
+		}
+		backoff := policy.Backoff << (dl.Attempts - 1)
+		if backoff <= 0 || backoff > policy.MaxBackoff {
+			backoff = policy.MaxBackoff
+		}
+		next := now.Add(backoff)
+		dl.NextAttempt = &next
+		b.deadLetters.restore(dl)
+	}
+	return redelivered
+}
+
+// failPermanently 将重试次数用尽的死信移入永久失败队列，并向告警 Agent 发送通知
+func (b *MailboxBus) failPermanently(dl *DeadLetter) {
+	b.permanentFailures.restore(dl)
+	slog.Error("dead letter failed permanently",
+		slog.String("id", dl.ID),
+		slog.String("kind", dl.Kind),
+		slog.Int("attempts", dl.Attempts),
+		slog.String("reason", dl.Reason),
+	)
+
+	b.mu.RLock()
+	agent := b.alertAgent
+	b.mu.RUnlock()
+	if agent == "" {
+		return
+	}
+	content := fmt.Sprintf("死信 %s（%s）重新投递 %d 次仍失败，已移入永久失败队列：%s", dl.ID, dl.Kind, dl.Attempts, dl.Reason)
+	if dl.Attempts == 0 {
+		content = fmt.Sprintf("死信 %s（%s）无法通过重新投递恢复，已移入永久失败队列：%s", dl.ID, dl.Kind, dl.Reason)
+	}
+	msg, err := ds.NewNotificationMessage("system", agent, "死信重新投递失败", content, "high")
+	if err != nil {
+		return
+	}
+	if err := b.Send(msg); err != nil {
+		slog.Error("failed to send permanent failure alert",
+			slog.String("agent", agent),
+			slog.Any("error", err),
+		)
+	}
+}
+
+// FailTaskPermanently 将重新提交也无法分发的任务直接放入永久失败队列并告警，不参与自动重新投递
+func (b *MailboxBus) FailTaskPermanently(task *ds.Task, priority, reason string) *DeadLetter {
+	dl := &DeadLetter{
+		ID:        newDeadLetterID(task.ID),
+		Kind:      DeadLetterKindTask,
+		Task:      task,
+		Priority:  priority,
+		Reason:    reason,
+		CreatedAt: time.Now(),
+		Attempts:  deadLetterAttempts(task),
+	}
+	b.failPermanently(dl)
+	return dl
+}
+
+// GetPermanentFailures 获取重试次数用尽或无法重新投递的死信队列
+func (b *MailboxBus) GetPermanentFailures() *DeadLetterQueue {
+	return b.permanentFailures
+}
+
+// deadLetterAttempts 返回任务元数据中记录的死信重新提交次数
+func deadLetterAttempts(task *ds.Task) int {
+	switch n := task.Metadata[MetadataDeadLetterAttempts].(type) {
+	case int:
+		return n
+	case float64: // 经 JSON 持久化后为 float64
+		return int(n)
+	}
+	return 0
+}
@@ -7,20 +7,36 @@ import (
 
 	"superman/ds"
 	"sync"
+	"sync/atomic"
 )
 
 // MailboxConfig Mailbox配置
 type MailboxConfig struct {
-	MailboxBus      *MailboxBus // 所属的MailboxBus
-	Receiver        string      // 接收者角色
-	InboxBufferSize int         // 收件箱channel缓冲区大小
+	MailboxBus      *MailboxBus   // 所属的MailboxBus
+	Receiver        string        // 接收者角色
+	InboxBufferSize int           // 收件箱channel缓冲区大小
+	DedupWindow     time.Duration // 消息去重窗口，窗口内相同消息ID只投递一次，0 表示不去重
+	MaxBodySize     int           // 消息体最大字节数，0 表示不限制
+	TruncateBody    bool          // 超限消息截断后投递，否则拒收
+	Role            string        // 接收者的角色，用于按角色模式路由
+	Hierarchy       int           // 接收者的层级，用于按层级路由，-1 表示未知
+	OrderedSenders  bool          // 按发送者保序：同一发送者的消息按发送顺序编号并依次入箱
+	MaxArchive      int           // 归档保留的最大消息数，0 使用 DefaultMaxArchive，负数不归档
+
+	AllowedSenders     []string // 只接收这些发送者的消息（系统发送者除外），为空接收所有发送者
+	DeniedSenders      []string // 拒收这些发送者的消息，优先于 AllowedSenders
+	DeadLetterRejected bool     // 被拒收的消息放入死信队列
 }
 
+// DefaultMaxArchive 信箱归档默认保留的最大消息数
+const DefaultMaxArchive = 1000
+
 // DefaultMailboxConfig 返回默认配置
 func DefaultMailboxConfig(receiver string) *MailboxConfig {
 	return &MailboxConfig{
 		Receiver:        receiver,
 		InboxBufferSize: 1000,
+		Hierarchy:       -1,
 	}
 }
 
@@ -34,6 +50,37 @@ type Mailbox struct {
 	Inbox    chan *ds.Message // 收件箱（导出字段）
 	archive  []*ds.Message    // 消息归档
 	mu       sync.RWMutex
+
+	maxArchive int // 归档保留的最大消息数，负数不归档
+
+	// 接收者画像，用于按层级、角色路由
+	role      string
+	hierarchy int
+
+	orderedSenders bool
+
+	// 发送者允许/拒绝名单
+	allowedSenders     []string
+	deniedSenders      []string
+	deadLetterRejected bool
+
+	dedupWindow       time.Duration
+	seen              map[string]time.Time // 消息ID -> 首次投递时间
+	seenOrder         []string             // seen 中的消息ID，按首次投递时间排序，用于按序清理过期记录
+	duplicatesDropped int64
+
+	maxBodySize   int
+	truncateBody  bool
+	truncated     int64
+	oversizeDrops int64
+
+	// 生命周期累计计数
+	delivered    int64 // 成功投递到收件箱
+	droppedFull  int64 // 收件箱已满被丢弃
+	filtered     int64 // 被去重、大小限制、发送者名单或接收方校验过滤
+	deadLettered int64 // 被放入死信队列
+
+	inboxTasks atomic.Int64 // 收件箱中尚未被取走的任务消息数
 }
 
 // NewMailbox 创建新的Mailbox
@@ -43,6 +90,23 @@ func NewMailbox(config *MailboxConfig) *Mailbox {
 		receiver: config.Receiver,
 		Inbox:    make(chan *ds.Message, config.InboxBufferSize),
 		archive:  make([]*ds.Message, 0),
+
+		maxArchive: config.MaxArchive,
+
+		role:      config.Role,
+		hierarchy: config.Hierarchy,
+
+		orderedSenders: config.OrderedSenders,
+
+		allowedSenders:     config.AllowedSenders,
+		deniedSenders:      config.DeniedSenders,
+		deadLetterRejected: config.DeadLetterRejected,
+
+		dedupWindow: config.DedupWindow,
+		seen:        make(map[string]time.Time),
+
+		maxBodySize:  config.MaxBodySize,
+		truncateBody: config.TruncateBody,
 	}
 
 	return mb
@@ -50,22 +114,113 @@ func NewMailbox(config *MailboxConfig) *Mailbox {
 
 // PushInbox 向收件箱推送消息（非阻塞，带超时）
 func (mb *Mailbox) PushInbox(msg *ds.Message) error {
+	if err := mb.enforceSender(msg); err != nil {
+		return err
+	}
+	if err := mb.enforceValid(msg); err != nil {
+		return err
+	}
+	if mb.isDuplicate(msg) {
+		slog.Debug("duplicate message dropped",
+			slog.String("receiver", mb.receiver),
+			slog.String("msg_id", msg.ID),
+			slog.String("sender", msg.Sender),
+		)
+		return nil
+	}
+
+	mb.countTask(msg, 1)
 	select {
 	case mb.Inbox <- msg:
+		mb.incr(&mb.delivered)
 		return nil
 	case <-time.After(5 * time.Second):
+		mb.countTask(msg, -1)
+		mb.incr(&mb.droppedFull)
 		slog.Warn("mailbox full, message dropped",
 			slog.String("receiver", mb.receiver),
 			slog.String("msg_id", msg.ID),
 			slog.String("sender", msg.Sender),
 		)
-		return fmt.Errorf("mailbox %s is full, message %s dropped", mb.receiver, msg.ID)
+		return fmt.Errorf("%w: %s, message %s dropped", ErrMailboxFull, mb.receiver, msg.ID)
+	}
+}
+
+// Requeue 将已入箱、尚未处理的消息放回收件箱（如 Agent 停止时），已通过入箱检查，不再校验与去重
+func (mb *Mailbox) Requeue(msg *ds.Message) error {
+	mb.countTask(msg, 1)
+	select {
+	case mb.Inbox <- msg:
+		return nil
+	case <-time.After(5 * time.Second):
+		mb.countTask(msg, -1)
+		mb.incr(&mb.droppedFull)
+		return fmt.Errorf("%w: %s, message %s dropped", ErrMailboxFull, mb.receiver, msg.ID)
+	}
+}
+
+// isDuplicate 检查消息是否在去重窗口内已投递过，未投递过则记录
+func (mb *Mailbox) isDuplicate(msg *ds.Message) bool {
+	if mb.dedupWindow <= 0 || msg.ID == "" {
+		return false
+	}
+
+	mb.mu.Lock()
+	defer mb.mu.Unlock()
+
+	// 按投递顺序从最早的记录开始清理，遇到未过期的记录即停止
+	now := time.Now()
+	for len(mb.seenOrder) > 0 && now.Sub(mb.seen[mb.seenOrder[0]]) > mb.dedupWindow {
+		delete(mb.seen, mb.seenOrder[0])
+		mb.seenOrder = mb.seenOrder[1:]
+	}
+
+	if _, exists := mb.seen[msg.ID]; exists {
+		mb.duplicatesDropped++
+		mb.filtered++
+		return true
 	}
+	mb.seen[msg.ID] = now
+	mb.seenOrder = append(mb.seenOrder, msg.ID)
+	return false
 }
 
 // PopInbox 从收件箱取出消息
 func (mb *Mailbox) PopInbox() *ds.Message {
-	return <-mb.Inbox
+	msg := <-mb.Inbox
+	mb.Received(msg)
+	return msg
+}
+
+// TryPopInbox 非阻塞地从收件箱取出消息，收件箱为空时返回 nil
+func (mb *Mailbox) TryPopInbox() *ds.Message {
+	select {
+	case msg := <-mb.Inbox:
+		mb.Received(msg)
+		return msg
+	default:
+		return nil
+	}
+}
+
+// Received 记录直接从 Inbox 读取的消息已交给处理方，任务消息不再计入收件箱任务数
+func (mb *Mailbox) Received(msg *ds.Message) {
+	mb.countTask(msg, -1)
+}
+
+// GetInboxTaskCount 获取收件箱中尚未被取走的任务消息数
+func (mb *Mailbox) GetInboxTaskCount() int {
+	return int(mb.inboxTasks.Load())
+}
+
+// countTask 任务消息进出收件箱时调整收件箱任务数
+func (mb *Mailbox) countTask(msg *ds.Message, delta int64) {
+	if msg == nil {
+		return
+	}
+	if _, ok := msg.GetTaskCreateBody(); ok {
+		mb.inboxTasks.Add(delta)
+	}
 }
 
 // PushOutbox 向发件箱推送消息
@@ -73,18 +228,32 @@ func (mb *Mailbox) PushOutbox(msg *ds.Message) error {
 	return mb.bus.Send(msg)
 }
 
-// ArchiveMessage 归档消息
+// ArchiveMessage 归档消息，超出归档上限时丢弃最早的消息；关闭归档时不保存
 func (mb *Mailbox) ArchiveMessage(msg *ds.Message) {
 	mb.mu.Lock()
 	defer mb.mu.Unlock()
+	if mb.maxArchive < 0 {
+		return
+	}
+	limit := mb.maxArchive
+	if limit == 0 {
+		limit = DefaultMaxArchive
+	}
 	mb.archive = append(mb.archive, msg)
-
-	// 限制归档大小，保留最新的1000条消息
-	if len(mb.archive) > 1000 {
-		mb.archive = mb.archive[1:]
+	if over := len(mb.archive) - limit; over > 0 {
+		mb.archive = mb.archive[over:]
 	}
 }
 
+// GetArchive 获取归档消息副本（按归档时间升序）
+func (mb *Mailbox) GetArchive() []*ds.Message {
+	mb.mu.RLock()
+	defer mb.mu.RUnlock()
+	result := make([]*ds.Message, len(mb.archive))
+	copy(result, mb.archive)
+	return result
+}
+
 // GetMailboxBus 获取信箱总线
 func (mb *Mailbox) GetMailboxBus() *MailboxBus {
 	return mb.bus
@@ -108,9 +277,71 @@ func (mb *Mailbox) GetMailboxStats() map[string]interface{} {
 	defer mb.mu.Unlock()
 
 	return map[string]interface{}{
-		"inbox_count":   len(mb.Inbox),
-		"archive_count": len(mb.archive),
-		"receiver":      mb.receiver,
-		"buffer_size":   cap(mb.Inbox),
+		"inbox_count":        len(mb.Inbox),
+		"archive_count":      len(mb.archive),
+		"receiver":           mb.receiver,
+		"buffer_size":        cap(mb.Inbox),
+		"duplicates_dropped": mb.duplicatesDropped,
+		"truncated":          mb.truncated,
+		"oversize_rejected":  mb.oversizeDrops,
+		"delivered":          mb.delivered,
+		"dropped_full":       mb.droppedFull,
+		"filtered":           mb.filtered,
+		"dead_lettered":      mb.deadLettered,
+	}
+}
+
+// recordDeadLettered 记录一条发往本信箱后被放入死信队列的消息
+func (mb *Mailbox) recordDeadLettered() {
+	mb.incr(&mb.deadLettered)
+}
+
+// incr 在锁内对计数器加一
+func (mb *Mailbox) incr(counter *int64) {
+	mb.mu.Lock()
+	defer mb.mu.Unlock()
+	*counter++
+}
+
+// enforceBodySize 按配置截断或拒收超过大小限制的消息
+func (mb *Mailbox) enforceBodySize(msg *ds.Message) error {
+	if mb.maxBodySize <= 0 {
+		return nil
+	}
+	size := msg.BodySize()
+	if size <= mb.maxBodySize {
+		return nil
+	}
+	if mb.truncateBody && msg.TruncateBody(mb.maxBodySize) {
+		mb.mu.Lock()
+		mb.truncated++
+		mb.mu.Unlock()
+		slog.Warn("oversized message truncated",
+			slog.String("receiver", mb.receiver),
+			slog.String("msg_id", msg.ID),
+			slog.Int("size", size),
+			slog.Int("limit", mb.maxBodySize),
+		)
+		return nil
+	}
+	mb.mu.Lock()
+	mb.oversizeDrops++
+	mb.mu.Unlock()
+	return fmt.Errorf("message %s body size %d exceeds limit %d", msg.ID, size, mb.maxBodySize)
+}
+
+// enforceValid 拒收超限（未截断）或字段不合法的消息，配置了 DeadLetterRejected 时放入死信队列
+func (mb *Mailbox) enforceValid(msg *ds.Message) error {
+	err := mb.enforceBodySize(msg)
+	if err == nil {
+		err = msg.Validate(0)
+	}
+	if err == nil {
+		return nil
+	}
+	mb.incr(&mb.filtered)
+	if mb.deadLetterRejected && mb.bus != nil {
+		mb.bus.DeadLetter(msg, err.Error())
 	}
+	return fmt.Errorf("message rejected by %s: %w", mb.receiver, err)
 }
@@ -1,8 +1,11 @@
 package mailbox
 
 import (
+	"errors"
 	"fmt"
+	"log/slog"
 	"sync"
+	"time"
 
 	"superman/ds"
 	"superman/state"
@@ -13,6 +16,27 @@ type MailboxBus struct {
 	mu          sync.RWMutex
 	mailboxes   map[string]*Mailbox
 	globalState *state.GlobalState // 全局共享状态
+	deadLetters *DeadLetterQueue   // 死信队列
+
+	// 按发送者保序：发送者 -> 接收者 的消息序号
+	sequences map[senderPair]*senderSequence
+
+	// 任务提交回调（提交到调度器），用于重新投递任务死信
+	submitTask func(task *ds.Task, priority string)
+
+	// 能力通告回调（更新调度器的能力视图）
+	onCapabilities func(agent string, capabilities []string)
+
+	// 发送者给自己发消息时的处理策略
+	selfMessagePolicy string
+
+	// 死信自动重新投递
+	retryPolicy       DeadLetterRetryPolicy
+	retryStop         chan struct{}
+	retryWG           sync.WaitGroup
+	redelivering      sync.Map         // 消息ID -> 正在重新投递
+	permanentFailures *DeadLetterQueue // 重试次数用尽或无法重新投递的死信
+	alertAgent        string           // 接收死信告警的 Agent
 }
 
 // MailboxBusConfig MailboxBus配置
@@ -41,6 +65,11 @@ func NewMailboxBusWithConfig(config *MailboxBusConfig) *MailboxBus {
 	b := &MailboxBus{
 		mailboxes:   make(map[string]*Mailbox),
 		globalState: state.NewGlobalState(),
+		deadLetters: NewDeadLetterQueue(0),
+		sequences:   make(map[senderPair]*senderSequence),
+
+		selfMessagePolicy: SelfMessageReject,
+		permanentFailures: NewDeadLetterQueue(0),
 	}
 
 	return b
@@ -79,13 +108,71 @@ func (b *MailboxBus) Send(msg *ds.Message) error {
 	if msg == nil {
 		return fmt.Errorf("message is nil")
 	}
+	if deliver, err := b.checkSelfMessage(msg); !deliver {
+		return err
+	}
 
 	m, err := b.GetMailbox(msg.Receiver)
 	if err != nil {
 		return err
 	}
 
-	return m.PushInbox(msg)
+	if err := b.push(m, msg); err != nil {
+		// 开启自动重新投递时，接收方收件箱已满（如 Agent 暂时停止）的消息放入死信队列稍后重试
+		if errors.Is(err, ErrMailboxFull) && b.retryEnabled() {
+			b.DeadLetter(msg, err.Error())
+		}
+		return err
+	}
+	b.globalState.RecordEvent(state.EventMessageSent, map[string]any{
+		"msg_id":   msg.ID,
+		"sender":   msg.Sender,
+		"receiver": msg.Receiver,
+		"type":     string(msg.Type),
+	})
+	return nil
+}
+
+// Broadcast 向除发送者外的所有 Mailbox 发送通知消息
+func (b *MailboxBus) Broadcast(sender, title, content, priority string) error {
+	b.mu.RLock()
+	receivers := make([]string, 0, len(b.mailboxes))
+	for name := range b.mailboxes {
+		if name != sender {
+			receivers = append(receivers, name)
+		}
+	}
+	b.mu.RUnlock()
+
+	var errs []error
+	for _, receiver := range receivers {
+		msg, err := ds.NewNotificationMessage(sender, receiver, title, content, priority)
+		if err != nil {
+			return err
+		}
+		if err := b.Send(msg); err != nil {
+			errs = append(errs, fmt.Errorf("broadcast to %s: %w", receiver, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// SetCapabilityListener 设置能力通告回调，Agent 通告能力时调用（如更新调度器的能力视图）
+func (b *MailboxBus) SetCapabilityListener(fn func(agent string, capabilities []string)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.onCapabilities = fn
+}
+
+// AdvertiseCapabilities 通告 Agent 的能力，通知能力通告回调（如更新调度器的能力视图）
+func (b *MailboxBus) AdvertiseCapabilities(sender string, capabilities []string) {
+	b.mu.RLock()
+	listener := b.onCapabilities
+	b.mu.RUnlock()
+
+	if listener != nil {
+		listener(sender, capabilities)
+	}
 }
 
 // SendTo 发送消息到指定角色
@@ -98,6 +185,113 @@ func (b *MailboxBus) SendTo(sender, receiver string, content map[string]interfac
 	})
 }
 
+// DeadLetter 将消息放入死信队列；消息正在自动重新投递时不重复放入，返回 nil
+func (b *MailboxBus) DeadLetter(msg *ds.Message, reason string) *DeadLetter {
+	if _, ok := b.redelivering.Load(msg.ID); ok {
+		return nil
+	}
+	slog.Warn("message dead-lettered",
+		slog.String("msg_id", msg.ID),
+		slog.String("sender", msg.Sender),
+		slog.String("receiver", msg.Receiver),
+		slog.String("reason", reason),
+	)
+	if m, err := b.GetMailbox(msg.Receiver); err == nil {
+		m.recordDeadLettered()
+	}
+	return b.deadLetters.Add(msg, reason)
+}
+
+// SetDeadLetterAlert 死信积压达到 threshold 时向 agent 发送高优先级通知，threshold <= 0 关闭积压告警；
+// 死信重新投递次数用尽时同样通知 agent
+func (b *MailboxBus) SetDeadLetterAlert(threshold int, agent string) {
+	b.mu.Lock()
+	b.alertAgent = agent
+	b.mu.Unlock()
+	if threshold <= 0 || agent == "" {
+		b.deadLetters.SetAlert(0, nil)
+		return
+	}
+	b.deadLetters.SetAlert(threshold, func(count int) {
+		msg, err := ds.NewNotificationMessage(
+			"system",
+			agent,
+			"死信积压告警",
+			fmt.Sprintf("死信队列中有 %d 条无法投递的消息，已达到告警阈值 %d，请检查并处理", count, threshold),
+			"high",
+		)
+		if err != nil {
+			return
+		}
+		if err := b.Send(msg); err != nil {
+			slog.Error("failed to send dead letter alert",
+				slog.String("agent", agent),
+				slog.Any("error", err),
+			)
+		}
+	})
+}
+
+// DeadLetterTask 将无法分发的任务放入死信队列
+func (b *MailboxBus) DeadLetterTask(task *ds.Task, priority, reason string) *DeadLetter {
+	slog.Warn("task dead-lettered",
+		slog.String("task_id", task.ID),
+		slog.String("priority", priority),
+		slog.String("reason", reason),
+	)
+	return b.deadLetters.AddTask(task, priority, reason)
+}
+
+// SetTaskSubmitter 设置任务提交回调（提交到调度器），重新投递任务死信时使用
+func (b *MailboxBus) SetTaskSubmitter(fn func(task *ds.Task, priority string)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.submitTask = fn
+}
+
+// RequeueDeadLetter 将死信按正常路径重新投递：消息投递给原接收者，任务重置为待处理后提交到调度器；
+// 消息投递失败时放回死信队列（投递路径本身不再产生重复的死信）
+func (b *MailboxBus) RequeueDeadLetter(id string) error {
+	dl := b.deadLetters.Remove(id)
+	if dl == nil {
+		return fmt.Errorf("dead letter %s not found", id)
+	}
+	if dl.Kind == DeadLetterKindTask {
+		return b.resubmitTask(dl)
+	}
+	b.redelivering.Store(dl.Message.ID, true)
+	err := b.Send(dl.Message)
+	b.redelivering.Delete(dl.Message.ID)
+	if err != nil {
+		b.deadLetters.Add(dl.Message, err.Error())
+		return fmt.Errorf("failed to requeue dead letter %s: %w", id, err)
+	}
+	return nil
+}
+
+// resubmitTask 将任务死信重置为待处理并提交到调度器，未设置提交回调时放回死信队列
+func (b *MailboxBus) resubmitTask(dl *DeadLetter) error {
+	b.mu.RLock()
+	submit := b.submitTask
+	b.mu.RUnlock()
+	if submit == nil {
+		b.deadLetters.AddTask(dl.Task, dl.Priority, dl.Reason)
+		return fmt.Errorf("failed to requeue dead letter %s: task submitter is not set", dl.ID)
+	}
+
+	task := dl.Task
+	task.Status = ds.TaskStatusPending
+	delete(task.Metadata, "failure_reason")
+	task.UpdatedAt = time.Now()
+	submit(task, dl.Priority)
+	return nil
+}
+
+// GetDeadLetterQueue 获取死信队列
+func (b *MailboxBus) GetDeadLetterQueue() *DeadLetterQueue {
+	return b.deadLetters
+}
+
 // GetGlobalState 获取全局共享状态
 func (b *MailboxBus) GetGlobalState() *state.GlobalState {
 	return b.globalState
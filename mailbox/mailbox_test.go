@@ -0,0 +1,389 @@
+package mailbox
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"superman/ds"
+)
+
+// newTestBus 创建 MailboxBus 并注册按 configure 配置的信箱 worker
+func newTestBus(t *testing.T, configure func(*MailboxConfig)) (*MailboxBus, *Mailbox) {
+	t.Helper()
+	bus := NewMailboxBus()
+	cfg := DefaultMailboxConfig("worker")
+	cfg.InboxBufferSize = 10
+	if configure != nil {
+		configure(cfg)
+	}
+	mb := NewMailbox(cfg)
+	if err := bus.RegisterMailbox("worker", mb); err != nil {
+		t.Fatalf("register mailbox: %v", err)
+	}
+	return bus, mb
+}
+
+// 经 MailboxBus 投递的非法消息在入箱时被拒收并放入死信队列
+func TestSendRejectsInvalidMessages(t *testing.T) {
+	bus, mb := newTestBus(t, func(cfg *MailboxConfig) {
+		cfg.MaxBodySize = 16
+		cfg.DeadLetterRejected = true
+	})
+
+	invalid := []*ds.Message{
+		{ID: "no-sender", Receiver: "worker", Type: ds.MessageTypeNotification, Body: "hi"},
+		{ID: "bad-type", Sender: "boss", Receiver: "worker", Type: "bogus", Body: "hi"},
+		{ID: "oversized", Sender: "boss", Receiver: "worker", Type: ds.MessageTypeNotification, Body: "this body is far too long"},
+	}
+	for _, msg := range invalid {
+		if err := bus.Send(msg); err == nil {
+			t.Errorf("Send(%s) succeeded, want rejection", msg.ID)
+		}
+	}
+	if n := len(mb.Inbox); n != 0 {
+		t.Fatalf("inbox has %d messages, want 0", n)
+	}
+	if n := bus.GetDeadLetterQueue().Len(); n != len(invalid) {
+		t.Fatalf("dead letters = %d, want %d", n, len(invalid))
+	}
+
+	valid := &ds.Message{ID: "ok", Sender: "boss", Receiver: "worker", Type: ds.MessageTypeNotification, Body: "hi"}
+	if err := bus.Send(valid); err != nil {
+		t.Fatalf("Send(valid): %v", err)
+	}
+	if got := mb.PopInbox(); got.ID != "ok" {
+		t.Fatalf("popped %s, want ok", got.ID)
+	}
+}
+
+// 开启去重时，放回收件箱的消息不应被当作重复消息丢弃
+func TestRequeueBypassesDedup(t *testing.T) {
+	_, mb := newTestBus(t, func(cfg *MailboxConfig) {
+		cfg.DedupWindow = time.Minute
+	})
+	msg := &ds.Message{ID: "m1", Sender: "boss", Receiver: "worker", Type: ds.MessageTypeNotification, Body: "hi"}
+	if err := mb.PushInbox(msg); err != nil {
+		t.Fatalf("PushInbox: %v", err)
+	}
+	popped := mb.PopInbox()
+
+	if err := mb.Requeue(popped); err != nil {
+		t.Fatalf("Requeue: %v", err)
+	}
+	if n := len(mb.Inbox); n != 1 {
+		t.Fatalf("inbox has %d messages after requeue, want 1", n)
+	}
+
+	// 真正的重复投递仍被丢弃
+	mb.PopInbox()
+	if err := mb.PushInbox(msg); err != nil {
+		t.Fatalf("PushInbox duplicate: %v", err)
+	}
+	if n := len(mb.Inbox); n != 0 {
+		t.Fatalf("duplicate was delivered, inbox has %d messages", n)
+	}
+}
+
+// 去重窗口过期的记录按投递顺序清理，过期后同一消息可再次投递
+func TestDedupWindowExpires(t *testing.T) {
+	_, mb := newTestBus(t, func(cfg *MailboxConfig) {
+		cfg.DedupWindow = 50 * time.Millisecond
+	})
+	push := func(id string) {
+		t.Helper()
+		msg := &ds.Message{ID: id, Sender: "boss", Receiver: "worker", Type: ds.MessageTypeNotification, Body: "hi"}
+		if err := mb.PushInbox(msg); err != nil {
+			t.Fatalf("PushInbox(%s): %v", id, err)
+		}
+	}
+
+	push("m1")
+	push("m1")
+	if n := len(mb.Inbox); n != 1 {
+		t.Fatalf("inbox has %d messages within the window, want 1", n)
+	}
+	time.Sleep(60 * time.Millisecond)
+	push("m2")
+	mb.mu.Lock()
+	remembered := len(mb.seen)
+	mb.mu.Unlock()
+	if remembered != 1 {
+		t.Fatalf("dedup remembers %d messages, want only m2", remembered)
+	}
+	push("m1")
+	if n := len(mb.Inbox); n != 3 {
+		t.Fatalf("inbox has %d messages, want m1 redelivered after the window", n)
+	}
+}
+
+// 手动重新投递失败时死信只放回一次，投递路径不再额外产生死信
+func TestRequeueDeadLetterFailureKeepsSingleEntry(t *testing.T) {
+	bus, _ := newTestBus(t, func(cfg *MailboxConfig) {
+		cfg.DeniedSenders = []string{"spammer"}
+		cfg.DeadLetterRejected = true
+	})
+	msg := &ds.Message{ID: "m1", Sender: "spammer", Receiver: "worker", Type: ds.MessageTypeNotification, Body: "hi"}
+	if err := bus.Send(msg); err == nil {
+		t.Fatal("Send from denied sender succeeded")
+	}
+	letters := bus.GetDeadLetterQueue().List()
+	if len(letters) != 1 {
+		t.Fatalf("dead letters = %d, want 1", len(letters))
+	}
+
+	if err := bus.RequeueDeadLetter(letters[0].ID); err == nil {
+		t.Fatal("RequeueDeadLetter succeeded for a denied sender")
+	}
+	if n := bus.GetDeadLetterQueue().Len(); n != 1 {
+		t.Fatalf("dead letters after failed requeue = %d, want 1", n)
+	}
+}
+
+// blockingStore 保存死信时阻塞直到 release 关闭
+type blockingStore struct {
+	saving  chan struct{}
+	release chan struct{}
+}
+
+func (s *blockingStore) SaveDeadLetter(dl *DeadLetter) error {
+	s.saving <- struct{}{}
+	<-s.release
+	return nil
+}
+func (s *blockingStore) DeleteDeadLetter(id string) error        { return nil }
+func (s *blockingStore) LoadDeadLetters() ([]*DeadLetter, error) { return nil, nil }
+
+// 写入存储时不持有队列锁，慢存储不会阻塞死信的读取
+func TestDeadLetterStoreWriteDoesNotBlockReads(t *testing.T) {
+	q := NewDeadLetterQueue(10)
+	store := &blockingStore{saving: make(chan struct{}, 1), release: make(chan struct{})}
+	if err := q.SetStore(store); err != nil {
+		t.Fatalf("SetStore: %v", err)
+	}
+
+	msg, err := ds.NewMessage("a", "b", ds.MessageTypeNotification, "hi")
+	if err != nil {
+		t.Fatalf("NewMessage: %v", err)
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		q.Add(msg, "test")
+	}()
+	<-store.saving
+
+	listed := make(chan int, 1)
+	go func() { listed <- len(q.List()) }()
+	select {
+	case n := <-listed:
+		if n != 1 {
+			t.Fatalf("List returned %d dead letters, want 1", n)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("List blocked while the store was writing")
+	}
+	close(store.release)
+	<-done
+}
+
+// 永久失败的任务直接进入永久失败队列，自动重新投递不会重新提交
+func TestFailTaskPermanentlySkipsRetry(t *testing.T) {
+	bus := NewMailboxBus()
+	submitted := 0
+	bus.SetTaskSubmitter(func(*ds.Task, string) { submitted++ })
+	bus.SetDeadLetterRetry(DeadLetterRetryPolicy{Interval: time.Millisecond, MaxAttempts: 3})
+
+	task := ds.NewTask("t1", "task", "test task", "ghost", "boss", ds.TaskStatusFailed, ds.TaskPriorityMedium)
+	bus.FailTaskPermanently(task, "medium", "unknown_assignee")
+
+	for range 5 {
+		bus.RetryDeadLetters(time.Now().Add(time.Hour))
+	}
+	if submitted != 0 {
+		t.Fatalf("task resubmitted %d times, want 0", submitted)
+	}
+	if n := len(bus.GetPermanentFailures().List()); n != 1 {
+		t.Fatalf("permanent failures = %d, want 1", n)
+	}
+	if n := len(bus.GetDeadLetterQueue().List()); n != 0 {
+		t.Fatalf("dead letters = %d, want 0", n)
+	}
+}
+
+// 发给自己的消息：reject 返回 ErrSelfMessage，drop 静默丢弃，allow 照常投递
+func TestSelfMessagePolicy(t *testing.T) {
+	for _, tc := range []struct {
+		policy  string
+		wantErr bool
+		inbox   int
+	}{
+		{SelfMessageReject, true, 0},
+		{SelfMessageDrop, false, 0},
+		{SelfMessageAllow, false, 1},
+	} {
+		t.Run(tc.policy, func(t *testing.T) {
+			bus, mb := newTestBus(t, nil)
+			if err := bus.SetSelfMessagePolicy(tc.policy); err != nil {
+				t.Fatal(err)
+			}
+			msg, _ := ds.NewMessage("worker", "worker", ds.MessageTypeSystem, "note to self")
+			err := bus.Send(msg)
+			if gotErr := errors.Is(err, ErrSelfMessage); gotErr != tc.wantErr {
+				t.Fatalf("Send err = %v, want ErrSelfMessage: %v", err, tc.wantErr)
+			}
+			if got := mb.GetInboxCount(); got != tc.inbox {
+				t.Fatalf("inbox = %d, want %d", got, tc.inbox)
+			}
+		})
+	}
+}
+
+// 允许名单之外与拒绝名单中的发送者被拒收并放入死信队列，允许的发送者与系统发送者照常投递
+func TestSenderAllowAndDenyLists(t *testing.T) {
+	bus, mb := newTestBus(t, func(cfg *MailboxConfig) {
+		cfg.AllowedSenders = []string{"cto", "ops"}
+		cfg.DeniedSenders = []string{"ops"}
+		cfg.DeadLetterRejected = true
+	})
+
+	for sender, allowed := range map[string]bool{
+		"cto":       true,
+		"scheduler": true,
+		"ops":       false,
+		"intern":    false,
+	} {
+		msg, _ := ds.NewMessage(sender, "worker", ds.MessageTypeSystem, "hello")
+		err := bus.Send(msg)
+		if allowed && err != nil {
+			t.Errorf("Send from %s: %v", sender, err)
+		}
+		if !allowed && !errors.Is(err, ErrSenderNotAllowed) {
+			t.Errorf("Send from %s err = %v, want ErrSenderNotAllowed", sender, err)
+		}
+	}
+	if got := mb.GetInboxCount(); got != 2 {
+		t.Fatalf("inbox = %d, want 2", got)
+	}
+	if got := len(bus.GetDeadLetterQueue().List()); got != 2 {
+		t.Fatalf("dead letters = %d, want 2 rejected messages", got)
+	}
+}
+
+// 接收方恢复后死信自动重新投递成功；接收方一直不可用时重试 MaxAttempts 次后移入永久失败队列
+func TestRetryDeadLetters(t *testing.T) {
+	bus, mb := newTestBus(t, nil)
+	bus.SetDeadLetterRetry(DeadLetterRetryPolicy{Interval: time.Minute, MaxAttempts: 3})
+
+	recovered, _ := ds.NewMessage("boss", "worker", ds.MessageTypeSystem, "worker was down")
+	bus.DeadLetter(recovered, "mailbox is full")
+	broken, _ := ds.NewMessage("boss", "ghost", ds.MessageTypeSystem, "nobody is listening")
+	bus.DeadLetter(broken, "unknown receiver")
+
+	now := time.Now()
+	for i := range 10 {
+		bus.RetryDeadLetters(now.Add(time.Duration(i+1) * 24 * time.Hour))
+	}
+
+	if got := mb.GetInboxCount(); got != 1 {
+		t.Fatalf("worker inbox = %d, want the redelivered message", got)
+	}
+	permanent := bus.GetPermanentFailures().List()
+	if len(permanent) != 1 || permanent[0].Message.ID != broken.ID || permanent[0].Attempts != 3 {
+		t.Fatalf("permanent failures = %+v, want the undeliverable message after 3 attempts", permanent)
+	}
+	if got := len(bus.GetDeadLetterQueue().List()); got != 0 {
+		t.Fatalf("dead letters = %d, want 0", got)
+	}
+}
+
+// 超限消息按策略截断后投递（元数据记录截断信息）或被拒收，不会原样进入收件箱
+func TestOversizedMessagePolicy(t *testing.T) {
+	long := strings.Repeat("x", 1000)
+	newOversized := func() *ds.Message {
+		msg, _ := ds.NewRequestMessage("boss", "worker", "report", long, nil)
+		return msg
+	}
+
+	bus, mb := newTestBus(t, func(cfg *MailboxConfig) { cfg.MaxBodySize = 256 })
+	if err := bus.Send(newOversized()); err == nil {
+		t.Fatal("oversized message accepted under the reject policy")
+	}
+	if got := mb.GetInboxCount(); got != 0 {
+		t.Fatalf("inbox = %d after rejecting, want 0", got)
+	}
+
+	bus, mb = newTestBus(t, func(cfg *MailboxConfig) {
+		cfg.MaxBodySize = 256
+		cfg.TruncateBody = true
+	})
+	if err := bus.Send(newOversized()); err != nil {
+		t.Fatalf("Send with truncation: %v", err)
+	}
+	msg := mb.TryPopInbox()
+	if msg == nil {
+		t.Fatal("truncated message was not delivered")
+	}
+	if size := msg.BodySize(); size > 256 {
+		t.Fatalf("delivered body is %d bytes, want at most 256", size)
+	}
+	body, _ := msg.GetRequestBody()
+	content, _ := body.Content.(string)
+	if !strings.HasSuffix(content, "...[truncated]") || body.Metadata[ds.MetadataTruncated] != true {
+		t.Fatalf("content = %q, metadata = %v; want a truncation marker and metadata", content, body.Metadata)
+	}
+	if got := mb.GetMailboxStats()["truncated"]; got != int64(1) {
+		t.Fatalf("truncated = %v, want 1", got)
+	}
+}
+
+// 收件箱已满时丢弃的消息计入累计的 dropped_full 计数，成功投递的计入 delivered
+func TestFullInboxCountsDroppedMessages(t *testing.T) {
+	bus, mb := newTestBus(t, func(cfg *MailboxConfig) { cfg.InboxBufferSize = 1 })
+	first, _ := ds.NewMessage("boss", "worker", ds.MessageTypeSystem, "first")
+	if err := bus.Send(first); err != nil {
+		t.Fatalf("Send first: %v", err)
+	}
+	second, _ := ds.NewMessage("boss", "worker", ds.MessageTypeSystem, "second")
+	if err := bus.Send(second); !errors.Is(err, ErrMailboxFull) {
+		t.Fatalf("Send second: %v, want ErrMailboxFull", err)
+	}
+	stats := mb.GetMailboxStats()
+	if stats["delivered"] != int64(1) || stats["dropped_full"] != int64(1) {
+		t.Fatalf("stats = %v, want 1 delivered and 1 dropped", stats)
+	}
+}
+
+// 自定义归档上限淘汰超出的最早消息，关闭归档后不再保存任何消息
+func TestArchiveCapIsConfigurable(t *testing.T) {
+	archive := func(mb *Mailbox, n int) {
+		t.Helper()
+		for i := range n {
+			msg, err := ds.NewNotificationMessage("boss", "worker", "note", string(rune('a'+i)), "medium")
+			if err != nil {
+				t.Fatal(err)
+			}
+			mb.ArchiveMessage(msg)
+		}
+	}
+
+	_, capped := newTestBus(t, func(cfg *MailboxConfig) { cfg.MaxArchive = 3 })
+	archive(capped, 5)
+	if got := capped.GetArchiveCount(); got != 3 {
+		t.Fatalf("archive count = %d with cap 3, want 3", got)
+	}
+	kept := capped.GetArchive()
+	if first := kept[0].Body.(*ds.NotificationBody).Content; first != "c" {
+		t.Fatalf("oldest archived message = %q, want c", first)
+	}
+
+	_, disabled := newTestBus(t, func(cfg *MailboxConfig) { cfg.MaxArchive = -1 })
+	archive(disabled, 5)
+	if got := disabled.GetArchiveCount(); got != 0 {
+		t.Fatalf("archive count = %d with archiving disabled, want 0", got)
+	}
+	if got := disabled.GetArchive(); len(got) != 0 {
+		t.Fatalf("archive = %v with archiving disabled, want empty", got)
+	}
+}
@@ -0,0 +1,46 @@
+package mailbox
+
+import (
+	"sync"
+
+	"superman/ds"
+)
+
+// senderPair 发送者与接收者
+type senderPair struct {
+	sender   string
+	receiver string
+}
+
+// senderSequence 同一发送者发往同一接收者的消息序号，锁覆盖编号与入箱，保证入箱顺序与编号一致
+type senderSequence struct {
+	mu   sync.Mutex
+	next uint64
+}
+
+// push 将消息放入接收者收件箱；接收者开启按发送者保序时先编号，同一发送者的并发发送依次入箱
+func (b *MailboxBus) push(m *Mailbox, msg *ds.Message) error {
+	if !m.orderedSenders || msg.Sender == "" {
+		return m.PushInbox(msg)
+	}
+
+	seq := b.senderSequence(msg.Sender, msg.Receiver)
+	seq.mu.Lock()
+	defer seq.mu.Unlock()
+	seq.next++
+	msg.Seq = seq.next
+	return m.PushInbox(msg)
+}
+
+// senderSequence 获取（必要时创建）发送者到接收者的序号
+func (b *MailboxBus) senderSequence(sender, receiver string) *senderSequence {
+	key := senderPair{sender: sender, receiver: receiver}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	seq, exists := b.sequences[key]
+	if !exists {
+		seq = &senderSequence{}
+		b.sequences[key] = seq
+	}
+	return seq
+}
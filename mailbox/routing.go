@@ -0,0 +1,77 @@
+package mailbox
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"superman/ds"
+	"superman/utils"
+)
+
+// SendToHierarchy 将消息发送给指定层级的所有 Agent（不含发送者），返回实际投递的接收者
+func (b *MailboxBus) SendToHierarchy(level int, msg *ds.Message) ([]string, error) {
+	return b.sendToMatching(msg, func(m *Mailbox) bool {
+		return m.hierarchy == level
+	})
+}
+
+// SendToRolePattern 将消息发送给角色匹配模式的所有 Agent（不含发送者），返回实际投递的接收者。
+// 模式不区分大小写，支持通配符（如 "c*"）及用 | 分隔的多个模式（如 "cto|cfo|cmo"）
+func (b *MailboxBus) SendToRolePattern(pattern string, msg *ds.Message) ([]string, error) {
+	alternatives := strings.Split(strings.ToLower(pattern), "|")
+	for _, alt := range alternatives {
+		if _, err := path.Match(strings.TrimSpace(alt), ""); err != nil {
+			return nil, fmt.Errorf("invalid role pattern %q: %w", pattern, err)
+		}
+	}
+	return b.sendToMatching(msg, func(m *Mailbox) bool {
+		if m.role == "" {
+			return false
+		}
+		role := strings.ToLower(m.role)
+		for _, alt := range alternatives {
+			if ok, _ := path.Match(strings.TrimSpace(alt), role); ok {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// sendToMatching 向满足条件的每个 Mailbox 投递一份消息副本（各自生成新的消息 ID）
+func (b *MailboxBus) sendToMatching(msg *ds.Message, match func(m *Mailbox) bool) ([]string, error) {
+	if msg == nil {
+		return nil, fmt.Errorf("message is nil")
+	}
+
+	b.mu.RLock()
+	receivers := make([]string, 0)
+	for name, m := range b.mailboxes {
+		if name != msg.Sender && match(m) {
+			receivers = append(receivers, name)
+		}
+	}
+	b.mu.RUnlock()
+	sort.Strings(receivers)
+
+	delivered := make([]string, 0, len(receivers))
+	var errs []error
+	for _, receiver := range receivers {
+		id, err := utils.NewUUID()
+		if err != nil {
+			return delivered, err
+		}
+		copied := msg.Copy()
+		copied.ID = id
+		copied.Receiver = receiver
+		if err := b.Send(copied); err != nil {
+			errs = append(errs, fmt.Errorf("send to %s: %w", receiver, err))
+			continue
+		}
+		delivered = append(delivered, receiver)
+	}
+	return delivered, errors.Join(errs...)
+}
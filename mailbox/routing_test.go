@@ -0,0 +1,82 @@
+package mailbox
+
+import (
+	"slices"
+	"testing"
+
+	"superman/ds"
+)
+
+// newOrgBus 按组织架构注册信箱：CEO 为层级 1，各 C 级高管与 HR 为层级 2，运营与工程师为层级 3
+func newOrgBus(t *testing.T) (*MailboxBus, map[string]*Mailbox) {
+	t.Helper()
+	bus := NewMailboxBus()
+	org := []struct {
+		name      string
+		hierarchy int
+	}{
+		{"ceo", 1}, {"cto", 2}, {"cfo", 2}, {"cmo", 2}, {"cpo", 2}, {"hr", 2}, {"operations", 3}, {"engineer", 3},
+	}
+	boxes := make(map[string]*Mailbox, len(org))
+	for _, a := range org {
+		cfg := DefaultMailboxConfig(a.name)
+		cfg.InboxBufferSize = 10
+		cfg.Role = a.name
+		cfg.Hierarchy = a.hierarchy
+		boxes[a.name] = NewMailbox(cfg)
+		if err := bus.RegisterMailbox(a.name, boxes[a.name]); err != nil {
+			t.Fatalf("register mailbox %s: %v", a.name, err)
+		}
+	}
+	return bus, boxes
+}
+
+// assertReceived 检查恰好 want 中的信箱各收到一条消息，其余信箱为空
+func assertReceived(t *testing.T, boxes map[string]*Mailbox, want []string) {
+	t.Helper()
+	for name, mb := range boxes {
+		wantCount := 0
+		if slices.Contains(want, name) {
+			wantCount = 1
+		}
+		if got := mb.GetInboxCount(); got != wantCount {
+			t.Errorf("%s inbox = %d, want %d", name, got, wantCount)
+		}
+	}
+}
+
+// 按层级 2 发送的消息恰好送达 CTO、CFO、CMO、CPO、HR，不会送达其他层级
+func TestSendToHierarchyReachesOnlyThatLevel(t *testing.T) {
+	bus, boxes := newOrgBus(t)
+	msg, _ := ds.NewMessage("ceo", "", ds.MessageTypeSystem, "季度目标已更新")
+
+	delivered, err := bus.SendToHierarchy(2, msg)
+	if err != nil {
+		t.Fatalf("SendToHierarchy: %v", err)
+	}
+	want := []string{"cfo", "cmo", "cpo", "cto", "hr"}
+	if !slices.Equal(delivered, want) {
+		t.Fatalf("delivered = %v, want %v", delivered, want)
+	}
+	assertReceived(t, boxes, want)
+}
+
+// 按角色模式发送支持通配符与 | 分隔的多个模式，且不投递给发送者本人
+func TestSendToRolePatternMatchesRoles(t *testing.T) {
+	bus, boxes := newOrgBus(t)
+	msg, _ := ds.NewMessage("cto", "", ds.MessageTypeSystem, "all C-suite")
+
+	delivered, err := bus.SendToRolePattern("C?O|hr", msg)
+	if err != nil {
+		t.Fatalf("SendToRolePattern: %v", err)
+	}
+	want := []string{"ceo", "cfo", "cmo", "cpo", "hr"}
+	if !slices.Equal(delivered, want) {
+		t.Fatalf("delivered = %v, want %v", delivered, want)
+	}
+	assertReceived(t, boxes, want)
+
+	if _, err := bus.SendToRolePattern("[", msg); err == nil {
+		t.Fatal("SendToRolePattern([) succeeded, want an invalid pattern error")
+	}
+}
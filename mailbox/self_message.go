@@ -0,0 +1,58 @@
+package mailbox
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"superman/ds"
+)
+
+// ErrSelfMessage 消息的发送者与接收者相同
+var ErrSelfMessage = errors.New("message addressed to its own sender")
+
+// 发送者给自己发消息时的处理策略
+const (
+	SelfMessageReject = "reject" // 拒绝并返回 ErrSelfMessage（默认），发送工具会把错误反馈给模型
+	SelfMessageDrop   = "drop"   // 记录日志后丢弃，发送方视为成功
+	SelfMessageAllow  = "allow"  // 照常投递
+)
+
+// SetSelfMessagePolicy 设置发送者给自己发消息时的处理策略，避免 Agent 回复自己形成消息风暴
+func (b *MailboxBus) SetSelfMessagePolicy(policy string) error {
+	switch policy {
+	case "":
+		policy = SelfMessageReject
+	case SelfMessageReject, SelfMessageDrop, SelfMessageAllow:
+	default:
+		return fmt.Errorf("unknown self message policy %q", policy)
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.selfMessagePolicy = policy
+	return nil
+}
+
+// checkSelfMessage 检查发给发送者自己的消息，返回 false 表示不应投递；被拒绝时同时返回 ErrSelfMessage
+func (b *MailboxBus) checkSelfMessage(msg *ds.Message) (bool, error) {
+	if msg.Sender == "" || msg.Sender != msg.Receiver {
+		return true, nil
+	}
+	b.mu.RLock()
+	policy := b.selfMessagePolicy
+	b.mu.RUnlock()
+
+	switch policy {
+	case SelfMessageAllow:
+		return true, nil
+	case SelfMessageDrop:
+		slog.Warn("self-addressed message dropped",
+			slog.String("msg_id", msg.ID),
+			slog.String("agent", msg.Sender),
+			slog.String("type", string(msg.Type)),
+		)
+		return false, nil
+	default:
+		return false, fmt.Errorf("%w: %s", ErrSelfMessage, msg.Sender)
+	}
+}
@@ -0,0 +1,39 @@
+package mailbox
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"superman/ds"
+)
+
+// ErrSenderNotAllowed 消息发送者不在接收者的允许名单中或在拒绝名单中
+var ErrSenderNotAllowed = errors.New("sender not allowed")
+
+// SystemSenders 系统内部发送者（任务分发、系统通知），不受允许名单限制，仍可被拒绝名单拦截
+var SystemSenders = []string{"scheduler", "system"}
+
+// acceptsSender 按允许/拒绝名单检查发送者：拒绝名单优先；允许名单为空时接收所有发送者
+func (mb *Mailbox) acceptsSender(sender string) bool {
+	if slices.Contains(mb.deniedSenders, sender) {
+		return false
+	}
+	if len(mb.allowedSenders) == 0 || slices.Contains(SystemSenders, sender) {
+		return true
+	}
+	return slices.Contains(mb.allowedSenders, sender)
+}
+
+// enforceSender 拒收不被允许的发送者的消息，配置了 DeadLetterRejected 时放入死信队列
+func (mb *Mailbox) enforceSender(msg *ds.Message) error {
+	if mb.acceptsSender(msg.Sender) {
+		return nil
+	}
+	mb.incr(&mb.filtered)
+	err := fmt.Errorf("%w: %s does not accept messages from %q", ErrSenderNotAllowed, mb.receiver, msg.Sender)
+	if mb.deadLetterRejected && mb.bus != nil {
+		mb.bus.DeadLetter(msg, err.Error())
+	}
+	return err
+}
@@ -2,30 +2,46 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"flag"
 	"fmt"
+	"io"
 	"log/slog"
+	"net/http"
 	"os"
 	"os/signal"
+	"sort"
+	"strings"
 	"syscall"
 	"time"
 
-	"superman/agents"
 	"superman/api"
+	"superman/company"
 	"superman/config"
-	"superman/ds"
 	"superman/infra"
-	"superman/mailbox"
-	"superman/scheduler"
-	"superman/timer"
-	"superman/workflow"
+	"superman/simulation"
 
 	"github.com/cv70/pkgo/mistake"
 )
 
 func main() {
+	configFlag := flag.String("config", "", "comma-separated config files, later files override earlier keys (default config.yaml + config.<APP_ENV>.yaml)")
+	simulateFlag := flag.Duration("simulate", 0, "run in simulation mode: fast-forward virtual time by this duration (e.g. 24h), then shut down")
+	reportFlag := flag.String("report", "", "print the markdown company report from a running server at this base URL (e.g. http://localhost:8080) and exit")
+	flag.Parse()
+
+	if *reportFlag != "" {
+		mistake.Unwrap(printReport(*reportFlag))
+		return
+	}
+
 	slog.Info("SuperMan AI Multi-Agent Company System starting")
 
-	err := config.InitConfig()
+	var configFiles []string
+	if *configFlag != "" {
+		configFiles = strings.Split(*configFlag, ",")
+	}
+	err := config.InitConfig(configFiles...)
 	mistake.Unwrap(err)
 
 	ctx := context.Background()
@@ -33,69 +49,51 @@ func main() {
 	r, err := infra.NewRegistry(ctx, &config.AppConfig)
 	mistake.Unwrap(err)
 
-	// 创建 MailboxBus（全局消息总线）
-	mailboxBus := mailbox.NewMailboxBus()
-	globalState := mailboxBus.GetGlobalState()
+	slog.Info("creating companies")
 
-	// 创建 Orchestrator（任务分发器）
-	orchestrator := workflow.NewOrchestrator(mailboxBus)
-
-	// 解析调度器轮询间隔
-	tickInterval := 5 * time.Second
-	if config.AppConfig.Scheduler != nil && config.AppConfig.Scheduler.TickInterval != "" {
-		if d, err := time.ParseDuration(config.AppConfig.Scheduler.TickInterval); err == nil {
-			tickInterval = d
+	companies := make(map[string]*company.Company)
+	for _, companyConfig := range config.AppConfig.GetCompanies() {
+		if _, exists := companies[companyConfig.ID]; exists {
+			mistake.Unwrap(fmt.Errorf("duplicate company id %s", companyConfig.ID))
 		}
-	}
-
-	// 创建 AutoScheduler（调度器）
-	schedulerInstance := scheduler.NewAutoScheduler(orchestrator, globalState, tickInterval)
-
-	slog.Info("creating AI agents")
-
-	agentMap := make(map[string]agents.Agent)
-	for _, agentConfig := range config.AppConfig.Agents {
-		agent, err := agents.NewBaseAgent(ctx, r.LLM[agentConfig.Model], mailboxBus, agentConfig, config.AppConfig.Agents...)
-		mistake.Unwrap(err)
-
-		orchestrator.RegisterAgent(agent)
-
-		mb := agent.GetMailbox()
-		err = mailboxBus.RegisterMailbox(agent.GetName(), mb)
+		co, err := company.NewCompany(ctx, r, companyConfig)
 		mistake.Unwrap(err)
+		companies[co.ID] = co
+	}
+	if path := config.AppConfig.ShutdownReport; path != "" {
+		r.RegisterShutdownHook("shutdown_report", func(ctx context.Context) error {
+			return writeShutdownReport(path, companies)
+		}, 0)
+	}
 
-		agent.SetGlobalState(globalState)
-
-		agent.SetTaskSubmitter(func(task *ds.Task, priority string) {
-			schedulerInstance.AddTask(task, priority)
-		})
-
-		agent.SetOnTaskComplete(schedulerInstance.OnTaskComplete)
-
-		agentMap[agent.GetName()] = agent
-
-		maxTasks := agentConfig.MaxTasks
-		if maxTasks <= 0 {
-			maxTasks = 3
-		}
-		schedulerInstance.AddAgent(agentConfig.Name, maxTasks, agentConfig.Hierarchy)
+	if *simulateFlag > 0 {
+		simulate(ctx, r, companies, *simulateFlag)
+		return
+	}
 
-		err = agent.Start()
+	for _, co := range companies {
+		err = co.Start()
 		mistake.Unwrap(err)
 	}
 
-	schedulerInstance.Start()
-
-	timerEngine := timer.NewTimerEngine(schedulerInstance, config.AppConfig.Timer)
-	timerEngine.Start()
-
-	api.Initialize(agentMap, mailboxBus, orchestrator, schedulerInstance, timerEngine)
+	api.Initialize(companies, config.DefaultCompanyID)
+	api.SetModelHealth(r.ModelHealth)
+	api.SetChatModels(r.LLM)
 
 	slog.Info("system initialized",
-		slog.Int("agent_count", len(agentMap)),
-		slog.Int("queue_length", schedulerInstance.GetQueueLength()),
+		slog.Int("company_count", len(companies)),
 	)
 
+	if interval, err := time.ParseDuration(config.AppConfig.ReloadInterval); err == nil && interval > 0 {
+		go config.Watch(ctx, configFiles, interval, func(c *config.Config) {
+			for _, companyConfig := range c.GetCompanies() {
+				if co, exists := companies[companyConfig.ID]; exists {
+					co.ApplyConfig(companyConfig)
+				}
+			}
+		})
+	}
+
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
 
@@ -109,24 +107,95 @@ func main() {
 	fmt.Printf("HTTP server started on port %s\n", port)
 	fmt.Println("Press Ctrl+C to shutdown...")
 
-	<-sigCh
-	fmt.Println("\nReceived shutdown signal, shutting down...")
+	select {
+	case <-sigCh:
+		fmt.Println("\nReceived shutdown signal, shutting down...")
+	case <-api.ShutdownRequested():
+		fmt.Println("Shutdown requested via API, shutting down...")
+	}
+
+	shutdown(r, companies, config.AppConfig.GetShutdownTimeout())
+}
+
+// simulate 以模拟模式运行所有公司：在虚拟时间上快进 d 后停止，Ctrl+C 提前结束
+func simulate(ctx context.Context, r *infra.Registry, companies map[string]*company.Company, d time.Duration) {
+	ctx, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	start := time.Now()
+	for _, co := range companies {
+		mistake.Unwrap(co.StartSimulated())
+	}
+	for _, co := range companies {
+		sim := simulation.New(co, start)
+		if err := sim.Advance(ctx, d); err != nil {
+			slog.Warn("simulation interrupted", slog.String("company", co.ID), slog.Any("error", err))
+		}
+		slog.Info("simulation finished",
+			slog.String("company", co.ID),
+			slog.Duration("virtual_elapsed", sim.Now().Sub(start)),
+			slog.Any("generation_cycles", sim.GenerationCycles()),
+		)
+	}
+
+	shutdown(r, companies, config.AppConfig.GetShutdownTimeout())
+}
 
-	shutdown(timerEngine, schedulerInstance, agentMap)
+// printReport 从运行中的服务获取默认公司的 Markdown 报告并输出到标准输出
+func printReport(baseURL string) error {
+	resp, err := http.Get(strings.TrimRight(baseURL, "/") + "/api/report?format=markdown")
+	if err != nil {
+		return fmt.Errorf("failed to fetch report: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read report: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to fetch report: %s: %s", resp.Status, body)
+	}
+	_, err = os.Stdout.Write(body)
+	return err
 }
 
-func shutdown(timerEngine interface{}, schedulerInstance *scheduler.AutoScheduler, agentMap map[string]agents.Agent) {
-	slog.Info("stopping timer engine")
+// writeShutdownReport 将各公司的运行摘要以 JSON 写入文件
+func writeShutdownReport(path string, companies map[string]*company.Company) error {
+	reports := make([]company.ShutdownReport, 0, len(companies))
+	for _, co := range companies {
+		reports = append(reports, co.ShutdownReport())
+	}
+	sort.Slice(reports, func(i, j int) bool { return reports[i].Company < reports[j].Company })
 
-	slog.Info("stopping scheduler")
-	schedulerInstance.Stop()
+	data, err := json.MarshalIndent(reports, "", "  ")
+	if err == nil {
+		err = os.WriteFile(path, data, 0o644)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write shutdown report %s: %w", path, err)
+	}
+	slog.Info("shutdown report written", slog.String("path", path))
+	return nil
+}
 
-	slog.Info("stopping agents")
-	for name, agent := range agentMap {
-		if err := agent.Stop(); err != nil {
-			slog.Error("failed to stop agent", slog.String("agent", name), slog.Any("error", err))
+// shutdown 停止所有公司并执行 Registry 中注册的停止钩子，超过 timeout 仍有 Agent 未退出时强制退出进程
+func shutdown(r *infra.Registry, companies map[string]*company.Company, timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	forced := false
+	for _, co := range companies {
+		if err := co.Stop(ctx); err != nil {
+			slog.Error("graceful shutdown incomplete", slog.Any("error", err))
+			forced = true
 		}
 	}
+	// 停止钩子在公司停止后执行，各自受钩子超时约束，不受已耗尽的整体停止时间影响
+	r.ShutdownHooks.Run(context.Background())
+	if forced {
+		slog.Error("shutdown timed out, forcing exit", slog.Duration("timeout", timeout))
+		os.Exit(1)
+	}
 
 	slog.Info("shutdown complete")
 }
@@ -0,0 +1,354 @@
+package persistence
+
+import (
+	"encoding/json"
+	"time"
+
+	"superman/mailbox"
+	"superman/state"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Persistence 基于 GORM 的持久化层
+type Persistence struct {
+	db *gorm.DB
+}
+
+// NewPersistence 创建持久化层并迁移表结构
+func NewPersistence(db *gorm.DB) (*Persistence, error) {
+	if err := db.AutoMigrate(&TimerJobRecord{}, &TaskLeaseRecord{}, &EventRecord{}, &DeadLetterRecord{}, &TaskResultRecord{}, &AgentMemoryRecord{}); err != nil {
+		return nil, err
+	}
+	return &Persistence{db: db}, nil
+}
+
+// TimerJobRecord 定时任务运行记录
+type TimerJobRecord struct {
+	CompanyID string `gorm:"primaryKey"`
+	Name      string `gorm:"primaryKey"`
+	LastRun   time.Time
+	UpdatedAt time.Time
+}
+
+// SaveTimerLastRun 保存定时任务的上次运行时间
+func (p *Persistence) SaveTimerLastRun(companyID, job string, lastRun time.Time) error {
+	return p.db.Save(&TimerJobRecord{
+		CompanyID: companyID,
+		Name:      job,
+		LastRun:   lastRun,
+	}).Error
+}
+
+// LoadTimerLastRuns 加载公司下所有定时任务的上次运行时间，返回 任务名 -> 上次运行时间
+func (p *Persistence) LoadTimerLastRuns(companyID string) (map[string]time.Time, error) {
+	var records []TimerJobRecord
+	if err := p.db.Where("company_id = ?", companyID).Find(&records).Error; err != nil {
+		return nil, err
+	}
+	result := make(map[string]time.Time, len(records))
+	for _, r := range records {
+		result[r.Name] = r.LastRun
+	}
+	return result, nil
+}
+
+// TaskLeaseRecord 任务分发租约，多实例共享数据库时保证同一任务只被一个调度器分发
+type TaskLeaseRecord struct {
+	TaskID      string `gorm:"primaryKey"`
+	LeasedBy    string `gorm:"index"`
+	LeaseExpiry time.Time
+	Completed   bool // 任务已完成：保留为墓碑，任何实例都不能再次获取，直到保留期满被清理
+}
+
+// CompletedLeaseRetention 已完成任务的租约墓碑保留时长
+const CompletedLeaseRetention = 24 * time.Hour
+
+// AcquireTaskLease 尝试获取任务租约：不存在时创建；已存在时仅当未完成且属于自己或已过期才接管
+func (p *Persistence) AcquireTaskLease(taskID, owner string, ttl time.Duration) (bool, error) {
+	now := time.Now()
+	res := p.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&TaskLeaseRecord{
+		TaskID:      taskID,
+		LeasedBy:    owner,
+		LeaseExpiry: now.Add(ttl),
+	})
+	if res.Error != nil {
+		return false, res.Error
+	}
+	if res.RowsAffected == 1 {
+		return true, nil
+	}
+
+	res = p.db.Model(&TaskLeaseRecord{}).
+		Where("task_id = ? AND completed = ? AND (leased_by = ? OR lease_expiry < ?)", taskID, false, owner, now).
+		Updates(map[string]any{
+			"leased_by":    owner,
+			"lease_expiry": now.Add(ttl),
+		})
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected == 1, nil
+}
+
+// ReleaseTaskLease 释放自己持有的任务租约（任务未完成，如失败待重试、回到队列）
+func (p *Persistence) ReleaseTaskLease(taskID, owner string) error {
+	return p.db.Where("task_id = ? AND leased_by = ? AND completed = ?", taskID, owner, false).Delete(&TaskLeaseRecord{}).Error
+}
+
+// CompleteTaskLease 将自己持有的任务租约标记为已完成，保留 CompletedLeaseRetention 防止其他实例重新分发
+func (p *Persistence) CompleteTaskLease(taskID, owner string) error {
+	return p.db.Model(&TaskLeaseRecord{}).
+		Where("task_id = ? AND leased_by = ?", taskID, owner).
+		Updates(map[string]any{
+			"completed":    true,
+			"lease_expiry": time.Now().Add(CompletedLeaseRetention),
+		}).Error
+}
+
+// RenewTaskLease 延长自己持有的未完成任务租约，返回是否仍持有租约
+func (p *Persistence) RenewTaskLease(taskID, owner string, ttl time.Duration) (bool, error) {
+	res := p.db.Model(&TaskLeaseRecord{}).
+		Where("task_id = ? AND leased_by = ? AND completed = ?", taskID, owner, false).
+		Update("lease_expiry", time.Now().Add(ttl))
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected == 1, nil
+}
+
+// ExpireTaskLeases 清理已过期的任务租约与保留期满的墓碑，返回清理数量
+func (p *Persistence) ExpireTaskLeases(now time.Time) (int64, error) {
+	res := p.db.Where("lease_expiry < ?", now).Delete(&TaskLeaseRecord{})
+	return res.RowsAffected, res.Error
+}
+
+// EventRecord 事件日志记录
+type EventRecord struct {
+	ID        uint   `gorm:"primaryKey"`
+	CompanyID string `gorm:"uniqueIndex:idx_event_company_seq"`
+	Seq       int64  `gorm:"uniqueIndex:idx_event_company_seq"`
+	Type      string
+	Timestamp time.Time
+	Data      string // JSON
+}
+
+// EventSink 将公司事件写入数据库的事件接收方
+type EventSink struct {
+	p         *Persistence
+	companyID string
+}
+
+// NewEventSink 创建公司的事件接收方
+func (p *Persistence) NewEventSink(companyID string) *EventSink {
+	return &EventSink{p: p, companyID: companyID}
+}
+
+// SaveEvent 保存事件
+func (s *EventSink) SaveEvent(e state.Event) error {
+	data, err := json.Marshal(e.Data)
+	if err != nil {
+		return err
+	}
+	return s.p.db.Create(&EventRecord{
+		CompanyID: s.companyID,
+		Seq:       e.Seq,
+		Type:      e.Type,
+		Timestamp: e.Timestamp,
+		Data:      string(data),
+	}).Error
+}
+
+// LastEventSeq 获取公司已持久化事件的最大序号，无事件时为 0
+func (s *EventSink) LastEventSeq() (int64, error) {
+	var seq int64
+	err := s.p.db.Model(&EventRecord{}).
+		Where("company_id = ?", s.companyID).
+		Select("COALESCE(MAX(seq), 0)").
+		Scan(&seq).Error
+	return seq, err
+}
+
+// DeadLetterRecord 死信记录
+type DeadLetterRecord struct {
+	ID        string `gorm:"primaryKey"`
+	CompanyID string `gorm:"index"`
+	Kind      string
+	CreatedAt time.Time
+	Data      string // JSON
+}
+
+// DeadLetterStore 公司死信的持久化存储
+type DeadLetterStore struct {
+	p         *Persistence
+	companyID string
+}
+
+// NewDeadLetterStore 创建公司的死信存储
+func (p *Persistence) NewDeadLetterStore(companyID string) *DeadLetterStore {
+	return &DeadLetterStore{p: p, companyID: companyID}
+}
+
+// SaveDeadLetter 保存死信
+func (s *DeadLetterStore) SaveDeadLetter(dl *mailbox.DeadLetter) error {
+	data, err := json.Marshal(dl)
+	if err != nil {
+		return err
+	}
+	return s.p.db.Save(&DeadLetterRecord{
+		ID:        dl.ID,
+		CompanyID: s.companyID,
+		Kind:      dl.Kind,
+		CreatedAt: dl.CreatedAt,
+		Data:      string(data),
+	}).Error
+}
+
+// DeleteDeadLetter 删除死信
+func (s *DeadLetterStore) DeleteDeadLetter(id string) error {
+	return s.p.db.Where("id = ? AND company_id = ?", id, s.companyID).Delete(&DeadLetterRecord{}).Error
+}
+
+// LoadDeadLetters 按产生时间加载公司的所有死信
+func (s *DeadLetterStore) LoadDeadLetters() ([]*mailbox.DeadLetter, error) {
+	var records []DeadLetterRecord
+	if err := s.p.db.Where("company_id = ?", s.companyID).Order("created_at").Find(&records).Error; err != nil {
+		return nil, err
+	}
+	result := make([]*mailbox.DeadLetter, 0, len(records))
+	for _, r := range records {
+		var dl mailbox.DeadLetter
+		if err := json.Unmarshal([]byte(r.Data), &dl); err != nil {
+			return nil, err
+		}
+		// 消息体反序列化后为通用结构，按消息类型还原为对应的消息体，重新投递后接收方可直接按类型读取
+		if dl.Message != nil && dl.Message.Body != nil {
+			body, err := json.Marshal(dl.Message.Body)
+			if err != nil {
+				return nil, err
+			}
+			dl.Message.Body = json.RawMessage(body)
+			if err := dl.Message.DecodeBody(); err != nil {
+				return nil, err
+			}
+		}
+		result = append(result, &dl)
+	}
+	return result, nil
+}
+
+// TaskResultRecord 任务结果记录
+type TaskResultRecord struct {
+	CompanyID   string `gorm:"primaryKey"`
+	TaskID      string `gorm:"primaryKey"`
+	Agent       string
+	Title       string
+	Status      string
+	Output      string
+	Result      string // JSON
+	CompletedAt time.Time
+}
+
+// TaskResultStore 公司任务结果的持久化存储
+type TaskResultStore struct {
+	p         *Persistence
+	companyID string
+}
+
+// NewTaskResultStore 创建公司的任务结果存储
+func (p *Persistence) NewTaskResultStore(companyID string) *TaskResultStore {
+	return &TaskResultStore{p: p, companyID: companyID}
+}
+
+// SaveTaskResult 保存任务结果，同一任务重复完成时覆盖
+func (s *TaskResultStore) SaveTaskResult(r *state.TaskResult) error {
+	result, err := json.Marshal(r.Result)
+	if err != nil {
+		return err
+	}
+	return s.p.db.Save(&TaskResultRecord{
+		CompanyID:   s.companyID,
+		TaskID:      r.TaskID,
+		Agent:       r.Agent,
+		Title:       r.Title,
+		Status:      r.Status,
+		Output:      r.Output,
+		Result:      string(result),
+		CompletedAt: r.CompletedAt,
+	}).Error
+}
+
+// LoadTaskResult 加载任务结果，无结果时返回 nil
+func (s *TaskResultStore) LoadTaskResult(taskID string) (*state.TaskResult, error) {
+	var records []TaskResultRecord
+	if err := s.p.db.Where("company_id = ? AND task_id = ?", s.companyID, taskID).Limit(1).Find(&records).Error; err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+	r := records[0]
+	var result any
+	if err := json.Unmarshal([]byte(r.Result), &result); err != nil {
+		return nil, err
+	}
+	return &state.TaskResult{
+		TaskID:      r.TaskID,
+		Agent:       r.Agent,
+		Title:       r.Title,
+		Status:      r.Status,
+		Output:      r.Output,
+		Result:      result,
+		CompletedAt: r.CompletedAt,
+	}, nil
+}
+
+// AgentMemoryRecord Agent 工作记忆记录
+type AgentMemoryRecord struct {
+	CompanyID string `gorm:"primaryKey"`
+	Agent     string `gorm:"primaryKey"`
+	Data      string // JSON
+	SavedAt   time.Time
+}
+
+// AgentMemoryStore 公司内 Agent 工作记忆的持久化存储
+type AgentMemoryStore struct {
+	p         *Persistence
+	companyID string
+}
+
+// NewAgentMemoryStore 创建公司的 Agent 工作记忆存储
+func (p *Persistence) NewAgentMemoryStore(companyID string) *AgentMemoryStore {
+	return &AgentMemoryStore{p: p, companyID: companyID}
+}
+
+// SaveAgentMemory 保存 Agent 的工作记忆，覆盖上次保存的内容
+func (s *AgentMemoryStore) SaveAgentMemory(m *state.AgentMemory) error {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	return s.p.db.Save(&AgentMemoryRecord{
+		CompanyID: s.companyID,
+		Agent:     m.Agent,
+		Data:      string(data),
+		SavedAt:   m.SavedAt,
+	}).Error
+}
+
+// LoadAgentMemory 加载 Agent 的工作记忆，未保存过时返回 nil
+func (s *AgentMemoryStore) LoadAgentMemory(agent string) (*state.AgentMemory, error) {
+	var records []AgentMemoryRecord
+	if err := s.p.db.Where("company_id = ? AND agent = ?", s.companyID, agent).Limit(1).Find(&records).Error; err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+	var memory state.AgentMemory
+	if err := json.Unmarshal([]byte(records[0].Data), &memory); err != nil {
+		return nil, err
+	}
+	return &memory, nil
+}
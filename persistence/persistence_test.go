@@ -0,0 +1,174 @@
+package persistence
+
+import (
+	"testing"
+	"time"
+
+	"superman/ds"
+	"superman/mailbox"
+	"superman/state"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// newTestPersistence 创建基于内存 SQLite 的持久化层
+func newTestPersistence(t *testing.T) *Persistence {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("db handle: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { _ = sqlDB.Close() })
+	p, err := NewPersistence(db)
+	if err != nil {
+		t.Fatalf("NewPersistence: %v", err)
+	}
+	return p
+}
+
+func mustAcquire(t *testing.T, p *Persistence, taskID, owner string, ttl time.Duration) bool {
+	t.Helper()
+	ok, err := p.AcquireTaskLease(taskID, owner, ttl)
+	if err != nil {
+		t.Fatalf("AcquireTaskLease(%s, %s): %v", taskID, owner, err)
+	}
+	return ok
+}
+
+// 已完成任务的租约保留为墓碑，其他实例（包括租约过期后）不能再次获取
+func TestCompletedTaskLeaseCannotBeReacquired(t *testing.T) {
+	p := newTestPersistence(t)
+
+	if !mustAcquire(t, p, "t1", "a", time.Millisecond) {
+		t.Fatal("a should acquire a new lease")
+	}
+	if err := p.CompleteTaskLease("t1", "a"); err != nil {
+		t.Fatalf("CompleteTaskLease: %v", err)
+	}
+	if _, err := p.ExpireTaskLeases(time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("ExpireTaskLeases: %v", err)
+	}
+	if mustAcquire(t, p, "t1", "b", time.Minute) {
+		t.Fatal("b acquired the lease of a completed task")
+	}
+	if err := p.ReleaseTaskLease("t1", "a"); err != nil {
+		t.Fatalf("ReleaseTaskLease: %v", err)
+	}
+	if mustAcquire(t, p, "t1", "a", time.Minute) {
+		t.Fatal("releasing must not remove the completed marker")
+	}
+
+	// 保留期满后墓碑被清理
+	if n, err := p.ExpireTaskLeases(time.Now().Add(CompletedLeaseRetention + time.Hour)); err != nil || n != 1 {
+		t.Fatalf("ExpireTaskLeases = %d, %v; want 1 tombstone removed", n, err)
+	}
+}
+
+// 续约延长租约，其他实例在续约后的有效期内无法接管
+func TestRenewTaskLease(t *testing.T) {
+	p := newTestPersistence(t)
+
+	if !mustAcquire(t, p, "t1", "a", time.Millisecond) {
+		t.Fatal("a should acquire a new lease")
+	}
+	held, err := p.RenewTaskLease("t1", "a", time.Hour)
+	if err != nil || !held {
+		t.Fatalf("RenewTaskLease = %v, %v; want held", held, err)
+	}
+	time.Sleep(5 * time.Millisecond)
+	if mustAcquire(t, p, "t1", "b", time.Minute) {
+		t.Fatal("b took over a renewed lease")
+	}
+	if held, _ := p.RenewTaskLease("t1", "b", time.Hour); held {
+		t.Fatal("b renewed a lease it does not hold")
+	}
+
+	// 失败释放后可被其他实例获取
+	if err := p.ReleaseTaskLease("t1", "a"); err != nil {
+		t.Fatalf("ReleaseTaskLease: %v", err)
+	}
+	if !mustAcquire(t, p, "t1", "b", time.Minute) {
+		t.Fatal("b should acquire a released lease")
+	}
+}
+
+// 事件序号按公司唯一，LastEventSeq 返回公司已持久化的最大序号
+func TestEventSinkSeq(t *testing.T) {
+	p := newTestPersistence(t)
+	sink := p.NewEventSink("acme")
+
+	if seq, err := sink.LastEventSeq(); err != nil || seq != 0 {
+		t.Fatalf("LastEventSeq = %d, %v; want 0", seq, err)
+	}
+	for _, seq := range []int64{1, 2, 7} {
+		if err := sink.SaveEvent(state.Event{Seq: seq, Type: state.EventAnnouncement, Timestamp: time.Now()}); err != nil {
+			t.Fatalf("SaveEvent(%d): %v", seq, err)
+		}
+	}
+	if err := p.NewEventSink("other").SaveEvent(state.Event{Seq: 100, Type: state.EventAnnouncement}); err != nil {
+		t.Fatalf("SaveEvent other company: %v", err)
+	}
+	if seq, err := sink.LastEventSeq(); err != nil || seq != 7 {
+		t.Fatalf("LastEventSeq = %d, %v; want 7", seq, err)
+	}
+	if err := sink.SaveEvent(state.Event{Seq: 7, Type: state.EventAnnouncement}); err == nil {
+		t.Fatal("duplicate (company, seq) was accepted")
+	}
+}
+
+// 任务结果按公司与任务 ID 读取，结构化结果经 JSON 往返
+func TestTaskResultStoreLoad(t *testing.T) {
+	p := newTestPersistence(t)
+	store := p.NewTaskResultStore("acme")
+
+	if r, err := store.LoadTaskResult("t1"); err != nil || r != nil {
+		t.Fatalf("LoadTaskResult before save = %+v, %v; want nil, nil", r, err)
+	}
+	want := &state.TaskResult{TaskID: "t1", Agent: "worker", Status: "completed", Output: "done", Result: map[string]any{"ok": true}, CompletedAt: time.Now()}
+	if err := store.SaveTaskResult(want); err != nil {
+		t.Fatalf("SaveTaskResult: %v", err)
+	}
+	got, err := store.LoadTaskResult("t1")
+	if err != nil || got == nil {
+		t.Fatalf("LoadTaskResult = %+v, %v", got, err)
+	}
+	if got.Agent != "worker" || got.Output != "done" || got.Result.(map[string]any)["ok"] != true {
+		t.Fatalf("LoadTaskResult = %+v, want %+v", got, want)
+	}
+	if r, _ := p.NewTaskResultStore("other").LoadTaskResult("t1"); r != nil {
+		t.Fatal("result leaked across companies")
+	}
+}
+
+// 加载的死信消息体按消息类型还原，接收方可直接按类型读取
+func TestDeadLetterStoreRestoresTypedBody(t *testing.T) {
+	p := newTestPersistence(t)
+	store := p.NewDeadLetterStore("acme")
+
+	msg, err := ds.NewRequestMessage("boss", "worker", "question", "status?", map[string]any{"k": "v"})
+	if err != nil {
+		t.Fatalf("NewRequestMessage: %v", err)
+	}
+	dl := &mailbox.DeadLetter{ID: "dl-1", Kind: mailbox.DeadLetterKindMessage, Message: msg, Reason: "test", CreatedAt: time.Now()}
+	if err := store.SaveDeadLetter(dl); err != nil {
+		t.Fatalf("SaveDeadLetter: %v", err)
+	}
+
+	loaded, err := store.LoadDeadLetters()
+	if err != nil || len(loaded) != 1 {
+		t.Fatalf("LoadDeadLetters = %v, %v; want one dead letter", loaded, err)
+	}
+	body, ok := loaded[0].Message.GetRequestBody()
+	if !ok {
+		t.Fatalf("GetRequestBody failed, body is %T", loaded[0].Message.Body)
+	}
+	if body.Type != "question" || body.Content != "status?" || body.Metadata["k"] != "v" {
+		t.Fatalf("GetRequestBody = %+v", body)
+	}
+}
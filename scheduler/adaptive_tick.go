@@ -0,0 +1,35 @@
+package scheduler
+
+import (
+	"log/slog"
+	"time"
+)
+
+// SetMaxIdleTick 设置空闲退避的最大轮询间隔：队列为空时轮询间隔逐轮翻倍直至该值，
+// 有任务入队或队列非空时恢复基础间隔；<= 基础间隔时关闭退避，按固定间隔轮询
+func (s *AutoScheduler) SetMaxIdleTick(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.maxIdleTick = max(d, 0)
+	slog.Info("scheduler idle backoff set", slog.Duration("max_idle_tick", s.maxIdleTick))
+}
+
+// nextTickInterval 根据本轮结束后队列是否为空计算下一轮的轮询间隔
+func (s *AutoScheduler) nextTickInterval(current time.Duration) time.Duration {
+	s.mu.RLock()
+	base, maxIdle := s.tickInterval, s.maxIdleTick
+	s.mu.RUnlock()
+
+	if maxIdle <= base || s.GetQueueLength() > 0 {
+		return base
+	}
+	return min(max(current, base)*2, maxIdle)
+}
+
+// signalWake 通知调度循环有新任务入队、立即尝试分发，不阻塞；连续入队的通知合并为一次
+func (s *AutoScheduler) signalWake() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,83 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"superman/ds"
+	"superman/state"
+)
+
+// 队列空闲时轮询间隔逐轮翻倍直至上限，队列非空时恢复基础间隔；上限不大于基础间隔时不退避
+func TestIdleTickBacksOffUntilMax(t *testing.T) {
+	s := NewAutoScheduler(&recordingDispatcher{}, state.NewGlobalState(), 100*time.Millisecond)
+	if got := s.nextTickInterval(100 * time.Millisecond); got != 100*time.Millisecond {
+		t.Fatalf("interval without backoff = %s, want the 100ms base", got)
+	}
+
+	s.SetMaxIdleTick(time.Second)
+	interval := 100 * time.Millisecond
+	var intervals []time.Duration
+	for range 5 {
+		interval = s.nextTickInterval(interval)
+		intervals = append(intervals, interval)
+	}
+	want := []time.Duration{200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, time.Second, time.Second}
+	for i := range want {
+		if intervals[i] != want[i] {
+			t.Fatalf("idle intervals = %v, want %v", intervals, want)
+		}
+	}
+
+	s.AddTask(newTestTask("t1"), PriorityMedium)
+	if got := s.nextTickInterval(interval); got != 100*time.Millisecond {
+		t.Fatalf("interval with a queued task = %s, want the 100ms base", got)
+	}
+}
+
+// 空闲退避中有新任务入队时唤醒调度循环，任务不必等待整个退避周期
+func TestAddTaskWakesLoopDuringIdleBackoff(t *testing.T) {
+	d := &recordingDispatcher{}
+	s := NewAutoScheduler(d, state.NewGlobalState(), 20*time.Millisecond)
+	s.SetMaxIdleTick(time.Hour)
+	s.AddAgent("alice", 1, 1)
+	s.Start()
+	t.Cleanup(s.Stop)
+
+	// 空闲约 700ms 后轮询间隔已退避到 640ms，下一次轮询在约 1.26s
+	time.Sleep(700 * time.Millisecond)
+	added := time.Now()
+	s.AddTask(newTestTask("t1"), PriorityMedium)
+	for len(d.dispatched()) == 0 {
+		if time.Since(added) > 2*time.Second {
+			t.Fatal("task not dispatched while the loop was backing off")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if waited := time.Since(added); waited > 150*time.Millisecond {
+		t.Fatalf("task dispatched %s after it was added, want the loop woken within the 20ms base interval", waited)
+	}
+}
+
+// 两次轮询之间加入的 Critical 任务在毫秒级内分发，而不是等待整个轮询间隔
+func TestCriticalTaskDispatchesBetweenTicks(t *testing.T) {
+	d := &recordingDispatcher{}
+	s := NewAutoScheduler(d, state.NewGlobalState(), time.Hour)
+	s.AddAgent("alice", 1, 1)
+	s.Start()
+	t.Cleanup(s.Stop)
+
+	task := newTestTask("urgent")
+	task.Priority = ds.TaskPriorityCritical
+	added := time.Now()
+	s.AddTask(task, PriorityCritical)
+	for len(d.dispatched()) == 0 {
+		if time.Since(added) > 2*time.Second {
+			t.Fatal("critical task not dispatched before the next 1h tick")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if waited := time.Since(added); waited > 100*time.Millisecond {
+		t.Fatalf("critical task dispatched %s after it was added, want within milliseconds", waited)
+	}
+}
@@ -0,0 +1,82 @@
+package scheduler
+
+import (
+	"slices"
+
+	"superman/ds"
+)
+
+// 任务元数据中的亲和性规则键，值为任务 ID 或标签（字符串或字符串列表）
+const (
+	MetadataAffinity     = "affinity"      // 优先分配给正在执行关联任务的 Agent，便于复用上下文
+	MetadataAntiAffinity = "anti_affinity" // 不分配给正在执行关联任务的 Agent，使任务分散执行
+)
+
+// inFlightTask 在途任务的执行者与标签，用于亲和性匹配
+type inFlightTask struct {
+	agent string
+	tags  []string
+}
+
+// affinityRefs 读取任务元数据中的亲和性引用（任务 ID 或标签）
+func affinityRefs(task *ds.Task, key string) []string {
+	switch v := task.Metadata[key].(type) {
+	case string:
+		if v != "" {
+			return []string{v}
+		}
+	case []string:
+		return v
+	case []any:
+		refs := make([]string, 0, len(v))
+		for _, item := range v {
+			if ref, ok := item.(string); ok && ref != "" {
+				refs = append(refs, ref)
+			}
+		}
+		return refs
+	}
+	return nil
+}
+
+// linkedAgents 返回正在执行与引用关联任务（ID 相同或带有该标签）的 Agent 集合（调用方持有锁）
+func (s *AutoScheduler) linkedAgents(taskID string, refs []string) map[string]bool {
+	if len(refs) == 0 {
+		return nil
+	}
+	agents := make(map[string]bool)
+	for id, running := range s.inFlightTasks {
+		if id == taskID {
+			continue
+		}
+		for _, ref := range refs {
+			if ref == id || slices.Contains(running.tags, ref) {
+				agents[running.agent] = true
+				break
+			}
+		}
+	}
+	return agents
+}
+
+// applyAffinity 按亲和性规则筛选候选 Agent（调用方持有锁）：排除正在执行反亲和任务的 Agent；
+// 若有候选正在执行亲和任务则只保留这些候选。返回筛选后的候选及是否命中亲和规则
+func (s *AutoScheduler) applyAffinity(task *ds.Task, candidates []*AgentLoad) ([]*AgentLoad, bool) {
+	if avoid := s.linkedAgents(task.ID, affinityRefs(task, MetadataAntiAffinity)); len(avoid) > 0 {
+		candidates = slices.DeleteFunc(candidates, func(agent *AgentLoad) bool { return avoid[agent.Name] })
+	}
+	prefer := s.linkedAgents(task.ID, affinityRefs(task, MetadataAffinity))
+	if len(prefer) == 0 {
+		return candidates, false
+	}
+	var preferred []*AgentLoad
+	for _, agent := range candidates {
+		if prefer[agent.Name] {
+			preferred = append(preferred, agent)
+		}
+	}
+	if len(preferred) == 0 {
+		return candidates, false
+	}
+	return preferred, true
+}
@@ -0,0 +1,58 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+// dispatchOne 加入单个任务并分发，返回其执行者
+func dispatchOne(t *testing.T, s *AutoScheduler, id string, tags []string, metadata map[string]any) string {
+	t.Helper()
+	task := newTestTask(id)
+	task.Tags = tags
+	for k, v := range metadata {
+		task.Metadata[k] = v
+	}
+	s.AddTask(task, PriorityMedium)
+	s.Tick(time.Now())
+	assigned := s.globalState.GetTask(id)
+	if assigned == nil || assigned.AssignedTo == "" {
+		t.Fatalf("task %s was not dispatched", id)
+	}
+	return assigned.AssignedTo
+}
+
+// 亲和任务即使另一 Agent 更空闲，也分配给正在执行关联任务的 Agent
+func TestAffinityTasksShareAgent(t *testing.T) {
+	s, _, _ := newTestScheduler(t)
+	s.AddAgent("alice", 3, 1)
+	s.AddAgent("bob", 3, 1)
+
+	first := dispatchOne(t, s, "step1", []string{"report"}, nil)
+	byTag := dispatchOne(t, s, "step2", nil, map[string]any{MetadataAffinity: "report"})
+	byID := dispatchOne(t, s, "step3", nil, map[string]any{MetadataAffinity: []any{"step1"}})
+	if byTag != first || byID != first {
+		t.Fatalf("affinity tasks ran on %s and %s, want %s", byTag, byID, first)
+	}
+}
+
+// 反亲和任务即使该 Agent 更空闲，也避开正在执行关联任务的 Agent
+func TestAntiAffinityTasksSpread(t *testing.T) {
+	s, _, _ := newTestScheduler(t)
+	s.AddAgent("alice", 3, 1)
+	s.AddAgent("bob", 3, 1)
+
+	first := dispatchOne(t, s, "shard1", []string{"shard"}, nil)
+	other := dispatchOne(t, s, "filler1", nil, nil)
+	if other == first {
+		t.Fatalf("filler task ran on %s with shard1, want the idle agent", other)
+	}
+	// 使另一 Agent 负载更高，无规则时下一任务会回到 first
+	if got := dispatchOne(t, s, "filler2", nil, map[string]any{MetadataAffinity: "filler1"}); got != other {
+		t.Fatalf("filler2 ran on %s, want %s", got, other)
+	}
+
+	if got := dispatchOne(t, s, "shard2", []string{"shard"}, map[string]any{MetadataAntiAffinity: "shard"}); got == first {
+		t.Fatalf("anti-affinity task shard2 ran on %s together with shard1", got)
+	}
+}
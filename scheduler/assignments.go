@@ -0,0 +1,48 @@
+package scheduler
+
+import (
+	"sort"
+
+	"superman/ds"
+)
+
+// GetAgentAssignments 获取已分发给指定 Agent 且尚未完成的任务副本，按优先级与创建时间排序
+func (s *AutoScheduler) GetAgentAssignments(agentName string) []*ds.Task {
+	result := make([]*ds.Task, 0)
+	if s.globalState == nil {
+		return result
+	}
+
+	// 以负载跟踪中的在途任务为准，再从全局状态中按执行者筛选
+	s.mu.RLock()
+	ids := make([]string, 0, len(s.inFlightEffort))
+	for id := range s.inFlightEffort {
+		ids = append(ids, id)
+	}
+	s.mu.RUnlock()
+
+	for _, task := range s.globalState.GetTasksByIDs(ids) {
+		if task.AssignedTo == agentName && !task.IsCompleted() {
+			result = append(result, task.Copy())
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		ri, rj := taskPriorityRank[result[i].Priority], taskPriorityRank[result[j].Priority]
+		if ri != rj {
+			return ri < rj
+		}
+		return result[i].CreatedAt.Before(result[j].CreatedAt)
+	})
+	return result
+}
+
+// GetAgentLoad 获取 Agent 负载快照
+func (s *AutoScheduler) GetAgentLoad(agentName string) (AgentLoad, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	load, exists := s.agentLoads[agentName]
+	if !exists {
+		return AgentLoad{}, false
+	}
+	return *load, true
+}
@@ -0,0 +1,43 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"superman/ds"
+)
+
+// 分发的任务出现在执行者的任务视图中，完成后从视图中移除
+func TestAgentAssignmentsFollowDispatchAndCompletion(t *testing.T) {
+	s, _, gs := newTestScheduler(t)
+	s.AddAgent("alice", 1, 1)
+	s.AddAgent("bob", 1, 1)
+	s.AddTask(newTestTask("t1"), PriorityMedium)
+	s.AddTask(newTestTask("t2"), PriorityMedium)
+	s.Tick(time.Now())
+
+	owner := gs.GetTask("t1").AssignedTo
+	other := map[string]string{"alice": "bob", "bob": "alice"}[owner]
+	assigned := s.GetAgentAssignments(owner)
+	if len(assigned) != 1 || assigned[0].ID != "t1" {
+		t.Fatalf("%s assignments = %v, want [t1]", owner, taskIDs(assigned))
+	}
+	if assigned := s.GetAgentAssignments(other); len(assigned) != 1 || assigned[0].ID != "t2" {
+		t.Fatalf("%s assignments = %v, want [t2]", other, taskIDs(assigned))
+	}
+
+	gs.UpdateTask("t1", func(t *ds.Task) { t.Status = ds.TaskStatusCompleted })
+	s.OnTaskComplete("t1", owner, true)
+	if assigned := s.GetAgentAssignments(owner); len(assigned) != 0 {
+		t.Fatalf("%s assignments = %v after completion, want none", owner, taskIDs(assigned))
+	}
+}
+
+// taskIDs 返回任务 ID 列表
+func taskIDs(tasks []*ds.Task) []string {
+	ids := make([]string, len(tasks))
+	for i, task := range tasks {
+		ids[i] = task.ID
+	}
+	return ids
+}
@@ -1,9 +1,12 @@
 package scheduler
 
 import (
+	"fmt"
 	"log/slog"
 	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"superman/ds"
@@ -15,12 +18,34 @@ type TaskDispatcher interface {
 	RunTask(task *ds.Task) error
 }
 
-// AgentLoad Agent 负载跟踪
+// AgentLoad Agent 负载跟踪，所有字段由 AutoScheduler.mu 保护
 type AgentLoad struct {
-	Name        string
-	MaxTasks    int
-	CurrentLoad int
-	Hierarchy   int
+	Name          string
+	MaxTasks      int
+	CurrentLoad   int
+	Hierarchy     int
+	TotalAssigned int                 // 累计分配任务数，用于长期公平性
+	EffortLoad    float64             // 执行中任务的预估工作量之和
+	Availability  *AvailabilityWindow // 可用时间段，nil 表示全天可用
+	Capabilities  []string            // Agent 具备的能力
+}
+
+// isAvailable 检查 Agent 在指定时间是否处于可用时间段
+func (l *AgentLoad) isAvailable(now time.Time) bool {
+	return l.Availability == nil || l.Availability.Contains(now)
+}
+
+// effortRatio 执行中工作量占容量（MaxTasks 个标准任务）的比例
+func (l *AgentLoad) effortRatio() float64 {
+	return l.EffortLoad / float64(l.MaxTasks)
+}
+
+// canAccept 检查 Agent 能否接收指定工作量的任务：需有空闲槽位，且工作量不超出容量（空闲 Agent 总可接收）
+func (l *AgentLoad) canAccept(effort float64) bool {
+	if l.CurrentLoad >= l.MaxTasks {
+		return false
+	}
+	return l.CurrentLoad == 0 || l.EffortLoad+effort <= float64(l.MaxTasks)
 }
 
 type AutoScheduler struct {
@@ -30,8 +55,76 @@ type AutoScheduler struct {
 	dispatcher   TaskDispatcher
 	globalState  *state.GlobalState
 	tickInterval time.Duration
+	tickReset    chan time.Duration                   // 运行时调整轮询间隔
+	maxIdleTick  time.Duration                        // 空闲退避的最大轮询间隔，0 不退避
+	wake         chan struct{}                        // 新任务入队通知
+	now          func() time.Time                     // 时钟，可替换
+	after        func(time.Duration) <-chan time.Time // 调度循环的轮询定时器，测试中替换为虚拟时钟
 	stopCh       chan struct{}
 	wg           sync.WaitGroup
+	started      bool              // Start 已调用，此后注册的任务来源立即开始消费
+	taskSources  []namedTaskSource // Start 前注册、等待启动的外部任务来源
+
+	// 工作量估算
+	estimator      Estimator
+	inFlightEffort map[string]float64 // 任务ID -> 执行中任务的工作量
+
+	// 亲和性匹配：任务ID -> 执行中任务的执行者与标签
+	inFlightTasks map[string]inFlightTask
+
+	// 按优先级限制在途任务数
+	priorityCaps     map[string]int    // 队列优先级 -> 在途上限
+	inFlightPriority map[string]string // 任务ID -> 分发时所在的队列优先级
+	maxInFlight      int               // 全系统在途上限，0 不限制
+
+	// 排队等待时间统计
+	enqueuedAt map[string]time.Time // 任务ID -> 首次入队时间，分发后清除
+	waitHist   *WaitHistogram
+
+	// 自动生成任务的优先级衰减
+	priorityDecay PriorityDecay
+
+	// 优先级继承：任务ID -> 从依赖方继承的队列优先级，任务完成后清除
+	inheritedPriority map[string]string
+
+	// Agent 选择策略，为 nil 时选择负载最低的 Agent
+	selector *weightedSelector
+
+	// 无可胜任 Agent 的处理
+	noCapablePolicy NoCapablePolicy
+	noCapableSince  map[string]time.Time // 任务ID -> 开始等待时间
+
+	// 无法分发而失败的任务放入死信队列（可选）
+	deadLetter TaskDeadLetterFunc
+
+	// 重复任务 ID 的处理
+	addMu           sync.Mutex
+	duplicatePolicy string
+
+	// 模型调用超时任务的升级
+	timeoutEscalation TimeoutEscalation
+
+	// 依赖任务取消或失败后下游任务的处理；depMu 串行化依赖修改
+	dependencyPolicy string
+	dependencyNotify DependencyNotifyFunc
+	depMu            sync.Mutex
+
+	// 指定执行者不存在时的处理
+	unknownAssigneePolicy string
+
+	// 自驱任务生成总开关与积压流控
+	taskGenPaused       atomic.Bool
+	taskGenBacklogLimit atomic.Int64 // 排队任务数超过该值时不生成任务，0 不限制
+	taskGenBacklogged   atomic.Bool  // 当前是否因积压而抑制生成
+
+	// 调度压力告警
+	pressure pressureState
+
+	// 分布式租约（可选）
+	leaser         TaskLeaser
+	leaseOwner     string
+	leaseTTL       time.Duration
+	leaseRenewedAt time.Time // 上次为执行中任务续约的时间
 }
 
 func NewAutoScheduler(dispatcher TaskDispatcher, globalState *state.GlobalState, tickInterval time.Duration) *AutoScheduler {
@@ -45,11 +138,24 @@ func NewAutoScheduler(dispatcher TaskDispatcher, globalState *state.GlobalState,
 			PriorityMedium:   NewTaskQueue(),
 			PriorityLow:      NewTaskQueue(),
 		},
-		agentLoads:   make(map[string]*AgentLoad),
-		dispatcher:   dispatcher,
-		globalState:  globalState,
-		tickInterval: tickInterval,
-		stopCh:       make(chan struct{}),
+		agentLoads:        make(map[string]*AgentLoad),
+		inFlightEffort:    make(map[string]float64),
+		inFlightTasks:     make(map[string]inFlightTask),
+		priorityCaps:      make(map[string]int),
+		inFlightPriority:  make(map[string]string),
+		inheritedPriority: make(map[string]string),
+		noCapableSince:    make(map[string]time.Time),
+		enqueuedAt:        make(map[string]time.Time),
+		waitHist:          NewWaitHistogram(nil),
+		estimator:         ConstantEstimator(DefaultEffort),
+		dispatcher:        dispatcher,
+		globalState:       globalState,
+		tickInterval:      tickInterval,
+		tickReset:         make(chan time.Duration, 1),
+		wake:              make(chan struct{}, 1),
+		now:               time.Now,
+		after:             time.After,
+		stopCh:            make(chan struct{}),
 	}
 }
 
@@ -57,29 +163,75 @@ func NewAutoScheduler(dispatcher TaskDispatcher, globalState *state.GlobalState,
 func (s *AutoScheduler) Start() {
 	s.wg.Add(1)
 	go s.scheduleLoop()
-	slog.Info("auto scheduler started", slog.Duration("tick_interval", s.tickInterval))
+	s.startTaskSources()
+	slog.Info("auto scheduler started", slog.Duration("tick_interval", s.GetTickInterval()))
 }
 
 // Stop 停止调度循环
 func (s *AutoScheduler) Stop() {
 	close(s.stopCh)
 	s.wg.Wait()
+	s.closePendingTaskSources()
 	slog.Info("auto scheduler stopped")
 }
 
+// SetTickInterval 运行时调整调度轮询间隔，调度循环在下一次 select 时重置定时器，不中断循环
+func (s *AutoScheduler) SetTickInterval(d time.Duration) error {
+	if d <= 0 {
+		return fmt.Errorf("tick interval must be positive, got %s", d)
+	}
+	s.mu.Lock()
+	s.tickInterval = d
+	s.mu.Unlock()
+
+	// 只保留最新的间隔：通道已满时替换旧值
+	for {
+		select {
+		case s.tickReset <- d:
+			slog.Info("scheduler tick interval updated", slog.Duration("tick_interval", d))
+			return nil
+		default:
+			select {
+			case <-s.tickReset:
+			default:
+			}
+		}
+	}
+}
+
+// GetTickInterval 获取调度轮询间隔
+func (s *AutoScheduler) GetTickInterval() time.Duration {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.tickInterval
+}
+
 // AddTask 添加任务到优先级队列
 func (s *AutoScheduler) AddTask(task *ds.Task, priority string) {
+	// 检查与入队之间不允许其他 AddTask 插入，避免同一任务被并发重复入队
+	s.addMu.Lock()
+	defer s.addMu.Unlock()
+	if s.handleDuplicate(task, priority) {
+		return
+	}
+
+	s.estimate(task)
+
+	// 先注册到 GlobalState 再入队：入队后调度循环随时可能分发任务，分发与 Agent 的状态更新都要求任务已在 GlobalState 中
+	if s.globalState != nil {
+		s.globalState.AddTask(task)
+	}
+
+	s.mu.Lock()
 	queue := s.taskQueues[priority]
 	if queue == nil {
 		queue = NewTaskQueue()
 		s.taskQueues[priority] = queue
 	}
+	s.mu.Unlock()
 	queue.Enqueue(task)
-
-	// 同时注册到 GlobalState
-	if s.globalState != nil {
-		s.globalState.AddTask(task)
-	}
+	s.markEnqueued(task.ID)
+	s.signalWake()
 
 	slog.Debug("task added to scheduler",
 		slog.String("task_id", task.ID),
@@ -88,10 +240,15 @@ func (s *AutoScheduler) AddTask(task *ds.Task, priority string) {
 	)
 }
 
-// AddAgent 注册 Agent 到调度器
+// AddAgent 注册 Agent 到调度器。重复注册时仅更新容量与层级，保留执行中任务的负载计数
 func (s *AutoScheduler) AddAgent(agentName string, maxTasks int, hierarchy int) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	if load, exists := s.agentLoads[agentName]; exists {
+		load.MaxTasks = maxTasks
+		load.Hierarchy = hierarchy
+		return
+	}
 	s.agentLoads[agentName] = &AgentLoad{
 		Name:        agentName,
 		MaxTasks:    maxTasks,
@@ -103,12 +260,32 @@ func (s *AutoScheduler) AddAgent(agentName string, maxTasks int, hierarchy int)
 // OnTaskComplete 任务完成回调，减少 Agent 负载计数
 func (s *AutoScheduler) OnTaskComplete(taskID, agentName string, success bool) {
 	s.mu.Lock()
-	defer s.mu.Unlock()
-	if load, exists := s.agentLoads[agentName]; exists {
-		if load.CurrentLoad > 0 {
-			load.CurrentLoad--
-		}
+	effort := s.inFlightEffort[taskID]
+	delete(s.inFlightEffort, taskID)
+	delete(s.inFlightPriority, taskID)
+	delete(s.inFlightTasks, taskID)
+	delete(s.inheritedPriority, taskID)
+	delete(s.noCapableSince, taskID)
+	s.releaseAgentLoad(agentName, effort)
+	s.mu.Unlock()
+
+	if success {
+		s.completeLease(taskID)
+	} else {
+		s.releaseLease(taskID)
+	}
+
+	if !success && s.escalateTimeout(taskID, agentName) {
+		return
 	}
+
+	if s.globalState != nil {
+		s.globalState.RecordTaskCompletion(taskID, agentName, success)
+	}
+	if !success {
+		s.onDependencyFailed(taskID)
+	}
+
 	status := "completed"
 	if !success {
 		status = "failed"
@@ -123,7 +300,7 @@ func (s *AutoScheduler) OnTaskComplete(taskID, agentName string, success bool) {
 // GetQueueLength 获取所有队列总长度
 func (s *AutoScheduler) GetQueueLength() int {
 	total := 0
-	for _, queue := range s.taskQueues {
+	for _, queue := range s.queues() {
 		total += queue.Len()
 	}
 	return total
@@ -131,89 +308,171 @@ func (s *AutoScheduler) GetQueueLength() int {
 
 // GetQueueLengthByPriority 获取指定优先级队列长度
 func (s *AutoScheduler) GetQueueLengthByPriority(priority string) int {
-	queue := s.taskQueues[priority]
+	queue := s.queue(priority)
 	if queue != nil {
 		return queue.Len()
 	}
 	return 0
 }
 
+// queue 获取指定优先级的队列，未知优先级返回 nil；队列表可能被 AddTask 扩充，读取需持有锁
+func (s *AutoScheduler) queue(priority string) *TaskQueue {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.taskQueues[priority]
+}
+
+// queues 获取队列表的副本（优先级 -> 队列）
+func (s *AutoScheduler) queues() map[string]*TaskQueue {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	result := make(map[string]*TaskQueue, len(s.taskQueues))
+	for priority, queue := range s.taskQueues {
+		result[priority] = queue
+	}
+	return result
+}
+
 // scheduleLoop 调度主循环
 func (s *AutoScheduler) scheduleLoop() {
 	defer s.wg.Done()
-	ticker := time.NewTicker(s.tickInterval)
-	defer ticker.Stop()
+	interval := s.GetTickInterval()
+	tick := s.after(interval)
 
 	for {
 		select {
 		case <-s.stopCh:
 			return
-		case <-ticker.C:
+		case d := <-s.tickReset:
+			interval = d
+			tick = s.after(interval)
+		case <-s.wake:
+			// 新任务入队后立即尝试分发，不等待下一次轮询；退避中同时恢复基础间隔
 			s.dispatchTasks()
+			if base := s.GetTickInterval(); interval > base {
+				interval = base
+				tick = s.after(interval)
+			}
+		case now := <-tick:
+			s.Tick(now)
+			interval = s.nextTickInterval(interval)
+			tick = s.after(interval)
 		}
 	}
 }
 
+// Tick 同步执行一轮调度：租约续约与过期、优先级衰减与继承、任务分发与压力检查；
+// 调度循环每次轮询时调用，模拟模式下由模拟驱动按虚拟时间直接调用
+func (s *AutoScheduler) Tick(now time.Time) {
+	s.renewLeases(now)
+	s.expireLeases(now)
+	s.applyPriorityDecay(now)
+	s.applyPriorityInheritance()
+	s.dispatchTasks()
+	s.checkPressure(now)
+}
+
 // dispatchTasks 从队列中取出任务并分配给空闲 Agent
 func (s *AutoScheduler) dispatchTasks() {
+	// 租约被其他实例持有或暂无可胜任 Agent 的任务，本轮结束后放回队列
+	var deferred []*ds.Task
+	defer func() {
+		for _, task := range deferred {
+			s.requeueTask(task)
+		}
+	}()
+
 	for {
-		task := s.getNextReady()
+		// 全系统在途任务已达上限，剩余任务留在队列中等待下一轮
+		if s.globalCapReached() {
+			break
+		}
+		task, priority := s.getNextReady()
 		if task == nil {
 			break
 		}
 
-		agent := s.findBestAgent(task)
-		if agent == nil {
+		if !s.acquireLease(task.ID) {
+			slog.Debug("task leased by another scheduler, skipping",
+				slog.String("task_id", task.ID),
+			)
+			deferred = append(deferred, task)
+			continue
+		}
+
+		if s.hasUnknownAssignee(task) && !s.handleUnknownAssignee(task, priority) {
+			s.releaseLease(task.ID)
+			continue
+		}
+
+		if !s.hasCapableAgent(task) {
+			s.releaseLease(task.ID)
+			if s.handleNoCapableAgent(task, priority) {
+				deferred = append(deferred, task)
+			}
+			continue
+		}
+
+		// 选择 Agent 与占用其槽位在同一把写锁内完成，避免并发分发超额分配或与完成回调交错
+		agent, reason := s.reserveAgent(task, priority)
+		if agent == "" {
 			// 所有 Agent 满载，任务回到队列
+			s.releaseLease(task.ID)
 			s.requeueTask(task)
 			break
 		}
 
 		// 设置任务分配信息
-		task.AssignedTo = agent.Name
-		task.Status = ds.TaskStatusAssigned
+		s.updateTask(task, func(t *ds.Task) {
+			t.AssignedTo = agent
+			t.Status = ds.TaskStatusAssigned
+			if t.Metadata == nil {
+				t.Metadata = make(map[string]any)
+			}
+			t.Metadata[MetadataAssignReason] = reason
+		})
 
 		// 通过 Dispatcher 分发任务
 		err := s.dispatcher.RunTask(task)
 		if err != nil {
 			slog.Error("failed to dispatch task",
 				slog.String("task_id", task.ID),
-				slog.String("agent", agent.Name),
+				slog.String("agent", agent),
 				slog.Any("error", err),
 			)
+			s.cancelReservation(task.ID, agent)
+			s.releaseLease(task.ID)
 			s.requeueTask(task)
 			continue
 		}
 
-		// 更新 Agent 负载
-		s.mu.Lock()
-		agent.CurrentLoad++
-		s.mu.Unlock()
+		s.observeDispatched(task.ID)
 
 		slog.Info("task dispatched",
 			slog.String("task_id", task.ID),
 			slog.String("title", task.Title),
-			slog.String("agent", agent.Name),
+			slog.String("agent", agent),
+			slog.String("reason", reason),
 		)
 	}
 }
 
-// getNextReady 从优先级队列取出依赖已满足的任务
-func (s *AutoScheduler) getNextReady() *ds.Task {
+// getNextReady 从优先级队列取出依赖已满足的任务，返回任务及其所在的队列优先级；在途数已达上限的优先级跳过
+func (s *AutoScheduler) getNextReady() (*ds.Task, string) {
 	priorities := []string{PriorityCritical, PriorityHigh, PriorityMedium, PriorityLow}
 	for _, priority := range priorities {
-		queue := s.taskQueues[priority]
-		if queue == nil || queue.IsEmpty() {
+		queue := s.queue(priority)
+		if queue == nil || queue.IsEmpty() || s.priorityCapReached(priority) {
 			continue
 		}
 		task := queue.DequeueIf(func(t *ds.Task) bool {
 			return s.areDependenciesMet(t)
 		})
 		if task != nil {
-			return task
+			return task, priority
 		}
 	}
-	return nil
+	return nil, ""
 }
 
 // areDependenciesMet 检查任务依赖是否已满足
@@ -225,72 +484,173 @@ func (s *AutoScheduler) areDependenciesMet(task *ds.Task) bool {
 		return true
 	}
 	for _, depID := range task.Dependencies {
-		depTask := s.globalState.GetTask(depID)
-		if depTask == nil || depTask.Status != ds.TaskStatusCompleted {
+		if !s.dependencySucceeded(depID) {
 			return false
 		}
 	}
 	return true
 }
 
-// findBestAgent 选择最佳 Agent 执行任务
-func (s *AutoScheduler) findBestAgent(task *ds.Task) *AgentLoad {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
+// MetadataAssignReason 任务元数据中记录调度器选择执行 Agent 原因的键
+const MetadataAssignReason = "assign_reason"
+
+// 选择执行 Agent 的原因
+const (
+	AssignReasonExplicit        = "explicit"         // 任务已指定执行者
+	AssignReasonCapabilityMatch = "capability_match" // 在具备所需能力的 Agent 中按负载选择
+	AssignReasonLeastLoaded     = "least_loaded"     // 选择负载最低的 Agent
+	AssignReasonWeightedRandom  = "weighted_random"  // 按负载反比加权随机选择
+	AssignReasonAffinity        = "affinity"         // 在正在执行亲和任务的 Agent 中按负载选择
+)
+
+// reserveAgent 选择最佳 Agent 并立即计入其负载，返回 Agent 名称与选择原因；无可用 Agent 时返回空名称
+func (s *AutoScheduler) reserveAgent(task *ds.Task, priority string) (string, string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	agent, reason := s.findBestAgent(task)
+	if agent == nil {
+		return "", ""
+	}
+	agent.CurrentLoad++
+	agent.TotalAssigned++
+	agent.EffortLoad += task.Effort
+	s.inFlightEffort[task.ID] = task.Effort
+	s.inFlightPriority[task.ID] = priority
+	s.inFlightTasks[task.ID] = inFlightTask{agent: agent.Name, tags: append([]string(nil), task.Tags...)}
+	return agent.Name, reason
+}
+
+// cancelReservation 撤销分发失败任务占用的 Agent 负载
+func (s *AutoScheduler) cancelReservation(taskID, agentName string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	effort := s.inFlightEffort[taskID]
+	delete(s.inFlightEffort, taskID)
+	delete(s.inFlightPriority, taskID)
+	delete(s.inFlightTasks, taskID)
+	if load, exists := s.agentLoads[agentName]; exists && load.TotalAssigned > 0 {
+		load.TotalAssigned--
+	}
+	s.releaseAgentLoad(agentName, effort)
+}
+
+// releaseAgentLoad 从 Agent 负载中扣除一个任务及其工作量（调用方持有写锁）
+func (s *AutoScheduler) releaseAgentLoad(agentName string, effort float64) {
+	load, exists := s.agentLoads[agentName]
+	if !exists {
+		return
+	}
+	if load.CurrentLoad > 0 {
+		load.CurrentLoad--
+	}
+	load.EffortLoad -= effort
+	if load.EffortLoad < 0 || load.CurrentLoad == 0 {
+		load.EffortLoad = 0
+	}
+}
+
+// findBestAgent 选择最佳 Agent 执行任务，同时返回选择原因（调用方持有写锁，返回的 AgentLoad 只能在锁内使用）
+func (s *AutoScheduler) findBestAgent(task *ds.Task) (*AgentLoad, string) {
+	candidates, reason := s.rankAgents(task)
+	if len(candidates) == 0 {
+		return nil, ""
+	}
+	if s.selector != nil && reason != AssignReasonExplicit {
+		return s.selector.pick(candidates), reason
+	}
+	return candidates[0], reason
+}
+
+// rankAgents 返回可接收任务的候选 Agent 与选择原因（调用方持有读锁）：指定执行者时只含该 Agent；
+// 使用 weighted_random 策略时候选不排序，由 selector 加权随机选择；否则按选择顺序排列，首个即最佳 Agent
+func (s *AutoScheduler) rankAgents(task *ds.Task) ([]*AgentLoad, string) {
+	now := s.now()
 
 	// 策略 1：如果任务已指定 AssignedTo，优先使用
 	if task.AssignedTo != "" {
 		if agent, ok := s.agentLoads[task.AssignedTo]; ok {
-			if agent.CurrentLoad < agent.MaxTasks {
-				return agent
+			if agent.isAvailable(now) && agent.canAccept(task.Effort) {
+				return []*AgentLoad{agent}, AssignReasonExplicit
 			}
 		}
-		// 指定的 Agent 满载，返回 nil 等待
-		return nil
+		// 指定的 Agent 满载或不在可用时间段，返回空等待
+		return nil, ""
 	}
 
-	// 策略 2：按负载率排序，选最空闲的 Agent
+	// 策略 2：按工作量负载率排序，选最空闲的 Agent（仅限处于可用时间段的 Agent）
 	var candidates []*AgentLoad
 	for _, agent := range s.agentLoads {
-		if agent.CurrentLoad < agent.MaxTasks {
+		if agent.isAvailable(now) && agent.canAccept(task.Effort) && agent.hasCapabilities(task.RequiredCapabilities) {
 			candidates = append(candidates, agent)
 		}
 	}
 
+	candidates, affine := s.applyAffinity(task, candidates)
 	if len(candidates) == 0 {
-		return nil
+		return nil, ""
+	}
+
+	reason := AssignReasonLeastLoaded
+	if len(task.RequiredCapabilities) > 0 {
+		reason = AssignReasonCapabilityMatch
+	}
+	if affine {
+		reason = AssignReasonAffinity
+	}
+	if s.selector != nil {
+		if reason == AssignReasonLeastLoaded {
+			reason = AssignReasonWeightedRandom
+		}
+		return candidates, reason
 	}
 
 	sort.Slice(candidates, func(i, j int) bool {
-		loadI := float64(candidates[i].CurrentLoad) / float64(candidates[i].MaxTasks)
-		loadJ := float64(candidates[j].CurrentLoad) / float64(candidates[j].MaxTasks)
+		loadI := candidates[i].effortRatio()
+		loadJ := candidates[j].effortRatio()
 		if loadI != loadJ {
 			return loadI < loadJ
 		}
 		// 同负载时，低层级的 Agent 优先（层级数值大 = 层级低 = 一线执行者）
-		return candidates[i].Hierarchy > candidates[j].Hierarchy
+		if candidates[i].Hierarchy != candidates[j].Hierarchy {
+			return candidates[i].Hierarchy > candidates[j].Hierarchy
+		}
+		// 同层级时，累计分配任务最少的 Agent 优先，保证长期公平
+		if candidates[i].TotalAssigned != candidates[j].TotalAssigned {
+			return candidates[i].TotalAssigned < candidates[j].TotalAssigned
+		}
+		return candidates[i].Name < candidates[j].Name
 	})
 
-	return candidates[0]
+	return candidates, reason
 }
 
-// requeueTask 将任务放回队列
+// requeueTask 将任务放回队列（保留继承的优先级）
 func (s *AutoScheduler) requeueTask(task *ds.Task) {
-	priority := string(task.Priority)
-	switch priority {
+	s.mu.RLock()
+	inherited := s.inheritedPriority[task.ID]
+	s.mu.RUnlock()
+	if inherited != "" {
+		s.queue(inherited).Enqueue(task)
+		return
+	}
+
+	queue := s.queue(queuePriorityOf(task))
+	if queue != nil {
+		queue.Enqueue(task)
+	}
+}
+
+// queuePriorityOf 返回任务优先级对应的队列，未知优先级使用 Medium
+func queuePriorityOf(task *ds.Task) string {
+	switch strings.ToLower(string(task.Priority)) {
 	case "critical":
-		priority = PriorityCritical
+		return PriorityCritical
 	case "high":
-		priority = PriorityHigh
-	case "medium":
-		priority = PriorityMedium
+		return PriorityHigh
 	case "low":
-		priority = PriorityLow
+		return PriorityLow
 	default:
-		priority = PriorityMedium
-	}
-	queue := s.taskQueues[priority]
-	if queue != nil {
-		queue.Enqueue(task)
+		return PriorityMedium
 	}
 }
@@ -0,0 +1,75 @@
+package scheduler
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// tickRecorder 记录每轮调度时间的租约存储：调度循环每轮都会调用 ExpireTaskLeases
+type tickRecorder struct {
+	mu    sync.Mutex
+	ticks []time.Time
+}
+
+func (r *tickRecorder) AcquireTaskLease(string, string, time.Duration) (bool, error) {
+	return true, nil
+}
+func (r *tickRecorder) ReleaseTaskLease(string, string) error                      { return nil }
+func (r *tickRecorder) CompleteTaskLease(string, string) error                     { return nil }
+func (r *tickRecorder) RenewTaskLease(string, string, time.Duration) (bool, error) { return true, nil }
+
+func (r *tickRecorder) ExpireTaskLeases(now time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ticks = append(r.ticks, now)
+	return 0, nil
+}
+
+func (r *tickRecorder) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.ticks)
+}
+
+// 运行时调整轮询间隔后，调度循环按新间隔轮询且不中断
+func TestSetTickIntervalChangesCadence(t *testing.T) {
+	s := NewAutoScheduler(&recordingDispatcher{}, nil, time.Hour)
+	start := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+	clock := newFakeTickClock(start)
+	s.after = clock.After
+	ticks := &tickRecorder{}
+	s.SetTaskLeaser(ticks, "test", time.Hour)
+	s.Start()
+	t.Cleanup(s.Stop)
+
+	if d := clock.nextWait(t); d != time.Hour {
+		t.Fatalf("first tick timer = %v, want 1h", d)
+	}
+	clock.Advance(50 * time.Minute)
+
+	const interval = 20 * time.Millisecond
+	if err := s.SetTickInterval(interval); err != nil {
+		t.Fatalf("SetTickInterval: %v", err)
+	}
+	if d := clock.nextWait(t); d != interval {
+		t.Fatalf("tick timer after SetTickInterval = %v, want %v", d, interval)
+	}
+	for range 15 {
+		clock.Advance(interval)
+		if d := clock.nextWait(t); d != interval {
+			t.Fatalf("tick timer = %v, want %v", d, interval)
+		}
+	}
+
+	ticks.mu.Lock()
+	defer ticks.mu.Unlock()
+	if len(ticks.ticks) != 15 {
+		t.Fatalf("%d ticks after 15 intervals, want 15", len(ticks.ticks))
+	}
+	for i, at := range ticks.ticks {
+		if want := start.Add(50*time.Minute + time.Duration(i+1)*interval); !at.Equal(want) {
+			t.Fatalf("tick %d at %v, want %v", i, at, want)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package scheduler
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// AvailabilityWindow Agent 每日可用时间段，End 早于 Start 时表示跨夜（如 22:00-06:00）
+type AvailabilityWindow struct {
+	Start    time.Duration // 距当日零点的偏移
+	End      time.Duration
+	Location *time.Location
+}
+
+// ParseAvailabilityWindow 解析形如 "09:00-18:00" 的可用时间段，tz 为 IANA 时区名，为空时使用本地时区
+func ParseAvailabilityWindow(spec, tz string) (*AvailabilityWindow, error) {
+	startStr, endStr, ok := strings.Cut(spec, "-")
+	if !ok {
+		return nil, fmt.Errorf("invalid availability window %q, expected HH:MM-HH:MM", spec)
+	}
+	start, err := parseClock(strings.TrimSpace(startStr))
+	if err != nil {
+		return nil, err
+	}
+	end, err := parseClock(strings.TrimSpace(endStr))
+	if err != nil {
+		return nil, err
+	}
+
+	loc := time.Local
+	if tz != "" {
+		if loc, err = time.LoadLocation(tz); err != nil {
+			return nil, fmt.Errorf("invalid timezone %q: %w", tz, err)
+		}
+	}
+	return &AvailabilityWindow{Start: start, End: end, Location: loc}, nil
+}
+
+// parseClock 解析 HH:MM 为距零点的偏移
+func parseClock(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time of day %q: %w", s, err)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// Contains 检查时间点是否落在可用时间段内
+func (w *AvailabilityWindow) Contains(t time.Time) bool {
+	t = t.In(w.Location)
+	offset := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second
+	if w.Start <= w.End {
+		return offset >= w.Start && offset < w.End
+	}
+	return offset >= w.Start || offset < w.End
+}
+
+// SetAgentAvailability 设置 Agent 的可用时间段，nil 表示全天可用
+func (s *AutoScheduler) SetAgentAvailability(agentName string, window *AvailabilityWindow) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if load, exists := s.agentLoads[agentName]; exists {
+		load.Availability = window
+	}
+}
+
+// SetClock 设置调度器使用的时钟（用于可用时间段判断）
+func (s *AutoScheduler) SetClock(now func() time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.now = now
+}
@@ -0,0 +1,33 @@
+package scheduler
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+// 可用时间段外的 Agent 不接收任务，任务排队等待，时间段开始后才分发
+func TestTaskWaitsForAvailabilityWindow(t *testing.T) {
+	s, d, _ := newTestScheduler(t)
+	s.AddAgent("analyst", 3, 1)
+	window, err := ParseAvailabilityWindow("09:00-18:00", "UTC")
+	if err != nil {
+		t.Fatalf("ParseAvailabilityWindow: %v", err)
+	}
+	s.SetAgentAvailability("analyst", window)
+
+	now := time.Date(2024, 6, 3, 7, 30, 0, 0, time.UTC)
+	s.SetClock(func() time.Time { return now })
+	s.AddTask(newTestTask("report"), PriorityMedium)
+
+	s.Tick(now)
+	if got := d.dispatched(); len(got) != 0 {
+		t.Fatalf("dispatched %v outside the window, want none", got)
+	}
+
+	now = time.Date(2024, 6, 3, 9, 0, 0, 0, time.UTC)
+	s.Tick(now)
+	if got := d.dispatched(); !slices.Equal(got, []string{"report"}) {
+		t.Fatalf("dispatched %v once the window opened, want [report]", got)
+	}
+}
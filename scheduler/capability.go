@@ -0,0 +1,170 @@
+package scheduler
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"superman/ds"
+)
+
+// 无可胜任 Agent 时的处理策略
+const (
+	NoCapablePolicyWait     = "wait"     // 一直等待（默认）
+	NoCapablePolicyFail     = "fail"     // 标记任务失败
+	NoCapablePolicyFallback = "fallback" // 转交指定的兜底 Agent
+	NoCapablePolicyEscalate = "escalate" // 上报给最高层级的 Agent
+)
+
+// NoCapableReason 因无可胜任 Agent 而失败的任务原因
+const NoCapableReason = "no_capable_agent"
+
+// NoCapablePolicy 无可胜任 Agent 时的处理策略配置
+type NoCapablePolicy struct {
+	Policy        string        // wait、fail、fallback、escalate
+	Wait          time.Duration // 等待多久后执行策略
+	FallbackAgent string        // fallback 策略的目标 Agent
+}
+
+// SetAgentCapabilities 设置 Agent 具备的能力
+func (s *AutoScheduler) SetAgentCapabilities(agentName string, capabilities []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if load, exists := s.agentLoads[agentName]; exists {
+		load.Capabilities = capabilities
+	}
+}
+
+// SetNoCapablePolicy 设置无可胜任 Agent 时的处理策略，fallback 策略必须指定目标 Agent
+func (s *AutoScheduler) SetNoCapablePolicy(policy NoCapablePolicy) error {
+	switch policy.Policy {
+	case "", NoCapablePolicyWait, NoCapablePolicyFail, NoCapablePolicyEscalate:
+	case NoCapablePolicyFallback:
+		if policy.FallbackAgent == "" {
+			return fmt.Errorf("no capable policy %q requires a fallback agent", policy.Policy)
+		}
+	default:
+		return fmt.Errorf("unknown no capable policy %q", policy.Policy)
+	}
+	if policy.Wait < 0 {
+		return fmt.Errorf("no capable wait must not be negative, got %s", policy.Wait)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.noCapablePolicy = policy
+	return nil
+}
+
+// GetWaitingTasks 获取因无可胜任 Agent 而等待的任务，返回 任务ID -> 开始等待时间
+func (s *AutoScheduler) GetWaitingTasks() map[string]time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	result := make(map[string]time.Time, len(s.noCapableSince))
+	for id, since := range s.noCapableSince {
+		result[id] = since
+	}
+	return result
+}
+
+// hasCapabilities 检查 Agent 是否具备任务要求的全部能力
+func (l *AgentLoad) hasCapabilities(required []string) bool {
+	for _, capability := range required {
+		if !slices.Contains(l.Capabilities, capability) {
+			return false
+		}
+	}
+	return true
+}
+
+// hasCapableAgent 检查是否存在具备任务所需能力的 Agent（不考虑负载与可用时间段）
+func (s *AutoScheduler) hasCapableAgent(task *ds.Task) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if task.AssignedTo != "" {
+		_, exists := s.agentLoads[task.AssignedTo]
+		return exists
+	}
+	for _, agent := range s.agentLoads {
+		if agent.hasCapabilities(task.RequiredCapabilities) {
+			return true
+		}
+	}
+	return false
+}
+
+// handleNoCapableAgent 处理无可胜任 Agent 的任务，返回 true 表示任务应放回队列继续等待；
+// 任务失败时放入死信队列（已设置时），priority 为任务所在的队列优先级
+func (s *AutoScheduler) handleNoCapableAgent(task *ds.Task, priority string) bool {
+	s.mu.Lock()
+	now := s.now()
+	since, waiting := s.noCapableSince[task.ID]
+	if !waiting {
+		since = now
+		s.noCapableSince[task.ID] = now
+		slog.Warn("no capable agent for task, waiting",
+			slog.String("task_id", task.ID),
+			slog.Any("required_capabilities", task.RequiredCapabilities),
+		)
+	}
+	policy := s.noCapablePolicy
+	if policy.Policy == "" || policy.Policy == NoCapablePolicyWait || now.Sub(since) < policy.Wait {
+		s.mu.Unlock()
+		return true
+	}
+	delete(s.noCapableSince, task.ID)
+
+	target := ""
+	switch policy.Policy {
+	case NoCapablePolicyFallback:
+		target = policy.FallbackAgent
+	case NoCapablePolicyEscalate:
+		target = s.topLevelAgent()
+	}
+	// 改派的任务放回队列继续排队，保留首次入队时间；失败的任务不再分发
+	if target == "" {
+		s.forgetQueued(task.ID)
+	}
+	s.mu.Unlock()
+
+	if target != "" {
+		s.updateTask(task, func(t *ds.Task) {
+			t.AssignedTo = target
+		})
+		slog.Info("task rerouted, no capable agent",
+			slog.String("task_id", task.ID),
+			slog.String("policy", policy.Policy),
+			slog.String("agent", target),
+		)
+		return true
+	}
+
+	s.updateTask(task, func(t *ds.Task) {
+		t.Status = ds.TaskStatusFailed
+		if t.Metadata == nil {
+			t.Metadata = make(map[string]any)
+		}
+		t.Metadata["failure_reason"] = NoCapableReason
+	})
+	slog.Warn("task failed, no capable agent",
+		slog.String("task_id", task.ID),
+		slog.String("policy", policy.Policy),
+	)
+	s.deadLetterTask(task, priority, NoCapableReason, false)
+	s.onDependencyFailed(task.ID)
+	return false
+}
+
+// topLevelAgent 返回层级最高（数值最小）的 Agent 名称（调用方持有锁）
+func (s *AutoScheduler) topLevelAgent() string {
+	var top *AgentLoad
+	for _, agent := range s.agentLoads {
+		if top == nil || agent.Hierarchy < top.Hierarchy || (agent.Hierarchy == top.Hierarchy && agent.Name < top.Name) {
+			top = agent
+		}
+	}
+	if top == nil {
+		return ""
+	}
+	return top.Name
+}
@@ -0,0 +1,100 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"superman/ds"
+)
+
+// 无可胜任 Agent 的策略校验：fallback 必须指定目标 Agent，未知策略被拒绝
+func TestSetNoCapablePolicyValidates(t *testing.T) {
+	s, _, _ := newTestScheduler(t)
+	for _, policy := range []NoCapablePolicy{
+		{Policy: NoCapablePolicyFallback},
+		{Policy: "retry"},
+		{Policy: NoCapablePolicyFail, Wait: -time.Second},
+	} {
+		if err := s.SetNoCapablePolicy(policy); err == nil {
+			t.Errorf("SetNoCapablePolicy(%+v) = nil, want error", policy)
+		}
+	}
+	if err := s.SetNoCapablePolicy(NoCapablePolicy{Policy: NoCapablePolicyFallback, FallbackAgent: "helper"}); err != nil {
+		t.Fatalf("SetNoCapablePolicy(fallback): %v", err)
+	}
+}
+
+// fallback 策略通过全局状态转交任务，fail 策略将任务标记失败
+func TestNoCapablePolicyUpdatesGlobalState(t *testing.T) {
+	t.Run("fallback", func(t *testing.T) {
+		s, d, gs := newTestScheduler(t)
+		s.AddAgent("helper", 1, 1)
+		if err := s.SetNoCapablePolicy(NoCapablePolicy{Policy: NoCapablePolicyFallback, FallbackAgent: "helper"}); err != nil {
+			t.Fatal(err)
+		}
+		task := newTestTask("t1")
+		task.RequiredCapabilities = []string{"rare"}
+		gs.AddTask(task)
+		s.AddTask(task, PriorityMedium)
+
+		s.Tick(time.Now())
+		s.Tick(time.Now())
+		if got := gs.GetTask("t1").AssignedTo; got != "helper" {
+			t.Fatalf("AssignedTo = %q, want helper", got)
+		}
+		if got := d.dispatched(); len(got) != 1 {
+			t.Fatalf("dispatched %v, want the rerouted task", got)
+		}
+	})
+
+	t.Run("fail", func(t *testing.T) {
+		s, d, gs := newTestScheduler(t)
+		s.AddAgent("worker", 1, 1)
+		if err := s.SetNoCapablePolicy(NoCapablePolicy{Policy: NoCapablePolicyFail}); err != nil {
+			t.Fatal(err)
+		}
+		task := newTestTask("t1")
+		task.RequiredCapabilities = []string{"rare"}
+		gs.AddTask(task)
+		s.AddTask(task, PriorityMedium)
+
+		s.Tick(time.Now())
+		got := gs.GetTask("t1")
+		if got.Status != ds.TaskStatusFailed || got.Metadata["failure_reason"] != NoCapableReason {
+			t.Fatalf("task = %s %v, want failed with %s", got.Status, got.Metadata, NoCapableReason)
+		}
+		if len(d.dispatched()) != 0 || s.GetQueueLength() != 0 {
+			t.Fatal("failed task was dispatched or left in the queue")
+		}
+	})
+}
+
+// 调度器在任务元数据中记录选择执行者的原因：指定执行者为 explicit，按能力匹配为 capability_match，其余为 least_loaded
+func TestAssignReasonRecorded(t *testing.T) {
+	s, _, gs := newTestScheduler(t)
+	s.AddAgent("alice", 5, 1)
+	s.AddAgent("bob", 5, 1)
+	s.SetAgentCapabilities("bob", []string{"finance"})
+
+	explicit := newTestTask("explicit")
+	explicit.AssignedTo = "alice"
+	capable := newTestTask("capable")
+	capable.RequiredCapabilities = []string{"finance"}
+	s.AddTask(explicit, PriorityMedium)
+	s.AddTask(capable, PriorityMedium)
+	s.AddTask(newTestTask("any"), PriorityMedium)
+	s.Tick(time.Now())
+
+	for id, want := range map[string]string{
+		"explicit": AssignReasonExplicit,
+		"capable":  AssignReasonCapabilityMatch,
+		"any":      AssignReasonLeastLoaded,
+	} {
+		if got := gs.GetTask(id).Metadata[MetadataAssignReason]; got != want {
+			t.Errorf("%s assign reason = %v, want %s", id, got, want)
+		}
+	}
+	if got := gs.GetTask("capable").AssignedTo; got != "bob" {
+		t.Fatalf("capable task assigned to %s, want bob", got)
+	}
+}
@@ -0,0 +1,24 @@
+package scheduler
+
+import "superman/ds"
+
+// TaskDeadLetterFunc 任务死信回调：priority 为任务所在的队列优先级，reason 为失败原因，
+// permanent 为 true 时重新提交也无法分发，不应自动重新投递
+type TaskDeadLetterFunc func(task *ds.Task, priority, reason string, permanent bool)
+
+// SetTaskDeadLetter 设置任务死信回调，无法分发而失败的任务通过它放入死信队列
+func (s *AutoScheduler) SetTaskDeadLetter(fn TaskDeadLetterFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.deadLetter = fn
+}
+
+// deadLetterTask 将失败的任务放入死信队列（未设置回调时忽略）
+func (s *AutoScheduler) deadLetterTask(task *ds.Task, priority, reason string, permanent bool) {
+	s.mu.RLock()
+	fn := s.deadLetter
+	s.mu.RUnlock()
+	if fn != nil {
+		fn(task.Copy(), priority, reason, permanent)
+	}
+}
@@ -0,0 +1,86 @@
+package scheduler
+
+import (
+	"fmt"
+	"log/slog"
+
+	"superman/ds"
+)
+
+// UpdateDependencies 修改排队中任务的依赖：先添加 add 再移除 remove，并同步到全局状态。
+// 修改在队列锁内完成，下一轮分发会按新的依赖重新判断任务是否就绪。
+// 依赖修改串行执行，环检测与修改之间不会插入其他修改，并发添加互相依赖时只有一个成功
+func (s *AutoScheduler) UpdateDependencies(taskID string, add, remove []string) error {
+	s.depMu.Lock()
+	defer s.depMu.Unlock()
+
+	for _, depID := range add {
+		if depID == taskID {
+			return fmt.Errorf("task %s cannot depend on itself", taskID)
+		}
+		if s.dependsOn(depID, taskID) {
+			return fmt.Errorf("adding dependency %s to task %s would create a cycle", depID, taskID)
+		}
+	}
+
+	update := func(t *ds.Task) {
+		for _, depID := range add {
+			t.AddDependency(depID)
+		}
+		for _, depID := range remove {
+			t.RemoveDependency(depID)
+		}
+	}
+
+	for _, priority := range queuePriorities {
+		updated := s.queue(priority).Update(taskID, func(t *ds.Task) {
+			s.updateTask(t, update)
+		})
+		if updated {
+			slog.Info("task dependencies updated",
+				slog.String("task_id", taskID),
+				slog.Any("added", add),
+				slog.Any("removed", remove),
+			)
+			return nil
+		}
+	}
+	return fmt.Errorf("task %s is not queued", taskID)
+}
+
+// dependencySucceeded 检查依赖任务是否已成功完成；任务不在全局状态中时（如重启前已完成），按任务结果判断。
+// 跨重启的判断依赖 persist_results 写入的任务结果，未开启时重启前完成的依赖视为未满足
+func (s *AutoScheduler) dependencySucceeded(depID string) bool {
+	if depTask := s.globalState.GetTask(depID); depTask != nil {
+		return depTask.IsSucceeded()
+	}
+	result := s.globalState.GetTaskResult(depID)
+	if result == nil {
+		return false
+	}
+	status := ds.TaskStatus(result.Status)
+	return status == ds.TaskStatusCompleted || status == ds.TaskStatusCompletedWithWarnings
+}
+
+// dependsOn 检查任务 from 是否直接或间接依赖任务 to，按全局状态锁内复制的依赖关系判断
+func (s *AutoScheduler) dependsOn(from, to string) bool {
+	if s.globalState == nil {
+		return false
+	}
+	deps := s.globalState.GetTaskDependencies()
+	visited := make(map[string]bool)
+	stack := []string{from}
+	for len(stack) > 0 {
+		id := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if id == to {
+			return true
+		}
+		if visited[id] {
+			continue
+		}
+		visited[id] = true
+		stack = append(stack, deps[id]...)
+	}
+	return false
+}
@@ -0,0 +1,66 @@
+package scheduler
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"superman/ds"
+	"superman/state"
+)
+
+// memoryResultStore 内存中的任务结果存储，模拟重启前持久化的结果
+type memoryResultStore map[string]*state.TaskResult
+
+func (m memoryResultStore) SaveTaskResult(r *state.TaskResult) error {
+	m[r.TaskID] = r
+	return nil
+}
+
+func (m memoryResultStore) LoadTaskResult(taskID string) (*state.TaskResult, error) {
+	return m[taskID], nil
+}
+
+// 依赖任务不在全局状态中时按持久化的任务结果判断：重启前成功完成的依赖视为已满足，失败的不满足
+func TestDependencyCompletedBeforeRestart(t *testing.T) {
+	s, d, gs := newTestScheduler(t)
+	gs.SetTaskResultStore(memoryResultStore{
+		"done":   {TaskID: "done", Status: string(ds.TaskStatusCompleted), CompletedAt: time.Now()},
+		"failed": {TaskID: "failed", Status: string(ds.TaskStatusFailed), CompletedAt: time.Now()},
+	})
+	s.AddAgent("worker", 2, 1)
+
+	ready := newTestTask("ready")
+	ready.AddDependency("done")
+	blocked := newTestTask("blocked")
+	blocked.AddDependency("failed")
+	s.AddTask(ready, PriorityMedium)
+	s.AddTask(blocked, PriorityMedium)
+
+	s.Tick(time.Now())
+	if got := d.dispatched(); len(got) != 1 || got[0] != "ready" {
+		t.Fatalf("dispatched %v, want only the task whose dependency completed before the restart", got)
+	}
+}
+
+// 并发添加互相依赖时环检测与修改不会交错，只有一个修改成功
+func TestConcurrentDependencyUpdatesRejectCycle(t *testing.T) {
+	for i := range 100 {
+		s, _, _ := newTestScheduler(t)
+		a, b := fmt.Sprintf("a%d", i), fmt.Sprintf("b%d", i)
+		s.AddTask(newTestTask(a), PriorityMedium)
+		s.AddTask(newTestTask(b), PriorityMedium)
+
+		var wg sync.WaitGroup
+		errs := make([]error, 2)
+		wg.Add(2)
+		go func() { defer wg.Done(); errs[0] = s.UpdateDependencies(a, []string{b}, nil) }()
+		go func() { defer wg.Done(); errs[1] = s.UpdateDependencies(b, []string{a}, nil) }()
+		wg.Wait()
+
+		if (errs[0] == nil) == (errs[1] == nil) {
+			t.Fatalf("UpdateDependencies errors = %v, want exactly one cycle rejection", errs)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package scheduler
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"superman/ds"
+)
+
+// statusColors 任务状态对应的节点颜色
+var statusColors = map[ds.TaskStatus]string{
+	ds.TaskStatusPending:    "lightgrey",
+	ds.TaskStatusAssigned:   "lightblue",
+	ds.TaskStatusProcessing: "gold",
+	ds.TaskStatusCompleted:  "palegreen",
+	ds.TaskStatusFailed:     "salmon",
+	ds.TaskStatusCancelled:  "grey",
+
+	ds.TaskStatusCompletedWithWarnings: "khaki",
+}
+
+// ExportDependencyGraph 以 Graphviz DOT 格式导出当前任务依赖图，节点按状态着色
+func (s *AutoScheduler) ExportDependencyGraph() ([]byte, error) {
+	if s.globalState == nil {
+		return nil, fmt.Errorf("global state is not set")
+	}
+
+	// 使用副本，避免在锁外读取正被调度器与 Agent 更新的任务字段
+	tasks := s.globalState.GetAllTaskCopies()
+	ids := make([]string, 0, len(tasks))
+	for id := range tasks {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	var buf bytes.Buffer
+	buf.WriteString("digraph tasks {\n")
+	buf.WriteString("  rankdir=LR;\n")
+	buf.WriteString("  node [shape=box, style=filled];\n")
+
+	for _, id := range ids {
+		task := tasks[id]
+		color, ok := statusColors[task.Status]
+		if !ok {
+			color = "white"
+		}
+		label := dotLabel(task.Title, fmt.Sprintf("[%s] %s", task.Status, task.AssignedTo))
+		fmt.Fprintf(&buf, "  %s [label=%s, fillcolor=%s];\n", dotQuote(id), label, color)
+	}
+
+	// 边方向：依赖 -> 被依赖任务
+	for _, id := range ids {
+		for _, depID := range tasks[id].Dependencies {
+			if _, exists := tasks[depID]; !exists {
+				fmt.Fprintf(&buf, "  %s [label=%s, fillcolor=white, style=dashed];\n", dotQuote(depID), dotLabel(depID, "[missing]"))
+			}
+			fmt.Fprintf(&buf, "  %s -> %s;\n", dotQuote(depID), dotQuote(id))
+		}
+	}
+
+	buf.WriteString("}\n")
+	return buf.Bytes(), nil
+}
+
+// dotQuote 转义并加引号，生成合法的 DOT 标识符
+func dotQuote(s string) string {
+	return `"` + dotEscape(s) + `"`
+}
+
+// dotLabel 将多行文本转义后以 DOT 换行符连接并加引号
+func dotLabel(lines ...string) string {
+	for i, line := range lines {
+		lines[i] = dotEscape(line)
+	}
+	return `"` + strings.Join(lines, `\n`) + `"`
+}
+
+// dotEscape 转义 DOT 字符串中的反斜杠、引号与换行；反斜杠须最先转义，否则引号转义加入的反斜杠会被再次转义
+func dotEscape(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return strings.ReplaceAll(s, "\n", `\n`)
+}
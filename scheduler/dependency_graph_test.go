@@ -0,0 +1,59 @@
+package scheduler
+
+import (
+	"strings"
+	"testing"
+)
+
+// 任务 ID 与标题中的反斜杠、引号被转义，不会提前结束 DOT 字符串
+func TestExportDependencyGraphEscapes(t *testing.T) {
+	s, _, _ := newTestScheduler(t)
+	task := newTestTask(`dir\`)
+	task.Title = `copy "C:\tmp"`
+	s.AddTask(task, PriorityMedium)
+
+	out, err := s.ExportDependencyGraph()
+	if err != nil {
+		t.Fatalf("ExportDependencyGraph: %v", err)
+	}
+	want := `  "dir\\" [label="copy \"C:\\tmp\"\n[pending] ", fillcolor=lightgrey];`
+	if !strings.Contains(string(out), want) {
+		t.Fatalf("graph is missing escaped node %s:\n%s", want, out)
+	}
+}
+
+// 依赖关系导出为从依赖指向被依赖任务的边，不存在的依赖以虚线节点表示
+func TestExportDependencyGraphEdges(t *testing.T) {
+	s, _, _ := newTestScheduler(t)
+	s.AddTask(newTestTask("A"), PriorityMedium)
+	for _, id := range []string{"B", "C"} {
+		task := newTestTask(id)
+		task.Dependencies = []string{"A"}
+		s.AddTask(task, PriorityMedium)
+	}
+	orphan := newTestTask("D")
+	orphan.Dependencies = []string{"ghost"}
+	s.AddTask(orphan, PriorityMedium)
+
+	out, err := s.ExportDependencyGraph()
+	if err != nil {
+		t.Fatalf("ExportDependencyGraph: %v", err)
+	}
+	graph := string(out)
+	for _, want := range []string{
+		`  "A" [label="task A\n[pending] ", fillcolor=lightgrey];`,
+		`  "B" [label="task B\n[pending] ", fillcolor=lightgrey];`,
+		`  "C" [label="task C\n[pending] ", fillcolor=lightgrey];`,
+		`  "A" -> "B";`,
+		`  "A" -> "C";`,
+		`  "ghost" [label="ghost\n[missing]", fillcolor=white, style=dashed];`,
+		`  "ghost" -> "D";`,
+	} {
+		if !strings.Contains(graph, want) {
+			t.Fatalf("graph is missing %s:\n%s", want, graph)
+		}
+	}
+	if n := strings.Count(graph, "->"); n != 3 {
+		t.Fatalf("graph has %d edges, want 3:\n%s", n, graph)
+	}
+}
@@ -0,0 +1,134 @@
+package scheduler
+
+import (
+	"fmt"
+	"log/slog"
+
+	"superman/ds"
+)
+
+// 依赖任务取消或失败后下游任务的处理策略
+const (
+	DependencyPolicyWait    = "wait"    // 保持排队（默认），直到依赖被人工处理
+	DependencyPolicyCancel  = "cancel"  // 级联取消全部下游任务
+	DependencyPolicyProceed = "proceed" // 移除失效的依赖，下游任务继续执行
+)
+
+// MetadataDependencyPolicy 任务元数据中覆盖依赖处理策略的键，取值同 DependencyPolicy*
+const MetadataDependencyPolicy = "dependency_policy"
+
+// DependencyCancelReason 因依赖任务取消或失败而级联取消的原因
+const DependencyCancelReason = "dependency_cancelled"
+
+// DependencyNotifyFunc 下游任务因依赖失效被取消或放行时的通知回调，cause 为失效的依赖任务 ID
+type DependencyNotifyFunc func(task *ds.Task, cause, policy string)
+
+// SetDependencyPolicy 设置依赖任务取消或失败后下游任务的默认处理策略，任务元数据 dependency_policy 可覆盖
+func (s *AutoScheduler) SetDependencyPolicy(policy string) error {
+	switch policy {
+	case "":
+		policy = DependencyPolicyWait
+	case DependencyPolicyWait, DependencyPolicyCancel, DependencyPolicyProceed:
+	default:
+		return fmt.Errorf("unknown dependency policy %q", policy)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.dependencyPolicy = policy
+	return nil
+}
+
+// SetDependencyNotifier 设置下游任务被级联取消或放行时的通知回调
+func (s *AutoScheduler) SetDependencyNotifier(fn DependencyNotifyFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.dependencyNotify = fn
+}
+
+// onDependencyFailed 任务被取消或失败后按策略处理其下游任务：cancel 策略的任务被取消后继续处理它自己的下游任务
+func (s *AutoScheduler) onDependencyFailed(rootID string) {
+	if s.globalState == nil {
+		return
+	}
+	s.mu.RLock()
+	defaultPolicy := s.dependencyPolicy
+	notify := s.dependencyNotify
+	s.mu.RUnlock()
+
+	dependents := make(map[string][]string)
+	for id, task := range s.globalState.GetAllTasks() {
+		for _, depID := range task.Dependencies {
+			dependents[depID] = append(dependents[depID], id)
+		}
+	}
+
+	visited := map[string]bool{rootID: true}
+	queue := []string{rootID}
+	for len(queue) > 0 {
+		cause := queue[0]
+		queue = queue[1:]
+		for _, id := range dependents[cause] {
+			if visited[id] {
+				continue
+			}
+			task := s.globalState.GetTask(id)
+			if task == nil || task.IsCompleted() || task.Status == ds.TaskStatusProcessing {
+				continue
+			}
+
+			policy := defaultPolicy
+			if p, ok := task.Metadata[MetadataDependencyPolicy].(string); ok && p != "" {
+				policy = p
+			}
+			switch policy {
+			case DependencyPolicyCancel:
+				visited[id] = true
+				s.cancelDependent(id, cause)
+				queue = append(queue, id)
+			case DependencyPolicyProceed:
+				s.dropDependency(id, cause)
+			default:
+				continue
+			}
+			if notify != nil {
+				notify(s.globalState.GetTask(id).Copy(), cause, policy)
+			}
+		}
+	}
+}
+
+// cancelDependent 将下游任务移出队列并标记为已取消
+func (s *AutoScheduler) cancelDependent(taskID, cause string) {
+	for _, priority := range queuePriorities {
+		if s.queue(priority).Remove(taskID) != nil {
+			break
+		}
+	}
+	s.mu.Lock()
+	s.forgetQueued(taskID)
+	s.mu.Unlock()
+
+	s.globalState.UpdateTask(taskID, func(t *ds.Task) {
+		t.Status = ds.TaskStatusCancelled
+		if t.Metadata == nil {
+			t.Metadata = make(map[string]any)
+		}
+		t.Metadata["cancel_reason"] = DependencyCancelReason
+		t.Metadata["cancelled_by"] = cause
+	})
+	slog.Info("task cancelled, dependency cancelled or failed",
+		slog.String("task_id", taskID),
+		slog.String("dependency", cause),
+	)
+}
+
+// dropDependency 移除下游任务对失效依赖的引用，使其可以继续分发
+func (s *AutoScheduler) dropDependency(taskID, cause string) {
+	s.globalState.UpdateTask(taskID, func(t *ds.Task) {
+		t.RemoveDependency(cause)
+	})
+	slog.Info("dependency dropped, dependency cancelled or failed",
+		slog.String("task_id", taskID),
+		slog.String("dependency", cause),
+	)
+}
@@ -0,0 +1,78 @@
+package scheduler
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"superman/ds"
+)
+
+// cancel 策略下取消根任务会级联取消两层下游任务并逐个通知，无关任务照常分发
+func TestCancelledRootCascadesToDependents(t *testing.T) {
+	s, d, gs := newTestScheduler(t)
+	if err := s.SetDependencyPolicy(DependencyPolicyCancel); err != nil {
+		t.Fatalf("SetDependencyPolicy: %v", err)
+	}
+	var notified []string
+	s.SetDependencyNotifier(func(task *ds.Task, cause, policy string) {
+		notified = append(notified, task.ID+"<-"+cause)
+	})
+	s.AddAgent("worker", 5, 1)
+
+	child := newTestTask("child")
+	child.AddDependency("root")
+	grandchild := newTestTask("grandchild")
+	grandchild.AddDependency("child")
+	s.AddTask(newTestTask("root"), PriorityHigh)
+	s.AddTask(child, PriorityMedium)
+	s.AddTask(grandchild, PriorityLow)
+	s.AddTask(newTestTask("unrelated"), PriorityMedium)
+
+	if removed := s.ClearQueue(PriorityHigh); removed != 1 {
+		t.Fatalf("ClearQueue removed %d tasks, want the root task", removed)
+	}
+	for _, id := range []string{"child", "grandchild"} {
+		task := gs.GetTask(id)
+		if task.Status != ds.TaskStatusCancelled || task.Metadata["cancel_reason"] != DependencyCancelReason {
+			t.Fatalf("%s status = %s (reason %v), want cancelled with %s", id, task.Status, task.Metadata["cancel_reason"], DependencyCancelReason)
+		}
+	}
+	if want := []string{"child<-root", "grandchild<-child"}; !slices.Equal(notified, want) {
+		t.Fatalf("notified %v, want %v", notified, want)
+	}
+
+	s.Tick(time.Now())
+	if got := d.dispatched(); !slices.Equal(got, []string{"unrelated"}) {
+		t.Fatalf("dispatched %v, want only the unrelated task", got)
+	}
+}
+
+// 默认 wait 策略下依赖失败的下游任务保持排队；任务元数据可单独指定 proceed 移除失效依赖继续执行
+func TestDependencyPolicyWaitAndProceed(t *testing.T) {
+	s, d, gs := newTestScheduler(t)
+	s.AddAgent("worker", 5, 1)
+
+	root := newTestTask("root")
+	s.AddTask(root, PriorityMedium)
+	s.Tick(time.Now())
+
+	waiting := newTestTask("waiting")
+	waiting.AddDependency("root")
+	proceeding := newTestTask("proceeding")
+	proceeding.AddDependency("root")
+	proceeding.Metadata[MetadataDependencyPolicy] = DependencyPolicyProceed
+	s.AddTask(waiting, PriorityMedium)
+	s.AddTask(proceeding, PriorityMedium)
+
+	gs.UpdateTask("root", func(t *ds.Task) { t.Status = ds.TaskStatusFailed })
+	s.OnTaskComplete("root", "worker", false)
+	s.Tick(time.Now())
+
+	if got := d.dispatched(); !slices.Equal(got, []string{"root", "proceeding"}) {
+		t.Fatalf("dispatched %v, want root then the proceeding dependent", got)
+	}
+	if status := gs.GetTask("waiting").Status; status == ds.TaskStatusCancelled {
+		t.Fatal("waiting dependent was cancelled under the wait policy")
+	}
+}
@@ -0,0 +1,91 @@
+package scheduler
+
+import (
+	"fmt"
+	"log/slog"
+
+	"superman/ds"
+)
+
+// 重复添加同一任务 ID 时的处理策略
+const (
+	DuplicatePolicySkip   = "skip"   // 忽略重复添加的任务（默认）
+	DuplicatePolicyUpdate = "update" // 用新任务替换队列中的同 ID 任务，可借此调整优先级
+)
+
+// SetDuplicatePolicy 设置重复添加排队中任务时的处理策略；已分发执行的任务无论策略如何都不会重复入队
+func (s *AutoScheduler) SetDuplicatePolicy(policy string) error {
+	switch policy {
+	case "":
+		policy = DuplicatePolicySkip
+	case DuplicatePolicySkip, DuplicatePolicyUpdate:
+	default:
+		return fmt.Errorf("unknown duplicate policy %q", policy)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.duplicatePolicy = policy
+	return nil
+}
+
+// handleDuplicate 处理重复添加的任务 ID，返回 true 表示任务已处理、不应再入队
+func (s *AutoScheduler) handleDuplicate(task *ds.Task, priority string) bool {
+	s.mu.RLock()
+	_, inFlight := s.inFlightPriority[task.ID]
+	policy := s.duplicatePolicy
+	s.mu.RUnlock()
+
+	if inFlight || s.isDispatched(task.ID) {
+		slog.Warn("task already dispatched, ignoring duplicate",
+			slog.String("task_id", task.ID),
+			slog.String("title", task.Title),
+		)
+		return true
+	}
+
+	queuedIn := ""
+	for name, queue := range s.queues() {
+		if queue.Contains(task.ID) {
+			queuedIn = name
+			break
+		}
+	}
+	if queuedIn == "" {
+		return false
+	}
+
+	if policy != DuplicatePolicyUpdate {
+		slog.Warn("task already queued, ignoring duplicate",
+			slog.String("task_id", task.ID),
+			slog.String("title", task.Title),
+			slog.String("queue", queuedIn),
+		)
+		return true
+	}
+
+	// 替换队列中的任务，优先级变化时移到新队列，保留首次入队时间
+	s.queue(queuedIn).Remove(task.ID)
+	queue := s.queue(priority)
+	if queue == nil {
+		queue = s.queue(queuedIn)
+	}
+	queue.Enqueue(task)
+	if s.globalState != nil {
+		s.globalState.AddTask(task)
+	}
+	slog.Info("queued task replaced by duplicate",
+		slog.String("task_id", task.ID),
+		slog.String("from", queuedIn),
+		slog.String("to", priority),
+	)
+	return true
+}
+
+// isDispatched 检查任务在全局状态中是否已分配或正在执行
+func (s *AutoScheduler) isDispatched(taskID string) bool {
+	if s.globalState == nil {
+		return false
+	}
+	task := s.globalState.GetTask(taskID)
+	return task != nil && (task.Status == ds.TaskStatusAssigned || task.Status == ds.TaskStatusProcessing)
+}
@@ -0,0 +1,45 @@
+package scheduler
+
+import "testing"
+
+// 重复添加排队中的任务 ID 不会产生第二个队列条目，已分发的任务再次添加也不会重新入队
+func TestDuplicateTaskIDIsNotEnqueuedTwice(t *testing.T) {
+	s, d, _ := newTestScheduler(t)
+	s.AddAgent("worker", 5, 1)
+
+	s.AddTask(newTestTask("t1"), PriorityMedium)
+	s.AddTask(newTestTask("t1"), PriorityMedium)
+	if got := s.GetQueueLength(); got != 1 {
+		t.Fatalf("queue length = %d after adding t1 twice, want 1", got)
+	}
+
+	s.dispatchTasks()
+	s.AddTask(newTestTask("t1"), PriorityMedium)
+	s.dispatchTasks()
+	if got := d.dispatched(); len(got) != 1 || s.GetQueueLength() != 0 {
+		t.Fatalf("dispatched %v with %d queued, want t1 once and an empty queue", got, s.GetQueueLength())
+	}
+}
+
+// update 策略下重复添加排队中的任务会替换原条目，并移到新的优先级队列
+func TestDuplicatePolicyUpdateReplacesQueuedTask(t *testing.T) {
+	s, _, gs := newTestScheduler(t)
+	if err := s.SetDuplicatePolicy(DuplicatePolicyUpdate); err != nil {
+		t.Fatalf("SetDuplicatePolicy: %v", err)
+	}
+
+	s.AddTask(newTestTask("t1"), PriorityLow)
+	replacement := newTestTask("t1")
+	replacement.Title = "revised"
+	s.AddTask(replacement, PriorityHigh)
+
+	if low, high := s.GetQueueLengthByPriority(PriorityLow), s.GetQueueLengthByPriority(PriorityHigh); low != 0 || high != 1 {
+		t.Fatalf("low/high queue lengths = %d/%d, want 0/1", low, high)
+	}
+	if got := gs.GetTask("t1").Title; got != "revised" {
+		t.Fatalf("stored title = %q, want the replacement", got)
+	}
+	if err := s.SetDuplicatePolicy("merge"); err == nil {
+		t.Fatal("SetDuplicatePolicy accepted an unknown policy")
+	}
+}
@@ -0,0 +1,72 @@
+package scheduler
+
+import (
+	"strconv"
+
+	"superman/ds"
+)
+
+// DefaultEffort 默认预估工作量（一个标准任务）
+const DefaultEffort = 1.0
+
+// Estimator 任务工作量估算器
+type Estimator interface {
+	Estimate(task *ds.Task) float64
+}
+
+// ConstantEstimator 对所有任务返回相同工作量
+type ConstantEstimator float64
+
+// Estimate 返回常量工作量
+func (e ConstantEstimator) Estimate(task *ds.Task) float64 {
+	return float64(e)
+}
+
+// MetadataEstimator 从任务元数据 effort 字段读取工作量，缺失时使用 Default
+type MetadataEstimator struct {
+	Default float64
+}
+
+// Estimate 读取 task.Metadata["effort"]
+func (e MetadataEstimator) Estimate(task *ds.Task) float64 {
+	switch v := task.Metadata["effort"].(type) {
+	case float64:
+		if v > 0 {
+			return v
+		}
+	case int:
+		if v > 0 {
+			return float64(v)
+		}
+	case string:
+		if f, err := strconv.ParseFloat(v, 64); err == nil && f > 0 {
+			return f
+		}
+	}
+	return e.Default
+}
+
+// SetEstimator 设置任务工作量估算器
+func (s *AutoScheduler) SetEstimator(estimator Estimator) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.estimator = estimator
+}
+
+// estimate 为尚未估算的任务计算工作量
+func (s *AutoScheduler) estimate(task *ds.Task) {
+	if task.Effort > 0 {
+		return
+	}
+	s.mu.RLock()
+	estimator := s.estimator
+	s.mu.RUnlock()
+
+	effort := DefaultEffort
+	if estimator != nil {
+		if e := estimator.Estimate(task); e > 0 {
+			effort = e
+		}
+	}
+	task.Effort = effort
+}
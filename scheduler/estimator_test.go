@@ -0,0 +1,35 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+// dispatchTo 加入指定执行者的任务并分发
+func dispatchTo(t *testing.T, s *AutoScheduler, id, agent string, effort float64) {
+	t.Helper()
+	task := newTestTask(id)
+	task.AssignedTo = agent
+	task.Metadata["effort"] = effort
+	s.AddTask(task, PriorityMedium)
+	s.Tick(time.Now())
+	if got := s.globalState.GetTask(id).AssignedTo; got != agent {
+		t.Fatalf("task %s assigned to %q, want %s", id, got, agent)
+	}
+}
+
+// 按工作量选择 Agent：任务数少但在途工作量大的 Agent 不再接收重任务
+func TestHeavyTaskAvoidsAgentWithHighEffort(t *testing.T) {
+	s, _, _ := newTestScheduler(t)
+	s.SetEstimator(MetadataEstimator{Default: DefaultEffort})
+	s.AddAgent("alice", 10, 1)
+	s.AddAgent("bob", 10, 1)
+
+	dispatchTo(t, s, "migration", "alice", 8)
+	dispatchTo(t, s, "typo1", "bob", 1)
+	dispatchTo(t, s, "typo2", "bob", 1)
+
+	if got := dispatchOne(t, s, "rewrite", nil, map[string]any{"effort": 2.0}); got != "bob" {
+		t.Fatalf("heavy task ran on %s with fewer but heavier tasks, want bob", got)
+	}
+}
@@ -0,0 +1,31 @@
+package scheduler
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// 两个同层级 Agent 轮流空闲时，累计分配数保持均衡，而不是总选同一个
+func TestLifetimeAssignmentsStayBalanced(t *testing.T) {
+	s, _, _ := newTestScheduler(t)
+	s.AddAgent("alice", 2, 1)
+	s.AddAgent("bob", 2, 1)
+
+	for i := range 100 {
+		id := fmt.Sprintf("t%d", i)
+		s.AddTask(newTestTask(id), PriorityMedium)
+		s.Tick(time.Now())
+		task := s.globalState.GetTask(id)
+		if task == nil || task.AssignedTo == "" {
+			t.Fatalf("task %s was not dispatched", id)
+		}
+		s.OnTaskComplete(id, task.AssignedTo, true)
+	}
+
+	alice, _ := s.GetAgentLoad("alice")
+	bob, _ := s.GetAgentLoad("bob")
+	if alice.TotalAssigned != 50 || bob.TotalAssigned != 50 {
+		t.Fatalf("lifetime assignments alice=%d bob=%d, want 50 each", alice.TotalAssigned, bob.TotalAssigned)
+	}
+}
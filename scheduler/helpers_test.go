@@ -0,0 +1,102 @@
+package scheduler
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"superman/ds"
+	"superman/state"
+)
+
+// recordingDispatcher 记录分发的任务，err 非空时分发失败
+type recordingDispatcher struct {
+	mu    sync.Mutex
+	tasks []string
+	err   error
+}
+
+func (d *recordingDispatcher) RunTask(task *ds.Task) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.err != nil {
+		return d.err
+	}
+	d.tasks = append(d.tasks, task.ID)
+	return nil
+}
+
+func (d *recordingDispatcher) dispatched() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]string(nil), d.tasks...)
+}
+
+// newTestScheduler 创建不启动调度循环的调度器，按需调用 Tick 或 dispatchTasks
+func newTestScheduler(t *testing.T) (*AutoScheduler, *recordingDispatcher, *state.GlobalState) {
+	t.Helper()
+	gs := state.NewGlobalState()
+	d := &recordingDispatcher{}
+	return NewAutoScheduler(d, gs, 0), d, gs
+}
+
+// newTestTask 创建指定 ID 的待分发任务
+func newTestTask(id string) *ds.Task {
+	return ds.NewTask(id, fmt.Sprintf("task %s", id), "test task", "", "boss", ds.TaskStatusPending, ds.TaskPriorityMedium)
+}
+
+// fakeTickClock 由测试推进的虚拟时钟，替换调度循环的轮询定时器；
+// 调度循环每次设置定时器时把等待时长写入 waits，测试据此与调度循环同步
+type fakeTickClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	pending []fakeTimer
+	waits   chan time.Duration
+}
+
+type fakeTimer struct {
+	at time.Time
+	ch chan time.Time
+}
+
+func newFakeTickClock(start time.Time) *fakeTickClock {
+	return &fakeTickClock{now: start, waits: make(chan time.Duration, 16)}
+}
+
+func (c *fakeTickClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	ch := make(chan time.Time, 1)
+	c.pending = append(c.pending, fakeTimer{at: c.now.Add(d), ch: ch})
+	c.mu.Unlock()
+	c.waits <- d
+	return ch
+}
+
+// Advance 推进虚拟时间并触发到期的定时器
+func (c *fakeTickClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	kept := c.pending[:0]
+	for _, timer := range c.pending {
+		if timer.at.After(c.now) {
+			kept = append(kept, timer)
+			continue
+		}
+		timer.ch <- timer.at
+	}
+	c.pending = kept
+}
+
+// nextWait 等待调度循环设置下一个定时器，返回其等待时长
+func (c *fakeTickClock) nextWait(t *testing.T) time.Duration {
+	t.Helper()
+	select {
+	case d := <-c.waits:
+		return d
+	case <-time.After(2 * time.Second):
+		t.Fatal("schedule loop did not set its next tick timer")
+		return 0
+	}
+}
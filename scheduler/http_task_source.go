@@ -0,0 +1,128 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"superman/ds"
+)
+
+// HTTPTaskSource 通过定期 GET 拉取外部任务的任务来源，接口返回任务 JSON 数组（字段同 ds.Task）
+type HTTPTaskSource struct {
+	name     string
+	url      string
+	interval time.Duration
+	client   *http.Client
+
+	tasks     chan *ds.Task
+	startOnce sync.Once
+	closeOnce sync.Once
+	ctx       context.Context
+	cancel    context.CancelFunc
+}
+
+// NewHTTPTaskSource 创建 HTTP 拉取任务来源，interval <= 0 时默认 30 秒
+func NewHTTPTaskSource(name, url string, interval time.Duration) *HTTPTaskSource {
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &HTTPTaskSource{
+		name:     name,
+		url:      url,
+		interval: interval,
+		client:   &http.Client{Timeout: 10 * time.Second},
+		tasks:    make(chan *ds.Task),
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+// Tasks 返回任务通道，首次调用时开始轮询
+func (h *HTTPTaskSource) Tasks() <-chan *ds.Task {
+	h.startOnce.Do(func() {
+		go h.pollLoop()
+	})
+	return h.tasks
+}
+
+// Close 停止轮询
+func (h *HTTPTaskSource) Close() error {
+	h.closeOnce.Do(h.cancel)
+	return nil
+}
+
+// pollLoop 按间隔拉取任务并写入通道，停止后关闭通道
+func (h *HTTPTaskSource) pollLoop() {
+	defer close(h.tasks)
+	ticker := time.NewTicker(h.interval)
+	defer ticker.Stop()
+
+	for {
+		tasks, err := h.fetch()
+		if err != nil {
+			slog.Warn("failed to fetch tasks from source",
+				slog.String("source", h.name),
+				slog.String("url", h.url),
+				slog.Any("error", err),
+			)
+		}
+		for _, task := range tasks {
+			select {
+			case h.tasks <- task:
+			case <-h.ctx.Done():
+				return
+			}
+		}
+
+		select {
+		case <-h.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// fetch 拉取一批任务并补全缺省字段
+func (h *HTTPTaskSource) fetch() ([]*ds.Task, error) {
+	req, err := http.NewRequestWithContext(h.ctx, http.MethodGet, h.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	var tasks []*ds.Task
+	if err := json.NewDecoder(resp.Body).Decode(&tasks); err != nil {
+		return nil, fmt.Errorf("decode tasks: %w", err)
+	}
+	now := time.Now()
+	for _, task := range tasks {
+		if task.ID == "" {
+			task.ID = ds.GenerateTaskID()
+		}
+		if task.Status == "" {
+			task.Status = ds.TaskStatusPending
+		}
+		if task.CreatedAt.IsZero() {
+			task.CreatedAt = now
+		}
+		task.UpdatedAt = now
+		if task.Metadata == nil {
+			task.Metadata = make(map[string]any)
+		}
+		task.Metadata["source"] = "http:" + h.name
+	}
+	return tasks, nil
+}
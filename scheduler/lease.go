@@ -0,0 +1,135 @@
+package scheduler
+
+import (
+	"log/slog"
+	"time"
+)
+
+// TaskLeaser 任务租约存储，多个调度器实例共享数据库时用于避免重复分发
+type TaskLeaser interface {
+	AcquireTaskLease(taskID, owner string, ttl time.Duration) (bool, error)
+	ReleaseTaskLease(taskID, owner string) error
+	CompleteTaskLease(taskID, owner string) error
+	RenewTaskLease(taskID, owner string, ttl time.Duration) (bool, error)
+	ExpireTaskLeases(now time.Time) (int64, error)
+}
+
+// SetTaskLeaser 启用分布式租约：分发前必须先取得任务租约，执行期间定期续约，
+// 任务成功后标记为已完成（其他实例不会再次分发），失败或回到队列时释放
+func (s *AutoScheduler) SetTaskLeaser(leaser TaskLeaser, owner string, ttl time.Duration) {
+	if ttl <= 0 {
+		ttl = 10 * time.Minute
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.leaser = leaser
+	s.leaseOwner = owner
+	s.leaseTTL = ttl
+}
+
+// acquireLease 获取任务租约，未启用租约时总是成功
+func (s *AutoScheduler) acquireLease(taskID string) bool {
+	s.mu.RLock()
+	leaser, owner, ttl := s.leaser, s.leaseOwner, s.leaseTTL
+	s.mu.RUnlock()
+	if leaser == nil {
+		return true
+	}
+
+	acquired, err := leaser.AcquireTaskLease(taskID, owner, ttl)
+	if err != nil {
+		slog.Error("failed to acquire task lease",
+			slog.String("task_id", taskID),
+			slog.String("owner", owner),
+			slog.Any("error", err),
+		)
+		return false
+	}
+	return acquired
+}
+
+// releaseLease 释放任务租约
+func (s *AutoScheduler) releaseLease(taskID string) {
+	s.mu.RLock()
+	leaser, owner := s.leaser, s.leaseOwner
+	s.mu.RUnlock()
+	if leaser == nil {
+		return
+	}
+
+	if err := leaser.ReleaseTaskLease(taskID, owner); err != nil {
+		slog.Error("failed to release task lease",
+			slog.String("task_id", taskID),
+			slog.String("owner", owner),
+			slog.Any("error", err),
+		)
+	}
+}
+
+// completeLease 将任务租约标记为已完成
+func (s *AutoScheduler) completeLease(taskID string) {
+	s.mu.RLock()
+	leaser, owner := s.leaser, s.leaseOwner
+	s.mu.RUnlock()
+	if leaser == nil {
+		return
+	}
+
+	if err := leaser.CompleteTaskLease(taskID, owner); err != nil {
+		slog.Error("failed to complete task lease",
+			slog.String("task_id", taskID),
+			slog.String("owner", owner),
+			slog.Any("error", err),
+		)
+	}
+}
+
+// renewLeases 为本实例执行中的任务续约，每 TTL 的三分之一续约一次，避免长任务的租约过期后被其他实例重复分发
+func (s *AutoScheduler) renewLeases(now time.Time) {
+	s.mu.Lock()
+	leaser, owner, ttl := s.leaser, s.leaseOwner, s.leaseTTL
+	if leaser == nil || now.Sub(s.leaseRenewedAt) < ttl/3 {
+		s.mu.Unlock()
+		return
+	}
+	s.leaseRenewedAt = now
+	ids := make([]string, 0, len(s.inFlightEffort))
+	for id := range s.inFlightEffort {
+		ids = append(ids, id)
+	}
+	s.mu.Unlock()
+
+	for _, id := range ids {
+		held, err := leaser.RenewTaskLease(id, owner, ttl)
+		if err != nil {
+			slog.Error("failed to renew task lease",
+				slog.String("task_id", id),
+				slog.String("owner", owner),
+				slog.Any("error", err),
+			)
+			continue
+		}
+		if !held {
+			slog.Warn("task lease lost while executing",
+				slog.String("task_id", id),
+				slog.String("owner", owner),
+			)
+		}
+	}
+}
+
+// expireLeases 清理过期租约
+func (s *AutoScheduler) expireLeases(now time.Time) {
+	s.mu.RLock()
+	leaser := s.leaser
+	s.mu.RUnlock()
+	if leaser == nil {
+		return
+	}
+
+	if n, err := leaser.ExpireTaskLeases(now); err != nil {
+		slog.Error("failed to expire task leases", slog.Any("error", err))
+	} else if n > 0 {
+		slog.Info("expired stale task leases", slog.Int64("count", n))
+	}
+}
@@ -0,0 +1,76 @@
+package scheduler
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"superman/persistence"
+	"superman/state"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// newTestLeaser 创建基于内存 SQLite 的共享租约存储
+func newTestLeaser(t *testing.T) *persistence.Persistence {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("db handle: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { _ = sqlDB.Close() })
+	p, err := persistence.NewPersistence(db)
+	if err != nil {
+		t.Fatalf("NewPersistence: %v", err)
+	}
+	return p
+}
+
+// 共享同一租约存储的两个调度器同时分发相同的任务，每个任务只被分发一次
+func TestSharedLeaseDispatchesTaskOnce(t *testing.T) {
+	leaser := newTestLeaser(t)
+	const total = 20
+
+	schedulers := make([]*AutoScheduler, 2)
+	dispatchers := make([]*recordingDispatcher, 2)
+	for i := range schedulers {
+		d := &recordingDispatcher{}
+		s := NewAutoScheduler(d, state.NewGlobalState(), 0)
+		s.SetTaskLeaser(leaser, fmt.Sprintf("instance-%d", i), time.Minute)
+		s.AddAgent("worker", total, 1)
+		for n := range total {
+			s.AddTask(newTestTask(fmt.Sprintf("t%d", n)), PriorityMedium)
+		}
+		schedulers[i], dispatchers[i] = s, d
+	}
+
+	var wg sync.WaitGroup
+	for _, s := range schedulers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.Tick(time.Now())
+		}()
+	}
+	wg.Wait()
+
+	counts := make(map[string]int)
+	for _, d := range dispatchers {
+		for _, id := range d.dispatched() {
+			counts[id]++
+			if counts[id] > 1 {
+				t.Fatalf("task %s dispatched by both schedulers", id)
+			}
+		}
+	}
+	if len(counts) != total {
+		t.Fatalf("dispatched %d tasks, want %d", len(counts), total)
+	}
+}
@@ -0,0 +1,80 @@
+package scheduler
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// 分发、完成回调与 Agent 注册、容量调整并发进行时负载计数不产生数据竞争，结束后负载归零（需 -race 运行）
+func TestConcurrentDispatchCompletionAndRegistration(t *testing.T) {
+	s, d, _ := newTestScheduler(t)
+	s.AddAgent("a0", 4, 1)
+
+	const tasks = 200
+	var wg sync.WaitGroup
+	wg.Add(4)
+	go func() {
+		defer wg.Done()
+		for i := range tasks {
+			s.AddTask(newTestTask(fmt.Sprintf("t%d", i)), PriorityMedium)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for range tasks {
+			s.Tick(time.Now())
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := range 20 {
+			name := fmt.Sprintf("a%d", i%5)
+			s.AddAgent(name, 4, 1)
+			_ = s.SetAgentMaxTasks(name, 4+i%3) // 只调高容量，避免收回已分配的任务
+			s.SetAgentCapabilities(name, nil)
+		}
+	}()
+	completed := make(map[string]bool)
+	go func() {
+		defer wg.Done()
+		for range tasks {
+			for _, id := range d.dispatched() {
+				if completed[id] {
+					continue
+				}
+				if task := s.globalState.GetTask(id); task != nil {
+					s.OnTaskComplete(id, task.AssignedTo, true)
+					completed[id] = true
+				}
+			}
+		}
+	}()
+	wg.Wait()
+
+	// 分发剩余任务并全部完成后，各 Agent 的负载计数归零
+	for len(completed) < tasks {
+		s.Tick(time.Now())
+		progressed := false
+		for _, id := range d.dispatched() {
+			if completed[id] {
+				continue
+			}
+			s.OnTaskComplete(id, s.globalState.GetTask(id).AssignedTo, true)
+			completed[id] = true
+			progressed = true
+		}
+		if !progressed && s.GetQueueLength() == 0 {
+			break
+		}
+	}
+	if len(completed) != tasks {
+		t.Fatalf("completed %d tasks, want %d", len(completed), tasks)
+	}
+	for i := range 5 {
+		if load, ok := s.GetAgentLoad(fmt.Sprintf("a%d", i)); ok && load.CurrentLoad != 0 {
+			t.Errorf("agent %s load = %d after all tasks completed, want 0", load.Name, load.CurrentLoad)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package scheduler
+
+import (
+	"log/slog"
+	"time"
+)
+
+// 扩容提示
+const (
+	ScaleHintUp     = "scale_up"   // 压力持续超过阈值，建议增加 Agent
+	ScaleHintSteady = "steady"     // 容量与需求基本匹配
+	ScaleHintIdle   = "scale_down" // 队列为空且大部分容量空闲，可考虑减少 Agent
+)
+
+// idlePressure 低于该压力且无排队任务时提示缩容
+const idlePressure = 0.25
+
+// Pressure 调度压力：排队与在途任务数相对 Agent 总容量的比例及排队等待时间，作为扩缩容提示
+type Pressure struct {
+	Value      float64    `json:"pressure"`    // (排队 + 在途) / 总容量，大于 1 表示需求超出容量
+	QueueDepth int        `json:"queue_depth"` // 排队任务数
+	InFlight   int        `json:"in_flight"`   // 在途任务数
+	Capacity   int        `json:"capacity"`    // 所有 Agent 的最大并发任务数之和
+	QueueRatio float64    `json:"queue_ratio"` // 排队任务数 / 总容量
+	AvgWaitMs  int64      `json:"avg_wait_ms"` // 排队中任务的平均已等待时间
+	Threshold  float64    `json:"threshold,omitempty"`
+	HighSince  *time.Time `json:"high_since,omitempty"` // 压力开始持续超过阈值的时间
+	Hint       string     `json:"hint"`
+}
+
+// PressureAlertFunc 压力持续超过阈值时的告警回调
+type PressureAlertFunc func(p Pressure, sustained time.Duration)
+
+// PressureAlert 压力告警配置：压力不低于 Threshold 持续 Sustain 后告警一次，回落到阈值以下后重新计时
+type PressureAlert struct {
+	Threshold float64
+	Sustain   time.Duration
+}
+
+// pressureState 压力告警的运行状态
+type pressureState struct {
+	alert     PressureAlert
+	notify    PressureAlertFunc
+	highSince time.Time
+	alerted   bool
+}
+
+// SetPressureAlert 设置压力告警，Threshold<=0 或 fn 为 nil 时关闭告警
+func (s *AutoScheduler) SetPressureAlert(alert PressureAlert, fn PressureAlertFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pressure = pressureState{alert: alert, notify: fn}
+}
+
+// GetPressure 计算当前调度压力
+func (s *AutoScheduler) GetPressure() Pressure {
+	queued := s.GetQueueLength()
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	p := Pressure{
+		QueueDepth: queued,
+		InFlight:   len(s.inFlightEffort),
+		Threshold:  s.pressure.alert.Threshold,
+	}
+	if !s.pressure.highSince.IsZero() {
+		highSince := s.pressure.highSince
+		p.HighSince = &highSince
+	}
+	for _, agent := range s.agentLoads {
+		p.Capacity += agent.MaxTasks
+	}
+	if p.Capacity > 0 {
+		p.Value = float64(p.QueueDepth+p.InFlight) / float64(p.Capacity)
+		p.QueueRatio = float64(p.QueueDepth) / float64(p.Capacity)
+	} else if p.QueueDepth > 0 {
+		// 没有 Agent 而有排队任务，压力按排队数计
+		p.Value = float64(p.QueueDepth)
+		p.QueueRatio = float64(p.QueueDepth)
+	}
+	if len(s.enqueuedAt) > 0 {
+		now := s.now()
+		var total time.Duration
+		for _, at := range s.enqueuedAt {
+			total += now.Sub(at)
+		}
+		p.AvgWaitMs = (total / time.Duration(len(s.enqueuedAt))).Milliseconds()
+	}
+
+	switch {
+	case p.Threshold > 0 && p.Value >= p.Threshold, p.Threshold <= 0 && p.Value > 1:
+		p.Hint = ScaleHintUp
+	case p.QueueDepth == 0 && p.Value < idlePressure:
+		p.Hint = ScaleHintIdle
+	default:
+		p.Hint = ScaleHintSteady
+	}
+	return p
+}
+
+// checkPressure 跟踪压力超过阈值的持续时间，达到 Sustain 时告警一次
+func (s *AutoScheduler) checkPressure(now time.Time) {
+	s.mu.RLock()
+	alert, notify := s.pressure.alert, s.pressure.notify
+	s.mu.RUnlock()
+	if alert.Threshold <= 0 || notify == nil {
+		return
+	}
+	p := s.GetPressure()
+
+	s.mu.Lock()
+	if p.Value < alert.Threshold {
+		s.pressure.highSince = time.Time{}
+		s.pressure.alerted = false
+		s.mu.Unlock()
+		return
+	}
+	if s.pressure.highSince.IsZero() {
+		s.pressure.highSince = now
+	}
+	sustained := now.Sub(s.pressure.highSince)
+	fire := !s.pressure.alerted && sustained >= alert.Sustain
+	if fire {
+		s.pressure.alerted = true
+	}
+	highSince := s.pressure.highSince
+	p.HighSince = &highSince
+	s.mu.Unlock()
+
+	if fire {
+		slog.Warn("scheduler pressure sustained above threshold",
+			slog.Float64("pressure", p.Value),
+			slog.Float64("threshold", alert.Threshold),
+			slog.Duration("sustained", sustained),
+		)
+		notify(p, sustained)
+	}
+}
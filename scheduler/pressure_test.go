@@ -0,0 +1,46 @@
+package scheduler
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// 排队任务持续积压时压力值上升并提示扩容，压力不低于阈值持续 Sustain 后只告警一次，回落后重新计时
+func TestSustainedQueueDepthRaisesPressureAlert(t *testing.T) {
+	s, _, _ := newTestScheduler(t)
+	s.AddAgent("alice", 1, 1)
+	var alerts []time.Duration
+	s.SetPressureAlert(PressureAlert{Threshold: 2, Sustain: time.Minute}, func(p Pressure, sustained time.Duration) {
+		alerts = append(alerts, sustained)
+	})
+
+	if p := s.GetPressure(); p.Value != 0 || p.Hint != ScaleHintIdle {
+		t.Fatalf("idle pressure = %+v, want 0 with a scale-down hint", p)
+	}
+	for i := range 4 {
+		s.AddTask(newTestTask(fmt.Sprintf("t%d", i)), PriorityMedium)
+	}
+	start := time.Now()
+	s.Tick(start)
+	p := s.GetPressure()
+	if p.QueueDepth != 3 || p.InFlight != 1 || p.Value != 4 || p.Hint != ScaleHintUp || p.HighSince == nil {
+		t.Fatalf("pressure = %+v, want 3 queued + 1 in flight over capacity 1 with a scale-up hint", p)
+	}
+
+	s.Tick(start.Add(30 * time.Second))
+	if len(alerts) != 0 {
+		t.Fatalf("alerted after 30s, want to wait for the 1m sustain")
+	}
+	s.Tick(start.Add(61 * time.Second))
+	s.Tick(start.Add(90 * time.Second))
+	if len(alerts) != 1 || alerts[0] != 61*time.Second {
+		t.Fatalf("alerts = %v, want a single alert after 61s", alerts)
+	}
+
+	s.ClearQueue(PriorityMedium)
+	s.Tick(start.Add(2 * time.Minute))
+	if p := s.GetPressure(); p.Value >= 2 || p.HighSince != nil {
+		t.Fatalf("pressure after clearing = %+v, want below threshold and reset", p)
+	}
+}
@@ -0,0 +1,75 @@
+package scheduler
+
+import (
+	"log/slog"
+	"strings"
+)
+
+// SetPriorityCaps 设置各优先级同时在途（已分发未完成）任务数上限，未设置或 <=0 的优先级不限制
+func (s *AutoScheduler) SetPriorityCaps(caps map[string]int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.priorityCaps = make(map[string]int, len(caps))
+	for priority, limit := range caps {
+		if limit <= 0 {
+			continue
+		}
+		// 优先级名称不区分大小写，统一为队列名称
+		for _, p := range queuePriorities {
+			if strings.EqualFold(p, priority) {
+				s.priorityCaps[p] = limit
+			}
+		}
+	}
+	slog.Info("priority dispatch caps set", slog.Any("caps", s.priorityCaps))
+}
+
+// GetInFlightByPriority 获取各优先级在途任务数
+func (s *AutoScheduler) GetInFlightByPriority() map[string]int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	result := make(map[string]int, len(queuePriorities))
+	for _, priority := range s.inFlightPriority {
+		result[priority]++
+	}
+	return result
+}
+
+// priorityCapReached 检查指定优先级的在途任务数是否已达上限
+func (s *AutoScheduler) priorityCapReached(priority string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	limit, ok := s.priorityCaps[priority]
+	if !ok {
+		return false
+	}
+	inFlight := 0
+	for _, p := range s.inFlightPriority {
+		if p == priority {
+			inFlight++
+		}
+	}
+	return inFlight >= limit
+}
+
+// SetMaxInFlight 设置全系统同时在途任务数上限（不区分优先级与 Agent），<=0 不限制
+func (s *AutoScheduler) SetMaxInFlight(limit int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.maxInFlight = max(limit, 0)
+	slog.Info("global in-flight cap set", slog.Int("max_in_flight", s.maxInFlight))
+}
+
+// GetMaxInFlight 获取全系统同时在途任务数上限，0 表示不限制
+func (s *AutoScheduler) GetMaxInFlight() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.maxInFlight
+}
+
+// globalCapReached 检查全系统在途任务数是否已达上限
+func (s *AutoScheduler) globalCapReached() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.maxInFlight > 0 && len(s.inFlightPriority) >= s.maxInFlight
+}
@@ -0,0 +1,71 @@
+package scheduler
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"superman/ds"
+)
+
+// Low 任务在途数达到上限后停止分发，Critical 任务不受影响继续分发
+func TestLowCapStopsLowWhileCriticalContinues(t *testing.T) {
+	s, d, gs := newTestScheduler(t)
+	s.AddAgent("alice", 10, 1)
+	s.SetPriorityCaps(map[string]int{"low": 2})
+	for i := range 4 {
+		task := newTestTask(fmt.Sprintf("low-%d", i))
+		task.Priority = ds.TaskPriorityLow
+		s.AddTask(task, PriorityLow)
+	}
+	for i := range 3 {
+		task := newTestTask(fmt.Sprintf("critical-%d", i))
+		task.Priority = ds.TaskPriorityCritical
+		s.AddTask(task, PriorityCritical)
+	}
+	s.Tick(time.Now())
+
+	inFlight := s.GetInFlightByPriority()
+	if inFlight[PriorityLow] != 2 || inFlight[PriorityCritical] != 3 {
+		t.Fatalf("in flight = %v, want 2 low and all 3 critical", inFlight)
+	}
+	if got := s.GetQueueLengthByPriority(PriorityLow); got != 2 {
+		t.Fatalf("low queue = %d, want 2 waiting for the cap", got)
+	}
+
+	// 一个 Low 任务完成后释放名额，下一个 Low 任务得以分发
+	gs.UpdateTask("low-0", func(t *ds.Task) { t.Status = ds.TaskStatusCompleted })
+	s.OnTaskComplete("low-0", "alice", true)
+	s.Tick(time.Now())
+	if !slices.Contains(d.dispatched(), "low-2") || s.GetQueueLengthByPriority(PriorityLow) != 1 {
+		t.Fatalf("dispatched %v, want low-2 dispatched after a low slot freed", d.dispatched())
+	}
+}
+
+// 全系统在途上限为 3 时，即使空闲 Agent 很多也最多同时执行 3 个任务，完成一个后再分发一个
+func TestGlobalCapLimitsInFlightAcrossAgents(t *testing.T) {
+	s, d, gs := newTestScheduler(t)
+	for i := range 5 {
+		s.AddAgent(fmt.Sprintf("agent-%d", i), 3, 1)
+	}
+	s.SetMaxInFlight(3)
+	for i := range 8 {
+		s.AddTask(newTestTask(fmt.Sprintf("t%d", i)), PriorityMedium)
+	}
+
+	s.Tick(time.Now())
+	s.Tick(time.Now())
+	first := d.dispatched()
+	if len(first) != 3 || s.GetQueueLength() != 5 {
+		t.Fatalf("dispatched %v with %d queued, want 3 running and 5 waiting", first, s.GetQueueLength())
+	}
+
+	done := gs.GetTask(first[0])
+	gs.UpdateTask(done.ID, func(t *ds.Task) { t.Status = ds.TaskStatusCompleted })
+	s.OnTaskComplete(done.ID, done.AssignedTo, true)
+	s.Tick(time.Now())
+	if got := d.dispatched(); len(got) != 4 || s.GetQueueLength() != 4 {
+		t.Fatalf("dispatched %v after one completion, want exactly one more", got)
+	}
+}
@@ -0,0 +1,69 @@
+package scheduler
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"superman/ds"
+	"superman/state"
+)
+
+// SetTaskPriority 人工调整排队中任务的优先级：移动到目标队列、更新任务优先级并记录变更，
+// 同时清除此前继承的优先级
+func (s *AutoScheduler) SetTaskPriority(taskID, priority string) error {
+	target := s.queue(priority)
+	if target == nil {
+		return fmt.Errorf("unknown priority %q", priority)
+	}
+	for _, from := range queuePriorities {
+		task := s.queue(from).Remove(taskID)
+		if task == nil {
+			continue
+		}
+
+		s.mu.Lock()
+		delete(s.inheritedPriority, taskID)
+		s.mu.Unlock()
+
+		s.recordPriorityChange(task, from, priority, ds.PriorityChangeManual)
+		s.updateTask(task, func(t *ds.Task) {
+			t.SetPriority(ds.TaskPriority(strings.ToLower(priority)))
+		})
+		target.Enqueue(task)
+
+		slog.Info("task priority changed manually",
+			slog.String("task_id", taskID),
+			slog.String("from", from),
+			slog.String("to", priority),
+		)
+		return nil
+	}
+	return fmt.Errorf("task %s is not queued", taskID)
+}
+
+// recordPriorityChange 在任务上记录一次优先级变更，并写入任务执行记录
+func (s *AutoScheduler) recordPriorityChange(task *ds.Task, from, to, reason string) {
+	if from == to {
+		return
+	}
+	s.updateTask(task, func(t *ds.Task) {
+		t.RecordPriorityChange(from, to, reason)
+	})
+	if s.globalState != nil {
+		s.globalState.AppendTranscript(task.ID, state.TranscriptEntry{
+			Agent:   "scheduler",
+			Role:    "system",
+			Content: fmt.Sprintf("priority changed from %s to %s (%s)", from, to, reason),
+		})
+	}
+}
+
+// updateTask 修改调度器持有的任务；任务与全局状态共享同一对象时在全局状态锁内修改
+func (s *AutoScheduler) updateTask(task *ds.Task, fn func(*ds.Task)) {
+	if s.globalState != nil && s.globalState.GetTask(task.ID) == task {
+		s.globalState.UpdateTask(task.ID, fn)
+		return
+	}
+	fn(task)
+}
@@ -0,0 +1,28 @@
+package scheduler
+
+import (
+	"strings"
+	"testing"
+
+	"superman/ds"
+)
+
+// 人工调整优先级记录变更历史，并写入任务执行记录
+func TestSetTaskPriorityRecordsChange(t *testing.T) {
+	s, _, gs := newTestScheduler(t)
+	task := newTestTask("t1")
+	gs.AddTask(task)
+	s.AddTask(task, PriorityLow)
+
+	if err := s.SetTaskPriority("t1", PriorityHigh); err != nil {
+		t.Fatalf("SetTaskPriority: %v", err)
+	}
+	history := gs.GetTask("t1").PriorityHistory
+	if len(history) != 1 || history[0].From != PriorityLow || history[0].To != PriorityHigh || history[0].Reason != ds.PriorityChangeManual {
+		t.Fatalf("PriorityHistory = %+v, want one manual change from %s to %s", history, PriorityLow, PriorityHigh)
+	}
+	entries := gs.GetTranscript("t1")
+	if len(entries) != 1 || entries[0].Agent != "scheduler" || !strings.Contains(entries[0].Content, ds.PriorityChangeManual) {
+		t.Fatalf("transcript = %+v, want one scheduler entry for the manual change", entries)
+	}
+}
@@ -0,0 +1,115 @@
+package scheduler
+
+import (
+	"log/slog"
+	"strings"
+	"time"
+
+	"superman/ds"
+)
+
+// StaleCancelReason 自动生成任务因过期被取消的原因
+const StaleCancelReason = "stale"
+
+// MetadataPriorityDecays 任务元数据中记录优先级衰减次数的键
+const MetadataPriorityDecays = "priority_decays"
+
+// PriorityDecay 自动生成任务的优先级衰减策略
+type PriorityDecay struct {
+	StaleAfter time.Duration // 排队超过该时长后优先级降一级，之后每经过该时长再降一级，0 不衰减
+	MaxAge     time.Duration // 排队超过该时长后取消任务，0 不取消
+}
+
+// SetPriorityDecay 设置自动生成任务（元数据 source=llm_generated）的优先级衰减策略
+func (s *AutoScheduler) SetPriorityDecay(decay PriorityDecay) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.priorityDecay = decay
+	slog.Info("priority decay set",
+		slog.Duration("stale_after", decay.StaleAfter),
+		slog.Duration("max_age", decay.MaxAge),
+	)
+}
+
+// applyPriorityDecay 对排队中的自动生成任务应用优先级衰减：超过最大存活时间的取消，
+// 否则按排队时长（自首次入队起）每个周期降一级（已从依赖方继承优先级的任务不衰减）
+func (s *AutoScheduler) applyPriorityDecay(now time.Time) {
+	s.mu.RLock()
+	decay := s.priorityDecay
+	s.mu.RUnlock()
+	if decay.StaleAfter <= 0 && decay.MaxAge <= 0 {
+		return
+	}
+
+	for i, priority := range queuePriorities {
+		for _, task := range s.queue(priority).Tasks() {
+			if task.Metadata["source"] != "llm_generated" {
+				continue
+			}
+			age := now.Sub(s.enqueueTime(task))
+			if decay.MaxAge > 0 && age >= decay.MaxAge {
+				s.cancelStaleTask(task.ID, priority)
+				continue
+			}
+			if decay.StaleAfter <= 0 || i == len(queuePriorities)-1 || s.GetInheritedPriority(task.ID) != "" {
+				continue
+			}
+			decays, _ := task.Metadata[MetadataPriorityDecays].(int)
+			if int(age/decay.StaleAfter) <= decays {
+				continue
+			}
+			s.decayTask(task.ID, priority, queuePriorities[i+1], decays+1)
+		}
+	}
+}
+
+// enqueueTime 返回任务首次入队时间，未记录时使用任务创建时间
+func (s *AutoScheduler) enqueueTime(task *ds.Task) time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if at, ok := s.enqueuedAt[task.ID]; ok {
+		return at
+	}
+	return task.CreatedAt
+}
+
+// decayTask 将排队任务从 from 队列降到 to 队列并记录衰减
+func (s *AutoScheduler) decayTask(taskID, from, to string, decays int) {
+	task := s.queue(from).Remove(taskID)
+	if task == nil {
+		return
+	}
+	s.recordPriorityChange(task, from, to, ds.PriorityChangeDecay)
+	s.updateTask(task, func(t *ds.Task) {
+		t.SetPriority(ds.TaskPriority(strings.ToLower(to)))
+		t.Metadata[MetadataPriorityDecays] = decays
+	})
+	s.queue(to).Enqueue(task)
+
+	slog.Info("stale generated task priority decayed",
+		slog.String("task_id", taskID),
+		slog.String("from", from),
+		slog.String("to", to),
+	)
+}
+
+// cancelStaleTask 将超过最大存活时间的排队任务移出队列并标记为已取消
+func (s *AutoScheduler) cancelStaleTask(taskID, priority string) {
+	task := s.queue(priority).Remove(taskID)
+	if task == nil {
+		return
+	}
+	s.mu.Lock()
+	s.forgetQueued(taskID)
+	s.mu.Unlock()
+
+	s.updateTask(task, func(t *ds.Task) {
+		t.Status = ds.TaskStatusCancelled
+		t.Metadata["cancel_reason"] = StaleCancelReason
+	})
+	slog.Info("stale generated task cancelled",
+		slog.String("task_id", taskID),
+		slog.String("priority", priority),
+	)
+	s.onDependencyFailed(taskID)
+}
@@ -0,0 +1,31 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"superman/ds"
+)
+
+// 优先级衰减按首次入队时间计算：创建很久后才入队的任务不会在入队时立即衰减
+func TestPriorityDecayUsesEnqueueTime(t *testing.T) {
+	s, _, gs := newTestScheduler(t)
+	start := time.Now()
+	s.SetClock(func() time.Time { return start })
+	s.SetPriorityDecay(PriorityDecay{StaleAfter: 10 * time.Minute, MaxAge: time.Hour})
+
+	task := newTestTask("gen")
+	task.CreatedAt = start.Add(-2 * time.Hour)
+	task.Metadata["source"] = "llm_generated"
+	s.AddTask(task, PriorityMedium)
+
+	s.Tick(start.Add(time.Minute))
+	if got := gs.GetTask("gen"); got.Status != ds.TaskStatusPending || s.queue(PriorityMedium).Len() != 1 {
+		t.Fatalf("task %s left the medium queue one minute after being enqueued", got.Status)
+	}
+
+	s.Tick(start.Add(11 * time.Minute))
+	if s.queue(PriorityLow).Len() != 1 {
+		t.Fatal("task was not decayed to low after waiting longer than stale_after")
+	}
+}
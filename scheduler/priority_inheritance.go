@@ -0,0 +1,61 @@
+package scheduler
+
+import (
+	"log/slog"
+
+	"superman/ds"
+)
+
+// queuePriorities 按紧急程度从高到低排列的队列优先级
+var queuePriorities = []string{PriorityCritical, PriorityHigh, PriorityMedium, PriorityLow}
+
+// applyPriorityInheritance 优先级继承：被高优先级任务依赖的低优先级排队任务临时提升到依赖方的队列，
+// 避免低优先级依赖拖慢整条高优先级链路。提升会沿依赖链传递，直到不再变化。
+func (s *AutoScheduler) applyPriorityInheritance() {
+	for changed := true; changed; {
+		changed = false
+		for _, priority := range queuePriorities {
+			for _, task := range s.queue(priority).Tasks() {
+				for _, depID := range task.Dependencies {
+					if s.promoteTask(depID, priority) {
+						changed = true
+					}
+				}
+			}
+		}
+	}
+}
+
+// promoteTask 若任务在比 priority 更低的队列中排队，则将其移到 priority 队列并记录继承的优先级
+func (s *AutoScheduler) promoteTask(taskID, priority string) bool {
+	for _, from := range queuePriorities {
+		if PriorityValue[from] <= PriorityValue[priority] {
+			continue
+		}
+		task := s.queue(from).Remove(taskID)
+		if task == nil {
+			continue
+		}
+
+		s.mu.Lock()
+		s.inheritedPriority[taskID] = priority
+		s.mu.Unlock()
+		s.recordPriorityChange(task, from, priority, ds.PriorityChangeInheritance)
+		s.queue(priority).Enqueue(task)
+
+		slog.Info("task priority inherited from dependent",
+			slog.String("task_id", taskID),
+			slog.String("from", from),
+			slog.String("to", priority),
+		)
+		return true
+	}
+	return false
+}
+
+// GetInheritedPriority 获取任务当前继承的优先级，未继承时返回空字符串
+func (s *AutoScheduler) GetInheritedPriority(taskID string) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.inheritedPriority[taskID]
+}
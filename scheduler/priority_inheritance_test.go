@@ -0,0 +1,30 @@
+package scheduler
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"superman/ds"
+)
+
+// 被 Critical 任务依赖的 Low 任务以 Critical 紧急程度分发，先于排队中的 High 任务
+func TestLowDependencyOfCriticalTaskInheritsPriority(t *testing.T) {
+	s, d, _ := newTestScheduler(t)
+	s.AddAgent("worker", 1, 1)
+
+	s.AddTask(newTestTask("report"), PriorityHigh)
+	s.AddTask(newTestTask("collect"), PriorityLow)
+	launch := newTestTask("launch")
+	launch.Priority = ds.TaskPriorityCritical
+	launch.Dependencies = []string{"collect"}
+	s.AddTask(launch, PriorityCritical)
+
+	s.Tick(time.Now())
+	if got := d.dispatched(); !slices.Equal(got, []string{"collect"}) {
+		t.Fatalf("dispatched %v, want the Critical task's dependency first", got)
+	}
+	if got := s.GetInheritedPriority("collect"); got != PriorityCritical {
+		t.Fatalf("inherited priority = %q, want %s", got, PriorityCritical)
+	}
+}
@@ -118,3 +118,59 @@ func (q *TaskQueue) sortByPriority() {
 		return priI < priJ
 	})
 }
+
+// Tasks 获取队列中所有任务的快照
+func (q *TaskQueue) Tasks() []*ds.Task {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	result := make([]*ds.Task, len(q.queue))
+	copy(result, q.queue)
+	return result
+}
+
+// Remove 移除并返回指定 ID 的任务，不存在时返回 nil
+func (q *TaskQueue) Remove(taskID string) *ds.Task {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for i, task := range q.queue {
+		if task.ID == taskID {
+			q.queue = append(q.queue[:i], q.queue[i+1:]...)
+			return task
+		}
+	}
+	return nil
+}
+
+// Drain 移除并返回队列中的全部任务
+func (q *TaskQueue) Drain() []*ds.Task {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	tasks := q.queue
+	q.queue = nil
+	return tasks
+}
+
+// Contains 检查指定 ID 的任务是否在队列中
+func (q *TaskQueue) Contains(taskID string) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, task := range q.queue {
+		if task.ID == taskID {
+			return true
+		}
+	}
+	return false
+}
+
+// Update 在队列锁内对指定 ID 的任务执行 fn，任务不在队列中时返回 false
+func (q *TaskQueue) Update(taskID string, fn func(*ds.Task)) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, task := range q.queue {
+		if task.ID == taskID {
+			fn(task)
+			return true
+		}
+	}
+	return false
+}
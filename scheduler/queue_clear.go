@@ -0,0 +1,43 @@
+package scheduler
+
+import (
+	"log/slog"
+
+	"superman/ds"
+)
+
+// QueueClearedCancelReason 因清空优先级队列而取消的任务原因
+const QueueClearedCancelReason = "queue_cleared"
+
+// ClearQueue 清空指定优先级队列，将其中的任务标记为已取消，返回移除的任务数；未知优先级返回 0
+func (s *AutoScheduler) ClearQueue(priority string) int {
+	queue := s.queue(priority)
+	if queue == nil {
+		return 0
+	}
+	tasks := queue.Drain()
+
+	s.mu.Lock()
+	for _, task := range tasks {
+		s.forgetQueued(task.ID)
+	}
+	s.mu.Unlock()
+
+	for _, task := range tasks {
+		s.updateTask(task, func(t *ds.Task) {
+			t.Status = ds.TaskStatusCancelled
+			if t.Metadata == nil {
+				t.Metadata = make(map[string]any)
+			}
+			t.Metadata["cancel_reason"] = QueueClearedCancelReason
+		})
+	}
+	slog.Warn("priority queue cleared",
+		slog.String("priority", priority),
+		slog.Int("removed", len(tasks)),
+	)
+	for _, task := range tasks {
+		s.onDependencyFailed(task.ID)
+	}
+	return len(tasks)
+}
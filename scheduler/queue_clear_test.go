@@ -0,0 +1,52 @@
+package scheduler
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"superman/ds"
+)
+
+// 清空队列将任务标记为已取消；与扩充队列表的 AddTask 并发时不产生数据竞争
+func TestClearQueueConcurrentWithAddTask(t *testing.T) {
+	s, _, gs := newTestScheduler(t)
+	for i := range 3 {
+		s.AddTask(newTestTask(fmt.Sprintf("t%d", i)), PriorityLow)
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(4)
+	go func() {
+		defer wg.Done()
+		for i := range 200 {
+			s.AddTask(newTestTask(fmt.Sprintf("custom-%d", i)), fmt.Sprintf("custom-%d", i))
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for range 200 {
+			s.ClearQueue(PriorityHigh)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for range 200 {
+			s.GetQueueLength()
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for range 200 {
+			s.GetQueueLengthByPriority(PriorityMedium)
+		}
+	}()
+	wg.Wait()
+
+	if n := s.ClearQueue(PriorityLow); n != 3 {
+		t.Fatalf("ClearQueue(low) = %d, want 3", n)
+	}
+	if got := gs.GetTask("t0"); got.Status != ds.TaskStatusCancelled || got.Metadata["cancel_reason"] != QueueClearedCancelReason {
+		t.Fatalf("task = %s %v, want cancelled by queue clear", got.Status, got.Metadata)
+	}
+}
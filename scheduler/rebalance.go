@@ -0,0 +1,103 @@
+package scheduler
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+
+	"superman/ds"
+	"superman/state"
+)
+
+// taskPriorityRank 任务优先级排序值（数值越大越不紧急）
+var taskPriorityRank = map[ds.TaskPriority]int{
+	ds.TaskPriorityCritical: 0,
+	ds.TaskPriorityHigh:     1,
+	ds.TaskPriorityMedium:   2,
+	ds.TaskPriorityLow:      3,
+}
+
+// SetAgentMaxTasks 运行时调整 Agent 最大并发任务数。调低后负载超出上限时，
+// 将已分配但尚未开始执行的多余任务收回，放回队列由分发流程交给其他 Agent
+func (s *AutoScheduler) SetAgentMaxTasks(agentName string, maxTasks int) error {
+	if maxTasks <= 0 {
+		return fmt.Errorf("max tasks must be positive, got %d", maxTasks)
+	}
+	s.mu.Lock()
+	load, exists := s.agentLoads[agentName]
+	if !exists {
+		s.mu.Unlock()
+		return fmt.Errorf("agent %s not found", agentName)
+	}
+	load.MaxTasks = maxTasks
+	s.mu.Unlock()
+
+	slog.Info("agent max tasks changed",
+		slog.String("agent", agentName),
+		slog.Int("max_tasks", maxTasks),
+	)
+	s.rebalanceAgent(agentName)
+	return nil
+}
+
+// rebalanceAgent 收回超出 Agent 容量、仍处于已分配状态（未开始执行）的任务，低优先级任务优先收回
+func (s *AutoScheduler) rebalanceAgent(agentName string) int {
+	if s.globalState == nil {
+		return 0
+	}
+	s.mu.RLock()
+	surplus := 0
+	if load := s.agentLoads[agentName]; load != nil {
+		surplus = load.CurrentLoad - load.MaxTasks
+	}
+	s.mu.RUnlock()
+	if surplus <= 0 {
+		return 0
+	}
+
+	candidates := s.globalState.QueryTasks(state.TaskFilter{AssignedTo: agentName, Status: ds.TaskStatusAssigned})
+	sort.SliceStable(candidates, func(i, j int) bool {
+		ri, rj := taskPriorityRank[candidates[i].Priority], taskPriorityRank[candidates[j].Priority]
+		if ri != rj {
+			return ri > rj
+		}
+		return candidates[i].CreatedAt.After(candidates[j].CreatedAt)
+	})
+
+	moved := 0
+	for _, task := range candidates {
+		if moved >= surplus {
+			break
+		}
+		// 在全局状态锁内确认任务仍未被 Agent 领取，避免与开始执行竞争
+		reclaimed := false
+		s.globalState.UpdateTask(task.ID, func(t *ds.Task) {
+			if t.AssignedTo == agentName && t.Status == ds.TaskStatusAssigned {
+				t.AssignedTo = ""
+				t.Status = ds.TaskStatusPending
+				reclaimed = true
+			}
+		})
+		if !reclaimed {
+			continue
+		}
+
+		s.mu.Lock()
+		s.releaseAgentLoad(agentName, s.inFlightEffort[task.ID])
+		delete(s.inFlightEffort, task.ID)
+		delete(s.inFlightPriority, task.ID)
+		delete(s.inFlightTasks, task.ID)
+		s.mu.Unlock()
+
+		s.releaseLease(task.ID)
+		s.markEnqueued(task.ID)
+		s.requeueTask(task)
+		moved++
+
+		slog.Info("task reclaimed from overloaded agent",
+			slog.String("task_id", task.ID),
+			slog.String("agent", agentName),
+		)
+	}
+	return moved
+}
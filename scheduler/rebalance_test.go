@@ -0,0 +1,43 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"superman/ds"
+)
+
+// 调低 Agent 最大任务数后，超出上限且未开始执行的任务被收回并分发给其他 Agent
+func TestLoweringMaxTasksMovesSurplusTasks(t *testing.T) {
+	s, d, gs := newTestScheduler(t)
+	s.AddAgent("alice", 3, 1)
+	for _, id := range []string{"t1", "t2", "t3"} {
+		s.AddTask(newTestTask(id), PriorityMedium)
+	}
+	s.Tick(time.Now())
+	if got := d.dispatched(); len(got) != 3 {
+		t.Fatalf("dispatched %v, want all three tasks on alice", got)
+	}
+	// t1 已开始执行，不能被收回
+	gs.UpdateTask("t1", func(t *ds.Task) { t.Status = ds.TaskStatusProcessing })
+
+	s.AddAgent("bob", 3, 1)
+	if err := s.SetAgentMaxTasks("alice", 1); err != nil {
+		t.Fatalf("SetAgentMaxTasks: %v", err)
+	}
+	s.Tick(time.Now())
+
+	owners := map[string]string{}
+	for _, id := range []string{"t1", "t2", "t3"} {
+		owners[id] = gs.GetTask(id).AssignedTo
+	}
+	if owners["t1"] != "alice" || owners["t2"] != "bob" || owners["t3"] != "bob" {
+		t.Fatalf("assignees = %v, want t1 kept on alice and the surplus moved to bob", owners)
+	}
+	if load, _ := s.GetAgentLoad("alice"); load.CurrentLoad != 1 {
+		t.Fatalf("alice load = %d, want 1", load.CurrentLoad)
+	}
+	if load, _ := s.GetAgentLoad("bob"); load.CurrentLoad != 2 {
+		t.Fatalf("bob load = %d, want 2", load.CurrentLoad)
+	}
+}
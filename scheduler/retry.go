@@ -0,0 +1,68 @@
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"superman/ds"
+)
+
+// ErrTaskNotRetryable 任务不处于可重试状态，只有失败的任务（含放入死信队列的任务）可以重试
+var ErrTaskNotRetryable = errors.New("task is not retryable")
+
+// MetadataRetryCount 任务元数据中记录人工重试次数的键
+const MetadataRetryCount = "retry_count"
+
+// RetryTask 将失败的任务重置为待处理并重新入队；priority 为空时按任务自身优先级入队，
+// 否则同时将任务优先级调整为 priority
+func (s *AutoScheduler) RetryTask(taskID, priority string) error {
+	if s.globalState == nil {
+		return fmt.Errorf("global state is not set")
+	}
+	task := s.globalState.GetTask(taskID)
+	if task == nil {
+		return fmt.Errorf("task %s not found", taskID)
+	}
+	if task.Status != ds.TaskStatusFailed {
+		return fmt.Errorf("%w: task %s is %s", ErrTaskNotRetryable, taskID, task.Status)
+	}
+	if priority != "" && s.queue(priority) == nil {
+		return fmt.Errorf("unknown priority %q", priority)
+	}
+
+	retry := task.Copy()
+	retry.Status = ds.TaskStatusPending
+	if retry.Metadata == nil {
+		retry.Metadata = make(map[string]any)
+	}
+	reason := retry.Metadata["failure_reason"]
+	delete(retry.Metadata, "failure_reason")
+	retry.Metadata[MetadataRetryCount] = retryCount(task) + 1
+	if priority == "" {
+		priority = queuePriorityOf(retry)
+	} else {
+		retry.SetPriority(ds.TaskPriority(strings.ToLower(priority)))
+	}
+
+	s.AddTask(retry, priority)
+	slog.Info("task retried",
+		slog.String("task_id", taskID),
+		slog.String("priority", priority),
+		slog.Any("previous_failure", reason),
+		slog.Int("retry_count", retryCount(retry)),
+	)
+	return nil
+}
+
+// retryCount 返回任务已被人工重试的次数
+func retryCount(task *ds.Task) int {
+	switch n := task.Metadata[MetadataRetryCount].(type) {
+	case int:
+		return n
+	case float64: // 经 JSON 持久化后为 float64
+		return int(n)
+	}
+	return 0
+}
@@ -0,0 +1,138 @@
+package scheduler
+
+import (
+	"sort"
+
+	"superman/ds"
+)
+
+// Agent 未入选候选的原因
+const (
+	ExcludeUnavailable         = "unavailable"          // 不在可用时间段
+	ExcludeAtCapacity          = "at_capacity"          // 槽位或工作量已满
+	ExcludeMissingCapabilities = "missing_capabilities" // 缺少任务所需能力
+	ExcludeNotAssigned         = "not_assigned"         // 任务已指定其他执行者
+	ExcludeAntiAffinity        = "anti_affinity"        // 正在执行反亲和任务
+	ExcludeNotAffine           = "not_affine"           // 其他 Agent 正在执行亲和任务
+)
+
+// RoutePreview 假设任务的路由预览：实际分发时会选择的 Agent、原因与各 Agent 的入选情况
+type RoutePreview struct {
+	Agent      string            `json:"agent,omitempty"` // 当前会选择的 Agent；weighted_random 策略下为空，见候选的选择概率
+	Reason     string            `json:"reason,omitempty"`
+	Priority   string            `json:"priority"` // 任务进入的队列优先级
+	Effort     float64           `json:"effort"`   // 估算的任务工作量
+	Candidates []RouteCandidate  `json:"candidates"`
+	Excluded   map[string]string `json:"excluded,omitempty"`          // 未入选的 Agent -> 原因
+	Waiting    []string          `json:"waiting,omitempty"`           // 即使有候选也会暂缓分发的原因，如在途上限
+	NoCapable  string            `json:"no_capable_policy,omitempty"` // 没有 Agent 具备所需能力时将采用的策略
+
+	UnknownAssignee string `json:"unknown_assignee_policy,omitempty"` // 指定的执行者不存在时将采用的策略
+}
+
+// RouteCandidate 路由预览中的候选 Agent，按选择顺序排列
+type RouteCandidate struct {
+	Name        string  `json:"name"`
+	Hierarchy   int     `json:"hierarchy"`
+	CurrentLoad int     `json:"current_load"`
+	MaxTasks    int     `json:"max_tasks"`
+	EffortRatio float64 `json:"effort_ratio"`
+	Probability float64 `json:"probability,omitempty"` // weighted_random 策略下被选中的概率
+}
+
+// PreviewRoute 按实际分发的选择逻辑预览任务会被分配给哪个 Agent，不入队、不改变任何状态
+func (s *AutoScheduler) PreviewRoute(task *ds.Task) RoutePreview {
+	task = task.Copy()
+	s.estimate(task)
+	priority := queuePriorityOf(task)
+
+	preview := RoutePreview{
+		Priority:   priority,
+		Effort:     task.Effort,
+		Candidates: make([]RouteCandidate, 0),
+	}
+	if s.globalCapReached() {
+		preview.Waiting = append(preview.Waiting, "max_in_flight reached")
+	}
+	if s.priorityCapReached(priority) {
+		preview.Waiting = append(preview.Waiting, "priority cap reached for "+priority)
+	}
+	if s.hasUnknownAssignee(task) {
+		s.mu.RLock()
+		preview.UnknownAssignee = s.unknownAssigneePolicy
+		s.mu.RUnlock()
+		if preview.UnknownAssignee == "" {
+			preview.UnknownAssignee = UnknownAssigneeReroute
+		}
+		if preview.UnknownAssignee == UnknownAssigneeReroute {
+			task.AssignedTo = ""
+		}
+	}
+	if !s.hasCapableAgent(task) {
+		s.mu.RLock()
+		preview.NoCapable = s.noCapablePolicy.Policy
+		s.mu.RUnlock()
+		if preview.NoCapable == "" {
+			preview.NoCapable = NoCapablePolicyWait
+		}
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	candidates, reason := s.rankAgents(task)
+	preview.Reason = reason
+
+	selected := make(map[string]bool, len(candidates))
+	for _, agent := range candidates {
+		selected[agent.Name] = true
+	}
+	weighted := s.selector != nil && reason != AssignReasonExplicit
+	if weighted {
+		// 与 weightedSelector.pick 使用相同的权重
+		sort.Slice(candidates, func(i, j int) bool { return candidates[i].Name < candidates[j].Name })
+	} else if len(candidates) > 0 {
+		preview.Agent = candidates[0].Name
+	}
+	total := 0.0
+	for _, agent := range candidates {
+		total += 1 / (1 + agent.EffortLoad)
+	}
+	for _, agent := range candidates {
+		candidate := RouteCandidate{
+			Name:        agent.Name,
+			Hierarchy:   agent.Hierarchy,
+			CurrentLoad: agent.CurrentLoad,
+			MaxTasks:    agent.MaxTasks,
+			EffortRatio: agent.effortRatio(),
+		}
+		if weighted {
+			candidate.Probability = 1 / (1 + agent.EffortLoad) / total
+		}
+		preview.Candidates = append(preview.Candidates, candidate)
+	}
+
+	now := s.now()
+	for name, agent := range s.agentLoads {
+		if selected[name] {
+			continue
+		}
+		if preview.Excluded == nil {
+			preview.Excluded = make(map[string]string)
+		}
+		switch {
+		case task.AssignedTo != "" && task.AssignedTo != name:
+			preview.Excluded[name] = ExcludeNotAssigned
+		case !agent.isAvailable(now):
+			preview.Excluded[name] = ExcludeUnavailable
+		case !agent.canAccept(task.Effort):
+			preview.Excluded[name] = ExcludeAtCapacity
+		case !agent.hasCapabilities(task.RequiredCapabilities):
+			preview.Excluded[name] = ExcludeMissingCapabilities
+		case s.linkedAgents(task.ID, affinityRefs(task, MetadataAntiAffinity))[name]:
+			preview.Excluded[name] = ExcludeAntiAffinity
+		default:
+			preview.Excluded[name] = ExcludeNotAffine
+		}
+	}
+	return preview
+}
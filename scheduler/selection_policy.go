@@ -0,0 +1,67 @@
+package scheduler
+
+import (
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"sort"
+	"sync"
+)
+
+// Agent 选择策略
+const (
+	SelectionLeastLoaded    = "least_loaded"    // 选择负载率最低的 Agent（默认）
+	SelectionWeightedRandom = "weighted_random" // 按负载反比加权随机选择，避免任务集中涌向同一 Agent
+)
+
+// weightedSelector 加权随机选择器，随机源加锁以支持并发调用
+type weightedSelector struct {
+	mu  sync.Mutex
+	rng *rand.Rand
+}
+
+// SetSelectionPolicy 设置 Agent 选择策略，seed 为 0 时以当前时钟作为随机种子
+func (s *AutoScheduler) SetSelectionPolicy(policy string, seed int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch policy {
+	case "", SelectionLeastLoaded:
+		s.selector = nil
+	case SelectionWeightedRandom:
+		if seed == 0 {
+			seed = s.now().UnixNano()
+		}
+		s.selector = &weightedSelector{rng: rand.New(rand.NewSource(seed))}
+	default:
+		return fmt.Errorf("unknown selection policy %q, expected %s or %s", policy, SelectionLeastLoaded, SelectionWeightedRandom)
+	}
+	slog.Info("agent selection policy set", slog.String("policy", policy), slog.Int64("seed", seed))
+	return nil
+}
+
+// pick 按权重 1/(1+工作量负载) 随机选择一个候选 Agent，负载越低被选中概率越高
+func (w *weightedSelector) pick(candidates []*AgentLoad) *AgentLoad {
+	// 先按名称排序，保证相同种子下选择序列可复现
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].Name < candidates[j].Name
+	})
+
+	weights := make([]float64, len(candidates))
+	total := 0.0
+	for i, agent := range candidates {
+		weights[i] = 1 / (1 + agent.EffortLoad)
+		total += weights[i]
+	}
+
+	w.mu.Lock()
+	r := w.rng.Float64() * total
+	w.mu.Unlock()
+
+	for i, weight := range weights {
+		if r < weight {
+			return candidates[i]
+		}
+		r -= weight
+	}
+	return candidates[len(candidates)-1]
+}
@@ -0,0 +1,51 @@
+package scheduler
+
+import "testing"
+
+// weighted_random 策略按负载反比加权：多次选择中空闲 Agent 占多数，负载高的 Agent 仍有机会被选中，相同种子的选择序列一致
+func TestWeightedRandomFavorsLessLoadedAgents(t *testing.T) {
+	picks := func() []string {
+		s, _, _ := newTestScheduler(t)
+		if err := s.SetSelectionPolicy(SelectionWeightedRandom, 42); err != nil {
+			t.Fatalf("SetSelectionPolicy: %v", err)
+		}
+		s.AddAgent("idle", 10, 1)
+		s.AddAgent("busy", 10, 1)
+		busy := s.agentLoads["busy"]
+		busy.CurrentLoad, busy.EffortLoad = 3, 3
+
+		s.mu.RLock()
+		defer s.mu.RUnlock()
+		var names []string
+		for range 1000 {
+			agent, _ := s.findBestAgent(newTestTask("t"))
+			names = append(names, agent.Name)
+		}
+		return names
+	}
+
+	first := picks()
+	counts := map[string]int{}
+	for _, name := range first {
+		counts[name]++
+	}
+	// 权重 idle 1、busy 1/4，期望约 800:200
+	if counts["idle"] < 700 || counts["busy"] < 100 {
+		t.Fatalf("selections = %v, want roughly 4:1 in favor of the idle agent", counts)
+	}
+
+	second := picks()
+	for i := range first {
+		if first[i] != second[i] {
+			t.Fatalf("selection %d differs between runs with the same seed: %s vs %s", i, first[i], second[i])
+		}
+	}
+}
+
+// 未知的选择策略返回错误
+func TestUnknownSelectionPolicy(t *testing.T) {
+	s, _, _ := newTestScheduler(t)
+	if err := s.SetSelectionPolicy("round_robin", 0); err == nil {
+		t.Fatal("SetSelectionPolicy(round_robin) succeeded, want an error")
+	}
+}
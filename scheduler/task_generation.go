@@ -0,0 +1,60 @@
+package scheduler
+
+import "log/slog"
+
+// PauseTaskGeneration 暂停所有 Agent 的自驱任务生成（不影响消息处理与已有任务分发）
+func (s *AutoScheduler) PauseTaskGeneration() {
+	if !s.taskGenPaused.Swap(true) {
+		slog.Warn("task generation paused")
+	}
+}
+
+// ResumeTaskGeneration 恢复自驱任务生成
+func (s *AutoScheduler) ResumeTaskGeneration() {
+	if s.taskGenPaused.Swap(false) {
+		slog.Info("task generation resumed")
+	}
+}
+
+// IsTaskGenerationPaused 检查自驱任务生成是否已暂停
+func (s *AutoScheduler) IsTaskGenerationPaused() bool {
+	return s.taskGenPaused.Load()
+}
+
+// SetTaskGenBacklogLimit 设置自驱任务生成的积压上限：排队任务数超过 limit 时抑制生成，limit<=0 不限制
+func (s *AutoScheduler) SetTaskGenBacklogLimit(limit int) {
+	s.taskGenBacklogLimit.Store(int64(max(limit, 0)))
+}
+
+// GetTaskGenBacklogLimit 获取自驱任务生成的积压上限，0 表示不限制
+func (s *AutoScheduler) GetTaskGenBacklogLimit() int {
+	return int(s.taskGenBacklogLimit.Load())
+}
+
+// TaskGenerationAllowed 检查是否允许自驱任务生成：未暂停，且排队任务数未超过积压上限
+func (s *AutoScheduler) TaskGenerationAllowed() bool {
+	if s.IsTaskGenerationPaused() {
+		return false
+	}
+	limit := s.taskGenBacklogLimit.Load()
+	if limit <= 0 {
+		s.taskGenBacklogged.Store(false)
+		return true
+	}
+	queued := s.GetQueueLength()
+	backlogged := int64(queued) > limit
+	if s.taskGenBacklogged.Swap(backlogged) != backlogged {
+		if backlogged {
+			slog.Warn("task generation suppressed by scheduler backlog",
+				slog.Int("queue_length", queued),
+				slog.Int64("limit", limit),
+			)
+		} else {
+			slog.Info("task generation resumed after backlog drained",
+				slog.Int("queue_length", queued),
+				slog.Int64("limit", limit),
+			)
+		}
+	}
+	return !backlogged
+}
@@ -0,0 +1,92 @@
+package scheduler
+
+import (
+	"io"
+	"log/slog"
+
+	"superman/ds"
+)
+
+// TaskSource 外部任务来源（如 HTTP 轮询、消息队列），通过通道持续产出任务，通道关闭表示来源结束
+type TaskSource interface {
+	Tasks() <-chan *ds.Task
+}
+
+// namedTaskSource 等待启动的外部任务来源
+type namedTaskSource struct {
+	name   string
+	source TaskSource
+}
+
+// AddTaskSource 注册外部任务来源，其产出的任务按任务优先级进入调度队列；
+// 调度器启动（Start）后才开始拉取，停止时停止消费，来源实现 io.Closer 时一并关闭
+func (s *AutoScheduler) AddTaskSource(name string, source TaskSource) {
+	s.mu.Lock()
+	started := s.started
+	if !started {
+		s.taskSources = append(s.taskSources, namedTaskSource{name: name, source: source})
+	}
+	s.mu.Unlock()
+	if started {
+		s.consumeTaskSource(name, source)
+	}
+	slog.Info("task source registered", slog.String("source", name))
+}
+
+// startTaskSources 调度器启动时开始消费已注册的任务来源
+func (s *AutoScheduler) startTaskSources() {
+	s.mu.Lock()
+	s.started = true
+	sources := s.taskSources
+	s.taskSources = nil
+	s.mu.Unlock()
+	for _, src := range sources {
+		s.consumeTaskSource(src.name, src.source)
+	}
+}
+
+// closePendingTaskSources 关闭调度器停止时仍未启动的任务来源
+func (s *AutoScheduler) closePendingTaskSources() {
+	s.mu.Lock()
+	sources := s.taskSources
+	s.taskSources = nil
+	s.mu.Unlock()
+	for _, src := range sources {
+		if closer, ok := src.source.(io.Closer); ok {
+			closer.Close()
+		}
+	}
+}
+
+// consumeTaskSource 在后台消费任务来源直到来源结束或调度器停止
+func (s *AutoScheduler) consumeTaskSource(name string, source TaskSource) {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		if closer, ok := source.(io.Closer); ok {
+			defer closer.Close()
+		}
+
+		tasks := source.Tasks()
+		for {
+			select {
+			case <-s.stopCh:
+				return
+			case task, ok := <-tasks:
+				if !ok {
+					slog.Info("task source closed", slog.String("source", name))
+					return
+				}
+				if task == nil {
+					continue
+				}
+				s.AddTask(task, queuePriorityOf(task))
+				slog.Info("task received from source",
+					slog.String("source", name),
+					slog.String("task_id", task.ID),
+					slog.String("title", task.Title),
+				)
+			}
+		}
+	}()
+}
@@ -0,0 +1,43 @@
+package scheduler
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"superman/ds"
+)
+
+// chanTaskSource 通过通道产出任务，记录是否已开始拉取
+type chanTaskSource struct {
+	ch     chan *ds.Task
+	pulled atomic.Bool
+}
+
+func (c *chanTaskSource) Tasks() <-chan *ds.Task {
+	c.pulled.Store(true)
+	return c.ch
+}
+
+// 调度器启动前注册的任务来源不会拉取任务，启动后产出的任务进入队列
+func TestTaskSourceStartsWithScheduler(t *testing.T) {
+	s, _, _ := newTestScheduler(t)
+	source := &chanTaskSource{ch: make(chan *ds.Task, 1)}
+	s.AddTaskSource("external", source)
+	source.ch <- newTestTask("ext1")
+
+	time.Sleep(20 * time.Millisecond)
+	if source.pulled.Load() || s.GetQueueLength() != 0 {
+		t.Fatal("task source was consumed before the scheduler started")
+	}
+
+	s.Start()
+	defer s.Stop()
+	deadline := time.Now().Add(5 * time.Second)
+	for s.globalState.GetTask("ext1") == nil {
+		if time.Now().After(deadline) {
+			t.Fatal("task from source never reached the scheduler")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,52 @@
+package scheduler
+
+import (
+	"sort"
+	"time"
+)
+
+// TaskTimeout 执行中任务距超时的剩余时间
+type TaskTimeout struct {
+	TaskID      string    `json:"task_id"`
+	Agent       string    `json:"agent"`
+	StartedAt   time.Time `json:"started_at"`
+	TimeoutMs   int64     `json:"timeout_ms"`
+	RemainingMs int64     `json:"remaining_ms"` // 已超时为 0
+}
+
+// GetTaskTimeouts 获取已开始执行且设置了超时的在途任务，按剩余时间升序（最先超时的在前）
+func (s *AutoScheduler) GetTaskTimeouts() []TaskTimeout {
+	result := make([]TaskTimeout, 0)
+	if s.globalState == nil {
+		return result
+	}
+
+	s.mu.RLock()
+	ids := make([]string, 0, len(s.inFlightEffort))
+	for id := range s.inFlightEffort {
+		ids = append(ids, id)
+	}
+	now := s.now()
+	s.mu.RUnlock()
+
+	for _, task := range s.globalState.GetTasksByIDs(ids) {
+		remaining, ok := task.RemainingTime(now)
+		if !ok {
+			continue
+		}
+		result = append(result, TaskTimeout{
+			TaskID:      task.ID,
+			Agent:       task.AssignedTo,
+			StartedAt:   *task.StartedAt,
+			TimeoutMs:   task.ExecTimeout.Milliseconds(),
+			RemainingMs: remaining.Milliseconds(),
+		})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].RemainingMs != result[j].RemainingMs {
+			return result[i].RemainingMs < result[j].RemainingMs
+		}
+		return result[i].TaskID < result[j].TaskID
+	})
+	return result
+}
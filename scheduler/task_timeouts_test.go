@@ -0,0 +1,51 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"superman/ds"
+)
+
+// 执行中任务的剩余时间随执行推进递减，到达超时时为 0
+func TestTaskRemainingTimeCountsDownToTimeout(t *testing.T) {
+	s, _, gs := newTestScheduler(t)
+	start := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+	now := start
+	s.SetClock(func() time.Time { return now })
+	s.AddAgent("worker", 1, 1)
+
+	s.AddTask(newTestTask("t1"), PriorityMedium)
+	s.dispatchTasks()
+	if got := s.GetTaskTimeouts(); len(got) != 0 {
+		t.Fatalf("timeouts = %+v before execution started, want none", got)
+	}
+	gs.UpdateTask("t1", func(task *ds.Task) {
+		task.StartedAt = &start
+		task.ExecTimeout = time.Minute
+	})
+
+	for _, step := range []struct {
+		elapsed time.Duration
+		want    int64
+	}{
+		{0, 60000},
+		{20 * time.Second, 40000},
+		{time.Minute, 0},
+		{2 * time.Minute, 0},
+	} {
+		now = start.Add(step.elapsed)
+		got := s.GetTaskTimeouts()
+		if len(got) != 1 || got[0].TaskID != "t1" || got[0].Agent != "worker" || got[0].TimeoutMs != 60000 {
+			t.Fatalf("timeouts after %s = %+v, want t1 on worker with a 1m timeout", step.elapsed, got)
+		}
+		if got[0].RemainingMs != step.want {
+			t.Fatalf("remaining after %s = %dms, want %dms", step.elapsed, got[0].RemainingMs, step.want)
+		}
+	}
+
+	gs.UpdateTask("t1", func(task *ds.Task) { task.Status = ds.TaskStatusCompleted })
+	if got := s.GetTaskTimeouts(); len(got) != 0 {
+		t.Fatalf("timeouts = %+v after completion, want none", got)
+	}
+}
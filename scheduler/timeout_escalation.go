@@ -0,0 +1,148 @@
+package scheduler
+
+import (
+	"log/slog"
+	"sort"
+	"time"
+
+	"superman/ds"
+)
+
+// MetadataEscalationChain 任务元数据中记录超时升级链的键，按顺序列出先后执行该任务的 Agent，升级次数为长度减一
+const MetadataEscalationChain = "escalation_chain"
+
+// failureReasonLLMTimeout 模型调用超时失败的 failure_reason
+const failureReasonLLMTimeout = "llm_timeout"
+
+// DefaultTimeoutFactor 每次升级时超时时间的默认倍数
+const DefaultTimeoutFactor = 2.0
+
+// TimeoutEscalation 模型调用超时任务的升级策略：改派给更高层级或更大容量的 Agent 并延长超时，而不是直接失败
+type TimeoutEscalation struct {
+	MaxEscalations int     // 单个任务最多升级次数，0 不升级
+	TimeoutFactor  float64 // 每次升级超时时间的倍数，<= 1 时使用 DefaultTimeoutFactor
+}
+
+// SetTimeoutEscalation 设置超时任务的升级策略
+func (s *AutoScheduler) SetTimeoutEscalation(policy TimeoutEscalation) {
+	if policy.TimeoutFactor <= 1 {
+		policy.TimeoutFactor = DefaultTimeoutFactor
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.timeoutEscalation = policy
+}
+
+// escalateTimeout 将因模型调用超时失败的任务改派给更高层级或更大容量的 Agent 并延长超时，
+// 返回 true 表示任务已重新入队、不应按失败处理
+func (s *AutoScheduler) escalateTimeout(taskID, agentName string) bool {
+	s.mu.RLock()
+	policy := s.timeoutEscalation
+	s.mu.RUnlock()
+	if policy.MaxEscalations <= 0 || s.globalState == nil {
+		return false
+	}
+
+	task := s.globalState.GetTask(taskID)
+	if task == nil || task.Status != ds.TaskStatusFailed || task.Metadata["failure_reason"] != failureReasonLLMTimeout {
+		return false
+	}
+	chain := escalationChain(task)
+	if len(chain) == 0 {
+		chain = []string{agentName}
+	}
+	if len(chain)-1 >= policy.MaxEscalations {
+		return false
+	}
+	raw, _ := task.Metadata[ds.MetadataLLMTimeout].(string)
+	timeout, err := time.ParseDuration(raw)
+	if err != nil || timeout <= 0 {
+		return false
+	}
+
+	target := s.escalationTarget(agentName, chain, task.RequiredCapabilities)
+	if target == "" {
+		slog.Warn("no agent to escalate timed-out task to",
+			slog.String("task_id", taskID),
+			slog.String("agent", agentName),
+		)
+		return false
+	}
+
+	escalated := task.Copy()
+	escalated.Status = ds.TaskStatusPending
+	escalated.AssignedTo = target
+	delete(escalated.Metadata, "failure_reason")
+	timeout = time.Duration(float64(timeout) * policy.TimeoutFactor)
+	escalated.Metadata[ds.MetadataLLMTimeout] = timeout.String()
+	escalated.Metadata[MetadataEscalationChain] = append(chain, target)
+
+	s.AddTask(escalated, queuePriorityOf(escalated))
+	slog.Warn("timed-out task escalated",
+		slog.String("task_id", taskID),
+		slog.String("from", agentName),
+		slog.String("to", target),
+		slog.Duration("timeout", timeout),
+	)
+	return true
+}
+
+// escalationTarget 选择升级目标：层级高于（数值小于）或容量大于当前 Agent、具备所需能力且未执行过该任务的 Agent，
+// 优先选择层级最接近的上级，其次容量最大者
+func (s *AutoScheduler) escalationTarget(agentName string, chain, required []string) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	current, ok := s.agentLoads[agentName]
+	if !ok {
+		return ""
+	}
+	tried := make(map[string]bool, len(chain))
+	for _, name := range chain {
+		tried[name] = true
+	}
+
+	var candidates []*AgentLoad
+	for _, agent := range s.agentLoads {
+		if tried[agent.Name] || !agent.hasCapabilities(required) {
+			continue
+		}
+		if agent.Hierarchy < current.Hierarchy || agent.MaxTasks > current.MaxTasks {
+			candidates = append(candidates, agent)
+		}
+	}
+	if len(candidates) == 0 {
+		return ""
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		ci, cj := candidates[i], candidates[j]
+		seniorI, seniorJ := ci.Hierarchy < current.Hierarchy, cj.Hierarchy < current.Hierarchy
+		if seniorI != seniorJ {
+			return seniorI
+		}
+		if ci.Hierarchy != cj.Hierarchy {
+			return ci.Hierarchy > cj.Hierarchy
+		}
+		if ci.MaxTasks != cj.MaxTasks {
+			return ci.MaxTasks > cj.MaxTasks
+		}
+		return ci.Name < cj.Name
+	})
+	return candidates[0].Name
+}
+
+// escalationChain 返回任务的超时升级链
+func escalationChain(task *ds.Task) []string {
+	switch chain := task.Metadata[MetadataEscalationChain].(type) {
+	case []string:
+		return append([]string(nil), chain...)
+	case []any: // 经 JSON 持久化后为 []any
+		names := make([]string, 0, len(chain))
+		for _, v := range chain {
+			if name, ok := v.(string); ok {
+				names = append(names, name)
+			}
+		}
+		return names
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package scheduler
+
+import (
+	"slices"
+	"testing"
+
+	"superman/ds"
+)
+
+// timeTaskOut 模拟 Agent 执行任务时模型调用超时失败并回报调度器
+func timeTaskOut(s *AutoScheduler, taskID, agent, timeout string) {
+	s.globalState.UpdateTask(taskID, func(t *ds.Task) {
+		t.Status = ds.TaskStatusFailed
+		t.Metadata["failure_reason"] = failureReasonLLMTimeout
+		t.Metadata[ds.MetadataLLMTimeout] = timeout
+	})
+	s.OnTaskComplete(taskID, agent, false)
+}
+
+// 模型调用超时的任务改派给更高层级的 Agent 并延长超时，升级链记录在元数据中；达到升级上限后按失败处理
+func TestTimedOutTaskEscalatesToSeniorAgent(t *testing.T) {
+	s, d, gs := newTestScheduler(t)
+	s.SetTimeoutEscalation(TimeoutEscalation{MaxEscalations: 1})
+	s.AddAgent("analyst", 1, 3)
+	s.AddAgent("manager", 1, 1)
+	s.AddAgent("peer", 1, 3)
+
+	task := newTestTask("t1")
+	task.AssignedTo = "analyst"
+	s.AddTask(task, PriorityMedium)
+	s.dispatchTasks()
+	timeTaskOut(s, "t1", "analyst", "30s")
+
+	escalated := gs.GetTask("t1")
+	if escalated.Status != ds.TaskStatusPending || escalated.AssignedTo != "manager" || escalated.Metadata[ds.MetadataLLMTimeout] != "1m0s" {
+		t.Fatalf("escalated task = %s on %s with timeout %v, want pending on manager with 1m0s",
+			escalated.Status, escalated.AssignedTo, escalated.Metadata[ds.MetadataLLMTimeout])
+	}
+	if chain := escalationChain(escalated); !slices.Equal(chain, []string{"analyst", "manager"}) {
+		t.Fatalf("escalation chain = %v, want [analyst manager]", chain)
+	}
+	s.dispatchTasks()
+	if got := d.dispatched(); !slices.Equal(got, []string{"t1", "t1"}) || gs.GetTask("t1").AssignedTo != "manager" {
+		t.Fatalf("dispatched %v to %s, want t1 re-dispatched to manager", got, gs.GetTask("t1").AssignedTo)
+	}
+
+	timeTaskOut(s, "t1", "manager", "1m0s")
+	if got := gs.GetTask("t1"); got.Status != ds.TaskStatusFailed || s.GetQueueLength() != 0 {
+		t.Fatalf("task after second timeout = %s with %d queued, want failed once escalations are exhausted", got.Status, s.GetQueueLength())
+	}
+}
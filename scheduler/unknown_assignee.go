@@ -0,0 +1,89 @@
+package scheduler
+
+import (
+	"fmt"
+	"log/slog"
+
+	"superman/ds"
+)
+
+// 任务指定的执行者不存在时的处理策略
+const (
+	UnknownAssigneeReroute = "reroute" // 清除指定执行者，按能力与负载重新选择（默认）
+	UnknownAssigneeFail    = "fail"    // 标记任务失败
+)
+
+// UnknownAssigneeReason 因指定的执行者不存在而失败的任务原因
+const UnknownAssigneeReason = "unknown_assignee"
+
+// MetadataUnknownAssignee 任务元数据中记录被清除的原指定执行者的键
+const MetadataUnknownAssignee = "unknown_assignee"
+
+// SetUnknownAssigneePolicy 设置任务指定的执行者不存在时的处理策略
+func (s *AutoScheduler) SetUnknownAssigneePolicy(policy string) error {
+	switch policy {
+	case "":
+		policy = UnknownAssigneeReroute
+	case UnknownAssigneeReroute, UnknownAssigneeFail:
+	default:
+		return fmt.Errorf("unknown assignee policy %q", policy)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.unknownAssigneePolicy = policy
+	return nil
+}
+
+// hasUnknownAssignee 检查任务是否指定了未注册的执行者
+func (s *AutoScheduler) hasUnknownAssignee(task *ds.Task) bool {
+	if task.AssignedTo == "" {
+		return false
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_, exists := s.agentLoads[task.AssignedTo]
+	return !exists
+}
+
+// handleUnknownAssignee 按策略处理指定执行者不存在的任务，返回任务是否继续分发
+func (s *AutoScheduler) handleUnknownAssignee(task *ds.Task, priority string) bool {
+	s.mu.RLock()
+	policy := s.unknownAssigneePolicy
+	s.mu.RUnlock()
+
+	assignee := task.AssignedTo
+	if policy != UnknownAssigneeFail {
+		s.updateTask(task, func(t *ds.Task) {
+			t.AssignedTo = ""
+			if t.Metadata == nil {
+				t.Metadata = make(map[string]any)
+			}
+			t.Metadata[MetadataUnknownAssignee] = assignee
+		})
+		slog.Warn("task assigned to unknown agent, rerouting",
+			slog.String("task_id", task.ID),
+			slog.String("assignee", assignee),
+		)
+		return true
+	}
+
+	s.mu.Lock()
+	s.forgetQueued(task.ID)
+	s.mu.Unlock()
+
+	s.updateTask(task, func(t *ds.Task) {
+		t.Status = ds.TaskStatusFailed
+		if t.Metadata == nil {
+			t.Metadata = make(map[string]any)
+		}
+		t.Metadata["failure_reason"] = UnknownAssigneeReason
+	})
+	slog.Warn("task failed, assigned to unknown agent",
+		slog.String("task_id", task.ID),
+		slog.String("assignee", assignee),
+	)
+	// 重新提交时指定的执行者仍不存在，自动重新投递只会重复失败
+	s.deadLetterTask(task, priority, UnknownAssigneeReason, true)
+	s.onDependencyFailed(task.ID)
+	return false
+}
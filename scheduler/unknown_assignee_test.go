@@ -0,0 +1,60 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"superman/ds"
+)
+
+// 指定执行者不存在：reroute 清除指定执行者后重新分发，fail 标记任务失败并作为永久失败放入死信
+func TestUnknownAssigneePolicies(t *testing.T) {
+	t.Run("reroute", func(t *testing.T) {
+		s, d, gs := newTestScheduler(t)
+		s.AddAgent("worker", 1, 1)
+		task := newTestTask("t1")
+		task.AssignedTo = "ghost"
+		gs.AddTask(task)
+		s.AddTask(task, PriorityMedium)
+
+		s.Tick(time.Now())
+		got := gs.GetTask("t1")
+		if got.AssignedTo != "worker" || got.Metadata[MetadataUnknownAssignee] != "ghost" {
+			t.Fatalf("task = assigned to %q, metadata %v; want rerouted to worker", got.AssignedTo, got.Metadata)
+		}
+		if len(d.dispatched()) != 1 {
+			t.Fatalf("dispatched %v, want the rerouted task", d.dispatched())
+		}
+	})
+
+	t.Run("fail", func(t *testing.T) {
+		s, d, gs := newTestScheduler(t)
+		s.AddAgent("worker", 1, 1)
+		if err := s.SetUnknownAssigneePolicy(UnknownAssigneeFail); err != nil {
+			t.Fatal(err)
+		}
+		var permanent []bool
+		s.SetTaskDeadLetter(func(task *ds.Task, priority, reason string, p bool) {
+			if reason != UnknownAssigneeReason {
+				t.Errorf("dead letter reason = %q, want %q", reason, UnknownAssigneeReason)
+			}
+			permanent = append(permanent, p)
+		})
+		task := newTestTask("t1")
+		task.AssignedTo = "ghost"
+		gs.AddTask(task)
+		s.AddTask(task, PriorityMedium)
+
+		s.Tick(time.Now())
+		got := gs.GetTask("t1")
+		if got.Status != ds.TaskStatusFailed || got.Metadata["failure_reason"] != UnknownAssigneeReason {
+			t.Fatalf("task = %s %v, want failed with %s", got.Status, got.Metadata, UnknownAssigneeReason)
+		}
+		if len(permanent) != 1 || !permanent[0] {
+			t.Fatalf("dead letters = %v, want one permanent dead letter", permanent)
+		}
+		if len(d.dispatched()) != 0 {
+			t.Fatalf("dispatched %v, want none", d.dispatched())
+		}
+	})
+}
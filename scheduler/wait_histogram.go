@@ -0,0 +1,140 @@
+package scheduler
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultWaitBuckets 排队等待时间直方图的默认桶上界
+var DefaultWaitBuckets = []time.Duration{
+	time.Second,
+	5 * time.Second,
+	30 * time.Second,
+	time.Minute,
+	5 * time.Minute,
+	15 * time.Minute,
+	time.Hour,
+}
+
+// WaitHistogram 任务排队等待时间直方图（从首次入队到分发）
+type WaitHistogram struct {
+	mu     sync.Mutex
+	bounds []time.Duration
+	counts []int64 // 最后一个桶统计超出所有上界的样本
+	count  int64
+	sum    time.Duration
+	max    time.Duration
+}
+
+// WaitBucket 直方图桶，Le 为桶上界（含），最后一个桶为 "+Inf"
+type WaitBucket struct {
+	Le    string `json:"le"`
+	Count int64  `json:"count"`
+}
+
+// WaitHistogramSnapshot 直方图快照
+type WaitHistogramSnapshot struct {
+	Buckets []WaitBucket `json:"buckets"`
+	Count   int64        `json:"count"`
+	SumMs   int64        `json:"sum_ms"`
+	MeanMs  int64        `json:"mean_ms"`
+	MaxMs   int64        `json:"max_ms"`
+}
+
+// NewWaitHistogram 创建直方图，bounds 需升序，为空时使用默认桶
+func NewWaitHistogram(bounds []time.Duration) *WaitHistogram {
+	if len(bounds) == 0 {
+		bounds = DefaultWaitBuckets
+	}
+	return &WaitHistogram{
+		bounds: bounds,
+		counts: make([]int64, len(bounds)+1),
+	}
+}
+
+// Observe 记录一次等待时间
+func (h *WaitHistogram) Observe(d time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	i := 0
+	for i < len(h.bounds) && d > h.bounds[i] {
+		i++
+	}
+	h.counts[i]++
+	h.count++
+	h.sum += d
+	if d > h.max {
+		h.max = d
+	}
+}
+
+// Snapshot 获取直方图快照（各桶为非累计计数）
+func (h *WaitHistogram) Snapshot() WaitHistogramSnapshot {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	snapshot := WaitHistogramSnapshot{
+		Buckets: make([]WaitBucket, len(h.counts)),
+		Count:   h.count,
+		SumMs:   h.sum.Milliseconds(),
+		MaxMs:   h.max.Milliseconds(),
+	}
+	for i, c := range h.counts {
+		le := "+Inf"
+		if i < len(h.bounds) {
+			le = h.bounds[i].String()
+		}
+		snapshot.Buckets[i] = WaitBucket{Le: le, Count: c}
+	}
+	if h.count > 0 {
+		snapshot.MeanMs = (h.sum / time.Duration(h.count)).Milliseconds()
+	}
+	return snapshot
+}
+
+// SchedulerMetrics 调度器指标
+type SchedulerMetrics struct {
+	QueueLength        int                   `json:"queue_length"`
+	InFlightByPriority map[string]int        `json:"in_flight_by_priority"`
+	MaxInFlight        int                   `json:"max_in_flight"` // 全系统在途上限，0 不限制
+	WaitTime           WaitHistogramSnapshot `json:"wait_time"`
+	Timeouts           []TaskTimeout         `json:"timeouts"` // 执行中任务距超时的剩余时间，最先超时的在前
+}
+
+// GetMetrics 获取调度器指标
+func (s *AutoScheduler) GetMetrics() SchedulerMetrics {
+	return SchedulerMetrics{
+		QueueLength:        s.GetQueueLength(),
+		InFlightByPriority: s.GetInFlightByPriority(),
+		MaxInFlight:        s.GetMaxInFlight(),
+		WaitTime:           s.waitHist.Snapshot(),
+		Timeouts:           s.GetTaskTimeouts(),
+	}
+}
+
+// markEnqueued 记录任务首次入队时间，重新入队不覆盖
+func (s *AutoScheduler) markEnqueued(taskID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.enqueuedAt[taskID]; !ok {
+		s.enqueuedAt[taskID] = s.now()
+	}
+}
+
+// observeDispatched 任务分发时记录其排队等待时间
+func (s *AutoScheduler) observeDispatched(taskID string) {
+	s.mu.Lock()
+	enqueued, ok := s.enqueuedAt[taskID]
+	delete(s.enqueuedAt, taskID)
+	now := s.now()
+	s.mu.Unlock()
+	if ok {
+		s.waitHist.Observe(now.Sub(enqueued))
+	}
+}
+
+// forgetQueued 清除任务的排队记录（调用方持有锁），任务取消或失败而不再分发时调用
+func (s *AutoScheduler) forgetQueued(taskID string) {
+	delete(s.enqueuedAt, taskID)
+	delete(s.noCapableSince, taskID)
+	delete(s.inheritedPriority, taskID)
+}
@@ -0,0 +1,81 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+// queuedCount 返回仍记录着入队时间的任务数
+func queuedCount(s *AutoScheduler) int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.enqueuedAt)
+}
+
+// 任务被取消或失败离开队列时清除入队时间；改派的任务保留入队时间，分发时计入等待直方图
+func TestQueueExitsForgetEnqueueTime(t *testing.T) {
+	t.Run("clear queue", func(t *testing.T) {
+		s, _, _ := newTestScheduler(t)
+		s.AddTask(newTestTask("t1"), PriorityLow)
+		s.ClearQueue(PriorityLow)
+		if n := queuedCount(s); n != 0 {
+			t.Fatalf("%d enqueue times left after ClearQueue", n)
+		}
+	})
+
+	t.Run("dependency cancel", func(t *testing.T) {
+		s, _, _ := newTestScheduler(t)
+		if err := s.SetDependencyPolicy(DependencyPolicyCancel); err != nil {
+			t.Fatal(err)
+		}
+		s.AddAgent("worker", 1, 1)
+		s.AddTask(newTestTask("dep"), PriorityMedium)
+		s.Tick(time.Now())
+		child := newTestTask("child")
+		child.Dependencies = []string{"dep"}
+		s.AddTask(child, PriorityMedium)
+
+		s.OnTaskComplete("dep", "worker", false)
+		if n := queuedCount(s); n != 0 {
+			t.Fatalf("%d enqueue times left after the dependent was cancelled", n)
+		}
+	})
+
+	t.Run("no capable agent", func(t *testing.T) {
+		s, _, _ := newTestScheduler(t)
+		s.AddAgent("worker", 1, 1)
+		if err := s.SetNoCapablePolicy(NoCapablePolicy{Policy: NoCapablePolicyFail}); err != nil {
+			t.Fatal(err)
+		}
+		task := newTestTask("t1")
+		task.RequiredCapabilities = []string{"rare"}
+		s.AddTask(task, PriorityMedium)
+		s.Tick(time.Now())
+		if n := queuedCount(s); n != 0 {
+			t.Fatalf("%d enqueue times left after the task failed", n)
+		}
+	})
+
+	t.Run("reroute", func(t *testing.T) {
+		s, _, _ := newTestScheduler(t)
+		s.AddAgent("helper", 1, 1)
+		if err := s.SetNoCapablePolicy(NoCapablePolicy{Policy: NoCapablePolicyFallback, FallbackAgent: "helper"}); err != nil {
+			t.Fatal(err)
+		}
+		task := newTestTask("t1")
+		task.RequiredCapabilities = []string{"rare"}
+		s.AddTask(task, PriorityMedium)
+
+		s.Tick(time.Now())
+		if n := queuedCount(s); n != 1 {
+			t.Fatalf("rerouted task has %d enqueue times, want 1", n)
+		}
+		s.Tick(time.Now())
+		if n := queuedCount(s); n != 0 {
+			t.Fatalf("%d enqueue times left after dispatch", n)
+		}
+		if got := s.waitHist.Snapshot().Count; got != 1 {
+			t.Fatalf("wait histogram count = %d, want 1", got)
+		}
+	})
+}
@@ -0,0 +1,116 @@
+package simulation
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"superman/agents"
+	"superman/company"
+)
+
+// Simulator 模拟驱动：在虚拟时间上推进公司运行，按步同步触发期间到期的定时任务、Agent 自驱任务生成与调度轮询，
+// 使按间隔触发的行为无需等待真实时间即可观察。公司需以 StartSimulated 启动；Advance 不可并发调用
+type Simulator struct {
+	company *company.Company
+	now     atomic.Int64 // 虚拟时间（UnixNano），调度器等其他 goroutine 通过 Now 读取
+	step    time.Duration
+	nextGen map[string]time.Time // Agent -> 下一次任务生成的虚拟时间
+	cycles  map[string]int       // Agent -> 已触发的任务生成轮数
+}
+
+// New 创建模拟驱动，虚拟时间从 start 开始，每步推进调度器轮询间隔；
+// 各 Agent 首次任务生成在 start 之后一个生成间隔
+func New(c *company.Company, start time.Time) *Simulator {
+	s := &Simulator{
+		company: c,
+		step:    c.Scheduler.GetTickInterval(),
+		nextGen: make(map[string]time.Time, len(c.Agents)),
+		cycles:  make(map[string]int, len(c.Agents)),
+	}
+	s.now.Store(start.UnixNano())
+	for name, agent := range c.Agents {
+		s.nextGen[name] = start.Add(agent.GetTaskGenInterval())
+	}
+	c.Scheduler.SetClock(s.Now)
+	return s
+}
+
+// Now 返回当前虚拟时间
+func (s *Simulator) Now() time.Time {
+	return time.Unix(0, s.now.Load())
+}
+
+// SetStep 设置每步推进的虚拟时长，步长越小事件触发时间越精确，<=0 时忽略
+func (s *Simulator) SetStep(d time.Duration) {
+	if d > 0 {
+		s.step = d
+	}
+}
+
+// Advance 将虚拟时间推进 d，逐步同步触发期间到期的事件；ctx 取消时提前结束
+func (s *Simulator) Advance(ctx context.Context, d time.Duration) error {
+	end := s.Now().Add(d)
+	for s.Now().Before(end) {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		now := s.Now().Add(s.step)
+		if now.After(end) {
+			now = end
+		}
+		s.now.Store(now.UnixNano())
+		s.tick(ctx, now)
+	}
+	return nil
+}
+
+// GenerationCycles 返回各 Agent 已触发的任务生成轮数
+func (s *Simulator) GenerationCycles() map[string]int {
+	result := make(map[string]int, len(s.cycles))
+	for name, n := range s.cycles {
+		result[name] = n
+	}
+	return result
+}
+
+// tick 按虚拟时间执行一步：定时任务、到期的任务生成（可能多轮补齐）、调度轮询
+func (s *Simulator) tick(ctx context.Context, now time.Time) {
+	s.company.TimerEngine.Tick(now)
+
+	names := make([]string, 0, len(s.company.Agents))
+	for name := range s.company.Agents {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		agent := s.company.Agents[name]
+		for !s.nextGen[name].After(now) {
+			s.generate(ctx, name, agent)
+			s.nextGen[name] = s.nextGen[name].Add(agent.GetTaskGenInterval())
+		}
+	}
+
+	s.company.Scheduler.Tick(now)
+}
+
+// generate 同步触发 Agent 的一轮任务生成，暂停或失败只记录日志
+func (s *Simulator) generate(ctx context.Context, name string, agent agents.Agent) {
+	s.cycles[name]++
+	ids, err := agent.TriggerTaskGeneration(ctx)
+	switch {
+	case errors.Is(err, agents.ErrTaskGenPaused):
+		slog.Debug("simulated task generation skipped", slog.String("agent", name), slog.Any("error", err))
+	case err != nil:
+		slog.Warn("simulated task generation failed", slog.String("agent", name), slog.Any("error", err))
+	default:
+		slog.Info("simulated task generation",
+			slog.String("agent", name),
+			slog.Time("virtual_time", s.Now()),
+			slog.Int("task_count", len(ids)),
+		)
+	}
+}
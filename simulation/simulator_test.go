@@ -0,0 +1,78 @@
+package simulation
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"superman/company"
+	"superman/config"
+	"superman/infra"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+)
+
+// fakeModel 总是返回空任务列表的模型，记录调用次数
+type fakeModel struct {
+	calls *atomic.Int64
+}
+
+func (m fakeModel) Generate(context.Context, []*schema.Message, ...model.Option) (*schema.Message, error) {
+	m.calls.Add(1)
+	return schema.AssistantMessage("[]", nil), nil
+}
+
+func (fakeModel) Stream(context.Context, []*schema.Message, ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	return schema.StreamReaderFromArray([]*schema.Message{schema.AssistantMessage("[]", nil)}), nil
+}
+
+func (m fakeModel) WithTools([]*schema.ToolInfo) (model.ToolCallingChatModel, error) {
+	return m, nil
+}
+
+// 虚拟时间推进一小时后，各 Agent 按自身生成间隔触发对应轮数的任务生成，且无需等待真实时间
+func TestAdvanceHourTriggersGenerationCycles(t *testing.T) {
+	agentConfig := func(name, interval string) config.AgentConfig {
+		return config.AgentConfig{Name: name, Desc: "test agent", Model: "fake", SkillDir: t.TempDir(), TaskGenInterval: interval}
+	}
+	llm := fakeModel{calls: &atomic.Int64{}}
+	r := &infra.Registry{
+		LLM:           map[string]model.ToolCallingChatModel{"fake": llm},
+		ShutdownHooks: infra.NewShutdownHooks(0),
+	}
+	co, err := company.NewCompany(context.Background(), r, config.CompanyConfig{ID: "acme", Agents: []config.AgentConfig{
+		agentConfig("ceo", "10m"),
+		agentConfig("cfo", "25m"),
+	}})
+	if err != nil {
+		t.Fatalf("NewCompany: %v", err)
+	}
+	if err := co.StartSimulated(); err != nil {
+		t.Fatalf("StartSimulated: %v", err)
+	}
+	t.Cleanup(func() { _ = co.Stop(context.Background()) })
+
+	start := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+	sim := New(co, start)
+	sim.SetStep(time.Minute)
+	began := time.Now()
+	if err := sim.Advance(context.Background(), time.Hour); err != nil {
+		t.Fatalf("Advance: %v", err)
+	}
+
+	if got := sim.Now(); !got.Equal(start.Add(time.Hour)) {
+		t.Fatalf("virtual time = %s, want %s", got, start.Add(time.Hour))
+	}
+	cycles := sim.GenerationCycles()
+	if cycles["ceo"] != 6 || cycles["cfo"] != 2 {
+		t.Fatalf("generation cycles = %v, want ceo 6 and cfo 2", cycles)
+	}
+	if got := llm.calls.Load(); got != 8 {
+		t.Fatalf("model called %d times, want one call per generation cycle", got)
+	}
+	if elapsed := time.Since(began); elapsed > 10*time.Second {
+		t.Fatalf("advancing one virtual hour took %s of real time", elapsed)
+	}
+}
@@ -0,0 +1,18 @@
+package state
+
+import "time"
+
+// AgentMemory Agent 跨重启保留的工作记忆：绩效指标与最近的执行历史
+type AgentMemory struct {
+	Agent   string                   `json:"agent"`
+	Metrics map[string]float64       `json:"metrics"`
+	History []*AgentExecutionHistory `json:"history"`
+	SavedAt time.Time                `json:"saved_at"`
+}
+
+// AgentMemoryStore Agent 工作记忆持久化存储，按 Agent 名称存取
+type AgentMemoryStore interface {
+	SaveAgentMemory(m *AgentMemory) error
+	// LoadAgentMemory 加载 Agent 的工作记忆，未保存过时返回 nil
+	LoadAgentMemory(agent string) (*AgentMemory, error)
+}
@@ -0,0 +1,61 @@
+package state
+
+import "time"
+
+// 公告级别
+const (
+	SeverityInfo     = "info"
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical"
+)
+
+// Announcement 公告记录
+type Announcement struct {
+	Author    string    `json:"author,omitempty"`
+	Severity  string    `json:"severity"`
+	Text      string    `json:"text"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// IsValidSeverity 检查公告级别是否合法
+func IsValidSeverity(severity string) bool {
+	switch severity {
+	case SeverityInfo, SeverityWarning, SeverityCritical:
+		return true
+	}
+	return false
+}
+
+// AddAnnouncement 记录带作者与级别的公告，公告文本同时追加到 Announcements
+func (gs *GlobalState) AddAnnouncement(author, severity, text string) Announcement {
+	if severity == "" {
+		severity = SeverityInfo
+	}
+	a := Announcement{
+		Author:    author,
+		Severity:  severity,
+		Text:      text,
+		Timestamp: time.Now(),
+	}
+
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+	gs.Announcements = append(gs.Announcements, text)
+	gs.AnnouncementLog = append(gs.AnnouncementLog, a)
+	gs.Version++
+	gs.events.Append(EventAnnouncement, map[string]any{
+		"text":     text,
+		"author":   author,
+		"severity": severity,
+	})
+	return a
+}
+
+// GetAnnouncements 获取公告记录
+func (gs *GlobalState) GetAnnouncements() []Announcement {
+	gs.mu.RLock()
+	defer gs.mu.RUnlock()
+	result := make([]Announcement, len(gs.AnnouncementLog))
+	copy(result, gs.AnnouncementLog)
+	return result
+}
@@ -0,0 +1,98 @@
+package state
+
+import "sync"
+
+// DefaultBlackboardTopicSize 黑板每个主题保留的最大条目数
+const DefaultBlackboardTopicSize = 100
+
+// Blackboard 按主题组织的只追加共享黑板，Agent 可发布结构化发现并按主题订阅
+type Blackboard struct {
+	mu          sync.RWMutex
+	topics      map[string][]any
+	subscribers map[string]map[int]chan any
+	nextSubID   int
+	maxPerTopic int
+}
+
+// NewBlackboard 创建黑板，maxPerTopic <= 0 时使用默认值
+func NewBlackboard(maxPerTopic int) *Blackboard {
+	if maxPerTopic <= 0 {
+		maxPerTopic = DefaultBlackboardTopicSize
+	}
+	return &Blackboard{
+		topics:      make(map[string][]any),
+		subscribers: make(map[string]map[int]chan any),
+		maxPerTopic: maxPerTopic,
+	}
+}
+
+// Post 向主题追加条目，超出上限时淘汰最旧的条目，并通知该主题的订阅者（订阅者缓冲区满时丢弃通知）
+func (b *Blackboard) Post(topic string, entry any) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	entries := append(b.topics[topic], entry)
+	if len(entries) > b.maxPerTopic {
+		entries = entries[len(entries)-b.maxPerTopic:]
+	}
+	b.topics[topic] = entries
+
+	for _, ch := range b.subscribers[topic] {
+		select {
+		case ch <- entry:
+		default:
+		}
+	}
+}
+
+// Read 读取主题下的所有条目（按发布顺序）
+func (b *Blackboard) Read(topic string) []any {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	entries := b.topics[topic]
+	result := make([]any, len(entries))
+	copy(result, entries)
+	return result
+}
+
+// Topics 获取所有主题
+func (b *Blackboard) Topics() []string {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	topics := make([]string, 0, len(b.topics))
+	for topic := range b.topics {
+		topics = append(topics, topic)
+	}
+	return topics
+}
+
+// Subscribe 订阅主题的新条目，返回接收通道与取消订阅函数
+func (b *Blackboard) Subscribe(topic string, buffer int) (<-chan any, func()) {
+	if buffer <= 0 {
+		buffer = 16
+	}
+	ch := make(chan any, buffer)
+
+	b.mu.Lock()
+	id := b.nextSubID
+	b.nextSubID++
+	if b.subscribers[topic] == nil {
+		b.subscribers[topic] = make(map[int]chan any)
+	}
+	b.subscribers[topic][id] = ch
+	b.mu.Unlock()
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			delete(b.subscribers[topic], id)
+			if len(b.subscribers[topic]) == 0 {
+				delete(b.subscribers, topic)
+			}
+			close(ch)
+		})
+	}
+	return ch, unsubscribe
+}
@@ -0,0 +1,36 @@
+package state
+
+import (
+	"slices"
+	"testing"
+)
+
+// 发布的条目只出现在所属主题下，订阅者只收到订阅主题的新条目，超出上限时淘汰最旧的条目
+func TestBlackboardTopicScopedReads(t *testing.T) {
+	b := NewBlackboard(2)
+	updates, unsubscribe := b.Subscribe("finance", 4)
+
+	b.Post("finance", "revenue up 5%")
+	b.Post("marketing", "campaign launched")
+	b.Post("finance", "costs flat")
+	b.Post("finance", "margin improved")
+
+	if got := b.Read("finance"); !slices.Equal(got, []any{"costs flat", "margin improved"}) {
+		t.Fatalf("finance entries = %v, want the two newest", got)
+	}
+	if got := b.Read("marketing"); !slices.Equal(got, []any{"campaign launched"}) {
+		t.Fatalf("marketing entries = %v, want only its own post", got)
+	}
+	if got := b.Read("hr"); len(got) != 0 {
+		t.Fatalf("unknown topic entries = %v, want none", got)
+	}
+
+	unsubscribe()
+	var received []any
+	for entry := range updates {
+		received = append(received, entry)
+	}
+	if !slices.Equal(received, []any{"revenue up 5%", "costs flat", "margin improved"}) {
+		t.Fatalf("subscriber received %v, want every finance post in order", received)
+	}
+}
@@ -0,0 +1,103 @@
+package state
+
+import (
+	"time"
+
+	"superman/ds"
+)
+
+// EventTaskCompleted 任务结束（完成或失败）事件类型
+const EventTaskCompleted = "task.completed"
+
+// SetCompletionEvents 设置是否在任务结束时向事件日志追加 task.completed 事件
+func (gs *GlobalState) SetCompletionEvents(enabled bool) {
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+	gs.completionEvents = enabled
+}
+
+// RecordTaskCompletion 记录任务结束事件，供未经 UpdateTask 更新状态的完成路径（如调度器回调）调用；
+// 同一次执行只记录一次，任务重新进入处理中后可再次记录
+func (gs *GlobalState) RecordTaskCompletion(taskID, agentName string, success bool) {
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+
+	outcome := ds.TaskStatusCompleted
+	if !success {
+		outcome = ds.TaskStatusFailed
+	}
+	if task, exists := gs.Tasks[taskID]; exists {
+		if isFinishedStatus(task.Status) {
+			outcome = task.Status
+		}
+		if agentName == "" {
+			agentName = task.AssignedTo
+		}
+		gs.unlinkMessageTaskLocked(task)
+	}
+	gs.recordCompletionLocked(taskID, agentName, outcome)
+}
+
+// trackTaskStatusLocked 跟踪任务状态变化：进入处理中时记录开始时间，结束时追加完成事件并移除与来源消息的关联，调用方需持有 gs.mu
+func (gs *GlobalState) trackTaskStatusLocked(task *ds.Task) {
+	switch {
+	case task.Status == ds.TaskStatusProcessing:
+		if gs.taskStarted == nil {
+			gs.taskStarted = make(map[string]time.Time)
+		}
+		gs.taskStarted[task.ID] = time.Now()
+		delete(gs.taskFinished, task.ID)
+	case isFinishedStatus(task.Status):
+		gs.recordCompletionLocked(task.ID, task.AssignedTo, task.Status)
+	}
+	if task.IsCompleted() {
+		gs.unlinkMessageTaskLocked(task)
+	}
+}
+
+// recordCompletionLocked 追加 task.completed 事件，耗时从进入处理中开始计算（未处理过则从创建时间起算），调用方需持有 gs.mu
+func (gs *GlobalState) recordCompletionLocked(taskID, agentName string, outcome ds.TaskStatus) {
+	if !gs.completionEvents || gs.taskFinished[taskID] {
+		return
+	}
+	if gs.taskFinished == nil {
+		gs.taskFinished = make(map[string]bool)
+	}
+	gs.taskFinished[taskID] = true
+
+	data := map[string]any{
+		"task_id": taskID,
+		"agent":   agentName,
+		"outcome": string(outcome),
+	}
+	start, started := gs.taskStarted[taskID]
+	task := gs.Tasks[taskID]
+	if !started && task != nil {
+		start, started = task.CreatedAt, !task.CreatedAt.IsZero()
+	}
+	if started {
+		data["duration_ms"] = time.Since(start).Milliseconds()
+	}
+	if task != nil {
+		data["title"] = task.Title
+		if reason, ok := task.Metadata["failure_reason"]; ok {
+			data["failure_reason"] = reason
+		}
+	}
+	gs.events.Append(EventTaskCompleted, data)
+}
+
+// forgetTaskLocked 清理任务的完成事件跟踪记录，调用方需持有 gs.mu
+func (gs *GlobalState) forgetTaskLocked(taskID string) {
+	delete(gs.taskStarted, taskID)
+	delete(gs.taskFinished, taskID)
+}
+
+// isFinishedStatus 判断状态是否表示一次执行已结束（取消不计入完成事件）
+func isFinishedStatus(status ds.TaskStatus) bool {
+	switch status {
+	case ds.TaskStatusCompleted, ds.TaskStatusCompletedWithWarnings, ds.TaskStatusFailed:
+		return true
+	}
+	return false
+}
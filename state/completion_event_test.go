@@ -0,0 +1,50 @@
+package state
+
+import (
+	"slices"
+	"testing"
+
+	"superman/ds"
+)
+
+// 开启完成事件后，经 UpdateTask 与调度器回调两条路径结束的任务按完成顺序各追加一条 task.completed 事件
+func TestTaskCompletionsAppendOrderedEvents(t *testing.T) {
+	gs := NewGlobalState()
+	gs.SetCompletionEvents(true)
+	for _, id := range []string{"t1", "t2", "t3"} {
+		gs.AddTask(ds.NewTask(id, "task "+id, "", "cfo", "ceo", ds.TaskStatusAssigned, ds.TaskPriorityMedium))
+	}
+	since := gs.GetEventLog().LastSeq()
+
+	setStatus := func(id string, status ds.TaskStatus) {
+		gs.UpdateTask(id, func(t *ds.Task) { t.Status = status })
+	}
+	setStatus("t2", ds.TaskStatusProcessing)
+	setStatus("t2", ds.TaskStatusCompleted)
+	// 调度器回调在 UpdateTask 之后到达时不重复记录
+	gs.RecordTaskCompletion("t2", "cfo", true)
+	gs.RecordTaskCompletion("t3", "cfo", false)
+	setStatus("t1", ds.TaskStatusProcessing)
+	setStatus("t1", ds.TaskStatusCompleted)
+
+	var ids, outcomes []string
+	for _, e := range gs.GetEventsSince(since) {
+		if e.Type != EventTaskCompleted {
+			continue
+		}
+		if e.Data["agent"] != "cfo" {
+			t.Fatalf("event %v has agent %v, want cfo", e.Data, e.Data["agent"])
+		}
+		if _, ok := e.Data["duration_ms"]; !ok {
+			t.Fatalf("event %v lacks duration_ms", e.Data)
+		}
+		ids = append(ids, e.Data["task_id"].(string))
+		outcomes = append(outcomes, e.Data["outcome"].(string))
+	}
+	if !slices.Equal(ids, []string{"t2", "t3", "t1"}) {
+		t.Fatalf("completion events for %v, want [t2 t3 t1] in completion order", ids)
+	}
+	if !slices.Equal(outcomes, []string{"completed", "failed", "completed"}) {
+		t.Fatalf("outcomes = %v, want [completed failed completed]", outcomes)
+	}
+}
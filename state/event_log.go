@@ -0,0 +1,164 @@
+package state
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// 事件类型
+const (
+	EventTaskCreated       = "task.created"
+	EventTaskStatusChanged = "task.status_changed"
+	EventMessageSent       = "message.sent"
+	EventAnnouncement      = "announcement"
+)
+
+// DefaultEventLogSize 事件日志默认保留的最大事件数
+const DefaultEventLogSize = 10000
+
+// Event 事件日志条目，Seq 单调递增，可用于断线后追赶
+type Event struct {
+	Seq       int64          `json:"seq"`
+	Type      string         `json:"type"`
+	Timestamp time.Time      `json:"timestamp"`
+	Data      map[string]any `json:"data,omitempty"`
+}
+
+// EventSink 事件持久化接收方
+type EventSink interface {
+	SaveEvent(e Event) error
+	LastEventSeq() (int64, error) // 已持久化事件的最大序号，无事件时为 0
+}
+
+// EventLog 只追加的有界事件日志
+type EventLog struct {
+	mu       sync.RWMutex
+	events   []Event
+	nextSeq  int64
+	maxSize  int
+	sinkCh   chan Event
+	sinkDone chan struct{} // 后台写入协程退出时关闭
+}
+
+// NewEventLog 创建事件日志，maxSize <= 0 时使用默认值
+func NewEventLog(maxSize int) *EventLog {
+	if maxSize <= 0 {
+		maxSize = DefaultEventLogSize
+	}
+	return &EventLog{
+		events:  make([]Event, 0),
+		nextSeq: 1,
+		maxSize: maxSize,
+	}
+}
+
+// Append 追加事件并返回分配的序号
+func (l *EventLog) Append(eventType string, data map[string]any) int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	e := Event{
+		Seq:       l.nextSeq,
+		Type:      eventType,
+		Timestamp: time.Now(),
+		Data:      data,
+	}
+	l.nextSeq++
+	l.events = append(l.events, e)
+	if len(l.events) > l.maxSize {
+		l.events = l.events[len(l.events)-l.maxSize:]
+	}
+
+	if l.sinkCh != nil {
+		select {
+		case l.sinkCh <- e:
+		default:
+			slog.Warn("event sink backlog full, event not persisted", slog.Int64("seq", e.Seq))
+		}
+	}
+	return e.Seq
+}
+
+// Since 返回序号大于 seq 的事件（按序号升序）
+func (l *EventLog) Since(seq int64) []Event {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	// 事件按序号递增存储，二分查找起点
+	lo, hi := 0, len(l.events)
+	for lo < hi {
+		mid := (lo + hi) / 2
+		if l.events[mid].Seq <= seq {
+			lo = mid + 1
+		} else {
+			hi = mid
+		}
+	}
+	result := make([]Event, len(l.events)-lo)
+	copy(result, l.events[lo:])
+	return result
+}
+
+// LastSeq 返回最新事件的序号，无事件时为 0
+func (l *EventLog) LastSeq() int64 {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.nextSeq - 1
+}
+
+// SetMaxSize 调整保留的最大事件数
+func (l *EventLog) SetMaxSize(maxSize int) {
+	if maxSize <= 0 {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.maxSize = maxSize
+	if len(l.events) > maxSize {
+		l.events = l.events[len(l.events)-maxSize:]
+	}
+}
+
+// SetSink 设置事件持久化接收方，事件在后台异步写入；序号从已持久化的最大序号之后继续，
+// 避免重启后与历史事件的序号重复
+func (l *EventLog) SetSink(sink EventSink) error {
+	lastSeq, err := sink.LastEventSeq()
+	if err != nil {
+		return err
+	}
+	ch := make(chan Event, 1024)
+	done := make(chan struct{})
+	l.mu.Lock()
+	l.nextSeq = max(l.nextSeq, lastSeq+1)
+	l.sinkCh = ch
+	l.sinkDone = done
+	l.mu.Unlock()
+
+	go func() {
+		defer close(done)
+		for e := range ch {
+			if err := sink.SaveEvent(e); err != nil {
+				slog.Error("failed to persist event",
+					slog.Int64("seq", e.Seq),
+					slog.String("type", e.Type),
+					slog.Any("error", err),
+				)
+			}
+		}
+	}()
+	return nil
+}
+
+// Close 停止后台写入：写完已排队的事件后返回，之后的事件只保留在内存中
+func (l *EventLog) Close() {
+	l.mu.Lock()
+	ch, done := l.sinkCh, l.sinkDone
+	l.sinkCh, l.sinkDone = nil, nil
+	l.mu.Unlock()
+	if ch == nil {
+		return
+	}
+	close(ch)
+	<-done
+}
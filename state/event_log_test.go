@@ -0,0 +1,90 @@
+package state
+
+import (
+	"slices"
+	"sync"
+	"testing"
+
+	"superman/ds"
+)
+
+// memorySink 内存中的事件接收方
+type memorySink struct {
+	mu     sync.Mutex
+	events []Event
+	last   int64
+}
+
+func (s *memorySink) SaveEvent(e Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, e)
+	return nil
+}
+
+func (s *memorySink) LastEventSeq() (int64, error) { return s.last, nil }
+
+// 设置接收方时序号从已持久化的最大序号之后继续，Close 写完排队的事件后返回
+func TestEventLogResumesSeqAndFlushesOnClose(t *testing.T) {
+	sink := &memorySink{last: 41}
+	l := NewEventLog(0)
+	if err := l.SetSink(sink); err != nil {
+		t.Fatalf("SetSink: %v", err)
+	}
+	if got := l.LastSeq(); got != 41 {
+		t.Fatalf("LastSeq = %d, want 41", got)
+	}
+
+	for range 100 {
+		l.Append(EventAnnouncement, nil)
+	}
+	l.Close()
+
+	sink.mu.Lock()
+	defer sink.mu.Unlock()
+	if len(sink.events) != 100 {
+		t.Fatalf("persisted %d events, want 100", len(sink.events))
+	}
+	if first := sink.events[0].Seq; first != 42 {
+		t.Fatalf("first persisted seq = %d, want 42", first)
+	}
+
+	// 关闭后追加的事件不再写入，也不应 panic
+	l.Append(EventAnnouncement, nil)
+	l.Close()
+}
+
+// 状态变化按发生顺序记录且序号递增，GetEventsSince 只返回序号更大的事件
+func TestGetEventsSinceReturnsNewerEventsInOrder(t *testing.T) {
+	gs := NewGlobalState()
+	gs.AddTask(ds.NewTask("t1", "task", "test task", "", "boss", ds.TaskStatusPending, ds.TaskPriorityMedium))
+	gs.UpdateTask("t1", func(task *ds.Task) { task.Status = ds.TaskStatusProcessing })
+	mark := gs.GetEventLog().LastSeq()
+	gs.AddAnnouncement("boss", "", "ship it")
+	gs.UpdateTask("t1", func(task *ds.Task) { task.Status = ds.TaskStatusCompleted })
+
+	all := gs.GetEventsSince(0)
+	types := make([]string, 0, len(all))
+	for i, e := range all {
+		if i > 0 && e.Seq <= all[i-1].Seq {
+			t.Fatalf("event %d seq %d not after %d", i, e.Seq, all[i-1].Seq)
+		}
+		types = append(types, e.Type)
+	}
+	if !slices.Contains(types, EventAnnouncement) || types[0] != EventTaskCreated || types[1] != EventTaskStatusChanged {
+		t.Fatalf("event types = %v, want task created, status change, then the rest", types)
+	}
+
+	newer := gs.GetEventsSince(mark)
+	if len(newer) == 0 || newer[0].Type != EventAnnouncement {
+		t.Fatalf("events since %d = %+v, want to start with the announcement", mark, newer)
+	}
+	for _, e := range newer {
+		if e.Seq <= mark {
+			t.Fatalf("GetEventsSince(%d) returned older event %d", mark, e.Seq)
+		}
+	}
+	if got := gs.GetEventsSince(gs.GetEventLog().LastSeq()); len(got) != 0 {
+		t.Fatalf("events since the last seq = %d, want none", len(got))
+	}
+}
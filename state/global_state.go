@@ -19,6 +19,7 @@ type GlobalState struct {
 	MarketData           map[string]any         `json:"market_data"`
 	UserFeedback         []map[string]any       `json:"user_feedback"`
 	SystemHealth         map[string]any         `json:"system_health"`
+	SharedData           map[string]any         `json:"shared_data"`
 	BudgetAllocation     map[string]any         `json:"budget_allocation"`
 	FinancialMetrics     map[string]any         `json:"financial_metrics"`
 	CampaignMetrics      map[string]any         `json:"campaign_metrics"`
@@ -34,8 +35,24 @@ type GlobalState struct {
 	BusinessMetrics      map[string]any         `json:"business_metrics"`
 	HistoricalFinancials map[string]any         `json:"historical_financials"`
 	Announcements        []string               `json:"announcements"`
+	AnnouncementLog      []Announcement         `json:"announcement_log"`
 	CompanyExecHistory   []*ExecutionHistory    `json:"company_exec_history"`
 	Version              int64                  `json:"version"`
+
+	blackboard *Blackboard // 主题黑板（自带锁）
+	events     *EventLog   // 事件日志（自带锁）
+	subs       subscribers // 状态变更订阅者（自带锁）
+
+	completionEvents bool                 // 任务结束时是否追加 task.completed 事件
+	taskStarted      map[string]time.Time // 任务最近一次进入处理中的时间
+	taskFinished     map[string]bool      // 本次执行已记录完成事件的任务
+
+	taskResults *taskResultCache // 最近的任务完成结果（自带锁），清空任务时保留
+	resultStore TaskResultStore  // 任务结果持久化存储（可选）
+
+	messageTasks map[string][]string // 消息ID -> 由该消息触发创建的任务ID
+
+	transcripts *TranscriptStore // 任务执行记录（自带锁）
 }
 
 // ExecutionHistory 执行历史记录
@@ -67,6 +84,7 @@ func NewGlobalState() *GlobalState {
 		MarketData:           make(map[string]any),
 		UserFeedback:         make([]map[string]any, 0),
 		SystemHealth:         make(map[string]any),
+		SharedData:           make(map[string]any),
 		BudgetAllocation:     make(map[string]any),
 		FinancialMetrics:     make(map[string]any),
 		CampaignMetrics:      make(map[string]any),
@@ -82,7 +100,12 @@ func NewGlobalState() *GlobalState {
 		BusinessMetrics:      make(map[string]any),
 		HistoricalFinancials: make(map[string]any),
 		Announcements:        make([]string, 0),
+		AnnouncementLog:      make([]Announcement, 0),
 		CompanyExecHistory:   make([]*ExecutionHistory, 0),
+		blackboard:           NewBlackboard(DefaultBlackboardTopicSize),
+		transcripts:          NewTranscriptStore(0, 0),
+		events:               NewEventLog(DefaultEventLogSize),
+		taskResults:          newTaskResultCache(DefaultTaskResultCacheSize),
 	}
 }
 
@@ -153,6 +176,12 @@ func (gs *GlobalState) AddTask(task *ds.Task) {
 	defer gs.mu.Unlock()
 	gs.Tasks[task.ID] = task
 	gs.Version++
+	gs.events.Append(EventTaskCreated, map[string]any{
+		"task_id":     task.ID,
+		"title":       task.Title,
+		"priority":    string(task.Priority),
+		"assigned_to": task.AssignedTo,
+	})
 }
 
 // GetTask 获取任务
@@ -162,6 +191,16 @@ func (gs *GlobalState) GetTask(taskID string) *ds.Task {
 	return gs.Tasks[taskID]
 }
 
+// GetTaskCopy 获取任务在锁内复制的副本，可在锁外读取而不与任务更新竞争；任务不存在时返回 nil
+func (gs *GlobalState) GetTaskCopy(taskID string) *ds.Task {
+	gs.mu.RLock()
+	defer gs.mu.RUnlock()
+	if task, exists := gs.Tasks[taskID]; exists {
+		return task.Copy()
+	}
+	return nil
+}
+
 // GetAllTasks 获取所有任务
 func (gs *GlobalState) GetAllTasks() map[string]*ds.Task {
 	gs.mu.RLock()
@@ -174,22 +213,146 @@ func (gs *GlobalState) GetAllTasks() map[string]*ds.Task {
 	return result
 }
 
+// GetAllTaskCopies 获取所有任务的副本，可在锁外读取而不与任务更新竞争
+func (gs *GlobalState) GetAllTaskCopies() map[string]*ds.Task {
+	gs.mu.RLock()
+	defer gs.mu.RUnlock()
+
+	result := make(map[string]*ds.Task, len(gs.Tasks))
+	for k, v := range gs.Tasks {
+		result[k] = v.Copy()
+	}
+	return result
+}
+
+// GetTaskDependencies 获取所有任务依赖列表的副本，任务 ID -> 依赖的任务 ID
+func (gs *GlobalState) GetTaskDependencies() map[string][]string {
+	gs.mu.RLock()
+	defer gs.mu.RUnlock()
+
+	result := make(map[string][]string, len(gs.Tasks))
+	for id, task := range gs.Tasks {
+		if len(task.Dependencies) > 0 {
+			result[id] = append([]string(nil), task.Dependencies...)
+		}
+	}
+	return result
+}
+
+// GetTasksByIDs 批量获取任务在锁内复制的副本，不存在的 ID 不出现在结果中
+func (gs *GlobalState) GetTasksByIDs(ids []string) map[string]*ds.Task {
+	gs.mu.RLock()
+	defer gs.mu.RUnlock()
+
+	result := make(map[string]*ds.Task, len(ids))
+	for _, id := range ids {
+		if task, exists := gs.Tasks[id]; exists {
+			result[id] = task.Copy()
+		}
+	}
+	return result
+}
+
+// GetSubtaskProgress 获取指定任务的子任务完成比例（已结束的子任务数 / 子任务总数），没有子任务的任务不出现在结果中
+func (gs *GlobalState) GetSubtaskProgress(parentIDs []string) map[string]float64 {
+	gs.mu.RLock()
+	defer gs.mu.RUnlock()
+
+	wanted := make(map[string]bool, len(parentIDs))
+	for _, id := range parentIDs {
+		wanted[id] = true
+	}
+	total := make(map[string]int)
+	done := make(map[string]int)
+	for _, task := range gs.Tasks {
+		parentID := task.ParentTaskID()
+		if parentID == "" || !wanted[parentID] {
+			continue
+		}
+		total[parentID]++
+		if task.IsCompleted() {
+			done[parentID]++
+		}
+	}
+	result := make(map[string]float64, len(total))
+	for id, n := range total {
+		result[id] = float64(done[id]) / float64(n)
+	}
+	return result
+}
+
 // UpdateTask 更新任务
 func (gs *GlobalState) UpdateTask(taskID string, updater func(*ds.Task)) {
 	gs.mu.Lock()
 	defer gs.mu.Unlock()
 
 	if task, exists := gs.Tasks[taskID]; exists {
+		oldStatus := task.Status
 		updater(task)
 		gs.Version++
+		if task.Status != oldStatus {
+			gs.events.Append(EventTaskStatusChanged, map[string]any{
+				"task_id":     taskID,
+				"from":        string(oldStatus),
+				"to":          string(task.Status),
+				"assigned_to": task.AssignedTo,
+			})
+			gs.trackTaskStatusLocked(task)
+		}
+	}
+}
+
+// AddTaskComment 向任务追加评论
+func (gs *GlobalState) AddTaskComment(taskID string, comment ds.TaskComment) error {
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+
+	task, exists := gs.Tasks[taskID]
+	if !exists {
+		return fmt.Errorf("task %s not found", taskID)
+	}
+	task.AddComment(comment)
+	gs.Version++
+	return nil
+}
+
+// AddTaskContributor 为任务添加贡献者
+func (gs *GlobalState) AddTaskContributor(taskID, agent string) error {
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+
+	task, exists := gs.Tasks[taskID]
+	if !exists {
+		return fmt.Errorf("task %s not found", taskID)
+	}
+	task.AddContributor(agent)
+	gs.Version++
+	return nil
+}
+
+// AddTaskWarning 为任务添加告警
+func (gs *GlobalState) AddTaskWarning(taskID, warning string) error {
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+
+	task, exists := gs.Tasks[taskID]
+	if !exists {
+		return fmt.Errorf("task %s not found", taskID)
 	}
+	task.AddWarning(warning)
+	gs.Version++
+	return nil
 }
 
 // DeleteTask 删除任务
 func (gs *GlobalState) DeleteTask(taskID string) {
 	gs.mu.Lock()
 	defer gs.mu.Unlock()
+	if task, exists := gs.Tasks[taskID]; exists {
+		gs.unlinkMessageTaskLocked(task)
+	}
 	delete(gs.Tasks, taskID)
+	gs.forgetTaskLocked(taskID)
 	gs.Version++
 }
 
@@ -202,6 +365,7 @@ func (gs *GlobalState) AddMessage(msg *ds.Message) {
 	gs.Messages = append(gs.Messages, msg)
 	gs.Version++
 }
+
 // GetMessages 获取消息
 func (gs *GlobalState) GetMessages() []*ds.Message {
 	gs.mu.RLock()
@@ -248,6 +412,7 @@ func (gs *GlobalState) SetKPI(key string, value float64) {
 	defer gs.mu.Unlock()
 	gs.KPIs[key] = value
 	gs.Version++
+	gs.notify(ChangeKindKPI, key, value)
 }
 
 // GetKPI 获取 KPI
@@ -275,6 +440,7 @@ func (gs *GlobalState) SetSystemHealth(key string, value any) {
 	defer gs.mu.Unlock()
 	gs.SystemHealth[key] = value
 	gs.Version++
+	gs.notify(ChangeKindSystemHealth, key, value)
 }
 
 // GetSystemHealth 获取系统健康度
@@ -363,6 +529,72 @@ func (gs *GlobalState) UpdateCurrentTime(t time.Time) {
 	gs.Version++
 }
 
+// ==================== Blackboard ====================
+
+// PostToBlackboard 向黑板主题发布条目
+func (gs *GlobalState) PostToBlackboard(topic string, entry any) {
+	gs.blackboard.Post(topic, entry)
+}
+
+// ReadBlackboard 读取黑板主题下的所有条目
+func (gs *GlobalState) ReadBlackboard(topic string) []any {
+	return gs.blackboard.Read(topic)
+}
+
+// SubscribeBlackboard 订阅黑板主题，返回接收通道与取消订阅函数
+func (gs *GlobalState) SubscribeBlackboard(topic string) (<-chan any, func()) {
+	return gs.blackboard.Subscribe(topic, 0)
+}
+
+// GetBlackboard 获取黑板
+func (gs *GlobalState) GetBlackboard() *Blackboard {
+	return gs.blackboard
+}
+
+// ==================== Transcripts ====================
+
+// AppendTranscript 追加任务执行记录
+func (gs *GlobalState) AppendTranscript(taskID string, entry TranscriptEntry) TranscriptEntry {
+	return gs.transcripts.Append(taskID, entry)
+}
+
+// OpenTranscript 标记任务开始一次新的执行，重新打开执行记录的订阅
+func (gs *GlobalState) OpenTranscript(taskID string) {
+	gs.transcripts.Open(taskID)
+}
+
+// CloseTranscript 标记任务本次执行结束，关闭执行记录的订阅
+func (gs *GlobalState) CloseTranscript(taskID string) {
+	gs.transcripts.Close(taskID)
+}
+
+// GetTranscript 获取任务执行记录
+func (gs *GlobalState) GetTranscript(taskID string) []TranscriptEntry {
+	return gs.transcripts.Get(taskID)
+}
+
+// SubscribeTranscript 订阅任务执行记录，返回已有记录、后续记录的接收通道（执行已结束时为 nil）与取消订阅函数
+func (gs *GlobalState) SubscribeTranscript(taskID string) ([]TranscriptEntry, <-chan TranscriptEntry, func()) {
+	return gs.transcripts.Subscribe(taskID, 0)
+}
+
+// ==================== Event Log ====================
+
+// RecordEvent 追加事件到事件日志，返回事件序号
+func (gs *GlobalState) RecordEvent(eventType string, data map[string]any) int64 {
+	return gs.events.Append(eventType, data)
+}
+
+// GetEventsSince 获取序号大于 seq 的事件，seq 为 0 时返回全部保留的事件
+func (gs *GlobalState) GetEventsSince(seq int64) []Event {
+	return gs.events.Since(seq)
+}
+
+// GetEventLog 获取事件日志
+func (gs *GlobalState) GetEventLog() *EventLog {
+	return gs.events
+}
+
 // ==================== Clear Methods ====================
 
 // ClearTasks 清空所有任务
@@ -370,6 +602,8 @@ func (gs *GlobalState) ClearTasks() {
 	gs.mu.Lock()
 	defer gs.mu.Unlock()
 	gs.Tasks = make(map[string]*ds.Task)
+	gs.taskStarted = nil
+	gs.taskFinished = nil
 	gs.Version++
 }
 
@@ -378,6 +612,7 @@ func (gs *GlobalState) ClearMessages() {
 	gs.mu.Lock()
 	defer gs.mu.Unlock()
 	gs.Messages = make([]*ds.Message, 0)
+	gs.messageTasks = nil
 	gs.Version++
 }
 
@@ -388,13 +623,17 @@ func (gs *GlobalState) ClearAll() {
 
 	gs.Agents = make(map[string]*AgentState)
 	gs.Tasks = make(map[string]*ds.Task)
+	gs.taskStarted = nil
+	gs.taskFinished = nil
 	gs.Messages = make([]*ds.Message, 0)
+	gs.messageTasks = nil
 	gs.CurrentTime = time.Now()
 	gs.StrategicGoals = make(map[string]any)
 	gs.KPIs = make(map[string]float64)
 	gs.MarketData = make(map[string]any)
 	gs.UserFeedback = make([]map[string]any, 0)
 	gs.SystemHealth = make(map[string]any)
+	gs.SharedData = make(map[string]any)
 	gs.BudgetAllocation = make(map[string]any)
 	gs.FinancialMetrics = make(map[string]any)
 	gs.CampaignMetrics = make(map[string]any)
@@ -410,6 +649,7 @@ func (gs *GlobalState) ClearAll() {
 	gs.BusinessMetrics = make(map[string]any)
 	gs.HistoricalFinancials = make(map[string]any)
 	gs.Announcements = make([]string, 0)
+	gs.AnnouncementLog = make([]Announcement, 0)
 	gs.CompanyExecHistory = make([]*ExecutionHistory, 0)
 	gs.Version++
 }
@@ -430,33 +670,34 @@ func DefaultGlobalStateConfig() *GlobalStateConfig {
 	}
 }
 
-// Set 设置全局状态的值
+// Set 设置共享数据的值（不影响系统健康度）
 func (gs *GlobalState) Set(key string, value any) error {
 	gs.mu.Lock()
 	defer gs.mu.Unlock()
-	gs.SystemHealth[key] = value
+	gs.SharedData[key] = value
 	gs.Version++
+	gs.notify(ChangeKindSharedData, key, value)
 	return nil
 }
 
-// Get 获取全局状态的值
+// Get 获取共享数据的值
 func (gs *GlobalState) Get(key string) (any, error) {
 	gs.mu.RLock()
 	defer gs.mu.RUnlock()
-	value, exists := gs.SystemHealth[key]
+	value, exists := gs.SharedData[key]
 	if !exists {
 		return nil, fmt.Errorf("key %s not found", key)
 	}
 	return value, nil
 }
 
-// GetAll 获取所有全局状态
+// GetAll 获取所有共享数据
 func (gs *GlobalState) GetAll() map[string]any {
 	gs.mu.RLock()
 	defer gs.mu.RUnlock()
 
 	result := make(map[string]any)
-	for k, v := range gs.SystemHealth {
+	for k, v := range gs.SharedData {
 		result[k] = v
 	}
 	return result
@@ -469,8 +710,7 @@ func (gs *GlobalState) GetVersion() int64 {
 	return gs.Version
 }
 
+// AddPublicAnnouncement 记录一条 info 级别的匿名公告
 func (gs *GlobalState) AddPublicAnnouncement(announcement string) {
-	gs.mu.Lock()
-	defer gs.mu.Unlock()
-	gs.Announcements = append(gs.Announcements, announcement)
+	gs.AddAnnouncement("", SeverityInfo, announcement)
 }
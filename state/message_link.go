@@ -0,0 +1,45 @@
+package state
+
+import (
+	"slices"
+
+	"superman/ds"
+)
+
+// LinkMessageTask 记录消息与其触发创建的任务之间的关联
+func (gs *GlobalState) LinkMessageTask(messageID, taskID string) {
+	if messageID == "" || taskID == "" {
+		return
+	}
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+	if gs.messageTasks == nil {
+		gs.messageTasks = make(map[string][]string)
+	}
+	if slices.Contains(gs.messageTasks[messageID], taskID) {
+		return
+	}
+	gs.messageTasks[messageID] = append(gs.messageTasks[messageID], taskID)
+}
+
+// GetMessageTasks 获取由指定消息触发创建、尚未结束的任务ID；已结束的任务仍在元数据中记录来源消息
+func (gs *GlobalState) GetMessageTasks(messageID string) []string {
+	gs.mu.RLock()
+	defer gs.mu.RUnlock()
+	return slices.Clone(gs.messageTasks[messageID])
+}
+
+// unlinkMessageTaskLocked 任务结束或删除后移除其与来源消息的关联，避免关联表随任务无限增长，调用方需持有 gs.mu
+func (gs *GlobalState) unlinkMessageTaskLocked(task *ds.Task) {
+	messageID := task.SourceMessageID()
+	taskIDs, ok := gs.messageTasks[messageID]
+	if !ok {
+		return
+	}
+	taskIDs = slices.DeleteFunc(taskIDs, func(id string) bool { return id == task.ID })
+	if len(taskIDs) == 0 {
+		delete(gs.messageTasks, messageID)
+		return
+	}
+	gs.messageTasks[messageID] = taskIDs
+}
@@ -0,0 +1,30 @@
+package state
+
+import (
+	"testing"
+
+	"superman/ds"
+)
+
+// 任务结束后移除其与来源消息的关联，消息的其他在途任务保留
+func TestMessageTaskLinkEvictedOnCompletion(t *testing.T) {
+	gs := NewGlobalState()
+	for _, id := range []string{"t1", "t2"} {
+		task := ds.NewTask(id, id, "", "worker", "boss", ds.TaskStatusPending, ds.TaskPriorityMedium)
+		task.Metadata[ds.MetadataSourceMessageID] = "m1"
+		gs.AddTask(task)
+		gs.LinkMessageTask("m1", id)
+	}
+
+	gs.UpdateTask("t1", func(t *ds.Task) { t.Status = ds.TaskStatusCompleted })
+	if got := gs.GetMessageTasks("m1"); len(got) != 1 || got[0] != "t2" {
+		t.Fatalf("GetMessageTasks = %v after t1 completed, want [t2]", got)
+	}
+
+	gs.RecordTaskCompletion("t2", "worker", false)
+	gs.mu.RLock()
+	defer gs.mu.RUnlock()
+	if len(gs.messageTasks) != 0 {
+		t.Fatalf("messageTasks = %v after all tasks finished, want empty", gs.messageTasks)
+	}
+}
@@ -0,0 +1,52 @@
+package state
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// stateFields 全局状态可选择的部分：JSON 字段名 -> 结构体字段下标
+var stateFields = func() map[string]int {
+	fields := make(map[string]int)
+	t := reflect.TypeOf(GlobalState{})
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			fields[name] = i
+		}
+	}
+	return fields
+}()
+
+// SelectableFields 返回可通过 Select 选择的字段名（按字母排序）
+func SelectableFields() []string {
+	names := make([]string, 0, len(stateFields))
+	for name := range stateFields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Select 在读锁内只序列化请求的部分，返回 字段名 -> JSON（json.RawMessage），未知字段忽略
+func (gs *GlobalState) Select(fields []string) map[string]any {
+	gs.mu.RLock()
+	defer gs.mu.RUnlock()
+
+	v := reflect.ValueOf(gs).Elem()
+	result := make(map[string]any, len(fields))
+	for _, name := range fields {
+		index, ok := stateFields[name]
+		if !ok {
+			continue
+		}
+		data, err := json.Marshal(v.Field(index).Interface())
+		if err != nil {
+			continue
+		}
+		result[name] = json.RawMessage(data)
+	}
+	return result
+}
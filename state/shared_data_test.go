@@ -0,0 +1,32 @@
+package state
+
+import "testing"
+
+// Set 写入的共享数据与系统健康度互不干扰：同名键不会出现在 GetSystemHealth 中，也不会覆盖真实的健康数据
+func TestSharedDataDoesNotAliasSystemHealth(t *testing.T) {
+	gs := NewGlobalState()
+	gs.SetSystemHealth("cpu", 0.42)
+	changes, cancel := gs.Subscribe(1)
+	defer cancel()
+
+	if err := gs.Set("cpu", "shared value"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if err := gs.Set("owner", "ceo"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	health := gs.GetSystemHealth()
+	if len(health) != 1 || health["cpu"] != 0.42 {
+		t.Fatalf("system health = %v, want only the real cpu reading", health)
+	}
+	if got, err := gs.Get("cpu"); err != nil || got != "shared value" {
+		t.Fatalf("Get(cpu) = %v, %v; want the shared value", got, err)
+	}
+	if all := gs.GetAll(); len(all) != 2 || all["owner"] != "ceo" {
+		t.Fatalf("GetAll = %v, want the two shared keys", all)
+	}
+	if change := <-changes; change.Kind != ChangeKindSharedData || change.Key != "cpu" {
+		t.Fatalf("change = %+v, want a shared_data change for cpu", change)
+	}
+}
@@ -0,0 +1,76 @@
+package state
+
+import (
+	"sync"
+	"time"
+)
+
+// 状态变更类别
+const (
+	ChangeKindKPI          = "kpi"
+	ChangeKindSystemHealth = "system_health"
+	ChangeKindSharedData   = "shared_data"
+)
+
+// StateChange 全局状态变更通知
+type StateChange struct {
+	Kind      string    `json:"kind"`
+	Key       string    `json:"key"`
+	Value     any       `json:"value"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// subscribers 状态变更订阅者集合
+type subscribers struct {
+	mu     sync.RWMutex
+	nextID int
+	chans  map[int]chan StateChange
+}
+
+// Subscribe 订阅 KPI、系统健康度与共享数据的变更，返回接收通道与取消订阅函数。
+// 通知为非阻塞发送，订阅者处理不及时时通知会被丢弃。
+func (gs *GlobalState) Subscribe(buffer int) (<-chan StateChange, func()) {
+	if buffer <= 0 {
+		buffer = 16
+	}
+	ch := make(chan StateChange, buffer)
+
+	s := &gs.subs
+	s.mu.Lock()
+	if s.chans == nil {
+		s.chans = make(map[int]chan StateChange)
+	}
+	id := s.nextID
+	s.nextID++
+	s.chans[id] = ch
+	s.mu.Unlock()
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			delete(s.chans, id)
+			close(ch)
+		})
+	}
+	return ch, unsubscribe
+}
+
+// notify 通知所有订阅者
+func (gs *GlobalState) notify(kind, key string, value any) {
+	change := StateChange{
+		Kind:      kind,
+		Key:       key,
+		Value:     value,
+		Timestamp: time.Now(),
+	}
+	gs.subs.mu.RLock()
+	defer gs.subs.mu.RUnlock()
+	for _, ch := range gs.subs.chans {
+		select {
+		case ch <- change:
+		default:
+		}
+	}
+}
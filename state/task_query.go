@@ -0,0 +1,67 @@
+package state
+
+import (
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"superman/ds"
+)
+
+// TaskFilter 任务查询条件，各条件同时满足才匹配；字符串条件不区分大小写，零值表示不限制
+type TaskFilter struct {
+	AssignedTo    string
+	Status        ds.TaskStatus
+	Priority      ds.TaskPriority
+	Type          ds.TaskType
+	Tag           string
+	CreatedAfter  time.Time // 创建时间不早于该时间
+	CreatedBefore time.Time // 创建时间早于该时间
+}
+
+// Match 判断任务是否满足查询条件
+func (f TaskFilter) Match(task *ds.Task) bool {
+	if f.AssignedTo != "" && !strings.EqualFold(task.AssignedTo, f.AssignedTo) {
+		return false
+	}
+	if f.Status != "" && !strings.EqualFold(string(task.Status), string(f.Status)) {
+		return false
+	}
+	if f.Priority != "" && !strings.EqualFold(string(task.Priority), string(f.Priority)) {
+		return false
+	}
+	if f.Type != "" && !strings.EqualFold(string(task.Type), string(f.Type)) {
+		return false
+	}
+	if f.Tag != "" && !slices.ContainsFunc(task.Tags, func(tag string) bool { return strings.EqualFold(tag, f.Tag) }) {
+		return false
+	}
+	if !f.CreatedAfter.IsZero() && task.CreatedAt.Before(f.CreatedAfter) {
+		return false
+	}
+	if !f.CreatedBefore.IsZero() && !task.CreatedAt.Before(f.CreatedBefore) {
+		return false
+	}
+	return true
+}
+
+// QueryTasks 按条件查询任务，结果按创建时间升序排列（相同时按 ID）
+func (gs *GlobalState) QueryTasks(filter TaskFilter) []*ds.Task {
+	gs.mu.RLock()
+	result := make([]*ds.Task, 0)
+	for _, task := range gs.Tasks {
+		if filter.Match(task) {
+			result = append(result, task)
+		}
+	}
+	gs.mu.RUnlock()
+
+	sort.Slice(result, func(i, j int) bool {
+		if !result[i].CreatedAt.Equal(result[j].CreatedAt) {
+			return result[i].CreatedAt.Before(result[j].CreatedAt)
+		}
+		return result[i].ID < result[j].ID
+	})
+	return result
+}
@@ -0,0 +1,60 @@
+package state
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"superman/ds"
+)
+
+// QueryTasks 的各条件可以任意组合，结果按创建时间升序排列
+func TestQueryTasksFilterCombinations(t *testing.T) {
+	base := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	gs := NewGlobalState()
+	for i, spec := range []struct {
+		id       string
+		assignee string
+		status   ds.TaskStatus
+		priority ds.TaskPriority
+		taskType ds.TaskType
+		tags     []string
+	}{
+		{"budget", "cfo", ds.TaskStatusCompleted, ds.TaskPriorityHigh, ds.TaskTypeReport, []string{"finance"}},
+		{"forecast", "cfo", ds.TaskStatusPending, ds.TaskPriorityMedium, ds.TaskTypeAnalysis, []string{"finance", "q2"}},
+		{"hiring", "hr", ds.TaskStatusPending, ds.TaskPriorityHigh, ds.TaskTypeDecision, nil},
+		{"audit", "cfo", ds.TaskStatusPending, ds.TaskPriorityHigh, ds.TaskTypeReport, []string{"Finance"}},
+		{"launch", "cmo", ds.TaskStatusProcessing, ds.TaskPriorityCritical, ds.TaskTypeImplementation, []string{"q2"}},
+	} {
+		task := ds.NewTask(spec.id, spec.id, "", spec.assignee, "ceo", spec.status, spec.priority)
+		task.Type = spec.taskType
+		task.Tags = spec.tags
+		task.CreatedAt = base.Add(time.Duration(i) * 24 * time.Hour)
+		gs.AddTask(task)
+	}
+
+	for _, tc := range []struct {
+		name   string
+		filter TaskFilter
+		want   []string
+	}{
+		{"all", TaskFilter{}, []string{"budget", "forecast", "hiring", "audit", "launch"}},
+		{"assignee", TaskFilter{AssignedTo: "CFO"}, []string{"budget", "forecast", "audit"}},
+		{"assignee and status", TaskFilter{AssignedTo: "cfo", Status: ds.TaskStatusPending}, []string{"forecast", "audit"}},
+		{"priority and type", TaskFilter{Priority: ds.TaskPriorityHigh, Type: ds.TaskTypeReport}, []string{"budget", "audit"}},
+		{"tag ignores case", TaskFilter{Tag: "finance"}, []string{"budget", "forecast", "audit"}},
+		{"assignee and date range", TaskFilter{AssignedTo: "cfo", CreatedAfter: base.Add(24 * time.Hour), CreatedBefore: base.Add(3 * 24 * time.Hour)}, []string{"forecast"}},
+		{"tag and created after", TaskFilter{Tag: "q2", CreatedAfter: base.Add(2 * 24 * time.Hour)}, []string{"launch"}},
+		{"no match", TaskFilter{AssignedTo: "hr", Status: ds.TaskStatusCompleted}, nil},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var got []string
+			for _, task := range gs.QueryTasks(tc.filter) {
+				got = append(got, task.ID)
+			}
+			if !slices.Equal(got, tc.want) {
+				t.Fatalf("QueryTasks(%+v) = %v, want %v", tc.filter, got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package state
+
+import (
+	"container/list"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// DefaultTaskResultCacheSize 内存中保留的任务结果数上限，超出时淘汰最久未访问的结果
+const DefaultTaskResultCacheSize = 1000
+
+// TaskResult 任务完成结果，任务本身不持久化，结果可单独持久化以便重启后查询历史交付物
+type TaskResult struct {
+	TaskID      string    `json:"task_id"`
+	Agent       string    `json:"agent"`
+	Title       string    `json:"title"`
+	Status      string    `json:"status"`
+	Output      string    `json:"output"`           // 最终回复文本
+	Result      any       `json:"result,omitempty"` // JSON 回复格式下解析出的结构化结果
+	CompletedAt time.Time `json:"completed_at"`
+}
+
+// TaskResultStore 任务结果持久化存储
+type TaskResultStore interface {
+	SaveTaskResult(r *TaskResult) error
+	LoadTaskResult(taskID string) (*TaskResult, error) // 无结果时返回 nil, nil
+}
+
+// taskResultCache 按最近访问淘汰的任务结果缓存
+type taskResultCache struct {
+	mu      sync.Mutex
+	maxSize int
+	order   *list.List // 元素为 *TaskResult，最近访问的在前
+	items   map[string]*list.Element
+}
+
+func newTaskResultCache(maxSize int) *taskResultCache {
+	if maxSize <= 0 {
+		maxSize = DefaultTaskResultCacheSize
+	}
+	return &taskResultCache{
+		maxSize: maxSize,
+		order:   list.New(),
+		items:   make(map[string]*list.Element),
+	}
+}
+
+// get 获取结果并标记为最近访问
+func (c *taskResultCache) get(taskID string) *TaskResult {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.items[taskID]
+	if !ok {
+		return nil
+	}
+	c.order.MoveToFront(elem)
+	return elem.Value.(*TaskResult)
+}
+
+// put 写入或覆盖结果，超出上限时淘汰最久未访问的结果
+func (c *taskResultCache) put(r *TaskResult) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.items[r.TaskID]; ok {
+		elem.Value = r
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.items[r.TaskID] = c.order.PushFront(r)
+	for c.order.Len() > c.maxSize {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*TaskResult).TaskID)
+	}
+}
+
+// len 获取缓存的结果数
+func (c *taskResultCache) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// SetTaskResultStore 设置任务结果存储：之后记录的结果同时写入存储，内存中没有的结果从存储中读取
+func (gs *GlobalState) SetTaskResultStore(store TaskResultStore) {
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+	gs.resultStore = store
+}
+
+// RecordTaskResult 记录任务结果，设置了存储时同步写入，写入失败只记录日志
+func (gs *GlobalState) RecordTaskResult(r *TaskResult) {
+	if r.CompletedAt.IsZero() {
+		r.CompletedAt = time.Now()
+	}
+	gs.taskResults.put(r)
+
+	gs.mu.RLock()
+	store := gs.resultStore
+	gs.mu.RUnlock()
+	if store == nil {
+		return
+	}
+	if err := store.SaveTaskResult(r); err != nil {
+		slog.Error("failed to persist task result",
+			slog.String("task_id", r.TaskID),
+			slog.Any("error", err),
+		)
+	}
+}
+
+// GetTaskResult 获取任务结果，内存中没有时从存储中读取（如重启前完成或已被淘汰的结果），无结果时返回 nil
+func (gs *GlobalState) GetTaskResult(taskID string) *TaskResult {
+	if r := gs.taskResults.get(taskID); r != nil {
+		return r
+	}
+
+	gs.mu.RLock()
+	store := gs.resultStore
+	gs.mu.RUnlock()
+	if store == nil {
+		return nil
+	}
+	r, err := store.LoadTaskResult(taskID)
+	if err != nil {
+		slog.Error("failed to load task result",
+			slog.String("task_id", taskID),
+			slog.Any("error", err),
+		)
+		return nil
+	}
+	if r != nil {
+		gs.taskResults.put(r)
+	}
+	return r
+}
@@ -0,0 +1,78 @@
+package state
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+// memoryResultStore 内存中的任务结果存储，记录读取次数
+type memoryResultStore struct {
+	mu      sync.Mutex
+	results map[string]*TaskResult
+	loads   int
+}
+
+func (s *memoryResultStore) SaveTaskResult(r *TaskResult) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.results[r.TaskID] = r
+	return nil
+}
+
+func (s *memoryResultStore) LoadTaskResult(taskID string) (*TaskResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.loads++
+	return s.results[taskID], nil
+}
+
+// 缓存超出上限时淘汰最久未访问的结果
+func TestTaskResultCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	c := newTaskResultCache(2)
+	c.put(&TaskResult{TaskID: "a"})
+	c.put(&TaskResult{TaskID: "b"})
+	c.get("a")
+	c.put(&TaskResult{TaskID: "c"})
+
+	if c.get("b") != nil {
+		t.Fatal("b should have been evicted")
+	}
+	if c.get("a") == nil || c.get("c") == nil {
+		t.Fatal("a and c should be cached")
+	}
+	if n := c.len(); n != 2 {
+		t.Fatalf("len = %d, want 2", n)
+	}
+}
+
+// 内存中没有的结果从存储读取并缓存，重启前的结果无需启动时全部加载
+func TestGetTaskResultReadsThroughStore(t *testing.T) {
+	store := &memoryResultStore{results: map[string]*TaskResult{
+		"old": {TaskID: "old", Status: "completed"},
+	}}
+	gs := NewGlobalState()
+	gs.SetTaskResultStore(store)
+
+	if r := gs.GetTaskResult("old"); r == nil || r.Status != "completed" {
+		t.Fatalf("GetTaskResult(old) = %+v, want the stored result", r)
+	}
+	gs.GetTaskResult("old")
+	if store.loads != 1 {
+		t.Fatalf("store loads = %d, want 1 (second read served from cache)", store.loads)
+	}
+	if gs.GetTaskResult("missing") != nil {
+		t.Fatal("GetTaskResult(missing) should be nil")
+	}
+
+	// 记录的结果写入存储，被淘汰后仍可从存储读回
+	for i := range DefaultTaskResultCacheSize + 1 {
+		gs.RecordTaskResult(&TaskResult{TaskID: fmt.Sprintf("t%d", i), Status: "completed"})
+	}
+	if n := gs.taskResults.len(); n != DefaultTaskResultCacheSize {
+		t.Fatalf("cached %d results, want %d", n, DefaultTaskResultCacheSize)
+	}
+	if r := gs.GetTaskResult("t0"); r == nil {
+		t.Fatal("evicted result t0 should be read back from the store")
+	}
+}
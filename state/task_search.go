@@ -0,0 +1,69 @@
+package state
+
+import (
+	"sort"
+	"strings"
+
+	"superman/ds"
+)
+
+// TaskSearchResult 任务搜索结果
+type TaskSearchResult struct {
+	Task  *ds.Task
+	Score int
+}
+
+// SearchTasks 按关键词搜索任务标题与描述（不区分大小写），返回按相关度降序排列的结果。
+// 查询按空白分词，所有词都需命中；标题命中权重高于描述，整句命中额外加分。
+func (gs *GlobalState) SearchTasks(query string, filter func(*ds.Task) bool) []TaskSearchResult {
+	query = strings.ToLower(strings.TrimSpace(query))
+	terms := strings.Fields(query)
+	if len(terms) == 0 {
+		return nil
+	}
+
+	results := make([]TaskSearchResult, 0)
+	for _, task := range gs.GetTasks() {
+		if filter != nil && !filter(task) {
+			continue
+		}
+		if score := scoreTask(task, query, terms); score > 0 {
+			results = append(results, TaskSearchResult{Task: task, Score: score})
+		}
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Task.CreatedAt.After(results[j].Task.CreatedAt)
+	})
+	return results
+}
+
+// scoreTask 计算任务与查询的相关度，任一词未命中时返回 0
+func scoreTask(task *ds.Task, query string, terms []string) int {
+	title := strings.ToLower(task.Title)
+	desc := strings.ToLower(task.Description)
+
+	score := 0
+	for _, term := range terms {
+		inTitle := strings.Contains(title, term)
+		inDesc := strings.Contains(desc, term)
+		if !inTitle && !inDesc {
+			return 0
+		}
+		if inTitle {
+			score += 3
+		}
+		if inDesc {
+			score++
+		}
+	}
+	if strings.Contains(title, query) {
+		score += 5
+	} else if strings.Contains(desc, query) {
+		score += 2
+	}
+	return score
+}
@@ -0,0 +1,40 @@
+package state
+
+import (
+	"testing"
+
+	"superman/ds"
+)
+
+// 查询按标题片段匹配任务（不区分大小写），不匹配的任务被排除，标题命中排在描述命中之前
+func TestSearchTasksMatchesPartialTitle(t *testing.T) {
+	gs := NewGlobalState()
+	for _, task := range []*ds.Task{
+		ds.NewTask("t1", "Quarterly Budget Review", "review spending", "cfo", "ceo", ds.TaskStatusPending, ds.TaskPriorityHigh),
+		ds.NewTask("t2", "Hire engineers", "expand the budget for hiring", "hr", "ceo", ds.TaskStatusPending, ds.TaskPriorityMedium),
+		ds.NewTask("t3", "Launch campaign", "spring marketing push", "cmo", "ceo", ds.TaskStatusPending, ds.TaskPriorityLow),
+	} {
+		gs.AddTask(task)
+	}
+
+	results := gs.SearchTasks("BUDG", nil)
+	if len(results) != 2 || results[0].Task.ID != "t1" || results[1].Task.ID != "t2" {
+		t.Fatalf("search results = %v, want t1 (title) then t2 (description)", searchIDs(results))
+	}
+
+	results = gs.SearchTasks("budget", func(task *ds.Task) bool { return task.Priority == ds.TaskPriorityMedium })
+	if len(results) != 1 || results[0].Task.ID != "t2" {
+		t.Fatalf("filtered search results = %v, want [t2]", searchIDs(results))
+	}
+	if results := gs.SearchTasks("payroll", nil); len(results) != 0 {
+		t.Fatalf("search for an unknown term = %v, want none", searchIDs(results))
+	}
+}
+
+func searchIDs(results []TaskSearchResult) []string {
+	ids := make([]string, len(results))
+	for i, r := range results {
+		ids[i] = r.Task.ID
+	}
+	return ids
+}
@@ -0,0 +1,164 @@
+package state
+
+import (
+	"sync"
+	"time"
+)
+
+// 任务执行记录的保留上限
+const (
+	DefaultTranscriptSize  = 500  // 每个任务保留的最大条目数
+	DefaultTranscriptTasks = 1000 // 保留执行记录的最大任务数，超出时淘汰最早开始记录的任务
+)
+
+// TranscriptEntry 任务执行记录中的一条模型运行输出
+type TranscriptEntry struct {
+	Seq       int       `json:"seq"`
+	Agent     string    `json:"agent"`
+	Role      string    `json:"role"`
+	Content   string    `json:"content"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// transcript 单个任务的执行记录
+type transcript struct {
+	entries []TranscriptEntry
+	nextSeq int
+	closed  bool                         // 任务本次执行已结束，重新执行时重新打开
+	subs    map[int]chan TranscriptEntry // 订阅者，执行结束时关闭
+}
+
+// TranscriptStore 按任务 ID 保存 Agent 执行任务时的运行输出，供实时订阅与结束后回放
+type TranscriptStore struct {
+	mu          sync.Mutex
+	transcripts map[string]*transcript
+	order       []string // 任务ID，按首次记录顺序，用于淘汰
+	nextSubID   int
+	maxEntries  int
+	maxTasks    int
+}
+
+// NewTranscriptStore 创建任务执行记录存储，参数 <= 0 时使用默认值
+func NewTranscriptStore(maxEntries, maxTasks int) *TranscriptStore {
+	if maxEntries <= 0 {
+		maxEntries = DefaultTranscriptSize
+	}
+	if maxTasks <= 0 {
+		maxTasks = DefaultTranscriptTasks
+	}
+	return &TranscriptStore{
+		transcripts: make(map[string]*transcript),
+		maxEntries:  maxEntries,
+		maxTasks:    maxTasks,
+	}
+}
+
+// get 获取任务的执行记录，不存在时创建（调用方持有锁）
+func (s *TranscriptStore) get(taskID string) *transcript {
+	t, ok := s.transcripts[taskID]
+	if ok {
+		return t
+	}
+	t = &transcript{subs: make(map[int]chan TranscriptEntry)}
+	s.transcripts[taskID] = t
+	s.order = append(s.order, taskID)
+	for len(s.order) > s.maxTasks {
+		oldest := s.order[0]
+		s.order = s.order[1:]
+		s.closeLocked(s.transcripts[oldest])
+		delete(s.transcripts, oldest)
+	}
+	return t
+}
+
+// Append 追加一条记录并通知订阅者（订阅者缓冲区满时丢弃通知）；已结束的记录重新打开
+func (s *TranscriptStore) Append(taskID string, entry TranscriptEntry) TranscriptEntry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t := s.get(taskID)
+	t.closed = false
+	t.nextSeq++
+	entry.Seq = t.nextSeq
+	if entry.Timestamp.IsZero() {
+		entry.Timestamp = time.Now()
+	}
+	t.entries = append(t.entries, entry)
+	if len(t.entries) > s.maxEntries {
+		t.entries = t.entries[len(t.entries)-s.maxEntries:]
+	}
+	for _, ch := range t.subs {
+		select {
+		case ch <- entry:
+		default:
+		}
+	}
+	return entry
+}
+
+// Open 标记任务开始一次新的执行，重新打开已结束的记录，使重试期间的订阅能收到后续记录
+func (s *TranscriptStore) Open(taskID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.get(taskID).closed = false
+}
+
+// Close 标记任务本次执行结束并关闭所有订阅通道
+func (s *TranscriptStore) Close(taskID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closeLocked(s.get(taskID))
+}
+
+// closeLocked 标记执行记录结束并关闭订阅通道（调用方持有锁）
+func (s *TranscriptStore) closeLocked(t *transcript) {
+	t.closed = true
+	for id, ch := range t.subs {
+		delete(t.subs, id)
+		close(ch)
+	}
+}
+
+// Get 获取任务的执行记录
+func (s *TranscriptStore) Get(taskID string) []TranscriptEntry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, ok := s.transcripts[taskID]
+	if !ok {
+		return nil
+	}
+	return append([]TranscriptEntry(nil), t.entries...)
+}
+
+// Subscribe 返回任务已有的记录与后续记录的接收通道；执行结束时通道关闭。
+// 执行已结束时通道为 nil，调用方只需回放已有记录
+func (s *TranscriptStore) Subscribe(taskID string, buffer int) ([]TranscriptEntry, <-chan TranscriptEntry, func()) {
+	if buffer <= 0 {
+		buffer = 64
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t := s.get(taskID)
+	history := append([]TranscriptEntry(nil), t.entries...)
+	if t.closed {
+		return history, nil, func() {}
+	}
+	ch := make(chan TranscriptEntry, buffer)
+	id := s.nextSubID
+	s.nextSubID++
+	t.subs[id] = ch
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			if _, ok := t.subs[id]; ok {
+				delete(t.subs, id)
+				close(ch)
+			}
+		})
+	}
+	return history, ch, unsubscribe
+}
@@ -0,0 +1,33 @@
+package state
+
+import "testing"
+
+// 执行结束后重新执行（重试）时，订阅者应能收到新一次执行的记录
+func TestTranscriptReopensForRetry(t *testing.T) {
+	s := NewTranscriptStore(0, 0)
+	s.Append("t1", TranscriptEntry{Agent: "a", Content: "first"})
+	s.Close("t1")
+
+	if _, ch, _ := s.Subscribe("t1", 0); ch != nil {
+		t.Fatal("closed transcript should not return a subscription channel")
+	}
+
+	s.Open("t1")
+	history, ch, unsubscribe := s.Subscribe("t1", 0)
+	defer unsubscribe()
+	if ch == nil {
+		t.Fatal("reopened transcript should return a subscription channel")
+	}
+	if len(history) != 1 {
+		t.Fatalf("history = %d entries, want 1", len(history))
+	}
+
+	s.Append("t1", TranscriptEntry{Agent: "a", Content: "retry"})
+	if entry := <-ch; entry.Content != "retry" || entry.Seq != 2 {
+		t.Fatalf("got %+v, want retry entry with seq 2", entry)
+	}
+	s.Close("t1")
+	if _, ok := <-ch; ok {
+		t.Fatal("subscription channel should be closed when the run ends")
+	}
+}
@@ -0,0 +1,69 @@
+package timer
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// TemplateData 定时任务标题与描述模板可用的变量
+type TemplateData struct {
+	Now         time.Time // 触发时间
+	Date        string    // 触发日期，如 2024-06-01
+	Time        string    // 触发时刻，如 09:30
+	Job         string    // 定时任务名称
+	Agent       string    // 目标 Agent
+	AgentLoad   int       // 目标 Agent 当前执行中的任务数
+	AgentMax    int       // 目标 Agent 最大并发任务数
+	QueueLength int       // 调度队列中的任务总数
+}
+
+// parseTaskTemplate 解析任务文本模板，不含模板语法时返回 nil
+func parseTaskTemplate(name, text string) (*template.Template, error) {
+	if !strings.Contains(text, "{{") {
+		return nil, nil
+	}
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template %s: %w", name, err)
+	}
+	return tmpl, nil
+}
+
+// templateData 构造触发时的模板变量
+func (te *TimerEngine) templateData(job *TimerJob, now time.Time) TemplateData {
+	data := TemplateData{
+		Now:   now,
+		Date:  now.Format("2006-01-02"),
+		Time:  now.Format("15:04"),
+		Job:   job.Name,
+		Agent: job.TargetAgent,
+	}
+	if te.scheduler != nil {
+		data.QueueLength = te.scheduler.GetQueueLength()
+		if load, ok := te.scheduler.GetAgentLoad(job.TargetAgent); ok {
+			data.AgentLoad = load.CurrentLoad
+			data.AgentMax = load.MaxTasks
+		}
+	}
+	return data
+}
+
+// render 渲染模板，模板为空时返回原文本，渲染失败时记录错误并返回原文本
+func render(tmpl *template.Template, text string, data TemplateData) string {
+	if tmpl == nil {
+		return text
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		slog.Error("failed to render timer task template",
+			slog.String("template", tmpl.Name()),
+			slog.Any("error", err),
+		)
+		return text
+	}
+	return buf.String()
+}
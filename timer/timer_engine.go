@@ -3,13 +3,21 @@ package timer
 import (
 	"log/slog"
 	"sync"
+	"text/template"
 	"time"
 
 	"superman/config"
 	"superman/ds"
 	"superman/scheduler"
+	"superman/utils"
 )
 
+// LastRunStore 定时任务上次运行时间的持久化存储
+type LastRunStore interface {
+	LoadTimerLastRuns(companyID string) (map[string]time.Time, error)
+	SaveTimerLastRun(companyID, job string, lastRun time.Time) error
+}
+
 // TimerEngine 定时任务引擎
 type TimerEngine struct {
 	mu        sync.RWMutex
@@ -17,6 +25,9 @@ type TimerEngine struct {
 	scheduler *scheduler.AutoScheduler
 	stopCh    chan struct{}
 	wg        sync.WaitGroup
+
+	store     LastRunStore
+	companyID string
 }
 
 // TimerJob 运行时定时任务
@@ -27,8 +38,15 @@ type TimerJob struct {
 	Title       string
 	Description string
 	Priority    string
+	Type        string
 	LastRun     time.Time
 	Enabled     bool
+	Jitter      float64         // 间隔抖动比例
+	Callback    func(time.Time) // 回调任务：设置后到期时调用回调，而不是向调度器提交任务
+
+	nextInterval time.Duration      // 本轮实际间隔（叠加抖动后）
+	titleTmpl    *template.Template // 标题模板，不含模板语法时为 nil
+	descTmpl     *template.Template // 描述模板，不含模板语法时为 nil
 }
 
 // NewTimerEngine 创建定时任务引擎
@@ -51,6 +69,23 @@ func NewTimerEngine(s *scheduler.AutoScheduler, timerConfig *config.TimerConfig)
 				continue
 			}
 
+			titleTmpl, err := parseTaskTemplate(jobConfig.Name+".title", jobConfig.Task.Title)
+			if err != nil {
+				slog.Error("invalid timer task title template, skipping job",
+					slog.String("job", jobConfig.Name),
+					slog.Any("error", err),
+				)
+				continue
+			}
+			descTmpl, err := parseTaskTemplate(jobConfig.Name+".description", jobConfig.Task.Description)
+			if err != nil {
+				slog.Error("invalid timer task description template, skipping job",
+					slog.String("job", jobConfig.Name),
+					slog.Any("error", err),
+				)
+				continue
+			}
+
 			priority := jobConfig.Task.Priority
 			if priority == "" {
 				priority = scheduler.PriorityMedium
@@ -63,8 +98,12 @@ func NewTimerEngine(s *scheduler.AutoScheduler, timerConfig *config.TimerConfig)
 				Title:       jobConfig.Task.Title,
 				Description: jobConfig.Task.Description,
 				Priority:    priority,
+				Type:        jobConfig.Task.Type,
 				LastRun:     time.Time{}, // 从未运行
 				Enabled:     true,
+				Jitter:      timerConfig.Jitter,
+				titleTmpl:   titleTmpl,
+				descTmpl:    descTmpl,
 			})
 
 			slog.Info("timer job registered",
@@ -78,15 +117,37 @@ func NewTimerEngine(s *scheduler.AutoScheduler, timerConfig *config.TimerConfig)
 	return te
 }
 
-// Start 启动定时引擎
-func (te *TimerEngine) Start() {
-	if len(te.jobs) == 0 {
-		slog.Info("timer engine: no jobs configured, skipping start")
-		return
+// SetLastRunStore 设置上次运行时间存储，并从中恢复各任务的 LastRun，避免重启后立即重复触发
+func (te *TimerEngine) SetLastRunStore(companyID string, store LastRunStore) error {
+	te.mu.Lock()
+	defer te.mu.Unlock()
+	te.store = store
+	te.companyID = companyID
+	if store == nil {
+		return nil
+	}
+
+	lastRuns, err := store.LoadTimerLastRuns(companyID)
+	if err != nil {
+		return err
+	}
+	for _, job := range te.jobs {
+		if lastRun, ok := lastRuns[job.Name]; ok {
+			job.LastRun = lastRun
+			slog.Info("timer job last run restored",
+				slog.String("job", job.Name),
+				slog.Time("last_run", lastRun),
+			)
+		}
 	}
+	return nil
+}
+
+// Start 启动定时引擎（无任务时同样启动，以便运行时动态添加的任务能够触发）
+func (te *TimerEngine) Start() {
 	te.wg.Add(1)
 	go te.tickLoop()
-	slog.Info("timer engine started", slog.Int("job_count", len(te.jobs)))
+	slog.Info("timer engine started", slog.Int("job_count", len(te.GetJobs())))
 }
 
 // Stop 停止定时引擎
@@ -96,20 +157,56 @@ func (te *TimerEngine) Stop() {
 	slog.Info("timer engine stopped")
 }
 
-// tickLoop 定时检查循环
+// 定时检查间隔的上下限：最短避免空转，最长保证动态添加的任务能及时被发现
+const (
+	minTickInterval = time.Second
+	maxTickInterval = 30 * time.Second
+)
+
+// tickLoop 定时检查循环，每轮等待到最近一个任务到期（限制在 [1s, 30s] 内）
 func (te *TimerEngine) tickLoop() {
 	defer te.wg.Done()
-	ticker := time.NewTicker(30 * time.Second)
-	defer ticker.Stop()
+	timer := time.NewTimer(te.nextTick(time.Now()))
+	defer timer.Stop()
 
 	for {
 		select {
 		case <-te.stopCh:
 			return
-		case now := <-ticker.C:
+		case now := <-timer.C:
 			te.checkAndFire(now)
+			timer.Reset(te.nextTick(time.Now()))
+		}
+	}
+}
+
+// Tick 按指定时间同步检查并触发到期的任务，供模拟模式在虚拟时间上驱动，不需要调用 Start
+func (te *TimerEngine) Tick(now time.Time) {
+	te.checkAndFire(now)
+}
+
+// nextTick 计算距离最近一个任务到期的等待时间
+func (te *TimerEngine) nextTick(now time.Time) time.Duration {
+	te.mu.RLock()
+	defer te.mu.RUnlock()
+
+	wait := maxTickInterval
+	for _, job := range te.jobs {
+		if !job.Enabled {
+			continue
+		}
+		if job.LastRun.IsZero() {
+			return minTickInterval
+		}
+		interval := job.nextInterval
+		if interval <= 0 {
+			interval = job.Interval
+		}
+		if d := job.LastRun.Add(interval).Sub(now); d < wait {
+			wait = d
 		}
 	}
+	return max(wait, minTickInterval)
 }
 
 // checkAndFire 检查并触发到期的任务
@@ -122,26 +219,52 @@ func (te *TimerEngine) checkAndFire(now time.Time) {
 			continue
 		}
 
+		if job.nextInterval <= 0 {
+			job.nextInterval = utils.Jitter(job.Interval, job.Jitter)
+		}
+
 		// 首次运行或已超过间隔
-		if job.LastRun.IsZero() || now.Sub(job.LastRun) >= job.Interval {
+		if job.LastRun.IsZero() || now.Sub(job.LastRun) >= job.nextInterval {
 			te.fireJob(job, now)
 			job.LastRun = now
+			job.nextInterval = utils.Jitter(job.Interval, job.Jitter)
+			te.saveLastRun(job)
 		}
 	}
 }
 
+// saveLastRun 持久化任务的上次运行时间（调用方持有锁）
+func (te *TimerEngine) saveLastRun(job *TimerJob) {
+	if te.store == nil {
+		return
+	}
+	if err := te.store.SaveTimerLastRun(te.companyID, job.Name, job.LastRun); err != nil {
+		slog.Error("failed to persist timer job last run",
+			slog.String("job", job.Name),
+			slog.Any("error", err),
+		)
+	}
+}
+
 // fireJob 触发一个定时任务
 func (te *TimerEngine) fireJob(job *TimerJob, now time.Time) {
+	if job.Callback != nil {
+		te.fireCallback(job, now)
+		return
+	}
+
+	data := te.templateData(job, now)
 	taskID := ds.GenerateTaskID()
 	task := ds.NewTask(
 		taskID,
-		job.Title,
-		job.Description,
+		render(job.titleTmpl, job.Title, data),
+		render(job.descTmpl, job.Description, data),
 		job.TargetAgent,
 		"timer_engine",
 		ds.TaskStatusPending,
 		ds.TaskPriority(job.Priority),
 	)
+	task.Type = ds.TaskType(job.Type)
 	task.Metadata["source"] = "timer"
 	task.Metadata["timer_job"] = job.Name
 	task.Metadata["fired_at"] = now.Format(time.RFC3339)
@@ -155,6 +278,34 @@ func (te *TimerEngine) fireJob(job *TimerJob, now time.Time) {
 	)
 }
 
+// fireCallback 执行回调任务，回调 panic 不影响引擎运行
+func (te *TimerEngine) fireCallback(job *TimerJob, now time.Time) {
+	defer func() {
+		if r := recover(); r != nil {
+			slog.Error("timer callback panicked",
+				slog.String("job", job.Name),
+				slog.Any("panic", r),
+			)
+		}
+	}()
+	job.Callback(now)
+	slog.Info("timer callback fired", slog.String("job", job.Name))
+}
+
+// RegisterJob 注册按固定间隔执行的回调任务
+func (te *TimerEngine) RegisterJob(name string, interval time.Duration, callback func(time.Time)) {
+	te.AddJob(&TimerJob{
+		Name:     name,
+		Interval: interval,
+		Enabled:  true,
+		Callback: callback,
+	})
+	slog.Info("timer callback job registered",
+		slog.String("name", name),
+		slog.Duration("interval", interval),
+	)
+}
+
 // AddJob 动态添加定时任务
 func (te *TimerEngine) AddJob(job *TimerJob) {
 	te.mu.Lock()
@@ -170,3 +321,51 @@ func (te *TimerEngine) GetJobs() []*TimerJob {
 	copy(result, te.jobs)
 	return result
 }
+
+// JobStatus 定时任务状态
+type JobStatus struct {
+	Name        string     `json:"name"`
+	Interval    string     `json:"interval"`
+	TargetAgent string     `json:"target_agent,omitempty"`
+	Title       string     `json:"title,omitempty"`
+	Callback    bool       `json:"callback"` // 是否为回调任务
+	Enabled     bool       `json:"enabled"`
+	LastRun     *time.Time `json:"last_run,omitempty"`  // 从未运行时为空
+	NextFire    *time.Time `json:"next_fire,omitempty"` // 停用时为空
+}
+
+// GetJobStatus 获取所有定时任务的状态，包含上次运行时间与预计下次触发时间
+func (te *TimerEngine) GetJobStatus() []JobStatus {
+	te.mu.RLock()
+	defer te.mu.RUnlock()
+
+	now := time.Now()
+	result := make([]JobStatus, 0, len(te.jobs))
+	for _, job := range te.jobs {
+		status := JobStatus{
+			Name:        job.Name,
+			Interval:    job.Interval.String(),
+			TargetAgent: job.TargetAgent,
+			Title:       job.Title,
+			Callback:    job.Callback != nil,
+			Enabled:     job.Enabled,
+		}
+		if !job.LastRun.IsZero() {
+			lastRun := job.LastRun
+			status.LastRun = &lastRun
+		}
+		if job.Enabled {
+			next := now
+			if !job.LastRun.IsZero() {
+				interval := job.nextInterval
+				if interval <= 0 {
+					interval = job.Interval
+				}
+				next = job.LastRun.Add(interval)
+			}
+			status.NextFire = &next
+		}
+		result = append(result, status)
+	}
+	return result
+}
@@ -0,0 +1,157 @@
+package timer
+
+import (
+	"testing"
+	"time"
+
+	"superman/config"
+	"superman/ds"
+	"superman/scheduler"
+	"superman/state"
+)
+
+// nopDispatcher 不执行任务的分发器，定时任务只需进入调度队列
+type nopDispatcher struct{}
+
+func (nopDispatcher) RunTask(*ds.Task) error { return nil }
+
+// memoryLastRuns 内存中的上次运行时间存储
+type memoryLastRuns map[string]time.Time
+
+func (m memoryLastRuns) LoadTimerLastRuns(string) (map[string]time.Time, error) {
+	return m, nil
+}
+
+func (m memoryLastRuns) SaveTimerLastRun(_, job string, lastRun time.Time) error {
+	m[job] = lastRun
+	return nil
+}
+
+// newTestEngine 创建包含单个定时任务的引擎，返回引擎与其调度器
+func newTestEngine(t *testing.T, job config.TimerJob) (*TimerEngine, *scheduler.AutoScheduler) {
+	t.Helper()
+	s := scheduler.NewAutoScheduler(nopDispatcher{}, state.NewGlobalState(), 0)
+	te := NewTimerEngine(s, &config.TimerConfig{Enabled: true, Jobs: []config.TimerJob{job}})
+	if len(te.GetJobs()) != 1 {
+		t.Fatalf("registered %d jobs, want 1", len(te.GetJobs()))
+	}
+	return te, s
+}
+
+// 恢复了最近 LastRun 的任务在引擎启动时不立即触发，间隔到期后才触发
+func TestRestoredLastRunDelaysFirstFire(t *testing.T) {
+	te, s := newTestEngine(t, config.TimerJob{Name: "report", Interval: "1h", Task: config.TimerTaskConfig{Title: "daily report"}})
+	now := time.Now()
+	store := memoryLastRuns{"report": now.Add(-10 * time.Minute)}
+	if err := te.SetLastRunStore("acme", store); err != nil {
+		t.Fatalf("SetLastRunStore: %v", err)
+	}
+
+	te.Tick(now)
+	if got := s.GetQueueLength(); got != 0 {
+		t.Fatalf("queued %d tasks on start, want none before the interval elapses", got)
+	}
+
+	fireAt := now.Add(50 * time.Minute)
+	te.Tick(fireAt)
+	if got := s.GetQueueLength(); got != 1 {
+		t.Fatalf("queued %d tasks after the interval, want 1", got)
+	}
+	if !store["report"].Equal(fireAt) {
+		t.Fatalf("persisted last run = %v, want %v", store["report"], fireAt)
+	}
+}
+
+// 回调任务与间隔任务通过同一引擎按各自间隔触发
+func TestCallbackAndIntervalJobsFire(t *testing.T) {
+	te, s := newTestEngine(t, config.TimerJob{Name: "report", Interval: "1h", Task: config.TimerTaskConfig{Title: "hourly report"}})
+	var fired []time.Time
+	te.RegisterJob("cleanup", 10*time.Minute, func(now time.Time) { fired = append(fired, now) })
+
+	start := time.Now()
+	te.Tick(start)
+	te.Tick(start.Add(5 * time.Minute))
+	te.Tick(start.Add(10 * time.Minute))
+
+	if len(fired) != 2 {
+		t.Fatalf("callback fired %d times, want 2", len(fired))
+	}
+	if got := s.GetQueueLength(); got != 1 {
+		t.Fatalf("interval job queued %d tasks, want 1", got)
+	}
+}
+
+// 5 秒间隔的任务按 5 秒节奏触发，而不是被固定的 30 秒检查间隔拖慢
+func TestShortIntervalJobFiresOnTime(t *testing.T) {
+	te, s := newTestEngine(t, config.TimerJob{Name: "heartbeat", Interval: "5s", Task: config.TimerTaskConfig{Title: "heartbeat"}})
+
+	// 按引擎计算出的等待时间推进时钟，30 秒内应触发首次加 6 个间隔
+	start := time.Now()
+	now := start
+	te.Tick(now)
+	for now.Sub(start) < 30*time.Second {
+		wait := te.nextTick(now)
+		if wait > 5*time.Second {
+			t.Fatalf("next tick in %s, want at most the 5s job interval", wait)
+		}
+		now = now.Add(wait)
+		te.Tick(now)
+	}
+	if got := s.GetQueueLength(); got != 7 {
+		t.Fatalf("queued %d tasks in 30s, want 7", got)
+	}
+}
+
+// 极小间隔的任务不会让检查循环空转
+func TestTinyIntervalClampsTick(t *testing.T) {
+	te, _ := newTestEngine(t, config.TimerJob{Name: "spin", Interval: "10ms", Task: config.TimerTaskConfig{Title: "spin"}})
+	now := time.Now()
+	te.Tick(now)
+	if wait := te.nextTick(now); wait < minTickInterval {
+		t.Fatalf("next tick in %s, want at least %s", wait, minTickInterval)
+	}
+}
+
+// 模板化的标题在触发时渲染为触发日期，模板语法错误的任务在创建引擎时被跳过
+func TestTemplatedTitleRendersFireDate(t *testing.T) {
+	gs := state.NewGlobalState()
+	s := scheduler.NewAutoScheduler(nopDispatcher{}, gs, 0)
+	te := NewTimerEngine(s, &config.TimerConfig{Enabled: true, Jobs: []config.TimerJob{
+		{Name: "daily", Interval: "24h", Task: config.TimerTaskConfig{Title: "Daily report for {{.Date}}", Description: "queue: {{.QueueLength}}"}},
+		{Name: "broken", Interval: "24h", Task: config.TimerTaskConfig{Title: "Report for {{.Date"}},
+	}})
+	if jobs := te.GetJobs(); len(jobs) != 1 {
+		t.Fatalf("registered %d jobs, want the invalid template skipped", len(jobs))
+	}
+
+	fireAt := time.Date(2024, 6, 1, 9, 30, 0, 0, time.Local)
+	te.Tick(fireAt)
+	tasks := gs.GetAllTasks()
+	if len(tasks) != 1 {
+		t.Fatalf("fired %d tasks, want 1", len(tasks))
+	}
+	for _, task := range tasks {
+		if task.Title != "Daily report for 2024-06-01" || task.Description != "queue: 0" {
+			t.Fatalf("task = %q / %q, want the rendered title and description", task.Title, task.Description)
+		}
+	}
+}
+
+// 任务触发后状态中的上次运行时间更新，下次触发时间按间隔推算
+func TestJobStatusReflectsLastRun(t *testing.T) {
+	te, _ := newTestEngine(t, config.TimerJob{Name: "report", Interval: "1h", Task: config.TimerTaskConfig{Title: "hourly report"}})
+	status := te.GetJobStatus()
+	if len(status) != 1 || status[0].LastRun != nil || status[0].NextFire == nil {
+		t.Fatalf("status before firing = %+v, want no last run and a pending fire", status)
+	}
+
+	fireAt := time.Now().Add(-time.Minute)
+	te.Tick(fireAt)
+	status = te.GetJobStatus()
+	if status[0].LastRun == nil || !status[0].LastRun.Equal(fireAt) {
+		t.Fatalf("last run = %v, want %v", status[0].LastRun, fireAt)
+	}
+	if want := fireAt.Add(time.Hour); status[0].NextFire == nil || !status[0].NextFire.Equal(want) {
+		t.Fatalf("next fire = %v, want %v", status[0].NextFire, want)
+	}
+}
@@ -0,0 +1,53 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"superman/mailbox"
+	"superman/state"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/components/tool/utils"
+)
+
+// MaxCriticalAnnouncementHierarchy 允许发布 critical 公告的最低层级（数值越小层级越高）
+const MaxCriticalAnnouncementHierarchy = 1
+
+type Announce struct {
+	Author     string
+	Hierarchy  int
+	MailboxBus *mailbox.MailboxBus
+}
+
+func (m *Announce) ToEinoTool() (tool.BaseTool, error) {
+	return utils.InferTool("make announcement", "publish a company-wide announcement; critical announcements are broadcast to every agent, info and warning ones are only recorded in the shared state", m.Invoke)
+}
+
+func (m *Announce) Invoke(ctx context.Context, req AnnounceRequest) (AnnounceResponse, error) {
+	severity := req.Severity
+	if severity == "" {
+		severity = state.SeverityInfo
+	}
+	if !state.IsValidSeverity(severity) {
+		return AnnounceResponse{}, fmt.Errorf("invalid severity %q", severity)
+	}
+	if severity == state.SeverityCritical && m.Hierarchy > MaxCriticalAnnouncementHierarchy {
+		return AnnounceResponse{}, fmt.Errorf("only agents with hierarchy <= %d may send critical announcements", MaxCriticalAnnouncementHierarchy)
+	}
+
+	m.MailboxBus.GetGlobalState().AddAnnouncement(m.Author, severity, req.Text)
+	if severity != state.SeverityCritical {
+		return AnnounceResponse{Broadcast: false}, nil
+	}
+	err := m.MailboxBus.Broadcast(m.Author, "紧急公告", req.Text, "critical")
+	return AnnounceResponse{Broadcast: true}, err
+}
+
+type AnnounceRequest struct {
+	Text     string `json:"text" jsonschema:"description=The announcement text"`
+	Severity string `json:"severity,omitempty" jsonschema:"description=Announcement severity,enum=info,enum=warning,enum=critical"`
+}
+
+type AnnounceResponse struct {
+	Broadcast bool `json:"broadcast"`
+}
@@ -0,0 +1,52 @@
+package tools
+
+import (
+	"context"
+	"testing"
+
+	"superman/mailbox"
+	"superman/state"
+)
+
+// critical 公告广播到其他所有信箱，info 公告只记录在全局状态中；低层级 Agent 不能发布 critical 公告
+func TestCriticalAnnouncementIsBroadcast(t *testing.T) {
+	bus := mailbox.NewMailboxBus()
+	inboxes := map[string]*mailbox.Mailbox{}
+	for _, name := range []string{"ceo", "cfo", "hr"} {
+		inboxes[name] = mailbox.NewMailbox(mailbox.DefaultMailboxConfig(name))
+		if err := bus.RegisterMailbox(name, inboxes[name]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ceo := &Announce{Author: "ceo", Hierarchy: 0, MailboxBus: bus}
+
+	resp, err := ceo.Invoke(context.Background(), AnnounceRequest{Text: "office closed friday"})
+	if err != nil || resp.Broadcast {
+		t.Fatalf("info announcement = %+v, %v; want recorded only", resp, err)
+	}
+	for name, mb := range inboxes {
+		if mb.GetInboxCount() != 0 {
+			t.Fatalf("%s received the info announcement", name)
+		}
+	}
+
+	resp, err = ceo.Invoke(context.Background(), AnnounceRequest{Text: "servers down", Severity: state.SeverityCritical})
+	if err != nil || !resp.Broadcast {
+		t.Fatalf("critical announcement = %+v, %v; want broadcast", resp, err)
+	}
+	for name, want := range map[string]int{"ceo": 0, "cfo": 1, "hr": 1} {
+		if got := inboxes[name].GetInboxCount(); got != want {
+			t.Fatalf("%s inbox = %d after the critical announcement, want %d", name, got, want)
+		}
+	}
+
+	staff := &Announce{Author: "hr", Hierarchy: 2, MailboxBus: bus}
+	if _, err := staff.Invoke(context.Background(), AnnounceRequest{Text: "fire drill", Severity: state.SeverityCritical}); err == nil {
+		t.Fatal("hierarchy 2 agent sent a critical announcement")
+	}
+
+	log := bus.GetGlobalState().GetAnnouncements()
+	if len(log) != 2 || log[0].Severity != state.SeverityInfo || log[1].Severity != state.SeverityCritical || log[1].Author != "ceo" {
+		t.Fatalf("announcements = %+v, want the info and critical ones by ceo", log)
+	}
+}
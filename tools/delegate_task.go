@@ -0,0 +1,87 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"superman/ds"
+	"superman/mailbox"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/components/tool/utils"
+)
+
+// ErrDelegationDepthExceeded 委派链深度超过上限
+var ErrDelegationDepthExceeded = errors.New("delegation depth exceeded")
+
+type DelegateTask struct {
+	Delegator  string
+	Receivers  []string
+	MaxDepth   int // 允许的最大委派深度，<= 0 不限制
+	MailboxBus *mailbox.MailboxBus
+	Submit     func(task *ds.Task) error
+	OnRejected func(task *ds.Task, reason string)
+}
+
+func (m *DelegateTask) ToEinoTool() (tool.BaseTool, error) {
+	return utils.InferTool("delegate task", "delegate a sub task to another agent; the task you are working on is recorded as its parent", m.Invoke)
+}
+
+func (m *DelegateTask) Invoke(ctx context.Context, req DelegateTaskRequest) (DelegateTaskResponse, error) {
+	if !slices.Contains(m.Receivers, req.AssignTo) {
+		return DelegateTaskResponse{}, fmt.Errorf("unknown agent %q", req.AssignTo)
+	}
+	if req.AssignTo == m.Delegator {
+		return DelegateTaskResponse{}, fmt.Errorf("cannot delegate to yourself, work on the task directly instead")
+	}
+	if strings.TrimSpace(req.Title) == "" {
+		return DelegateTaskResponse{}, fmt.Errorf("task title is required")
+	}
+
+	priority := ds.TaskPriority(strings.ToLower(req.Priority))
+	switch priority {
+	case ds.TaskPriorityCritical, ds.TaskPriorityHigh, ds.TaskPriorityMedium, ds.TaskPriorityLow:
+	default:
+		priority = ds.TaskPriorityMedium
+	}
+
+	task := ds.NewTask(ds.GenerateTaskID(), req.Title, req.Description, req.AssignTo, m.Delegator, ds.TaskStatusPending, priority)
+	// 父任务取自正在执行的任务而非模型提供的参数，避免模型遗漏或伪造父任务绕过深度限制
+	depth := 1
+	if parentID := SourceTaskID(ctx); parentID != "" {
+		if parent := m.MailboxBus.GetGlobalState().GetTask(parentID); parent != nil {
+			depth = parent.DelegationDepth() + 1
+			task.Metadata[ds.MetadataParentTaskID] = parent.ID
+		}
+	}
+	task.Metadata[ds.MetadataDelegationDepth] = depth
+	if msgID := SourceMessageID(ctx); msgID != "" {
+		task.Metadata[ds.MetadataSourceMessageID] = msgID
+	}
+
+	if m.MaxDepth > 0 && depth > m.MaxDepth {
+		reason := fmt.Sprintf("delegation depth %d exceeds the limit of %d, finish the work yourself instead of delegating further", depth, m.MaxDepth)
+		if m.OnRejected != nil {
+			m.OnRejected(task, reason)
+		}
+		return DelegateTaskResponse{}, fmt.Errorf("%w: %s", ErrDelegationDepthExceeded, reason)
+	}
+	if err := m.Submit(task); err != nil {
+		return DelegateTaskResponse{}, err
+	}
+	return DelegateTaskResponse{TaskID: task.ID, Depth: depth}, nil
+}
+
+type DelegateTaskRequest struct {
+	AssignTo    string `json:"assign_to" jsonschema:"description=The name of the agent to delegate to"`
+	Title       string `json:"title" jsonschema:"description=Short title of the sub task"`
+	Description string `json:"description" jsonschema:"description=What needs to be done and the expected result"`
+	Priority    string `json:"priority,omitempty" jsonschema:"description=critical, high, medium or low, default medium"`
+}
+
+type DelegateTaskResponse struct {
+	TaskID string `json:"task_id"`
+	Depth  int    `json:"depth"`
+}
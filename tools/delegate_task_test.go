@@ -0,0 +1,74 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"superman/ds"
+	"superman/mailbox"
+)
+
+// 委派深度取自正在执行的任务，模型无法通过省略父任务绕过深度限制
+func TestDelegateDepthFromExecutingTask(t *testing.T) {
+	bus := mailbox.NewMailboxBus()
+	gs := bus.GetGlobalState()
+	var submitted []*ds.Task
+	tool := &DelegateTask{
+		Delegator:  "manager",
+		Receivers:  []string{"manager", "worker"},
+		MaxDepth:   2,
+		MailboxBus: bus,
+		Submit: func(task *ds.Task) error {
+			submitted = append(submitted, task)
+			gs.AddTask(task)
+			return nil
+		},
+	}
+	req := DelegateTaskRequest{AssignTo: "worker", Title: "sub"}
+
+	// 不在任务执行中委派：深度 1
+	resp, err := tool.Invoke(context.Background(), req)
+	if err != nil || resp.Depth != 1 {
+		t.Fatalf("Invoke = %+v, %v; want depth 1", resp, err)
+	}
+
+	// 在子任务中继续委派：深度 2，记录父任务
+	ctx := WithSourceTaskID(context.Background(), resp.TaskID)
+	resp, err = tool.Invoke(ctx, req)
+	if err != nil || resp.Depth != 2 {
+		t.Fatalf("Invoke = %+v, %v; want depth 2", resp, err)
+	}
+	if got := submitted[1].ParentTaskID(); got != submitted[0].ID {
+		t.Fatalf("parent = %q, want %q", got, submitted[0].ID)
+	}
+
+	// 超过深度限制
+	ctx = WithSourceTaskID(context.Background(), resp.TaskID)
+	if _, err := tool.Invoke(ctx, req); !errors.Is(err, ErrDelegationDepthExceeded) {
+		t.Fatalf("Invoke err = %v, want ErrDelegationDepthExceeded", err)
+	}
+	if len(submitted) != 2 {
+		t.Fatalf("submitted %d tasks, want 2", len(submitted))
+	}
+}
+
+// 不能把任务委派给自己
+func TestDelegateToSelfRejected(t *testing.T) {
+	submitted := 0
+	tool := &DelegateTask{
+		Delegator:  "manager",
+		Receivers:  []string{"manager", "worker"},
+		MailboxBus: mailbox.NewMailboxBus(),
+		Submit: func(*ds.Task) error {
+			submitted++
+			return nil
+		},
+	}
+	if _, err := tool.Invoke(context.Background(), DelegateTaskRequest{AssignTo: "manager", Title: "loop"}); err == nil {
+		t.Fatal("Invoke succeeded delegating to the delegator")
+	}
+	if submitted != 0 {
+		t.Fatalf("submitted %d tasks, want 0", submitted)
+	}
+}
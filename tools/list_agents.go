@@ -0,0 +1,58 @@
+package tools
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/components/tool/utils"
+)
+
+// AgentInfo Agent 基本信息
+type AgentInfo struct {
+	Name      string `json:"name"`
+	Desc      string `json:"desc"`
+	Hierarchy int    `json:"hierarchy"`
+}
+
+type ListAgents struct {
+	mu     sync.RWMutex
+	source func() []AgentInfo
+}
+
+// SetSource 设置 Agent 信息来源（通常为编排器的注册表）
+func (m *ListAgents) SetSource(source func() []AgentInfo) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.source = source
+}
+
+func (m *ListAgents) ToEinoTool() (tool.BaseTool, error) {
+	return utils.InferTool("list agents", "list all agents in the company with their descriptions and hierarchies (0 is the top level), to decide who to message or delegate to", m.Invoke)
+}
+
+func (m *ListAgents) Invoke(ctx context.Context, req ListAgentsRequest) (ListAgentsResponse, error) {
+	m.mu.RLock()
+	source := m.source
+	m.mu.RUnlock()
+
+	agents := make([]AgentInfo, 0)
+	if source != nil {
+		agents = source()
+	}
+	sort.Slice(agents, func(i, j int) bool {
+		if agents[i].Hierarchy != agents[j].Hierarchy {
+			return agents[i].Hierarchy < agents[j].Hierarchy
+		}
+		return agents[i].Name < agents[j].Name
+	})
+	return ListAgentsResponse{Agents: agents}, nil
+}
+
+type ListAgentsRequest struct {
+}
+
+type ListAgentsResponse struct {
+	Agents []AgentInfo `json:"agents"`
+}
@@ -0,0 +1,58 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"superman/mailbox"
+	"superman/state"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/components/tool/utils"
+)
+
+// 读取公告的默认与最大条数
+const (
+	DefaultAnnouncementLimit = 10
+	MaxAnnouncementLimit     = 50
+)
+
+type ReadAnnouncements struct {
+	MailboxBus *mailbox.MailboxBus
+}
+
+func (m *ReadAnnouncements) ToEinoTool() (tool.BaseTool, error) {
+	return utils.InferTool("read announcements", "read the most recent company-wide announcements (oldest first), to take company directives into account", m.Invoke)
+}
+
+func (m *ReadAnnouncements) Invoke(ctx context.Context, req ReadAnnouncementsRequest) (ReadAnnouncementsResponse, error) {
+	if req.Severity != "" && !state.IsValidSeverity(req.Severity) {
+		return ReadAnnouncementsResponse{}, fmt.Errorf("invalid severity %q", req.Severity)
+	}
+	limit := req.Limit
+	if limit <= 0 {
+		limit = DefaultAnnouncementLimit
+	}
+	limit = min(limit, MaxAnnouncementLimit)
+
+	all := m.MailboxBus.GetGlobalState().GetAnnouncements()
+	result := make([]state.Announcement, 0, min(limit, len(all)))
+	// 从最新的公告向前收集，再恢复时间顺序
+	for i := len(all) - 1; i >= 0 && len(result) < limit; i-- {
+		if req.Severity == "" || all[i].Severity == req.Severity {
+			result = append(result, all[i])
+		}
+	}
+	for i, j := 0, len(result)-1; i < j; i, j = i+1, j-1 {
+		result[i], result[j] = result[j], result[i]
+	}
+	return ReadAnnouncementsResponse{Announcements: result}, nil
+}
+
+type ReadAnnouncementsRequest struct {
+	Limit    int    `json:"limit,omitempty" jsonschema:"description=Maximum number of recent announcements to return (default 10, max 50)"`
+	Severity string `json:"severity,omitempty" jsonschema:"description=Only return announcements of this severity,enum=info,enum=warning,enum=critical"`
+}
+
+type ReadAnnouncementsResponse struct {
+	Announcements []state.Announcement `json:"announcements"`
+}
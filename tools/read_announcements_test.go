@@ -0,0 +1,59 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"superman/mailbox"
+	"superman/state"
+
+	"github.com/cloudwego/eino/components/tool"
+)
+
+// CEO 发布公告后，其他 Agent 通过工具调用能读到该公告，并可按严重级别过滤
+func TestReadAnnouncementsReturnsCEOAnnouncement(t *testing.T) {
+	bus := mailbox.NewMailboxBus()
+	for _, name := range []string{"ceo", "cfo"} {
+		if err := bus.RegisterMailbox(name, mailbox.NewMailbox(mailbox.DefaultMailboxConfig(name))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ceo := &Announce{Author: "ceo", Hierarchy: 0, MailboxBus: bus}
+	for _, req := range []AnnounceRequest{
+		{Text: "freeze hiring"},
+		{Text: "budget cut 10%", Severity: state.SeverityWarning},
+	} {
+		if _, err := ceo.Invoke(context.Background(), req); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	base, err := (&ReadAnnouncements{MailboxBus: bus}).ToEinoTool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	read := func(args string) ReadAnnouncementsResponse {
+		t.Helper()
+		out, err := base.(tool.InvokableTool).InvokableRun(context.Background(), args)
+		if err != nil {
+			t.Fatalf("read announcements(%s): %v", args, err)
+		}
+		var resp ReadAnnouncementsResponse
+		if err := json.Unmarshal([]byte(out), &resp); err != nil {
+			t.Fatalf("decode %q: %v", out, err)
+		}
+		return resp
+	}
+
+	got := read(`{}`).Announcements
+	if len(got) != 2 || got[0].Text != "freeze hiring" || got[0].Author != "ceo" || got[1].Text != "budget cut 10%" {
+		t.Fatalf("announcements = %+v, want both ceo announcements oldest first", got)
+	}
+	if got := read(`{"limit":1}`).Announcements; len(got) != 1 || got[0].Text != "budget cut 10%" {
+		t.Fatalf("limit 1 = %+v, want the latest announcement", got)
+	}
+	if got := read(`{"severity":"info"}`).Announcements; len(got) != 1 || got[0].Text != "freeze hiring" {
+		t.Fatalf("info only = %+v, want the info announcement", got)
+	}
+}
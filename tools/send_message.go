@@ -7,16 +7,21 @@ import (
 	"reflect"
 	"superman/ds"
 	"superman/mailbox"
+	sutils "superman/utils"
 
 	"github.com/cloudwego/eino/components/tool"
 	"github.com/cloudwego/eino/components/tool/utils"
 	"github.com/eino-contrib/jsonschema"
 )
 
+// ErrMessageRateLimited 发送消息超过频率限制
+var ErrMessageRateLimited = errors.New("message rate limit exceeded")
+
 type SendMessage struct {
 	Sender     string
 	Receivers  []string
 	MailboxBus *mailbox.MailboxBus
+	Limiter    *sutils.RateLimiter // 发送频率限制，每个接收者消耗一个令牌，nil 不限制
 }
 
 func (m *SendMessage) ToEinoTool() (tool.BaseTool, error) {
@@ -41,6 +46,10 @@ func (m *SendMessage) schemaModifier() utils.SchemaModifierFn {
 }
 
 func (m *SendMessage) Invoke(ctx context.Context, req SendMessageRequest) (SendMessageResponse, error) {
+	if !m.Limiter.AllowN(len(req.Receivers)) {
+		return SendMessageResponse{}, fmt.Errorf("%w: no message was sent, wait before messaging again or send fewer messages", ErrMessageRateLimited)
+	}
+
 	var e error
 	for _, receiver := range req.Receivers {
 		msg, err := ds.NewRequestMessage(
@@ -0,0 +1,55 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"superman/mailbox"
+	sutils "superman/utils"
+)
+
+// 超过发送频率的连续调用被拒绝且不投递任何消息，额度内的消息照常送达；令牌补充后可再次发送
+func TestSendMessageRateLimit(t *testing.T) {
+	bus := mailbox.NewMailboxBus()
+	inboxes := map[string]*mailbox.Mailbox{}
+	for _, name := range []string{"cfo", "hr"} {
+		inboxes[name] = mailbox.NewMailbox(mailbox.DefaultMailboxConfig(name))
+		if err := bus.RegisterMailbox(name, inboxes[name]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	now := time.Unix(0, 0)
+	limiter := sutils.NewRateLimiter(6, 3)
+	limiter.SetClock(func() time.Time { return now })
+	send := &SendMessage{Sender: "ceo", Receivers: []string{"cfo", "hr"}, MailboxBus: bus, Limiter: limiter}
+	ctx := context.Background()
+
+	if _, err := send.Invoke(ctx, SendMessageRequest{Receivers: []string{"cfo", "hr"}, Body: "q3 plan"}); err != nil {
+		t.Fatalf("first send: %v", err)
+	}
+	if _, err := send.Invoke(ctx, SendMessageRequest{Receivers: []string{"cfo"}, Body: "budget"}); err != nil {
+		t.Fatalf("second send: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		_, err := send.Invoke(ctx, SendMessageRequest{Receivers: []string{"hr"}, Body: "spam"})
+		if !errors.Is(err, ErrMessageRateLimited) {
+			t.Fatalf("send %d beyond the burst = %v, want ErrMessageRateLimited", i, err)
+		}
+	}
+	for name, want := range map[string]int{"cfo": 2, "hr": 1} {
+		if got := inboxes[name].GetInboxCount(); got != want {
+			t.Fatalf("%s inbox = %d, want %d allowed messages", name, got, want)
+		}
+	}
+
+	// 每分钟 6 条即每 10 秒补充一个令牌
+	now = now.Add(10 * time.Second)
+	if _, err := send.Invoke(ctx, SendMessageRequest{Receivers: []string{"hr"}, Body: "follow up"}); err != nil {
+		t.Fatalf("send after refill: %v", err)
+	}
+	if got := inboxes["hr"].GetInboxCount(); got != 2 {
+		t.Fatalf("hr inbox = %d after refill, want 2", got)
+	}
+}
@@ -0,0 +1,61 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"regexp"
+	"superman/mailbox"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/components/tool/utils"
+)
+
+// MaxKPIHierarchy 允许记录 KPI 的最低层级（数值越小层级越高），默认只有高管可用
+const MaxKPIHierarchy = 2
+
+// maxKPIValue KPI 取值的绝对值上限
+const maxKPIValue = 1e12
+
+// kpiKeyPattern KPI 键名：小写字母开头，仅含小写字母、数字与下划线，最长 64 字符
+var kpiKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,63}$`)
+
+type SetKPI struct {
+	Author     string
+	MailboxBus *mailbox.MailboxBus
+}
+
+func (m *SetKPI) ToEinoTool() (tool.BaseTool, error) {
+	return utils.InferTool("set kpi", "record a company KPI value in the shared state so other agents can read it, e.g. gross_margin = 0.42", m.Invoke)
+}
+
+func (m *SetKPI) Invoke(ctx context.Context, req SetKPIRequest) (SetKPIResponse, error) {
+	if !kpiKeyPattern.MatchString(req.Key) {
+		return SetKPIResponse{}, fmt.Errorf("invalid kpi key %q, expected lowercase letters, digits and underscores starting with a letter", req.Key)
+	}
+	if math.IsNaN(req.Value) || math.IsInf(req.Value, 0) || math.Abs(req.Value) > maxKPIValue {
+		return SetKPIResponse{}, fmt.Errorf("kpi value %v out of range, expected a finite number within ±%g", req.Value, maxKPIValue)
+	}
+
+	gs := m.MailboxBus.GetGlobalState()
+	previous := gs.GetKPI(req.Key)
+	gs.SetKPI(req.Key, req.Value)
+	slog.Info("kpi updated",
+		slog.String("author", m.Author),
+		slog.String("key", req.Key),
+		slog.Float64("value", req.Value),
+	)
+	return SetKPIResponse{Key: req.Key, Previous: previous, Value: req.Value}, nil
+}
+
+type SetKPIRequest struct {
+	Key   string  `json:"key" jsonschema:"description=KPI name in snake_case, e.g. gross_margin"`
+	Value float64 `json:"value" jsonschema:"description=KPI value"`
+}
+
+type SetKPIResponse struct {
+	Key      string  `json:"key"`
+	Previous float64 `json:"previous"`
+	Value    float64 `json:"value"`
+}
@@ -0,0 +1,29 @@
+package tools
+
+import "context"
+
+type sourceMessageKey struct{}
+
+type sourceTaskKey struct{}
+
+// WithSourceMessageID 在上下文中记录当前正在处理的消息 ID，工具据此关联其创建的任务
+func WithSourceMessageID(ctx context.Context, messageID string) context.Context {
+	return context.WithValue(ctx, sourceMessageKey{}, messageID)
+}
+
+// SourceMessageID 获取上下文中记录的消息 ID，不在消息处理过程中返回空字符串
+func SourceMessageID(ctx context.Context) string {
+	messageID, _ := ctx.Value(sourceMessageKey{}).(string)
+	return messageID
+}
+
+// WithSourceTaskID 在上下文中记录当前正在执行的任务 ID，委派等工具据此确定父任务
+func WithSourceTaskID(ctx context.Context, taskID string) context.Context {
+	return context.WithValue(ctx, sourceTaskKey{}, taskID)
+}
+
+// SourceTaskID 获取上下文中记录的任务 ID，不在任务执行过程中返回空字符串
+func SourceTaskID(ctx context.Context) string {
+	taskID, _ := ctx.Value(sourceTaskKey{}).(string)
+	return taskID
+}
@@ -0,0 +1,35 @@
+package tools
+
+import (
+	"context"
+	"superman/ds"
+	"superman/mailbox"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/components/tool/utils"
+)
+
+type TaskComment struct {
+	Author     string
+	MailboxBus *mailbox.MailboxBus
+}
+
+func (m *TaskComment) ToEinoTool() (tool.BaseTool, error) {
+	return utils.InferTool("comment task", "add a comment to a task's comment thread, e.g. progress notes or caveats", m.Invoke)
+}
+
+func (m *TaskComment) Invoke(ctx context.Context, req TaskCommentRequest) (TaskCommentResponse, error) {
+	err := m.MailboxBus.GetGlobalState().AddTaskComment(req.TaskID, ds.TaskComment{
+		Author: m.Author,
+		Body:   req.Body,
+	})
+	return TaskCommentResponse{}, err
+}
+
+type TaskCommentRequest struct {
+	TaskID string `json:"task_id" jsonschema:"description=The ID of the task to comment on"`
+	Body   string `json:"body" jsonschema:"description=The comment text"`
+}
+
+type TaskCommentResponse struct {
+}
@@ -0,0 +1,30 @@
+package tools
+
+import (
+	"context"
+	"superman/mailbox"
+
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/components/tool/utils"
+)
+
+type TaskWarning struct {
+	MailboxBus *mailbox.MailboxBus
+}
+
+func (m *TaskWarning) ToEinoTool() (tool.BaseTool, error) {
+	return utils.InferTool("flag task warning", "flag an issue or caveat on a task you are executing; the task will finish as completed with warnings", m.Invoke)
+}
+
+func (m *TaskWarning) Invoke(ctx context.Context, req TaskWarningRequest) (TaskWarningResponse, error) {
+	err := m.MailboxBus.GetGlobalState().AddTaskWarning(req.TaskID, req.Warning)
+	return TaskWarningResponse{}, err
+}
+
+type TaskWarningRequest struct {
+	TaskID  string `json:"task_id" jsonschema:"description=The ID of the task being executed"`
+	Warning string `json:"warning" jsonschema:"description=A short description of the issue or caveat"`
+}
+
+type TaskWarningResponse struct {
+}
@@ -0,0 +1,22 @@
+package utils
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// Jitter 在 d 上叠加 ±ratio 比例的随机抖动，ratio<=0 时原样返回
+func Jitter(d time.Duration, ratio float64) time.Duration {
+	if ratio <= 0 || d <= 0 {
+		return d
+	}
+	if ratio > 1 {
+		ratio = 1
+	}
+	delta := float64(d) * ratio
+	jittered := time.Duration(float64(d) - delta + rand.Float64()*2*delta)
+	if jittered <= 0 {
+		return time.Millisecond
+	}
+	return jittered
+}
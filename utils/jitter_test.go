@@ -0,0 +1,37 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+// 抖动后的间隔落在 ±ratio 区间内，且在区间内分散分布而非集中于某一点
+func TestJitterSpreadsWithinBand(t *testing.T) {
+	const (
+		base    = 10 * time.Second
+		ratio   = 0.2
+		samples = 4000
+		buckets = 4
+	)
+	low, high := base-base/5, base+base/5
+	width := (high - low) / buckets
+
+	var counts [buckets]int
+	for range samples {
+		d := Jitter(base, ratio)
+		if d < low || d > high {
+			t.Fatalf("Jitter(%v, %v) = %v, want within [%v, %v]", base, ratio, d, low, high)
+		}
+		counts[min(int((d-low)/width), buckets-1)]++
+	}
+	// 均匀分布时每个分段约占 1/4，允许较大偏差以避免偶发失败
+	for i, n := range counts {
+		if n < samples/buckets/2 {
+			t.Fatalf("bucket %d has %d of %d samples, want fire times spread across the band: %v", i, n, samples, counts)
+		}
+	}
+
+	if got := Jitter(base, 0); got != base {
+		t.Fatalf("Jitter(%v, 0) = %v, want unchanged", base, got)
+	}
+}
@@ -0,0 +1,67 @@
+package utils
+
+import (
+	"sync"
+	"time"
+)
+
+// RateLimiter 令牌桶限流器，nil 表示不限制
+type RateLimiter struct {
+	mu     sync.Mutex
+	rate   float64 // 每秒补充的令牌数
+	burst  float64 // 桶容量
+	tokens float64
+	last   time.Time
+	now    func() time.Time
+}
+
+// NewRateLimiter 创建每分钟补充 perMinute 个令牌、容量为 burst 的限流器，
+// burst<=0 时容量取 perMinute（至少 1），perMinute<=0 时返回 nil（不限制）
+func NewRateLimiter(perMinute float64, burst int) *RateLimiter {
+	if perMinute <= 0 {
+		return nil
+	}
+	capacity := float64(burst)
+	if burst <= 0 {
+		capacity = max(perMinute, 1)
+	}
+	return &RateLimiter{
+		rate:   perMinute / 60,
+		burst:  capacity,
+		tokens: capacity,
+		last:   time.Now(),
+		now:    time.Now,
+	}
+}
+
+// SetClock 替换时钟，便于测试
+func (l *RateLimiter) SetClock(now func() time.Time) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.now = now
+	l.last = now()
+}
+
+// AllowN 尝试一次性取出 n 个令牌，令牌不足时不消耗并返回 false
+func (l *RateLimiter) AllowN(n int) bool {
+	if l == nil {
+		return true
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if elapsed := now.Sub(l.last).Seconds(); elapsed > 0 {
+		l.tokens = min(l.burst, l.tokens+elapsed*l.rate)
+	}
+	l.last = now
+
+	if l.tokens < float64(n) {
+		return false
+	}
+	l.tokens -= float64(n)
+	return true
+}
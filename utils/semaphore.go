@@ -0,0 +1,45 @@
+package utils
+
+import "context"
+
+// Semaphore 计数信号量，nil 表示不限制
+type Semaphore struct {
+	slots chan struct{}
+}
+
+// NewSemaphore 创建容量为 n 的信号量，n<=0 时返回 nil（不限制）
+func NewSemaphore(n int) *Semaphore {
+	if n <= 0 {
+		return nil
+	}
+	return &Semaphore{slots: make(chan struct{}, n)}
+}
+
+// Acquire 获取一个槽位，无空闲槽位时排队等待，ctx 取消时返回错误
+func (s *Semaphore) Acquire(ctx context.Context) error {
+	if s == nil {
+		return nil
+	}
+	select {
+	case s.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Release 释放一个槽位
+func (s *Semaphore) Release() {
+	if s == nil {
+		return
+	}
+	<-s.slots
+}
+
+// InUse 获取已占用的槽位数
+func (s *Semaphore) InUse() int {
+	if s == nil {
+		return 0
+	}
+	return len(s.slots)
+}
@@ -0,0 +1,196 @@
+package workflow
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"superman/agents"
+	"superman/ds"
+	"superman/scheduler"
+)
+
+// 分支失败时父任务的处理策略
+const (
+	FanOutPolicyAll     = "all"     // 任一分支失败则父任务失败（默认）
+	FanOutPolicyPartial = "partial" // 部分分支失败时父任务带告警完成，全部失败才失败
+)
+
+// 扇出任务相关的元数据键
+const (
+	MetadataFanOutPolicy  = "fan_out_policy"  // 父任务上的失败处理策略
+	MetadataFanOutResults = "fan_out_results" // 父任务上汇总的分支结果
+)
+
+// FanOutBranchResult 扇出分支的执行结果
+type FanOutBranchResult struct {
+	TaskID  string `json:"task_id"`
+	Agent   string `json:"agent"`
+	Success bool   `json:"success"`
+	Result  any    `json:"result,omitempty"`
+}
+
+// fanOut 进行中的扇出任务
+type fanOut struct {
+	parentID string
+	policy   string
+	order    []string // 分支任务ID（创建顺序）
+	pending  map[string]bool
+	results  map[string]FanOutBranchResult
+}
+
+// SetTaskSubmitter 设置任务提交回调（提交到调度器），FanOut 通过它创建分支任务
+func (o *orchestratorImpl) SetTaskSubmitter(fn agents.TaskSubmitFunc) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.submit = fn
+}
+
+// FanOut 将父任务扇出为多个分支任务并行执行，所有分支结束后把结果汇总写回父任务。
+// 分支任务需指定 AssignedTo；父任务的元数据 fan_out_policy 决定部分失败时的处理方式
+func (o *orchestratorImpl) FanOut(parent *ds.Task, branches []*ds.Task) error {
+	if parent == nil || len(branches) == 0 {
+		return fmt.Errorf("fan-out requires a parent task and at least one branch")
+	}
+	o.mu.Lock()
+	submit := o.submit
+	o.mu.Unlock()
+	if submit == nil {
+		return fmt.Errorf("task submitter is not set")
+	}
+	for _, branch := range branches {
+		if o.GetAgent(branch.AssignedTo) == nil {
+			return fmt.Errorf("branch %s: agent %s not found", branch.ID, branch.AssignedTo)
+		}
+	}
+
+	policy, _ := parent.Metadata[MetadataFanOutPolicy].(string)
+	if policy != FanOutPolicyPartial {
+		policy = FanOutPolicyAll
+	}
+
+	gs := o.MailboxBus.GetGlobalState()
+	if gs.GetTask(parent.ID) == nil {
+		parent.Status = ds.TaskStatusProcessing
+		gs.AddTask(parent)
+	} else {
+		gs.UpdateTask(parent.ID, func(t *ds.Task) {
+			t.Status = ds.TaskStatusProcessing
+		})
+	}
+
+	f := &fanOut{
+		parentID: parent.ID,
+		policy:   policy,
+		order:    make([]string, 0, len(branches)),
+		pending:  make(map[string]bool, len(branches)),
+		results:  make(map[string]FanOutBranchResult, len(branches)),
+	}
+	o.mu.Lock()
+	for _, branch := range branches {
+		f.order = append(f.order, branch.ID)
+		f.pending[branch.ID] = true
+		o.branchParent[branch.ID] = parent.ID
+	}
+	o.fanOuts[parent.ID] = f
+	o.mu.Unlock()
+
+	for _, branch := range branches {
+		if branch.Metadata == nil {
+			branch.Metadata = make(map[string]any)
+		}
+		branch.Metadata[ds.MetadataParentTaskID] = parent.ID
+		if branch.AssignedBy == "" {
+			branch.AssignedBy = parent.AssignedTo
+		}
+		submit(branch, queuePriority(branch.Priority))
+	}
+
+	slog.Info("task fanned out",
+		slog.String("parent_task_id", parent.ID),
+		slog.Int("branches", len(branches)),
+		slog.String("policy", policy),
+	)
+	return nil
+}
+
+// OnTaskComplete 任务完成回调：记录扇出分支结果，所有分支结束后汇总到父任务
+func (o *orchestratorImpl) OnTaskComplete(taskID, agentName string, success bool) {
+	o.mu.Lock()
+	parentID, ok := o.branchParent[taskID]
+	if !ok {
+		o.mu.Unlock()
+		return
+	}
+	delete(o.branchParent, taskID)
+	f := o.fanOuts[parentID]
+
+	result := FanOutBranchResult{TaskID: taskID, Agent: agentName, Success: success}
+	if task := o.MailboxBus.GetGlobalState().GetTask(taskID); task != nil {
+		result.Result = task.Metadata[agents.MetadataTaskResult]
+	}
+	f.results[taskID] = result
+	delete(f.pending, taskID)
+	if len(f.pending) > 0 {
+		o.mu.Unlock()
+		return
+	}
+	delete(o.fanOuts, parentID)
+	o.mu.Unlock()
+
+	o.joinFanOut(f)
+}
+
+// joinFanOut 汇总分支结果并按策略结束父任务
+func (o *orchestratorImpl) joinFanOut(f *fanOut) {
+	results := make([]FanOutBranchResult, 0, len(f.order))
+	var failed []string
+	for _, id := range f.order {
+		r := f.results[id]
+		results = append(results, r)
+		if !r.Success {
+			failed = append(failed, id)
+		}
+	}
+
+	status := ds.TaskStatusCompleted
+	switch {
+	case len(failed) == 0:
+	case f.policy == FanOutPolicyPartial && len(failed) < len(results):
+		status = ds.TaskStatusCompletedWithWarnings
+	default:
+		status = ds.TaskStatusFailed
+	}
+
+	o.MailboxBus.GetGlobalState().UpdateTask(f.parentID, func(t *ds.Task) {
+		if t.Metadata == nil {
+			t.Metadata = make(map[string]any)
+		}
+		t.Metadata[MetadataFanOutResults] = results
+		if status == ds.TaskStatusCompletedWithWarnings {
+			t.AddWarning(fmt.Sprintf("fan-out branches failed: %s", strings.Join(failed, ", ")))
+		}
+		t.SetStatus(status)
+	})
+
+	slog.Info("fan-out joined",
+		slog.String("parent_task_id", f.parentID),
+		slog.String("status", string(status)),
+		slog.Int("branches", len(results)),
+		slog.Int("failed", len(failed)),
+	)
+}
+
+// queuePriority 将任务优先级转换为调度队列优先级
+func queuePriority(p ds.TaskPriority) string {
+	switch p {
+	case ds.TaskPriorityCritical:
+		return scheduler.PriorityCritical
+	case ds.TaskPriorityHigh:
+		return scheduler.PriorityHigh
+	case ds.TaskPriorityLow:
+		return scheduler.PriorityLow
+	default:
+		return scheduler.PriorityMedium
+	}
+}
@@ -2,6 +2,7 @@ package workflow
 
 import (
 	"fmt"
+	"sync"
 	"time"
 
 	"superman/agents"
@@ -17,17 +18,29 @@ type Orchestrator interface {
 	SendMessage(msg *ds.Message) error
 	SendMessageTo(sender, receiver string, content map[string]interface{}) error
 	GetMailboxBus() *mailbox.MailboxBus
+	GetCompanyStats() CompanyStats
+	SetTaskSubmitter(fn agents.TaskSubmitFunc)
+	FanOut(parent *ds.Task, branches []*ds.Task) error
+	OnTaskComplete(taskID, agentName string, success bool)
 }
 
 type orchestratorImpl struct {
 	agents     map[string]agents.Agent
 	MailboxBus *mailbox.MailboxBus
+
+	// 扇出任务跟踪
+	mu           sync.Mutex
+	submit       agents.TaskSubmitFunc
+	fanOuts      map[string]*fanOut // 父任务ID -> 扇出
+	branchParent map[string]string  // 分支任务ID -> 父任务ID
 }
 
 func NewOrchestrator(MailboxBus *mailbox.MailboxBus) Orchestrator {
 	return &orchestratorImpl{
-		agents:     make(map[string]agents.Agent),
-		MailboxBus: MailboxBus,
+		agents:       make(map[string]agents.Agent),
+		MailboxBus:   MailboxBus,
+		fanOuts:      make(map[string]*fanOut),
+		branchParent: make(map[string]string),
 	}
 }
 
@@ -57,6 +70,8 @@ func (o *orchestratorImpl) RunTask(task *ds.Task) error {
 			Description:  task.Description,
 			AssignedTo:   task.AssignedTo,
 			AssignedBy:   task.AssignedBy,
+			Priority:     task.Priority,
+			Type:         task.Type,
 			Dependencies: task.Dependencies,
 			Deliverables: task.Deliverables,
 			Metadata:     task.Metadata,
@@ -68,17 +83,7 @@ func (o *orchestratorImpl) RunTask(task *ds.Task) error {
 			body.Deadline = &deadlineStr
 		}
 
-		msg, err := ds.NewTaskCreateMessage(
-			task.ID,
-			task.Title,
-			task.Description,
-			task.AssignedTo,
-			task.AssignedBy,
-			task.Dependencies,
-			task.Deliverables,
-			nil, // deadline 通过 body 传递
-			task.Metadata,
-		)
+		msg, err := ds.NewMessage("scheduler", task.AssignedTo, ds.MessageTypeTaskCreate, body)
 		if err != nil {
 			return fmt.Errorf("failed to create task message: %w", err)
 		}
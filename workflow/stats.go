@@ -0,0 +1,65 @@
+package workflow
+
+import (
+	"sort"
+	"time"
+)
+
+// AgentStats 单个 Agent 的执行统计
+type AgentStats struct {
+	Name            string        `json:"name"`
+	TotalExecutions int           `json:"total_executions"`
+	SuccessCount    int           `json:"success_count"`
+	FailedCount     int           `json:"failed_count"`
+	SuccessRate     float64       `json:"success_rate"`
+	AvgDuration     time.Duration `json:"avg_duration"`
+}
+
+// CompanyStats 公司级执行统计（各 Agent 汇总）
+type CompanyStats struct {
+	TotalExecutions int           `json:"total_executions"`
+	SuccessCount    int           `json:"success_count"`
+	FailedCount     int           `json:"failed_count"`
+	SuccessRate     float64       `json:"success_rate"`
+	AvgDuration     time.Duration `json:"avg_duration"`
+	Agents          []AgentStats  `json:"agents"`
+}
+
+// GetCompanyStats 汇总所有 Agent 的执行统计，平均耗时按执行次数加权
+func (o *orchestratorImpl) GetCompanyStats() CompanyStats {
+	stats := CompanyStats{Agents: make([]AgentStats, 0, len(o.agents))}
+	var totalDuration time.Duration
+
+	for _, agent := range o.GetAllAgents() {
+		raw := agent.GetExecutionStats()
+		agentStats := AgentStats{Name: agent.GetName()}
+		agentStats.TotalExecutions, _ = raw["total_executions"].(int)
+		agentStats.SuccessCount, _ = raw["success_count"].(int)
+		agentStats.FailedCount, _ = raw["failed_count"].(int)
+		agentStats.AvgDuration, _ = raw["avg_duration"].(time.Duration)
+		agentStats.SuccessRate = successRate(agentStats.SuccessCount, agentStats.TotalExecutions)
+
+		stats.TotalExecutions += agentStats.TotalExecutions
+		stats.SuccessCount += agentStats.SuccessCount
+		stats.FailedCount += agentStats.FailedCount
+		totalDuration += agentStats.AvgDuration * time.Duration(agentStats.TotalExecutions)
+		stats.Agents = append(stats.Agents, agentStats)
+	}
+
+	stats.SuccessRate = successRate(stats.SuccessCount, stats.TotalExecutions)
+	if stats.TotalExecutions > 0 {
+		stats.AvgDuration = totalDuration / time.Duration(stats.TotalExecutions)
+	}
+	sort.Slice(stats.Agents, func(i, j int) bool {
+		return stats.Agents[i].Name < stats.Agents[j].Name
+	})
+	return stats
+}
+
+// successRate 计算成功率，无执行记录时为 0
+func successRate(success, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(success) / float64(total)
+}